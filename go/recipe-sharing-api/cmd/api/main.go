@@ -3,8 +3,14 @@
 
 // Command api runs the Recipe Sharing API as a local HTTP server backed
 // by Amazon Aurora DSQL. This entrypoint is useful for local testing
-// against a remote DSQL cluster. For production deployment on AWS Lambda,
-// use cmd/lambda/main.go.
+// against a remote DSQL cluster. Set STORE=memory to run against an
+// in-memory store instead, which requires no AWS resources. Recipe images
+// are stored under BLOB_DIR, which defaults to data/blobs. Drafts scheduled
+// for publishing are published by a background sweep every
+// PUBLISH_INTERVAL, which defaults to one minute, and reads are cached in
+// process when CACHE_SIZE is set. Requests are traced with OpenTelemetry
+// when TRACE_EXPORTER is set to stdout or otlp. For production deployment
+// on AWS Lambda, use cmd/lambda/main.go, and run cmd/publish on a schedule.
 package main
 
 import (
@@ -13,33 +19,71 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/aws-samples/recipe-share-dsql-go/internal/router"
 	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/telemetry"
 )
 
 func main() {
 	ctx := context.Background()
 
-	// Read the Amazon Aurora DSQL endpoint from the environment.
-	endpoint := os.Getenv("DSQL_ENDPOINT")
-	if endpoint == "" {
-		log.Fatal("DSQL_ENDPOINT environment variable is required")
+	// Export traces when TRACE_EXPORTER is set; spans still buffered at
+	// shutdown are flushed on the way out.
+	tracing, err := telemetry.Setup(ctx, os.Getenv("TRACE_EXPORTER"))
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
 	}
+	defer tracing.Shutdown(ctx)
 
-	// Create the Amazon Aurora DSQL store with IAM token-based authentication.
-	dsqlStore, err := store.NewDSQLStore(ctx, endpoint)
+	// Recipe images are kept on the local filesystem under BLOB_DIR.
+	blobDir := os.Getenv("BLOB_DIR")
+	if blobDir == "" {
+		blobDir = "data/blobs"
+	}
+	blobs, err := store.NewFSBlobStore(blobDir)
 	if err != nil {
-		log.Fatalf("Failed to connect to Amazon Aurora DSQL: %v", err)
+		log.Fatalf("Failed to open blob store: %v", err)
 	}
-	defer dsqlStore.Close()
 
-	// Create the database schema if it does not already exist.
-	if err := dsqlStore.InitSchema(ctx); err != nil {
-		log.Fatalf("Failed to initialize database schema: %v", err)
+	// Select the store backend. STORE=memory runs entirely in process and
+	// is useful for offline development; data is lost on shutdown.
+	var s store.Store
+	var backend string
+	switch os.Getenv("STORE") {
+	case "memory":
+		memStore := store.NewMemoryStore()
+		memStore.SetBlobStore(blobs)
+		s = memStore
+		backend = "in-memory store"
+	case "", "dsql":
+		// Read the Amazon Aurora DSQL endpoint from the environment.
+		endpoint := os.Getenv("DSQL_ENDPOINT")
+		if endpoint == "" {
+			log.Fatal("DSQL_ENDPOINT environment variable is required")
+		}
+
+		// Create the Amazon Aurora DSQL store with IAM token-based authentication.
+		dsqlStore, err := store.NewDSQLStore(ctx, endpoint)
+		if err != nil {
+			log.Fatalf("Failed to connect to Amazon Aurora DSQL: %v", err)
+		}
+		// Apply pending schema migrations before serving requests.
+		if err := dsqlStore.Migrate(ctx); err != nil {
+			dsqlStore.Close()
+			log.Fatalf("Failed to migrate database schema: %v", err)
+		}
+		dsqlStore.SetBlobStore(blobs)
+		s = dsqlStore
+		backend = "Aurora DSQL: " + endpoint
+	default:
+		log.Fatalf("Unknown STORE %q: must be one of: dsql, memory", os.Getenv("STORE"))
 	}
+	s = store.NewTracingStore(withCache(s))
+	defer s.Close()
 
 	// Determine the listen port from the environment, defaulting to 8080.
 	port := os.Getenv("PORT")
@@ -47,8 +91,20 @@ func main() {
 		port = "8080"
 	}
 
-	// Build the Gin router with the DSQL store.
-	r := router.New(dsqlStore)
+	// Publish scheduled drafts in the background until shutdown.
+	interval := time.Minute
+	if v := os.Getenv("PUBLISH_INTERVAL"); v != "" {
+		interval, err = time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			log.Fatalf("PUBLISH_INTERVAL must be a positive duration such as 30s, got %q", v)
+		}
+	}
+	publishCtx, stopPublishing := context.WithCancel(store.WithActor(ctx, "publisher"))
+	defer stopPublishing()
+	go publishScheduled(publishCtx, s, interval)
+
+	// Build the Gin router with the selected store.
+	r := router.New(s, blobs)
 
 	srv := &http.Server{
 		Addr:    ":" + port,
@@ -57,7 +113,7 @@ func main() {
 
 	// Start the server in a goroutine so we can handle graceful shutdown.
 	go func() {
-		log.Printf("Recipe Sharing API listening on http://localhost:%s (Aurora DSQL: %s)", port, endpoint)
+		log.Printf("Recipe Sharing API listening on http://localhost:%s (%s)", port, backend)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server error: %v", err)
 		}
@@ -68,6 +124,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopPublishing()
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -76,3 +133,50 @@ func main() {
 	}
 	log.Println("Server stopped")
 }
+
+// publishScheduled publishes due drafts every interval until ctx is
+// cancelled. A failed sweep is logged and retried at the next tick; drafts
+// it did not reach are still due then.
+func publishScheduled(ctx context.Context, s store.Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := s.PublishDue(ctx, now.UTC())
+			if err != nil && ctx.Err() == nil {
+				log.Printf("ERROR failed to publish scheduled recipes after publishing %d: %v", n, err)
+			} else if n > 0 {
+				log.Printf("Published %d scheduled recipes", n)
+			}
+		}
+	}
+}
+
+// withCache wraps s in a read-through cache when CACHE_SIZE is set to a
+// positive number of entries. Entries expire after CACHE_TTL, which
+// defaults to 30 seconds.
+func withCache(s store.Store) store.Store {
+	v := os.Getenv("CACHE_SIZE")
+	if v == "" {
+		return s
+	}
+	size, err := strconv.Atoi(v)
+	if err != nil || size < 0 {
+		log.Fatalf("CACHE_SIZE must be a number of entries, got %q", v)
+	}
+	if size == 0 {
+		return s
+	}
+	ttl := 30 * time.Second
+	if v := os.Getenv("CACHE_TTL"); v != "" {
+		ttl, err = time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			log.Fatalf("CACHE_TTL must be a positive duration such as 30s, got %q", v)
+		}
+	}
+	log.Printf("Caching up to %d reads for %s", size, ttl)
+	return store.NewCachingStore(s, store.NewLRUCache(size), ttl)
+}
@@ -0,0 +1,80 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Command backfill brings rows written by earlier versions of the API up to
+// date, and is safe to re-run:
+//
+//   - It converts the free-text ingredients of existing recipes into
+//     structured recipe_ingredients rows. Lines that start with a quantity
+//     are split into quantity, unit, and name; other lines are kept whole as
+//     the ingredient name. Recipes that already have structured ingredients
+//     are skipped.
+//   - It sets the case-insensitive email key that enforces unique chef
+//     emails. Chefs whose email duplicates another chef's are reported and
+//     must be fixed by hand, for example by updating one of the emails.
+//   - It keys existing ratings by chef so that a chef can rate each recipe
+//     only once. Where a chef has rated a recipe more than once, the extra
+//     ratings are reported and should be deleted by hand.
+//   - It sets published_at on recipes published before it existed, using
+//     their creation time, so that they appear in feeds.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+)
+
+func main() {
+	ctx := context.Background()
+
+	// Read the Amazon Aurora DSQL endpoint from the environment.
+	endpoint := os.Getenv("DSQL_ENDPOINT")
+	if endpoint == "" {
+		log.Fatal("DSQL_ENDPOINT environment variable is required")
+	}
+
+	// Create the Amazon Aurora DSQL store with IAM token-based authentication.
+	dsqlStore, err := store.NewDSQLStore(ctx, endpoint)
+	if err != nil {
+		log.Fatalf("Failed to connect to Amazon Aurora DSQL: %v", err)
+	}
+	defer dsqlStore.Close()
+
+	// Ensure the recipe_ingredients table exists before writing to it.
+	if err := dsqlStore.Migrate(ctx); err != nil {
+		log.Fatalf("Failed to migrate database schema: %v", err)
+	}
+
+	n, err := dsqlStore.BackfillIngredients(ctx, 100)
+	if err != nil {
+		log.Fatalf("Failed to backfill ingredients after converting %d recipes: %v", n, err)
+	}
+	log.Printf("Converted ingredients for %d recipes", n)
+
+	n, duplicates, err := dsqlStore.BackfillEmailKeys(ctx, 100)
+	if err != nil {
+		log.Fatalf("Failed to backfill chef email keys after updating %d chefs: %v", n, err)
+	}
+	log.Printf("Set email keys for %d chefs", n)
+	for _, id := range duplicates {
+		log.Printf("Chef %s has a duplicate email and was skipped; change its email and re-run", id)
+	}
+
+	n, duplicates, err = dsqlStore.BackfillRatingKeys(ctx, 100)
+	if err != nil {
+		log.Fatalf("Failed to backfill rating keys after updating %d ratings: %v", n, err)
+	}
+	log.Printf("Set chef keys for %d ratings", n)
+	for _, id := range duplicates {
+		log.Printf("Rating %s duplicates another rating by the same chef and was skipped; delete one and re-run", id)
+	}
+
+	n, err = dsqlStore.BackfillPublishedAt(ctx, 100)
+	if err != nil {
+		log.Fatalf("Failed to backfill published_at after updating %d recipes: %v", n, err)
+	}
+	log.Printf("Set published_at for %d recipes", n)
+}
@@ -3,16 +3,22 @@
 
 // Command lambda runs the Recipe Sharing API on AWS Lambda behind
 // Amazon API Gateway. It wraps the Gin router with the aws-lambda-go-api-proxy
-// adapter and connects to Amazon Aurora DSQL for the database.
+// adapter and connects to Amazon Aurora DSQL for the database. Requests
+// are traced when TRACE_EXPORTER is set, and the spans of each invocation
+// are flushed before it returns.
 package main
 
 import (
 	"context"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/aws-samples/recipe-share-dsql-go/internal/router"
 	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/telemetry"
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	ginadapter "github.com/awslabs/aws-lambda-go-api-proxy/gin"
 	"github.com/gin-gonic/gin"
@@ -24,6 +30,12 @@ func main() {
 
 	ctx := context.Background()
 
+	tracing, err := telemetry.Setup(ctx, os.Getenv("TRACE_EXPORTER"))
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer tracing.Shutdown(ctx)
+
 	// Read the Amazon Aurora DSQL endpoint from the environment.
 	// This is set by the AWS CloudFormation template as a Lambda
 	// environment variable.
@@ -39,15 +51,63 @@ func main() {
 	}
 	defer dsqlStore.Close()
 
-	// Create the recipe_share schema and tables if they do not already exist.
-	if err := dsqlStore.InitSchema(ctx); err != nil {
-		log.Fatalf("Failed to initialize database schema: %v", err)
+	// Apply pending schema migrations. Concurrent cold starts coordinate
+	// through the migration lock, and the check is cheap once up to date.
+	if err := dsqlStore.Migrate(ctx); err != nil {
+		log.Fatalf("Failed to migrate database schema: %v", err)
+	}
+
+	// Recipe images are kept on the local filesystem under BLOB_DIR. The
+	// Lambda filesystem is ephemeral and per instance, so this suits
+	// demonstrations only; production deployments should use an Amazon S3
+	// backed store.BlobStore.
+	blobDir := os.Getenv("BLOB_DIR")
+	if blobDir == "" {
+		blobDir = "/tmp/blobs"
+	}
+	blobs, err := store.NewFSBlobStore(blobDir)
+	if err != nil {
+		log.Fatalf("Failed to open blob store: %v", err)
 	}
+	dsqlStore.SetBlobStore(blobs)
 
-	// Build the Gin router with the Amazon Aurora DSQL store.
-	r := router.New(dsqlStore)
+	// Build the Gin router with the Amazon Aurora DSQL store, cached if
+	// CACHE_SIZE is set. Each Lambda instance has its own cache, so a write
+	// may take up to CACHE_TTL to be seen by other instances.
+	r := router.New(store.NewTracingStore(withCache(dsqlStore)), blobs)
 
 	// Wrap the Gin router with the Lambda adapter and start the handler.
+	// Spans are flushed before each invocation returns, since the execution
+	// environment may be frozen before the next batch would be sent.
 	ginLambda := ginadapter.New(r)
-	lambda.Start(ginLambda.ProxyWithContext)
+	lambda.Start(func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		defer tracing.ForceFlush(ctx)
+		return ginLambda.ProxyWithContext(ctx, req)
+	})
+}
+
+// withCache wraps s in a read-through cache when CACHE_SIZE is set to a
+// positive number of entries. Entries expire after CACHE_TTL, which
+// defaults to 30 seconds.
+func withCache(s store.Store) store.Store {
+	v := os.Getenv("CACHE_SIZE")
+	if v == "" {
+		return s
+	}
+	size, err := strconv.Atoi(v)
+	if err != nil || size < 0 {
+		log.Fatalf("CACHE_SIZE must be a number of entries, got %q", v)
+	}
+	if size == 0 {
+		return s
+	}
+	ttl := 30 * time.Second
+	if v := os.Getenv("CACHE_TTL"); v != "" {
+		ttl, err = time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			log.Fatalf("CACHE_TTL must be a positive duration such as 30s, got %q", v)
+		}
+	}
+	log.Printf("Caching up to %d reads for %s", size, ttl)
+	return store.NewCachingStore(s, store.NewLRUCache(size), ttl)
 }
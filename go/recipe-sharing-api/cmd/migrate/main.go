@@ -0,0 +1,73 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Command migrate manages the Amazon Aurora DSQL schema for the Recipe
+// Sharing API.
+//
+//	migrate up      apply all pending migrations
+//	migrate status  list migrations and whether each has been applied
+//
+// The API and Lambda entrypoints also apply pending migrations on start-up;
+// this command lets you run them ahead of a deployment and inspect progress.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+)
+
+func main() {
+	if len(os.Args) != 2 || (os.Args[1] != "up" && os.Args[1] != "status") {
+		fmt.Fprintln(os.Stderr, "usage: migrate up|status")
+		os.Exit(2)
+	}
+	ctx := context.Background()
+
+	// Read the Amazon Aurora DSQL endpoint from the environment.
+	endpoint := os.Getenv("DSQL_ENDPOINT")
+	if endpoint == "" {
+		log.Fatal("DSQL_ENDPOINT environment variable is required")
+	}
+
+	// Create the Amazon Aurora DSQL store with IAM token-based authentication.
+	dsqlStore, err := store.NewDSQLStore(ctx, endpoint)
+	if err != nil {
+		log.Fatalf("Failed to connect to Amazon Aurora DSQL: %v", err)
+	}
+	defer dsqlStore.Close()
+
+	m, err := dsqlStore.Migrator()
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	switch os.Args[1] {
+	case "up":
+		n, err := m.Up(ctx)
+		if err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		log.Printf("Applied %d migrations", n)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATEMENTS\tAPPLIED AT")
+		for _, st := range statuses {
+			applied := "pending"
+			if st.Applied() {
+				applied = st.AppliedAt.Local().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%d/%d\t%s\n", st.Version, st.Name, st.StatementsApplied, st.Statements, applied)
+		}
+		w.Flush()
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Command publish publishes the drafts whose publish_at has passed and
+// exits. The local API server runs the same sweep in the background; on AWS
+// Lambda, where nothing runs between requests, run this command on a
+// schedule instead, for example every minute. Each recipe is published in
+// its own transaction after checking that it is still due, so overlapping
+// or repeated runs publish it only once.
+//
+//	publish
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+)
+
+func main() {
+	ctx := store.WithActor(context.Background(), "publisher")
+
+	// Read the Amazon Aurora DSQL endpoint from the environment.
+	endpoint := os.Getenv("DSQL_ENDPOINT")
+	if endpoint == "" {
+		log.Fatal("DSQL_ENDPOINT environment variable is required")
+	}
+
+	// Create the Amazon Aurora DSQL store with IAM token-based authentication.
+	dsqlStore, err := store.NewDSQLStore(ctx, endpoint)
+	if err != nil {
+		log.Fatalf("Failed to connect to Amazon Aurora DSQL: %v", err)
+	}
+	defer dsqlStore.Close()
+
+	if err := dsqlStore.Migrate(ctx); err != nil {
+		log.Fatalf("Failed to migrate database schema: %v", err)
+	}
+
+	now := time.Now().UTC()
+	n, err := dsqlStore.PublishDue(ctx, now)
+	if err != nil {
+		log.Fatalf("Failed to publish recipes due by %s after publishing %d: %v", now.Format(time.RFC3339), n, err)
+	}
+	log.Printf("Published %d recipes due by %s", n, now.Format(time.RFC3339))
+}
@@ -0,0 +1,69 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Command purge permanently removes chefs, recipes, and ratings that were
+// soft-deleted longer ago than the retention period. Purged recipes take
+// their ingredient lines, ratings, and images with them; image files are
+// removed from the blob store under BLOB_DIR, which defaults to data/blobs.
+// Rows are removed in batches that stay within the Amazon Aurora DSQL
+// transaction limits, children before parents, so an interrupted purge can
+// simply be run again.
+//
+// Run it on a schedule, for example daily:
+//
+//	purge -retention 720h
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+)
+
+func main() {
+	retention := flag.Duration("retention", 30*24*time.Hour, "how long soft-deleted rows are kept before they are purged")
+	flag.Parse()
+	if *retention <= 0 {
+		log.Fatal("-retention must be positive")
+	}
+	ctx := context.Background()
+
+	// Read the Amazon Aurora DSQL endpoint from the environment.
+	endpoint := os.Getenv("DSQL_ENDPOINT")
+	if endpoint == "" {
+		log.Fatal("DSQL_ENDPOINT environment variable is required")
+	}
+
+	// Create the Amazon Aurora DSQL store with IAM token-based authentication.
+	dsqlStore, err := store.NewDSQLStore(ctx, endpoint)
+	if err != nil {
+		log.Fatalf("Failed to connect to Amazon Aurora DSQL: %v", err)
+	}
+	defer dsqlStore.Close()
+
+	if err := dsqlStore.Migrate(ctx); err != nil {
+		log.Fatalf("Failed to migrate database schema: %v", err)
+	}
+
+	blobDir := os.Getenv("BLOB_DIR")
+	if blobDir == "" {
+		blobDir = "data/blobs"
+	}
+	blobs, err := store.NewFSBlobStore(blobDir)
+	if err != nil {
+		log.Fatalf("Failed to open blob store: %v", err)
+	}
+	dsqlStore.SetBlobStore(blobs)
+
+	cutoff := time.Now().Add(-*retention)
+	result, err := dsqlStore.PurgeDeleted(ctx, cutoff)
+	if err != nil {
+		log.Fatalf("Failed to purge rows deleted before %s after removing %+v: %v", cutoff.Format(time.RFC3339), result, err)
+	}
+	log.Printf("Purged rows deleted before %s: %d chefs, %d recipes, %d ratings, %d ingredient lines, %d images",
+		cutoff.Format(time.RFC3339), result.Chefs, result.Recipes, result.Ratings, result.Ingredients, result.Images)
+}
@@ -0,0 +1,131 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package diff compares versions of a recipe field by field and line by
+// line.
+package diff
+
+import (
+	"strings"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+)
+
+// maxCells bounds the table Lines fills to find the longest common
+// subsequence. Past it, the differing middle of the two texts is reported
+// as deleted and inserted whole rather than aligned line by line.
+const maxCells = 4_000_000
+
+// Recipes returns the changes from one version of a recipe to another.
+// Scalar fields are compared by value; the ingredient and instruction text
+// is compared line by line.
+func Recipes(from, to model.Recipe) model.RecipeDiff {
+	d := model.RecipeDiff{RecipeID: to.ID, From: from.Version, To: to.Version, Fields: []model.FieldChange{}}
+	field := func(name string, a, b any) {
+		if a != b {
+			d.Fields = append(d.Fields, model.FieldChange{Field: name, From: a, To: b})
+		}
+	}
+	field("title", from.Title, to.Title)
+	field("description", from.Description, to.Description)
+	field("prep_time", from.PrepTime, to.PrepTime)
+	field("cook_time", from.CookTime, to.CookTime)
+	field("servings", from.Servings, to.Servings)
+	field("difficulty", from.Difficulty, to.Difficulty)
+	field("cuisine", from.Cuisine, to.Cuisine)
+	field("status", from.Status, to.Status)
+
+	if from.Ingredients != to.Ingredients {
+		d.Ingredients = Lines(from.Ingredients, to.Ingredients)
+	}
+	if from.Instructions != to.Instructions {
+		d.Instructions = Lines(from.Instructions, to.Instructions)
+	}
+	return d
+}
+
+// Lines returns a line diff that turns text a into text b, keeping as many
+// lines as possible. Where a line is replaced, its deletion comes before
+// the insertion of its replacement. Trailing carriage returns are ignored.
+func Lines(a, b string) []model.LineChange {
+	x, y := split(a), split(b)
+
+	// Lines shared at the start and end need no alignment.
+	pre := 0
+	for pre < len(x) && pre < len(y) && x[pre] == y[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(x)-pre && suf < len(y)-pre && x[len(x)-1-suf] == y[len(y)-1-suf] {
+		suf++
+	}
+
+	var out []model.LineChange
+	for _, line := range x[:pre] {
+		out = append(out, model.LineChange{Op: model.LineEqual, Text: line})
+	}
+	out = append(out, align(x[pre:len(x)-suf], y[pre:len(y)-suf])...)
+	for _, line := range x[len(x)-suf:] {
+		out = append(out, model.LineChange{Op: model.LineEqual, Text: line})
+	}
+	return out
+}
+
+// split breaks text into lines. Empty text has no lines.
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// align diffs two runs of lines through their longest common subsequence.
+func align(x, y []string) []model.LineChange {
+	var out []model.LineChange
+	if len(x)*len(y) > maxCells {
+		for _, line := range x {
+			out = append(out, model.LineChange{Op: model.LineDelete, Text: line})
+		}
+		for _, line := range y {
+			out = append(out, model.LineChange{Op: model.LineInsert, Text: line})
+		}
+		return out
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			out = append(out, model.LineChange{Op: model.LineEqual, Text: x[i]})
+			i++
+			j++
+		case j == len(y) || (i < len(x) && lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, model.LineChange{Op: model.LineDelete, Text: x[i]})
+			i++
+		default:
+			out = append(out, model.LineChange{Op: model.LineInsert, Text: y[j]})
+			j++
+		}
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler holds the store dependency for audit log route handlers.
+type AuditHandler struct {
+	Store store.Store
+}
+
+// List returns one page of audit events for the entity named by the
+// entity_id query parameter, newest first.
+func (h *AuditHandler) List(c *gin.Context) {
+	entityID := c.Query("entity_id")
+	if entityID == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "entity_id is required"},
+		})
+		return
+	}
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	events, next, err := h.Store.ListAuditEvents(c.Request.Context(), entityID, page)
+	if err != nil {
+		log.Printf("ERROR list audit events for %s: %v", entityID, err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to list audit events"},
+		})
+		return
+	}
+	if events == nil {
+		events = []model.AuditEvent{}
+	}
+	c.JSON(http.StatusOK, model.ListResponse{Data: events, Count: len(events), NextCursor: next})
+}
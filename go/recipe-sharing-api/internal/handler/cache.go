@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// CacheHandler holds the caching store whose statistics it reports.
+type CacheHandler struct {
+	Store *store.CachingStore
+}
+
+// Stats returns the cache's hit, miss, invalidation, and error counts since
+// the process started.
+func (h *CacheHandler) Stats(c *gin.Context) {
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: h.Store.Stats()})
+}
@@ -4,6 +4,7 @@
 package handler
 
 import (
+	"errors"
 	"log"
 	"net/http"
 
@@ -17,9 +18,18 @@ type ChefHandler struct {
 	Store store.Store
 }
 
-// List returns all chefs.
+// List returns one page of chefs.
 func (h *ChefHandler) List(c *gin.Context) {
-	chefs, err := h.Store.ListChefs(c.Request.Context())
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	ctx, ok := readContext(c)
+	if !ok {
+		return
+	}
+
+	chefs, next, err := h.Store.ListChefs(ctx, page)
 	if err != nil {
 		log.Printf("ERROR list chefs: %v", err)
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
@@ -30,13 +40,17 @@ func (h *ChefHandler) List(c *gin.Context) {
 	if chefs == nil {
 		chefs = []model.Chef{}
 	}
-	c.JSON(http.StatusOK, model.ListResponse{Data: chefs, Count: len(chefs)})
+	c.JSON(http.StatusOK, model.ListResponse{Data: chefs, Count: len(chefs), NextCursor: next})
 }
 
 // Get returns a single chef by ID, including their recipes.
 func (h *ChefHandler) Get(c *gin.Context) {
 	id := c.Param("id")
-	chef, err := h.Store.GetChefWithRecipes(c.Request.Context(), id)
+	ctx, ok := readContext(c)
+	if !ok {
+		return
+	}
+	chef, err := h.Store.GetChefWithRecipes(ctx, id)
 	if err != nil {
 		log.Printf("ERROR get chef %s: %v", id, err)
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
@@ -50,10 +64,12 @@ func (h *ChefHandler) Get(c *gin.Context) {
 		})
 		return
 	}
+	setETag(c, chef.Version)
 	c.JSON(http.StatusOK, model.SuccessResponse{Data: chef})
 }
 
-// Create adds a new chef.
+// Create adds a new chef. Emails are unique regardless of case, so a
+// duplicate email gets a 409 response.
 func (h *ChefHandler) Create(c *gin.Context) {
 	var input model.CreateChefInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -64,6 +80,10 @@ func (h *ChefHandler) Create(c *gin.Context) {
 	}
 
 	chef, err := h.Store.CreateChef(c.Request.Context(), input)
+	if errors.Is(err, store.ErrConflict) {
+		conflict(c, "a chef with this email already exists")
+		return
+	}
 	if err != nil {
 		log.Printf("ERROR create chef: %v", err)
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
@@ -71,6 +91,7 @@ func (h *ChefHandler) Create(c *gin.Context) {
 		})
 		return
 	}
+	setETag(c, chef.Version)
 	c.JSON(http.StatusCreated, model.SuccessResponse{Data: chef})
 }
 
@@ -84,8 +105,21 @@ func (h *ChefHandler) Update(c *gin.Context) {
 		})
 		return
 	}
+	ifVersion, ok := parseIfMatch(c)
+	if !ok {
+		return
+	}
+	input.IfVersion = ifVersion
 
 	chef, err := h.Store.UpdateChef(c.Request.Context(), id, input)
+	if errors.Is(err, store.ErrPreconditionFailed) {
+		preconditionFailed(c)
+		return
+	}
+	if errors.Is(err, store.ErrConflict) {
+		conflict(c, "a chef with this email already exists")
+		return
+	}
 	if err != nil {
 		log.Printf("ERROR update chef %s: %v", id, err)
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
@@ -99,15 +133,20 @@ func (h *ChefHandler) Update(c *gin.Context) {
 		})
 		return
 	}
+	setETag(c, chef.Version)
 	c.JSON(http.StatusOK, model.SuccessResponse{Data: chef})
 }
 
-// Delete removes a chef by ID.
+// Delete soft-deletes a chef by ID along with their recipes, the ratings
+// they wrote, and the ratings on their recipes, and reports how many rows of
+// each kind were deleted. If it fails part-way the request can be retried:
+// deleting a chef that is already deleted finishes the cascade.
 func (h *ChefHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
-	// Verify the chef exists before deleting.
-	chef, err := h.Store.GetChef(c.Request.Context(), id)
+	// Verify the chef exists before deleting, including a chef whose
+	// deletion was interrupted.
+	chef, err := h.Store.GetChef(store.IncludeDeleted(c.Request.Context()), id)
 	if err != nil {
 		log.Printf("ERROR delete chef %s: %v", id, err)
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
@@ -122,12 +161,40 @@ func (h *ChefHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.Store.DeleteChef(c.Request.Context(), id); err != nil {
-		log.Printf("ERROR delete chef %s: %v", id, err)
+	result, err := h.Store.DeleteChef(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("ERROR delete chef %s after removing %+v: %v", id, result, err)
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to delete chef"},
 		})
 		return
 	}
-	c.JSON(http.StatusOK, model.SuccessResponse{Data: gin.H{"deleted": true}})
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: result})
+}
+
+// Restore undoes the deletion of a chef, restoring the recipes and ratings
+// that were deleted with them. A deleted chef's email may be reused, so
+// restoring returns 409 if another chef now has it.
+func (h *ChefHandler) Restore(c *gin.Context) {
+	id := c.Param("id")
+	chef, err := h.Store.RestoreChef(c.Request.Context(), id)
+	if errors.Is(err, store.ErrConflict) {
+		conflict(c, "another chef now uses this chef's email")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR restore chef %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to restore chef"},
+		})
+		return
+	}
+	if chef == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "chef not found"},
+		})
+		return
+	}
+	setETag(c, chef.Version)
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: chef})
 }
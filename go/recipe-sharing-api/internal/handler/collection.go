@@ -0,0 +1,292 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// CollectionHandler holds the store dependency for collection route
+// handlers.
+type CollectionHandler struct {
+	Store store.Store
+}
+
+// List returns one page of a chef's collections, newest first.
+func (h *CollectionHandler) List(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	chef, err := h.Store.GetChef(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("ERROR failed to verify chef: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to verify chef"},
+		})
+		return
+	}
+	if chef == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "chef not found"},
+		})
+		return
+	}
+
+	collections, next, err := h.Store.ListCollections(c.Request.Context(), chef.ID, page)
+	if err != nil {
+		log.Printf("ERROR failed to list collections: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to list collections"},
+		})
+		return
+	}
+	if collections == nil {
+		collections = []model.Collection{}
+	}
+	c.JSON(http.StatusOK, model.ListResponse{Data: collections, Count: len(collections), NextCursor: next})
+}
+
+// Get returns a collection with its items in order.
+func (h *CollectionHandler) Get(c *gin.Context) {
+	collection := h.findCollection(c)
+	if collection == nil {
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: collection})
+}
+
+// Create adds a new collection after verifying that the owning chef exists.
+func (h *CollectionHandler) Create(c *gin.Context) {
+	var input model.CreateCollectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "invalid request body"},
+		})
+		return
+	}
+
+	// Enforce referential integrity: verify the chef exists.
+	chef, err := h.Store.GetChef(c.Request.Context(), input.ChefID)
+	if err != nil {
+		log.Printf("ERROR failed to verify chef: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to verify chef"},
+		})
+		return
+	}
+	if chef == nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "chef_id references a chef that does not exist"},
+		})
+		return
+	}
+
+	collection, err := h.Store.CreateCollection(c.Request.Context(), input)
+	if err != nil {
+		log.Printf("ERROR failed to create collection: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to create collection"},
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, model.SuccessResponse{Data: collection})
+}
+
+// Update applies partial updates to a collection's name or description.
+func (h *CollectionHandler) Update(c *gin.Context) {
+	var input model.UpdateCollectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "invalid request body"},
+		})
+		return
+	}
+	if h.findCollection(c) == nil {
+		return
+	}
+
+	collection, err := h.Store.UpdateCollection(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		log.Printf("ERROR failed to update collection: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to update collection"},
+		})
+		return
+	}
+	if collection == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "collection not found"},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: collection})
+}
+
+// Delete permanently deletes a collection and its items. The recipes
+// themselves are not affected.
+func (h *CollectionHandler) Delete(c *gin.Context) {
+	if h.findCollection(c) == nil {
+		return
+	}
+	if _, err := h.Store.DeleteCollection(c.Request.Context(), c.Param("id")); err != nil {
+		log.Printf("ERROR failed to delete collection: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to delete collection"},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: gin.H{"deleted": true}})
+}
+
+// AddItem adds a recipe to a collection, at the end or at the requested
+// position, after verifying the recipe exists.
+func (h *CollectionHandler) AddItem(c *gin.Context) {
+	var input model.AddCollectionItemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "invalid request body"},
+		})
+		return
+	}
+	if h.findCollection(c) == nil {
+		return
+	}
+
+	// Enforce referential integrity: verify the recipe exists.
+	recipe, err := h.Store.GetRecipe(c.Request.Context(), input.RecipeID)
+	if err != nil {
+		log.Printf("ERROR failed to verify recipe: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to verify recipe"},
+		})
+		return
+	}
+	if recipe == nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "recipe_id references a recipe that does not exist"},
+		})
+		return
+	}
+
+	item, err := h.Store.AddCollectionItem(c.Request.Context(), c.Param("id"), input)
+	switch {
+	case errors.Is(err, store.ErrConflict):
+		conflict(c, "the recipe is already in this collection")
+		return
+	case errors.Is(err, store.ErrCollectionFull):
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	case err != nil:
+		log.Printf("ERROR failed to add collection item: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to add recipe to collection"},
+		})
+		return
+	case item == nil:
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "collection not found"},
+		})
+		return
+	}
+	item.Title = recipe.Title
+	c.JSON(http.StatusCreated, model.SuccessResponse{Data: item})
+}
+
+// MoveItem moves a recipe to a new position in a collection. The items in
+// between shift by one place.
+func (h *CollectionHandler) MoveItem(c *gin.Context) {
+	var input model.MoveCollectionItemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "position must be a number of at least 1"},
+		})
+		return
+	}
+	if h.findCollection(c) == nil {
+		return
+	}
+
+	item, err := h.Store.MoveCollectionItem(c.Request.Context(), c.Param("id"), c.Param("recipeId"), input.Position)
+	if err != nil {
+		log.Printf("ERROR failed to move collection item: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to move recipe in collection"},
+		})
+		return
+	}
+	if item == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "recipe is not in this collection"},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: item})
+}
+
+// RemoveItem removes a recipe from a collection.
+func (h *CollectionHandler) RemoveItem(c *gin.Context) {
+	if h.findCollection(c) == nil {
+		return
+	}
+
+	removed, err := h.Store.RemoveCollectionItem(c.Request.Context(), c.Param("id"), c.Param("recipeId"))
+	if err != nil {
+		log.Printf("ERROR failed to remove collection item: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to remove recipe from collection"},
+		})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "recipe is not in this collection"},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: gin.H{"deleted": true}})
+}
+
+// findCollection returns the collection named by the id path parameter if
+// it exists and its chef is not deleted. It writes a 404 or 500 response and
+// returns nil otherwise.
+func (h *CollectionHandler) findCollection(c *gin.Context) *model.CollectionWithItems {
+	collection, err := h.Store.GetCollection(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("ERROR failed to get collection: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to get collection"},
+		})
+		return nil
+	}
+	if collection != nil {
+		chef, err := h.Store.GetChef(c.Request.Context(), collection.ChefID)
+		if err != nil {
+			log.Printf("ERROR failed to verify chef: %v", err)
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to verify chef"},
+			})
+			return nil
+		}
+		if chef == nil {
+			collection = nil
+		}
+	}
+	if collection == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "collection not found"},
+		})
+		return nil
+	}
+	return collection
+}
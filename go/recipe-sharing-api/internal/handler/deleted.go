@@ -0,0 +1,43 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// readContext returns the context for a list or get request. With
+// include_deleted=true the store also returns soft-deleted rows. On invalid
+// input it writes a 400 response and returns false.
+func readContext(c *gin.Context) (context.Context, bool) {
+	ctx := c.Request.Context()
+	raw := c.Query("include_deleted")
+	if raw == "" {
+		return ctx, true
+	}
+	include, err := strconv.ParseBool(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "include_deleted must be true or false"},
+		})
+		return nil, false
+	}
+	if include {
+		ctx = store.IncludeDeleted(ctx)
+	}
+	return ctx, true
+}
+
+// conflict writes a 409 response.
+func conflict(c *gin.Context, message string) {
+	c.JSON(http.StatusConflict, model.ErrorResponse{
+		Error: model.ErrorDetail{Code: "CONFLICT", Message: message},
+	})
+}
@@ -0,0 +1,55 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// setETag sets the ETag response header to the resource version.
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", `"`+strconv.FormatInt(version, 10)+`"`)
+}
+
+// parseIfMatch reads the If-Match request header. It returns nil when the
+// header is absent or "*", which place no condition on the version. A header
+// holding a single strong entity tag yields that version. Weak tags never
+// match under the strong comparison If-Match requires, and tags this API did
+// not issue cannot match either, so both get a 412 response. On invalid
+// input it writes the response and returns false.
+func parseIfMatch(c *gin.Context) (*int64, bool) {
+	raw := strings.TrimSpace(c.GetHeader("If-Match"))
+	if raw == "" || raw == "*" {
+		return nil, true
+	}
+	if strings.Contains(raw, ",") {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "If-Match must contain a single entity tag"},
+		})
+		return nil, false
+	}
+
+	unquoted, ok := strings.CutPrefix(raw, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if !ok || err != nil {
+		preconditionFailed(c)
+		return nil, false
+	}
+	return &version, true
+}
+
+// preconditionFailed writes the 412 response for an If-Match mismatch.
+func preconditionFailed(c *gin.Context) {
+	c.JSON(http.StatusPreconditionFailed, model.ErrorResponse{
+		Error: model.ErrorDetail{Code: "PRECONDITION_FAILED", Message: "resource has been modified; fetch the latest version and retry"},
+	})
+}
@@ -0,0 +1,190 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// FollowHandler holds the store dependency for follow and feed route
+// handlers.
+type FollowHandler struct {
+	Store store.Store
+}
+
+// Follow makes the chef named by follower_id in the body a follower of the
+// chef named by the id path parameter. Following a chef again is not an
+// error; it returns 200 instead of 201.
+func (h *FollowHandler) Follow(c *gin.Context) {
+	var input model.FollowInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "invalid request body"},
+		})
+		return
+	}
+	followeeID := c.Param("id")
+	if !h.checkFollow(c, input.FollowerID, followeeID) {
+		return
+	}
+
+	created, err := h.Store.Follow(c.Request.Context(), input.FollowerID, followeeID)
+	if err != nil {
+		log.Printf("ERROR failed to follow chef: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to follow chef"},
+		})
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, model.SuccessResponse{Data: gin.H{"follower_id": input.FollowerID, "followee_id": followeeID}})
+}
+
+// Unfollow removes the follow of the chef named by the id path parameter by
+// the chef named by the follower_id query parameter.
+func (h *FollowHandler) Unfollow(c *gin.Context) {
+	followerID := c.Query("follower_id")
+	if followerID == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "follower_id is required"},
+		})
+		return
+	}
+
+	removed, err := h.Store.Unfollow(c.Request.Context(), followerID, c.Param("id"))
+	if err != nil {
+		log.Printf("ERROR failed to unfollow chef: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to unfollow chef"},
+		})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "chef is not followed by follower_id"},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: gin.H{"deleted": true}})
+}
+
+// Followers returns one page of the chefs who follow a chef, most recent
+// follow first.
+func (h *FollowHandler) Followers(c *gin.Context) {
+	h.list(c, h.Store.ListFollowers)
+}
+
+// Following returns one page of the chefs a chef follows, most recent
+// follow first.
+func (h *FollowHandler) Following(c *gin.Context) {
+	h.list(c, h.Store.ListFollowing)
+}
+
+func (h *FollowHandler) list(c *gin.Context, fetch func(context.Context, string, model.PageRequest) ([]model.FollowedChef, string, error)) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	chefID := c.Param("id")
+	if !h.chefExists(c, chefID, http.StatusNotFound) {
+		return
+	}
+
+	chefs, next, err := fetch(c.Request.Context(), chefID, page)
+	if err != nil {
+		log.Printf("ERROR failed to list follows: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to list follows"},
+		})
+		return
+	}
+	if chefs == nil {
+		chefs = []model.FollowedChef{}
+	}
+	c.JSON(http.StatusOK, model.ListResponse{Data: chefs, Count: len(chefs), NextCursor: next})
+}
+
+// Feed returns one page of the recently published recipes of the chefs
+// that the chef named by the chef_id query parameter follows, newest first.
+func (h *FollowHandler) Feed(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	chefID := c.Query("chef_id")
+	if chefID == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "chef_id is required"},
+		})
+		return
+	}
+	if !h.chefExists(c, chefID, http.StatusNotFound) {
+		return
+	}
+
+	recipes, next, err := h.Store.Feed(c.Request.Context(), chefID, page)
+	if err != nil {
+		log.Printf("ERROR failed to list feed: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to list feed"},
+		})
+		return
+	}
+	if recipes == nil {
+		recipes = []model.Recipe{}
+	}
+	c.JSON(http.StatusOK, model.ListResponse{Data: recipes, Count: len(recipes), NextCursor: next})
+}
+
+// checkFollow verifies that a follow is allowed: a chef cannot follow
+// themselves, the followee must exist, and so must the follower. It writes
+// an error response and returns false otherwise.
+func (h *FollowHandler) checkFollow(c *gin.Context, followerID, followeeID string) bool {
+	if followerID == followeeID {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "chefs cannot follow themselves"},
+		})
+		return false
+	}
+	if !h.chefExists(c, followeeID, http.StatusNotFound) {
+		return false
+	}
+	return h.chefExists(c, followerID, http.StatusBadRequest)
+}
+
+// chefExists reports whether a live chef exists. It writes a 500 response,
+// or a response with the missing status, and returns false otherwise. A
+// missing chef named in the path is a 404; one named in the body is a 400.
+func (h *FollowHandler) chefExists(c *gin.Context, id string, missing int) bool {
+	chef, err := h.Store.GetChef(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("ERROR failed to verify chef: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to verify chef"},
+		})
+		return false
+	}
+	if chef != nil {
+		return true
+	}
+	if missing == http.StatusBadRequest {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "follower_id references a chef that does not exist"},
+		})
+	} else {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "chef not found"},
+		})
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// Fork copies another chef's published recipe into a new draft owned by
+// the chef named by chef_id in the body. The draft records the recipe it
+// was forked from.
+func (h *RecipeHandler) Fork(c *gin.Context) {
+	var input model.ForkRecipeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "invalid request body"},
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	src, err := h.Store.GetRecipe(ctx, c.Param("id"))
+	if err != nil {
+		log.Printf("ERROR failed to get recipe: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to get recipe"},
+		})
+		return
+	}
+	if src == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "recipe not found"},
+		})
+		return
+	}
+	if src.ChefID == input.ChefID {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "chefs cannot fork their own recipes"},
+		})
+		return
+	}
+	chef, err := h.Store.GetChef(ctx, input.ChefID)
+	if err != nil {
+		log.Printf("ERROR failed to verify chef: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to verify chef"},
+		})
+		return
+	}
+	if chef == nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "chef_id references a chef that does not exist"},
+		})
+		return
+	}
+
+	fork, err := h.Store.ForkRecipe(ctx, src.ID, input.ChefID)
+	if errors.Is(err, store.ErrNotPublished) {
+		conflict(c, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR failed to fork recipe: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to fork recipe"},
+		})
+		return
+	}
+	// The recipe was deleted between the read above and the fork.
+	if fork == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "recipe not found"},
+		})
+		return
+	}
+	setETag(c, fork.Version)
+	c.JSON(http.StatusCreated, model.SuccessResponse{Data: fork})
+}
+
+// Lineage returns the fork history of a recipe: the recipes it descends
+// from, nearest first, and one page of its direct forks, newest first.
+func (h *RecipeHandler) Lineage(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	ctx, ok := readContext(c)
+	if !ok {
+		return
+	}
+
+	lineage, err := h.Store.RecipeLineage(ctx, c.Param("id"), page)
+	if err != nil {
+		log.Printf("ERROR failed to get lineage: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to get lineage"},
+		})
+		return
+	}
+	if lineage == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "recipe not found"},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: lineage})
+}
@@ -0,0 +1,247 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/images"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead allows for the multipart headers and boundaries around
+// an image of model.MaxImageBytes.
+const multipartOverhead = 64 << 10
+
+// ImageHandler holds the store and blob store dependencies for recipe image
+// route handlers.
+type ImageHandler struct {
+	Store store.Store
+	Blobs store.BlobStore
+}
+
+// Upload accepts an image for a recipe as the "image" field of a multipart
+// form. The image must be JPEG, PNG, GIF, or WebP and at most
+// model.MaxImageBytes. The image and a thumbnail are written to the blob
+// store before the metadata row, so a failed upload never leaves a row
+// pointing at missing blobs.
+func (h *ImageHandler) Upload(c *gin.Context) {
+	if !recipeExists(c, h.Store) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, model.MaxImageBytes+multipartOverhead)
+	file, err := c.FormFile("image")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || (err == nil && file.Size > model.MaxImageBytes) {
+		c.JSON(http.StatusRequestEntityTooLarge, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "PAYLOAD_TOO_LARGE", Message: fmt.Sprintf("images must be at most %d MiB", model.MaxImageBytes>>20)},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "request must be a multipart form with an image field"},
+		})
+		return
+	}
+	data, err := readFormFile(file)
+	if err != nil {
+		log.Printf("ERROR failed to read upload: %v", err)
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "failed to read image"},
+		})
+		return
+	}
+
+	processed, err := images.Process(data, file.Header.Get("Content-Type"))
+	if errors.Is(err, images.ErrUnsupportedType) {
+		c.JSON(http.StatusUnsupportedMediaType, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "UNSUPPORTED_MEDIA_TYPE", Message: err.Error()},
+		})
+		return
+	}
+	if errors.Is(err, images.ErrInvalidImage) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR failed to process image: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to process image"},
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	recipeID, id := c.Param("id"), uuid.New().String()
+	img := model.RecipeImage{
+		ID:                   id,
+		RecipeID:             recipeID,
+		ContentType:          processed.ContentType,
+		SizeBytes:            int64(len(data)),
+		Width:                processed.Width,
+		Height:               processed.Height,
+		ThumbnailContentType: processed.ThumbnailContentType,
+		BlobKey:              fmt.Sprintf("recipes/%s/images/%s", recipeID, id),
+		ThumbnailKey:         fmt.Sprintf("recipes/%s/thumbnails/%s", recipeID, id),
+	}
+	err = h.Blobs.Put(ctx, img.BlobKey, img.ContentType, bytes.NewReader(data))
+	if err == nil {
+		err = h.Blobs.Put(ctx, img.ThumbnailKey, img.ThumbnailContentType, bytes.NewReader(processed.Thumbnail))
+	}
+	if err != nil {
+		h.discard(ctx, img)
+		log.Printf("ERROR failed to store image: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to store image"},
+		})
+		return
+	}
+
+	created, err := h.Store.CreateRecipeImage(ctx, img)
+	if err != nil {
+		h.discard(ctx, img)
+	}
+	if errors.Is(err, store.ErrTooManyImages) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR failed to create image: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to create image"},
+		})
+		return
+	}
+	withURLs(created)
+	c.JSON(http.StatusCreated, model.SuccessResponse{Data: created})
+}
+
+// readFormFile reads an uploaded file into memory. Uploads are bounded by
+// model.MaxImageBytes, and decoding needs the whole file anyway.
+func readFormFile(file *multipart.FileHeader) ([]byte, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// discard removes the blobs of an image whose upload failed.
+func (h *ImageHandler) discard(ctx context.Context, img model.RecipeImage) {
+	for _, key := range []string{img.BlobKey, img.ThumbnailKey} {
+		if err := h.Blobs.Delete(ctx, key); err != nil {
+			log.Printf("ERROR failed to remove blob %s of failed upload: %v", key, err)
+		}
+	}
+}
+
+// List returns the images of a recipe in upload order.
+func (h *ImageHandler) List(c *gin.Context) {
+	if !recipeExists(c, h.Store) {
+		return
+	}
+
+	imgs, err := h.Store.ListRecipeImages(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("ERROR failed to list images: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to list images"},
+		})
+		return
+	}
+	if imgs == nil {
+		imgs = []model.RecipeImage{}
+	}
+	for i := range imgs {
+		withURLs(&imgs[i])
+	}
+	c.JSON(http.StatusOK, model.ListResponse{Data: imgs, Count: len(imgs)})
+}
+
+// Get serves the bytes of a recipe image.
+func (h *ImageHandler) Get(c *gin.Context) {
+	img := h.findImage(c)
+	if img == nil {
+		return
+	}
+	h.serve(c, img.BlobKey, img.ContentType, img.SizeBytes)
+}
+
+// Thumbnail serves the thumbnail of a recipe image.
+func (h *ImageHandler) Thumbnail(c *gin.Context) {
+	img := h.findImage(c)
+	if img == nil {
+		return
+	}
+	h.serve(c, img.ThumbnailKey, img.ThumbnailContentType, -1)
+}
+
+// findImage returns the image named by the path parameters of a live
+// recipe. It writes a 404 or 500 response and returns nil otherwise.
+func (h *ImageHandler) findImage(c *gin.Context) *model.RecipeImage {
+	if !recipeExists(c, h.Store) {
+		return nil
+	}
+	img, err := h.Store.GetRecipeImage(c.Request.Context(), c.Param("id"), c.Param("imageId"))
+	if err != nil {
+		log.Printf("ERROR failed to get image: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to get image"},
+		})
+		return nil
+	}
+	if img == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "image not found"},
+		})
+		return nil
+	}
+	return img
+}
+
+// serve streams a blob to the client. Image blobs never change once
+// written, so clients may cache them indefinitely.
+func (h *ImageHandler) serve(c *gin.Context, key, contentType string, size int64) {
+	r, err := h.Blobs.Open(c.Request.Context(), key)
+	if errors.Is(err, store.ErrBlobNotFound) {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "image not found"},
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR failed to open image: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to open image"},
+		})
+		return
+	}
+	defer r.Close()
+	c.DataFromReader(http.StatusOK, size, contentType, r, map[string]string{
+		"Cache-Control": "public, max-age=31536000, immutable",
+	})
+}
+
+// withURLs sets the API paths that serve an image and its thumbnail.
+func withURLs(img *model.RecipeImage) {
+	img.URL = fmt.Sprintf("/api/v1/recipes/%s/images/%s", img.RecipeID, img.ID)
+	img.ThumbnailURL = img.URL + "/thumbnail"
+}
@@ -0,0 +1,380 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// recipeCSVColumns are the columns of a recipe CSV export, in order. Imports
+// accept any subset in any order, and ignore id, created_at, and updated_at
+// so that an export can be imported again.
+var recipeCSVColumns = []string{
+	"id", "chef_id", "title", "description", "ingredients", "instructions",
+	"prep_time", "cook_time", "servings", "difficulty", "cuisine", "status",
+	"created_at", "updated_at",
+}
+
+// importFormats maps the content types accepted by Import to formats.
+var importFormats = map[string]string{
+	"application/x-ndjson": model.FormatNDJSON,
+	"application/jsonl":    model.FormatNDJSON,
+	"text/csv":             model.FormatCSV,
+}
+
+// exportContentTypes maps export formats to their content types.
+var exportContentTypes = map[string]string{
+	model.FormatNDJSON: "application/x-ndjson",
+	model.FormatCSV:    "text/csv; charset=utf-8",
+}
+
+// ImportHandler holds the store dependency for the bulk import and export
+// route handlers.
+type ImportHandler struct {
+	Store store.Store
+}
+
+// importRow is one parsed row of an import, with the reason it was
+// rejected if it was.
+type importRow struct {
+	line  int
+	input model.CreateRecipeInput
+	err   string
+}
+
+// Import creates recipes in bulk from NDJSON, one recipe object per line,
+// or CSV with a header row naming the columns. The format comes from the
+// Content-Type header. Every row is validated with the rules Create uses;
+// valid rows are inserted and invalid ones skipped, and the response
+// reports the outcome of every row.
+func (h *ImportHandler) Import(c *gin.Context) {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	format, ok := importFormats[mediaType]
+	if !ok {
+		c.JSON(http.StatusUnsupportedMediaType, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "UNSUPPORTED_MEDIA_TYPE", Message: "Content-Type must be application/x-ndjson or text/csv"},
+		})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, model.MaxImportBytes)
+	var rows []importRow
+	var err error
+	if format == model.FormatCSV {
+		rows, err = parseCSVRows(body)
+	} else {
+		rows, err = parseNDJSONRows(body)
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "PAYLOAD_TOO_LARGE", Message: fmt.Sprintf("imports must be at most %d MiB", model.MaxImportBytes>>20)},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "import contains no rows"},
+		})
+		return
+	}
+
+	if !h.validateRows(c, rows) {
+		return
+	}
+	var valid []int
+	var inputs []model.CreateRecipeInput
+	for i, row := range rows {
+		if row.err == "" {
+			valid = append(valid, i)
+			inputs = append(inputs, row.input)
+		}
+	}
+
+	created, err := h.Store.CreateRecipes(c.Request.Context(), inputs)
+	if err != nil {
+		log.Printf("ERROR failed to import recipes after %d of %d: %v", len(created), len(inputs), err)
+	}
+	report := model.ImportReport{Total: len(rows), Rows: make([]model.ImportRowResult, len(rows))}
+	for i, row := range rows {
+		report.Rows[i] = model.ImportRowResult{Row: row.line, Status: model.ImportFailed, Error: row.err}
+	}
+	for n, i := range valid {
+		if n < len(created) {
+			report.Rows[i].Status, report.Rows[i].ID = model.ImportCreated, created[n].ID
+		} else {
+			report.Rows[i].Error = "failed to create recipe"
+		}
+	}
+	for _, r := range report.Rows {
+		if r.Status == model.ImportCreated {
+			report.Created++
+		} else {
+			report.Failed++
+		}
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: report})
+}
+
+// validateRows applies the binding tags and validateRecipeInput rules to
+// every row not already rejected, and checks that each referenced chef
+// exists, looking each chef up once. It writes a 500 response and returns
+// false if a chef cannot be looked up.
+func (h *ImportHandler) validateRows(c *gin.Context, rows []importRow) bool {
+	chefs := make(map[string]bool)
+	for i := range rows {
+		row := &rows[i]
+		if row.err != "" {
+			continue
+		}
+		if err := binding.Validator.ValidateStruct(&row.input); err != nil {
+			row.err = describeValidation(err)
+			continue
+		}
+		if msg := validateRecipeInput(row.input); msg != "" {
+			row.err = msg
+			continue
+		}
+		exists, seen := chefs[row.input.ChefID]
+		if !seen {
+			chef, err := h.Store.GetChef(c.Request.Context(), row.input.ChefID)
+			if err != nil {
+				log.Printf("ERROR failed to verify chef: %v", err)
+				c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+					Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to verify chef"},
+				})
+				return false
+			}
+			exists = chef != nil
+			chefs[row.input.ChefID] = exists
+		}
+		if !exists {
+			row.err = "chef_id references a chef that does not exist"
+		}
+	}
+	return true
+}
+
+// describeValidation turns binding validation errors into a message naming
+// the JSON fields that failed and the rules they broke.
+func describeValidation(err error) string {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err.Error()
+	}
+	inputType := reflect.TypeFor[model.CreateRecipeInput]()
+	msgs := make([]string, len(verrs))
+	for i, fe := range verrs {
+		name := fe.Namespace()
+		if f, ok := inputType.FieldByName(fe.StructField()); ok && fe.Namespace() == "CreateRecipeInput."+fe.StructField() {
+			name, _, _ = strings.Cut(f.Tag.Get("json"), ",")
+		}
+		msgs[i] = fmt.Sprintf("%s failed the %s rule", name, fe.Tag())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// parseNDJSONRows reads one recipe object per line, skipping blank lines.
+// A line that is not a valid recipe object is reported as a rejected row.
+func parseNDJSONRows(r io.Reader) ([]importRow, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), model.MaxImportBytes)
+	var rows []importRow
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		if len(rows) == model.MaxImportRows {
+			return nil, fmt.Errorf("imports can hold at most %d rows", model.MaxImportRows)
+		}
+		row := importRow{line: line}
+		if err := json.Unmarshal([]byte(text), &row.input); err != nil {
+			row.err = "invalid JSON: " + err.Error()
+		}
+		rows = append(rows, row)
+	}
+	return rows, sc.Err()
+}
+
+// parseCSVRows reads a header row naming recipeCSVColumns and one recipe
+// per following record. Records that cannot be parsed, or whose numeric
+// columns are not integers, are reported as rejected rows.
+func parseCSVRows(r io.Reader) ([]importRow, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+		if !slices.Contains(recipeCSVColumns, header[i]) {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return nil, err
+		}
+		if len(rows) == model.MaxImportRows {
+			return nil, fmt.Errorf("imports can hold at most %d rows", model.MaxImportRows)
+		}
+		if parseErr != nil {
+			rows = append(rows, importRow{line: parseErr.StartLine, err: parseErr.Err.Error()})
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		row := importRow{line: line}
+		row.input, row.err = csvRecipeInput(header, record)
+		rows = append(rows, row)
+	}
+}
+
+// csvRecipeInput builds a create input from a CSV record, and returns a
+// message describing the first malformed column or "".
+func csvRecipeInput(header, record []string) (model.CreateRecipeInput, string) {
+	var in model.CreateRecipeInput
+	for i, name := range header {
+		v := record[i]
+		var dst *int
+		switch name {
+		case "chef_id":
+			in.ChefID = v
+		case "title":
+			in.Title = v
+		case "description":
+			in.Description = v
+		case "ingredients":
+			in.Ingredients = v
+		case "instructions":
+			in.Instructions = v
+		case "difficulty":
+			in.Difficulty = v
+		case "cuisine":
+			in.Cuisine = v
+		case "status":
+			in.Status = v
+		case "prep_time":
+			dst = &in.PrepTime
+		case "cook_time":
+			dst = &in.CookTime
+		case "servings":
+			dst = &in.Servings
+		}
+		if dst != nil && strings.TrimSpace(v) != "" {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return in, name + " must be an integer"
+			}
+			*dst = n
+		}
+	}
+	return in, ""
+}
+
+// Export streams every live recipe as NDJSON, the default, or CSV with a
+// header row, selected with the format query parameter. Recipes are written
+// as they are read from the store, so the response is never held in memory.
+// An error after the first recipe has been written cannot change the
+// status, so it is logged and the response is cut short.
+func (h *ImportHandler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", model.FormatNDJSON)
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "format must be one of: ndjson, csv"},
+		})
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="recipes.%s"`, format))
+	c.Status(http.StatusOK)
+
+	var write func(model.Recipe) error
+	var flush func() error
+	if format == model.FormatCSV {
+		w := csv.NewWriter(c.Writer)
+		if err := w.Write(recipeCSVColumns); err != nil {
+			log.Printf("ERROR failed to export recipes: %v", err)
+			return
+		}
+		write = func(r model.Recipe) error { return w.Write(recipeCSVRecord(r)) }
+		flush = func() error { w.Flush(); return w.Error() }
+	} else {
+		enc := json.NewEncoder(c.Writer)
+		write = func(r model.Recipe) error { return enc.Encode(r) }
+		flush = func() error { return nil }
+	}
+
+	n := 0
+	err := h.Store.ExportRecipes(c.Request.Context(), func(r model.Recipe) error {
+		if err := write(r); err != nil {
+			return err
+		}
+		if n++; n%100 == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		log.Printf("ERROR failed to export recipes after %d: %v", n, err)
+	}
+}
+
+// recipeCSVRecord renders a recipe as a record of recipeCSVColumns.
+func recipeCSVRecord(r model.Recipe) []string {
+	itoa := func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+	return []string{
+		r.ID, r.ChefID, r.Title, r.Description, r.Ingredients, r.Instructions,
+		itoa(r.PrepTime), itoa(r.CookTime), itoa(r.Servings), r.Difficulty, r.Cuisine, r.Status,
+		r.CreatedAt.Format(time.RFC3339Nano), r.UpdatedAt.Format(time.RFC3339Nano),
+	}
+}
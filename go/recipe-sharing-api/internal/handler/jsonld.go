@@ -0,0 +1,136 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/schemaorg"
+	"github.com/gin-gonic/gin"
+)
+
+// recipeDocumentTypes are the content types accepted by ImportDocument.
+var recipeDocumentTypes = []string{
+	"text/html", "application/xhtml+xml", schemaorg.MediaType, "application/json",
+}
+
+// writeJSONLD writes a recipe as a schema.org Recipe in JSON-LD, naming its
+// chef as the author.
+func (h *RecipeHandler) writeJSONLD(c *gin.Context, recipe model.Recipe) {
+	chef, err := h.Store.GetChef(c.Request.Context(), recipe.ChefID)
+	if err != nil {
+		log.Printf("ERROR failed to get chef: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to get recipe"},
+		})
+		return
+	}
+	var author string
+	if chef != nil {
+		author = chef.Name
+	}
+	c.Header("Content-Type", schemaorg.MediaType+"; charset=utf-8")
+	c.JSON(http.StatusOK, schemaorg.FromRecipe(recipe, author))
+}
+
+// ImportDocument creates a draft recipe for the chef named by the chef_id
+// query parameter from a schema.org Recipe. The body is either an HTML page
+// carrying JSON-LD structured data or a JSON-LD document itself; the first
+// Recipe found is used, and its durations, yield, ingredients, and
+// instructions are mapped onto the recipe fields.
+func (h *RecipeHandler) ImportDocument(c *gin.Context) {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if !slices.Contains(recipeDocumentTypes, mediaType) {
+		c.JSON(http.StatusUnsupportedMediaType, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "UNSUPPORTED_MEDIA_TYPE", Message: "Content-Type must be one of: " + strings.Join(recipeDocumentTypes, ", ")},
+		})
+		return
+	}
+	chefID := c.Query("chef_id")
+	if chefID == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "chef_id is required"},
+		})
+		return
+	}
+
+	doc, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, model.MaxRecipeDocumentBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "PAYLOAD_TOO_LARGE", Message: fmt.Sprintf("documents must be at most %d MiB", model.MaxRecipeDocumentBytes>>20)},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "failed to read request body"},
+		})
+		return
+	}
+
+	input, err := schemaorg.Extract(doc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+	input.ChefID = chefID
+	if msg := validateRecipeDocument(input); msg != "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: msg},
+		})
+		return
+	}
+
+	chef, err := h.Store.GetChef(c.Request.Context(), chefID)
+	if err != nil {
+		log.Printf("ERROR failed to verify chef: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to verify chef"},
+		})
+		return
+	}
+	if chef == nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "chef_id references a chef that does not exist"},
+		})
+		return
+	}
+
+	recipe, err := h.Store.CreateRecipe(c.Request.Context(), input)
+	if err != nil {
+		log.Printf("ERROR failed to create recipe: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to create recipe"},
+		})
+		return
+	}
+	setETag(c, recipe.Version)
+	c.JSON(http.StatusCreated, model.SuccessResponse{Data: recipe})
+}
+
+// validateRecipeDocument checks that an extracted recipe has the fields a
+// recipe needs, naming them as schema.org does, and then applies the rules
+// for a new recipe. It returns a message describing the first problem or "".
+func validateRecipeDocument(input model.CreateRecipeInput) string {
+	switch {
+	case input.Title == "":
+		return "Recipe has no name"
+	case input.Ingredients == "":
+		return "Recipe has no recipeIngredient"
+	case input.Instructions == "":
+		return "Recipe has no recipeInstructions"
+	}
+	return validateRecipeInput(input)
+}
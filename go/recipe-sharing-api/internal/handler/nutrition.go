@@ -0,0 +1,50 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/ingredients"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/nutrition"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// NutritionHandler holds the store dependency for the nutrition route
+// handler.
+type NutritionHandler struct {
+	Store store.Store
+}
+
+// Get estimates the calories, protein, fat, and carbohydrates of a recipe,
+// in total and per serving, and lists the ingredient lines that could not
+// be estimated.
+func (h *NutritionHandler) Get(c *gin.Context) {
+	recipe, err := h.Store.GetRecipe(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("ERROR failed to get recipe: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to get recipe"},
+		})
+		return
+	}
+	if recipe == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "recipe not found"},
+		})
+		return
+	}
+
+	// Recipes that predate structured ingredients are parsed on the fly.
+	list := recipe.IngredientList
+	if len(list) == 0 {
+		list = ingredients.Parse(recipe.Ingredients)
+	}
+	estimate := nutrition.Estimate(list, recipe.Servings)
+	estimate.RecipeID = recipe.ID
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: estimate})
+}
@@ -0,0 +1,42 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// parsePage reads the limit and cursor query parameters. On invalid input it
+// writes a 400 response and returns false.
+func parsePage(c *gin.Context) (model.PageRequest, bool) {
+	var page model.PageRequest
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > model.MaxPageLimit {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: fmt.Sprintf("limit must be an integer between 1 and %d", model.MaxPageLimit)},
+			})
+			return page, false
+		}
+		page.Limit = limit
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := model.DecodeCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "cursor is malformed"},
+			})
+			return page, false
+		}
+		page.Cursor = cursor
+	}
+	return page, true
+}
@@ -0,0 +1,76 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// Publish makes a draft or archived recipe public and records when in
+// published_at. The body is optional; a publish_at in the future schedules
+// a draft to be published then by the publishing sweep instead.
+func (h *RecipeHandler) Publish(c *gin.Context) {
+	var input model.PublishInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "invalid request body"},
+		})
+		return
+	}
+	h.transition(c, model.TransitionPublish, input.PublishAt)
+}
+
+// Unpublish returns a published recipe to draft, or cancels the schedule of
+// a draft waiting to be published.
+func (h *RecipeHandler) Unpublish(c *gin.Context) {
+	h.transition(c, model.TransitionUnpublish, nil)
+}
+
+// Archive retires a draft or published recipe. An archived recipe can only
+// be published again.
+func (h *RecipeHandler) Archive(c *gin.Context) {
+	h.transition(c, model.TransitionArchive, nil)
+}
+
+// transition applies a status transition to the recipe named by the id path
+// parameter. A transition the recipe's status does not allow is a 409.
+func (h *RecipeHandler) transition(c *gin.Context, name string, publishAt *time.Time) {
+	ifVersion, ok := parseIfMatch(c)
+	if !ok {
+		return
+	}
+
+	recipe, err := h.Store.TransitionRecipe(c.Request.Context(), c.Param("id"), name, publishAt, ifVersion)
+	if errors.Is(err, store.ErrPreconditionFailed) {
+		preconditionFailed(c)
+		return
+	}
+	if errors.Is(err, store.ErrInvalidTransition) {
+		conflict(c, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR failed to %s recipe: %v", name, err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to " + name + " recipe"},
+		})
+		return
+	}
+	if recipe == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "recipe not found"},
+		})
+		return
+	}
+	setETag(c, recipe.Version)
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: recipe})
+}
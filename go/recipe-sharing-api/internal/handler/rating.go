@@ -4,6 +4,8 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 
@@ -17,12 +19,21 @@ type RatingHandler struct {
 	Store store.Store
 }
 
-// List returns all ratings for a given recipe.
+// List returns one page of ratings for a given recipe.
 func (h *RatingHandler) List(c *gin.Context) {
 	recipeID := c.Param("id")
 
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	ctx, ok := readContext(c)
+	if !ok {
+		return
+	}
+
 	// Verify the recipe exists.
-	recipe, err := h.Store.GetRecipe(c.Request.Context(), recipeID)
+	recipe, err := h.Store.GetRecipe(ctx, recipeID)
 	if err != nil {
 		log.Printf("ERROR failed to verify recipe: %v", err)
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
@@ -37,7 +48,7 @@ func (h *RatingHandler) List(c *gin.Context) {
 		return
 	}
 
-	ratings, err := h.Store.ListRatings(c.Request.Context(), recipeID)
+	ratings, next, err := h.Store.ListRatings(ctx, recipeID, page)
 	if err != nil {
 		log.Printf("ERROR failed to list ratings: %v", err)
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
@@ -48,12 +59,15 @@ func (h *RatingHandler) List(c *gin.Context) {
 	if ratings == nil {
 		ratings = []model.Rating{}
 	}
-	c.JSON(http.StatusOK, model.ListResponse{Data: ratings, Count: len(ratings)})
+	c.JSON(http.StatusOK, model.ListResponse{Data: ratings, Count: len(ratings), NextCursor: next})
 }
 
 // Create adds a new rating to a recipe after verifying both the recipe and
 // the rating chef exist. This enforces referential integrity at the
 // application layer since Amazon Aurora DSQL does not support foreign keys.
+// A chef may rate a recipe only once and may not rate their own recipes.
+// With ?upsert=true, a second rating by the same chef replaces the first
+// and the response is 200 rather than 201.
 func (h *RatingHandler) Create(c *gin.Context) {
 	recipeID := c.Param("id")
 
@@ -96,8 +110,24 @@ func (h *RatingHandler) Create(c *gin.Context) {
 		})
 		return
 	}
+	if recipe.ChefID == input.ChefID {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "chefs cannot rate their own recipes"},
+		})
+		return
+	}
 
-	rating, err := h.Store.CreateRating(c.Request.Context(), recipeID, input)
+	var rating *model.Rating
+	created := true
+	if c.Query("upsert") == "true" {
+		rating, created, err = h.Store.UpsertRating(c.Request.Context(), recipeID, input)
+	} else {
+		rating, err = h.Store.CreateRating(c.Request.Context(), recipeID, input)
+	}
+	if errors.Is(err, store.ErrConflict) {
+		conflict(c, "the chef has already rated this recipe; update that rating or use ?upsert=true")
+		return
+	}
 	if err != nil {
 		log.Printf("ERROR failed to create rating: %v", err)
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
@@ -105,5 +135,131 @@ func (h *RatingHandler) Create(c *gin.Context) {
 		})
 		return
 	}
-	c.JSON(http.StatusCreated, model.SuccessResponse{Data: rating})
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
+	c.JSON(status, model.SuccessResponse{Data: rating})
+}
+
+// findRating returns the rating named by the ratingId path parameter if it
+// belongs to the recipe named by id. It writes a 404 or 500 response and
+// returns nil otherwise.
+func (h *RatingHandler) findRating(ctx context.Context, c *gin.Context) *model.Rating {
+	rating, err := h.Store.GetRating(ctx, c.Param("ratingId"))
+	if err != nil {
+		log.Printf("ERROR failed to get rating: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to get rating"},
+		})
+		return nil
+	}
+	if rating == nil || rating.RecipeID != c.Param("id") {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "rating not found"},
+		})
+		return nil
+	}
+	return rating
+}
+
+// Update applies partial updates to the score or comment of a rating.
+func (h *RatingHandler) Update(c *gin.Context) {
+	var input model.UpdateRatingInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "invalid request body"},
+		})
+		return
+	}
+
+	rating := h.findRating(c.Request.Context(), c)
+	if rating == nil {
+		return
+	}
+	updated, err := h.Store.UpdateRating(c.Request.Context(), rating.ID, input)
+	if err != nil {
+		log.Printf("ERROR failed to update rating: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to update rating"},
+		})
+		return
+	}
+	if updated == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "rating not found"},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: updated})
+}
+
+// Delete soft-deletes a rating.
+func (h *RatingHandler) Delete(c *gin.Context) {
+	rating := h.findRating(c.Request.Context(), c)
+	if rating == nil {
+		return
+	}
+	if err := h.Store.DeleteRating(c.Request.Context(), rating.ID); err != nil {
+		log.Printf("ERROR failed to delete rating: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to delete rating"},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: gin.H{"deleted": true}})
+}
+
+// Restore undoes the deletion of a rating. A rating on a deleted recipe, or
+// by a deleted chef, cannot be restored until its parent is.
+func (h *RatingHandler) Restore(c *gin.Context) {
+	rating := h.findRating(store.IncludeDeleted(c.Request.Context()), c)
+	if rating == nil {
+		return
+	}
+
+	recipe, err := h.Store.GetRecipe(c.Request.Context(), rating.RecipeID)
+	if err != nil {
+		log.Printf("ERROR failed to verify recipe: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to verify recipe"},
+		})
+		return
+	}
+	if recipe == nil {
+		conflict(c, "the rated recipe is deleted; restore the recipe first")
+		return
+	}
+	chef, err := h.Store.GetChef(c.Request.Context(), rating.ChefID)
+	if err != nil {
+		log.Printf("ERROR failed to verify chef: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to verify chef"},
+		})
+		return
+	}
+	if chef == nil {
+		conflict(c, "the rating's chef is deleted; restore the chef first")
+		return
+	}
+
+	restored, err := h.Store.RestoreRating(c.Request.Context(), rating.ID)
+	if errors.Is(err, store.ErrConflict) {
+		conflict(c, "the chef has rated this recipe again since; delete that rating first")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR failed to restore rating: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to restore rating"},
+		})
+		return
+	}
+	if restored == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "rating not found"},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: restored})
 }
@@ -4,26 +4,43 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/aws-samples/recipe-share-dsql-go/internal/ingredients"
 	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/schemaorg"
 	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
 	"github.com/gin-gonic/gin"
 )
 
+// maxScaledServings caps the servings query parameter on recipe reads.
+const maxScaledServings = 1000
+
 // RecipeHandler holds the store dependency for recipe route handlers.
 type RecipeHandler struct {
 	Store store.Store
 }
 
-// List returns recipes, optionally filtered by cuisine, difficulty, or status.
+// List returns one page of recipes, optionally filtered by cuisine,
+// difficulty, status, minimum average rating, or tags, and sorted newest
+// first or by rating. The tags query parameter is a comma-separated list of
+// tag names or slugs; match=any (the default) keeps recipes with at least
+// one of them and match=all keeps recipes with every one.
 func (h *RecipeHandler) List(c *gin.Context) {
 	filter := model.RecipeFilter{
 		Cuisine:    c.Query("cuisine"),
 		Difficulty: c.Query("difficulty"),
 		Status:     c.Query("status"),
+		TagMatch:   c.DefaultQuery("match", model.TagMatchAny),
+		Sort:       c.Query("sort"),
 	}
 
 	// Validate filter values when provided.
@@ -39,8 +56,52 @@ func (h *RecipeHandler) List(c *gin.Context) {
 		})
 		return
 	}
+	if filter.Sort != "" && !slices.Contains(model.ValidSorts, filter.Sort) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "sort must be one of: newest, rating"},
+		})
+		return
+	}
+	if !slices.Contains(model.ValidTagMatches, filter.TagMatch) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "match must be one of: any, all"},
+		})
+		return
+	}
+	for name := range strings.SplitSeq(c.Query("tags"), ",") {
+		slug := model.Slugify(name)
+		if slug != "" && !slices.Contains(filter.Tags, slug) {
+			filter.Tags = append(filter.Tags, slug)
+		}
+	}
+	if len(filter.Tags) > model.MaxTagFilters {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: fmt.Sprintf("tags must list at most %d tags", model.MaxTagFilters)},
+		})
+		return
+	}
+	if raw := c.Query("min_rating"); raw != "" {
+		minRating, err := strconv.ParseFloat(raw, 64)
+		// The negated range check also rejects NaN.
+		if err != nil || !(minRating >= model.MinScore && minRating <= model.MaxScore) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: fmt.Sprintf("min_rating must be a number between %d and %d", model.MinScore, model.MaxScore)},
+			})
+			return
+		}
+		filter.MinRating = minRating
+	}
 
-	recipes, err := h.Store.ListRecipes(c.Request.Context(), filter)
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	ctx, ok := readContext(c)
+	if !ok {
+		return
+	}
+
+	recipes, next, err := h.Store.ListRecipes(ctx, filter, page)
 	if err != nil {
 		log.Printf("ERROR failed to list recipes: %v", err)
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
@@ -51,13 +112,84 @@ func (h *RecipeHandler) List(c *gin.Context) {
 	if recipes == nil {
 		recipes = []model.Recipe{}
 	}
-	c.JSON(http.StatusOK, model.ListResponse{Data: recipes, Count: len(recipes)})
+	c.JSON(http.StatusOK, model.ListResponse{Data: recipes, Count: len(recipes), NextCursor: next})
+}
+
+// Search returns one page of recipes matching every term in the q query
+// parameter, most relevant first.
+func (h *RecipeHandler) Search(c *gin.Context) {
+	terms := model.SearchTerms(c.Query("q"))
+	if len(terms) == 0 {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "q must contain at least one search term"},
+		})
+		return
+	}
+	if len(terms) > model.MaxSearchTerms {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: fmt.Sprintf("q must contain at most %d search terms", model.MaxSearchTerms)},
+		})
+		return
+	}
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	ctx, ok := readContext(c)
+	if !ok {
+		return
+	}
+
+	recipes, next, err := h.Store.SearchRecipes(ctx, terms, page)
+	if err != nil {
+		log.Printf("ERROR failed to search recipes: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to search recipes"},
+		})
+		return
+	}
+	if recipes == nil {
+		recipes = []model.Recipe{}
+	}
+	c.JSON(http.StatusOK, model.ListResponse{Data: recipes, Count: len(recipes), NextCursor: next})
 }
 
-// Get returns a single recipe by ID, including its ratings and average score.
+// Get returns a single recipe by ID, including its most recent ratings and
+// its rating summary and histogram.
+// The optional servings query parameter scales ingredient quantities to the
+// requested number of servings, and units=metric|imperial converts volume
+// and mass quantities to that measurement system.
+// A request that prefers application/ld+json in its Accept header gets the
+// recipe as a schema.org Recipe instead.
 func (h *RecipeHandler) Get(c *gin.Context) {
 	id := c.Param("id")
-	recipe, err := h.Store.GetRecipeWithRatings(c.Request.Context(), id)
+
+	var servings int
+	if raw := c.Query("servings"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxScaledServings {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: fmt.Sprintf("servings must be an integer between 1 and %d", maxScaledServings)},
+			})
+			return
+		}
+		servings = n
+	}
+	system := ingredients.System(c.Query("units"))
+	if system != "" && !slices.Contains(ingredients.ValidSystems, system) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "units must be one of: metric, imperial"},
+		})
+		return
+	}
+
+	ctx, ok := readContext(c)
+	if !ok {
+		return
+	}
+
+	recipe, err := h.Store.GetRecipeWithRatings(ctx, id)
 	if err != nil {
 		log.Printf("ERROR failed to get recipe: %v", err)
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
@@ -71,9 +203,81 @@ func (h *RecipeHandler) Get(c *gin.Context) {
 		})
 		return
 	}
+
+	if servings > 0 || system != "" {
+		factor := 1.0
+		if servings > 0 {
+			if recipe.Servings == 0 {
+				c.JSON(http.StatusBadRequest, model.ErrorResponse{
+					Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "recipe does not specify servings, so it cannot be scaled"},
+				})
+				return
+			}
+			factor = float64(servings) / float64(recipe.Servings)
+			recipe.Servings = servings
+		}
+		// Recipes that predate structured ingredients are parsed on the fly.
+		list := recipe.IngredientList
+		if len(list) == 0 {
+			list = ingredients.Parse(recipe.Ingredients)
+		}
+		recipe.IngredientList = ingredients.Adjust(list, factor, system)
+		recipe.Ingredients = ingredients.Format(recipe.IngredientList)
+	}
+	setETag(c, recipe.Version)
+	c.Header("Vary", "Accept")
+	if c.NegotiateFormat(gin.MIMEJSON, schemaorg.MediaType) == schemaorg.MediaType {
+		h.writeJSONLD(c, recipe.Recipe)
+		return
+	}
 	c.JSON(http.StatusOK, model.SuccessResponse{Data: recipe})
 }
 
+// validateRecipeInput applies the rules for a new recipe beyond its binding
+// tags, and returns a message describing the first rule broken or "".
+func validateRecipeInput(input model.CreateRecipeInput) string {
+	if strings.TrimSpace(input.Ingredients) == "" && len(input.IngredientList) == 0 {
+		return "one of ingredients or ingredient_list is required"
+	}
+	if len(input.IngredientList) == 0 {
+		if msg := validateIngredientText(input.Ingredients); msg != "" {
+			return msg
+		}
+	}
+	// Validate difficulty and status values.
+	if input.Difficulty != "" && !slices.Contains(model.ValidDifficulties, input.Difficulty) {
+		return "difficulty must be one of: easy, medium, hard"
+	}
+	if input.Status != "" && !slices.Contains(model.ValidStatuses, input.Status) {
+		return "status must be one of: draft, published, archived"
+	}
+	if input.PublishAt != nil {
+		if input.Status != "" && input.Status != model.StatusDraft {
+			return "publish_at can only be set on a draft"
+		}
+		if !input.PublishAt.After(time.Now()) {
+			return "publish_at must be in the future"
+		}
+	}
+	return ""
+}
+
+// validateIngredientText checks that free-text ingredients parse into lines
+// the recipe_ingredients table can hold: at most model.MaxIngredients lines,
+// each named in at most model.MaxIngredientName characters.
+func validateIngredientText(text string) string {
+	lines := ingredients.Parse(text)
+	if len(lines) > model.MaxIngredients {
+		return fmt.Sprintf("ingredients must have at most %d lines", model.MaxIngredients)
+	}
+	for _, ing := range lines {
+		if utf8.RuneCountInString(ing.Name) > model.MaxIngredientName {
+			return fmt.Sprintf("ingredient names must be at most %d characters", model.MaxIngredientName)
+		}
+	}
+	return ""
+}
+
 // Create adds a new recipe after verifying the referenced chef exists.
 func (h *RecipeHandler) Create(c *gin.Context) {
 	var input model.CreateRecipeInput
@@ -84,16 +288,9 @@ func (h *RecipeHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// Validate difficulty and status values.
-	if input.Difficulty != "" && !slices.Contains(model.ValidDifficulties, input.Difficulty) {
+	if msg := validateRecipeInput(input); msg != "" {
 		c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "difficulty must be one of: easy, medium, hard"},
-		})
-		return
-	}
-	if input.Status != "" && !slices.Contains(model.ValidStatuses, input.Status) {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "status must be one of: draft, published, archived"},
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: msg},
 		})
 		return
 	}
@@ -122,10 +319,12 @@ func (h *RecipeHandler) Create(c *gin.Context) {
 		})
 		return
 	}
+	setETag(c, recipe.Version)
 	c.JSON(http.StatusCreated, model.SuccessResponse{Data: recipe})
 }
 
-// Update modifies an existing recipe.
+// Update modifies an existing recipe. A change of status must be one the
+// publishing state machine allows; see Publish.
 func (h *RecipeHandler) Update(c *gin.Context) {
 	id := c.Param("id")
 	var input model.UpdateRecipeInput
@@ -135,6 +334,27 @@ func (h *RecipeHandler) Update(c *gin.Context) {
 		})
 		return
 	}
+	ifVersion, ok := parseIfMatch(c)
+	if !ok {
+		return
+	}
+	input.IfVersion = ifVersion
+
+	if input.IngredientList != nil && len(*input.IngredientList) == 0 &&
+		(input.Ingredients == nil || strings.TrimSpace(*input.Ingredients) == "") {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "ingredient_list must not be empty"},
+		})
+		return
+	}
+	if input.Ingredients != nil && (input.IngredientList == nil || len(*input.IngredientList) == 0) {
+		if msg := validateIngredientText(*input.Ingredients); msg != "" {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: msg},
+			})
+			return
+		}
+	}
 
 	if input.Difficulty != nil && !slices.Contains(model.ValidDifficulties, *input.Difficulty) {
 		c.JSON(http.StatusBadRequest, model.ErrorResponse{
@@ -150,6 +370,14 @@ func (h *RecipeHandler) Update(c *gin.Context) {
 	}
 
 	recipe, err := h.Store.UpdateRecipe(c.Request.Context(), id, input)
+	if errors.Is(err, store.ErrPreconditionFailed) {
+		preconditionFailed(c)
+		return
+	}
+	if errors.Is(err, store.ErrInvalidTransition) {
+		conflict(c, err.Error())
+		return
+	}
 	if err != nil {
 		log.Printf("ERROR failed to update recipe: %v", err)
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
@@ -163,10 +391,12 @@ func (h *RecipeHandler) Update(c *gin.Context) {
 		})
 		return
 	}
+	setETag(c, recipe.Version)
 	c.JSON(http.StatusOK, model.SuccessResponse{Data: recipe})
 }
 
-// Delete removes a recipe by ID.
+// Delete soft-deletes a recipe by ID. Its ratings and collection items are
+// hidden with it, and its image files are kept until the recipe is purged.
 func (h *RecipeHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
@@ -194,3 +424,54 @@ func (h *RecipeHandler) Delete(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, model.SuccessResponse{Data: gin.H{"deleted": true}})
 }
+
+// Restore undoes the deletion of a recipe. A recipe whose chef is deleted
+// cannot be restored until the chef is.
+func (h *RecipeHandler) Restore(c *gin.Context) {
+	id := c.Param("id")
+
+	recipe, err := h.Store.GetRecipe(store.IncludeDeleted(c.Request.Context()), id)
+	if err != nil {
+		log.Printf("ERROR failed to restore recipe: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to restore recipe"},
+		})
+		return
+	}
+	if recipe == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "recipe not found"},
+		})
+		return
+	}
+
+	chef, err := h.Store.GetChef(c.Request.Context(), recipe.ChefID)
+	if err != nil {
+		log.Printf("ERROR failed to verify chef: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to verify chef"},
+		})
+		return
+	}
+	if chef == nil {
+		conflict(c, "the recipe's chef is deleted; restore the chef first")
+		return
+	}
+
+	recipe, err = h.Store.RestoreRecipe(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("ERROR failed to restore recipe: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to restore recipe"},
+		})
+		return
+	}
+	if recipe == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "recipe not found"},
+		})
+		return
+	}
+	setETag(c, recipe.Version)
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: recipe})
+}
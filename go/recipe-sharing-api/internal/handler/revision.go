@@ -0,0 +1,227 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/diff"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// Revisions returns one page of the revisions of a recipe, newest first.
+// The current version is the recipe itself and is not listed.
+func (h *RecipeHandler) Revisions(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	ctx, ok := readContext(c)
+	if !ok {
+		return
+	}
+	recipe := h.findRecipe(c, ctx)
+	if recipe == nil {
+		return
+	}
+
+	revisions, next, err := h.Store.ListRecipeRevisions(ctx, recipe.ID, page)
+	if err != nil {
+		log.Printf("ERROR failed to list revisions: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to list revisions"},
+		})
+		return
+	}
+	if revisions == nil {
+		revisions = []model.RecipeRevision{}
+	}
+	c.JSON(http.StatusOK, model.ListResponse{Data: revisions, Count: len(revisions), NextCursor: next})
+}
+
+// Revision returns one revision of a recipe, named by the n path parameter,
+// with its ingredient lines.
+func (h *RecipeHandler) Revision(c *gin.Context) {
+	ctx, ok := readContext(c)
+	if !ok {
+		return
+	}
+	n, ok := parseRevision(c, c.Param("n"), "revision")
+	if !ok {
+		return
+	}
+	recipe := h.findRecipe(c, ctx)
+	if recipe == nil {
+		return
+	}
+	rev := h.findRevision(c, ctx, recipe.ID, n)
+	if rev == nil {
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: rev})
+}
+
+// Diff returns the changes from revision n of a recipe to the version named
+// by the to query parameter, which defaults to the current version. Either
+// end may be the current version or any revision.
+func (h *RecipeHandler) Diff(c *gin.Context) {
+	ctx, ok := readContext(c)
+	if !ok {
+		return
+	}
+	from, ok := parseRevision(c, c.Param("n"), "revision")
+	if !ok {
+		return
+	}
+	recipe := h.findRecipe(c, ctx)
+	if recipe == nil {
+		return
+	}
+	to := recipe.Version
+	if raw := c.Query("to"); raw != "" {
+		if to, ok = parseRevision(c, raw, "to"); !ok {
+			return
+		}
+	}
+
+	older := h.version(c, ctx, recipe, from)
+	if older == nil {
+		return
+	}
+	newer := h.version(c, ctx, recipe, to)
+	if newer == nil {
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: diff.Recipes(*older, *newer)})
+}
+
+// Revert applies the content of revision n to a recipe as a new version:
+// its title, description, times, servings, difficulty, cuisine, ingredients,
+// and instructions. The status is left alone, since it changes only through
+// the publishing transitions. It accepts If-Match like Update.
+func (h *RecipeHandler) Revert(c *gin.Context) {
+	n, ok := parseRevision(c, c.Param("n"), "revision")
+	if !ok {
+		return
+	}
+	ifVersion, ok := parseIfMatch(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	recipe := h.findRecipe(c, ctx)
+	if recipe == nil {
+		return
+	}
+	rev := h.findRevision(c, ctx, recipe.ID, n)
+	if rev == nil {
+		return
+	}
+
+	old := rev.Recipe
+	updated, err := h.Store.UpdateRecipe(ctx, recipe.ID, model.UpdateRecipeInput{
+		Title:          &old.Title,
+		Description:    &old.Description,
+		Ingredients:    &old.Ingredients,
+		IngredientList: &old.IngredientList,
+		Instructions:   &old.Instructions,
+		PrepTime:       &old.PrepTime,
+		CookTime:       &old.CookTime,
+		Servings:       &old.Servings,
+		Difficulty:     &old.Difficulty,
+		Cuisine:        &old.Cuisine,
+		IfVersion:      ifVersion,
+	})
+	if errors.Is(err, store.ErrPreconditionFailed) {
+		preconditionFailed(c)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR failed to revert recipe: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to revert recipe"},
+		})
+		return
+	}
+	// The recipe was deleted between the read above and the update.
+	if updated == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "recipe not found"},
+		})
+		return
+	}
+	setETag(c, updated.Version)
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: updated})
+}
+
+// parseRevision parses a revision number. On invalid input it writes a 400
+// response naming the parameter and returns false.
+func parseRevision(c *gin.Context, raw, name string) (int64, bool) {
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n < 1 {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: name + " must be a positive integer"},
+		})
+		return 0, false
+	}
+	return n, true
+}
+
+// findRecipe returns the recipe named by the id path parameter. It writes a
+// 404 or 500 response and returns nil otherwise.
+func (h *RecipeHandler) findRecipe(c *gin.Context, ctx context.Context) *model.Recipe {
+	recipe, err := h.Store.GetRecipe(ctx, c.Param("id"))
+	if err != nil {
+		log.Printf("ERROR failed to get recipe: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to get recipe"},
+		})
+		return nil
+	}
+	if recipe == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "recipe not found"},
+		})
+	}
+	return recipe
+}
+
+// findRevision returns revision n of a recipe. It writes a 404 or 500
+// response and returns nil otherwise.
+func (h *RecipeHandler) findRevision(c *gin.Context, ctx context.Context, recipeID string, n int64) *model.RecipeRevision {
+	rev, err := h.Store.GetRecipeRevision(ctx, recipeID, n)
+	if err != nil {
+		log.Printf("ERROR failed to get revision: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to get revision"},
+		})
+		return nil
+	}
+	if rev == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "revision not found"},
+		})
+	}
+	return rev
+}
+
+// version returns a recipe as it was at version n: the recipe itself for
+// its current version, and the revision's snapshot otherwise. It writes a
+// 404 or 500 response and returns nil if there is no such version.
+func (h *RecipeHandler) version(c *gin.Context, ctx context.Context, recipe *model.Recipe, n int64) *model.Recipe {
+	if n == recipe.Version {
+		return recipe
+	}
+	rev := h.findRevision(c, ctx, recipe.ID, n)
+	if rev == nil {
+		return nil
+	}
+	return &rev.Recipe
+}
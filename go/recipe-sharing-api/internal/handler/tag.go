@@ -0,0 +1,125 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// TagHandler holds the store dependency for tag route handlers.
+type TagHandler struct {
+	Store store.Store
+}
+
+// List returns one page of tags in slug order, each with the number of live
+// recipes that carry it.
+func (h *TagHandler) List(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	tags, next, err := h.Store.ListTags(c.Request.Context(), page)
+	if err != nil {
+		log.Printf("ERROR failed to list tags: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to list tags"},
+		})
+		return
+	}
+	if tags == nil {
+		tags = []model.TagUsage{}
+	}
+	c.JSON(http.StatusOK, model.ListResponse{Data: tags, Count: len(tags), NextCursor: next})
+}
+
+// Add tags a recipe with one or more tag names, creating tags that do not
+// exist yet, and returns the slugs of all the recipe's tags. Names are
+// normalized into slugs, so "Quick Meals" and "quick-meals" are one tag.
+func (h *TagHandler) Add(c *gin.Context) {
+	var input model.AddTagsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "tags must list between 1 and 20 names of at most 50 characters"},
+		})
+		return
+	}
+	for _, name := range input.Tags {
+		if model.Slugify(name) == "" {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "tag names must contain a letter or digit"},
+			})
+			return
+		}
+	}
+	if !recipeExists(c, h.Store) {
+		return
+	}
+
+	slugs, err := h.Store.AddRecipeTags(c.Request.Context(), c.Param("id"), input.Tags)
+	if errors.Is(err, store.ErrTooManyTags) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: err.Error()},
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR failed to tag recipe: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to tag recipe"},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: slugs})
+}
+
+// Remove removes the tag named by the slug path parameter from a recipe. The
+// parameter is normalized like a tag name, so a name works as well.
+func (h *TagHandler) Remove(c *gin.Context) {
+	if !recipeExists(c, h.Store) {
+		return
+	}
+
+	removed, err := h.Store.RemoveRecipeTag(c.Request.Context(), c.Param("id"), model.Slugify(c.Param("slug")))
+	if err != nil {
+		log.Printf("ERROR failed to untag recipe: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to untag recipe"},
+		})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "recipe does not have this tag"},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessResponse{Data: gin.H{"deleted": true}})
+}
+
+// recipeExists reports whether the recipe named by the id path parameter
+// exists. It writes a 404 or 500 response and returns false otherwise.
+func recipeExists(c *gin.Context, s store.Store) bool {
+	recipe, err := s.GetRecipe(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("ERROR failed to verify recipe: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "INTERNAL_ERROR", Message: "failed to verify recipe"},
+		})
+		return false
+	}
+	if recipe == nil {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Error: model.ErrorDetail{Code: "NOT_FOUND", Message: "recipe not found"},
+		})
+		return false
+	}
+	return true
+}
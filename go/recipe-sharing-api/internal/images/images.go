@@ -0,0 +1,100 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package images validates uploaded recipe images and generates their
+// thumbnails.
+package images
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register the WebP decoder
+)
+
+// Errors returned by Process wrap one of these with the reason.
+var (
+	// ErrUnsupportedType is returned for files that are not one of the
+	// accepted image formats, or whose declared type does not match.
+	ErrUnsupportedType = errors.New("unsupported image type")
+	// ErrInvalidImage is returned for files in an accepted format that
+	// cannot be decoded or are too large once decoded.
+	ErrInvalidImage = errors.New("invalid image")
+)
+
+// Processed describes a validated image and holds its encoded thumbnail.
+type Processed struct {
+	ContentType          string
+	Width                int
+	Height               int
+	Thumbnail            []byte
+	ThumbnailContentType string
+}
+
+// Process validates an uploaded image and generates its thumbnail. The
+// content type is sniffed from the data and must be one of
+// model.ImageContentTypes and, unless declared is empty or generic, match
+// the declared type. Thumbnails of JPEG images are JPEG; those of other
+// formats are PNG, which keeps any transparency.
+func Process(data []byte, declared string) (*Processed, error) {
+	sniffed := http.DetectContentType(data)
+	if !model.ImageContentTypes[sniffed] {
+		return nil, fmt.Errorf("%w: content type %s is not supported; use JPEG, PNG, GIF, or WebP", ErrUnsupportedType, sniffed)
+	}
+	if declared != "" && declared != "application/octet-stream" && declared != sniffed {
+		return nil, fmt.Errorf("%w: declared content type %s does not match the file contents (%s)", ErrUnsupportedType, declared, sniffed)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if cfg.Width < 1 || cfg.Height < 1 || cfg.Width*cfg.Height > model.MaxImagePixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels is outside the supported size", ErrInvalidImage, cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	p := &Processed{ContentType: sniffed, Width: cfg.Width, Height: cfg.Height}
+	thumb := Thumbnail(img, model.ThumbnailSize)
+	var buf bytes.Buffer
+	if sniffed == "image/jpeg" {
+		p.ThumbnailContentType = "image/jpeg"
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+	} else {
+		p.ThumbnailContentType = "image/png"
+		err = png.Encode(&buf, thumb)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encode thumbnail: %w", err)
+	}
+	p.Thumbnail = buf.Bytes()
+	return p, nil
+}
+
+// Thumbnail scales img so that its longer side is at most size pixels,
+// keeping its aspect ratio. Images that already fit are copied unscaled.
+func Thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,72 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package ingredients
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+)
+
+// commonFractions are the fractional parts FormatQuantity renders as
+// fractions rather than decimals.
+var commonFractions = []struct {
+	value float64
+	text  string
+}{
+	{1.0 / 8, "1/8"}, {1.0 / 4, "1/4"}, {1.0 / 3, "1/3"}, {3.0 / 8, "3/8"},
+	{1.0 / 2, "1/2"}, {5.0 / 8, "5/8"}, {2.0 / 3, "2/3"}, {3.0 / 4, "3/4"},
+	{7.0 / 8, "7/8"},
+}
+
+// Format renders structured ingredients as free text, one line per
+// ingredient, in the form accepted by Parse.
+func Format(list []model.Ingredient) string {
+	lines := make([]string, len(list))
+	for i, ing := range list {
+		lines[i] = FormatLine(ing)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatLine renders one ingredient, e.g. "1 1/2 cup flour (sifted)".
+func FormatLine(ing model.Ingredient) string {
+	var parts []string
+	if ing.Quantity > 0 {
+		parts = append(parts, FormatQuantity(ing.Quantity))
+	}
+	if ing.Unit != "" {
+		parts = append(parts, ing.Unit)
+	}
+	parts = append(parts, ing.Name)
+	line := strings.Join(parts, " ")
+	if ing.Note != "" {
+		line += " (" + ing.Note + ")"
+	}
+	return line
+}
+
+// FormatQuantity renders a quantity as a whole number, a mixed fraction such
+// as "1 1/2" when the fractional part is a common kitchen fraction, or a
+// decimal with at most two places.
+func FormatQuantity(q float64) string {
+	whole, frac := math.Modf(q)
+	if frac < 0.005 {
+		return strconv.FormatFloat(whole, 'f', -1, 64)
+	}
+	if frac > 0.995 {
+		return strconv.FormatFloat(whole+1, 'f', -1, 64)
+	}
+	for _, f := range commonFractions {
+		if math.Abs(frac-f.value) < 0.005 {
+			if whole == 0 {
+				return f.text
+			}
+			return strconv.FormatFloat(whole, 'f', -1, 64) + " " + f.text
+		}
+	}
+	return strconv.FormatFloat(math.Round(q*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,188 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package ingredients converts between the free-text ingredients column and
+// structured model.Ingredient lines.
+package ingredients
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+)
+
+// unitAliases maps the spellings accepted by Parse to canonical unit names.
+var unitAliases = map[string]string{
+	"tsp": "tsp", "teaspoon": "tsp", "teaspoons": "tsp",
+	"tbsp": "tbsp", "tablespoon": "tbsp", "tablespoons": "tbsp", "tbs": "tbsp", "tbl": "tbsp",
+	"cup": "cup", "cups": "cup", "c": "cup",
+	"fl oz": "fl oz", "floz": "fl oz", "fluid ounce": "fl oz", "fluid ounces": "fl oz",
+	"pt": "pint", "pint": "pint", "pints": "pint",
+	"qt": "quart", "quart": "quart", "quarts": "quart",
+	"gal": "gallon", "gallon": "gallon", "gallons": "gallon",
+	"ml": "ml", "milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml",
+	"l": "l", "liter": "l", "liters": "l", "litre": "l", "litres": "l",
+	"oz": "oz", "ounce": "oz", "ounces": "oz",
+	"lb": "lb", "lbs": "lb", "pound": "lb", "pounds": "lb",
+	"g": "g", "gram": "g", "grams": "g", "gr": "g",
+	"kg": "kg", "kilogram": "kg", "kilograms": "kg",
+	"pinch": "pinch", "pinches": "pinch",
+	"clove": "clove", "cloves": "clove",
+	"can": "can", "cans": "can",
+	"slice": "slice", "slices": "slice",
+}
+
+// caseUnits maps the abbreviations whose meaning depends on case, which
+// Parse matches before lowercasing: "T" is a tablespoon and "t" a teaspoon.
+var caseUnits = map[string]string{"T": "tbsp", "t": "tsp"}
+
+// vulgarFractions maps Unicode fraction characters to their values.
+var vulgarFractions = map[rune]float64{
+	'¼': 0.25, '½': 0.5, '¾': 0.75,
+	'⅓': 1.0 / 3, '⅔': 2.0 / 3,
+	'⅛': 0.125, '⅜': 0.375, '⅝': 0.625, '⅞': 0.875,
+}
+
+// Parse splits free-text ingredients into structured lines. Multi-line text
+// is split on newlines; single-line text is split on commas. Each line is
+// parsed with ParseLine, and positions are numbered from 1.
+func Parse(text string) []model.Ingredient {
+	var lines []string
+	multiline := strings.Contains(text, "\n")
+	if multiline {
+		lines = strings.Split(text, "\n")
+	} else {
+		lines = strings.Split(text, ",")
+	}
+
+	var out []model.Ingredient
+	for _, line := range lines {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*•"))
+		if line == "" {
+			continue
+		}
+		ing := ParseLine(line, multiline)
+		ing.Position = len(out) + 1
+		out = append(out, ing)
+	}
+	return out
+}
+
+// ParseLine parses a single line such as "1 1/2 cups flour (sifted)" into its
+// quantity, unit, name, and note. Parenthesized text becomes the note; when
+// allowCommaNote is true, text after the first comma does too. Lines that do
+// not start with a quantity are kept whole as the name.
+func ParseLine(line string, allowCommaNote bool) model.Ingredient {
+	var ing model.Ingredient
+
+	if open := strings.Index(line, "("); open >= 0 {
+		if close := strings.Index(line[open:], ")"); close > 0 {
+			ing.Note = strings.TrimSpace(line[open+1 : open+close])
+			line = strings.TrimSpace(line[:open] + " " + line[open+close+1:])
+		}
+	}
+	if allowCommaNote {
+		if name, note, ok := strings.Cut(line, ","); ok {
+			line = strings.TrimSpace(name)
+			ing.Note = joinNote(strings.TrimSpace(note), ing.Note)
+		}
+	}
+
+	words := strings.Fields(line)
+	qty, n := parseQuantity(words)
+	if n == 0 {
+		ing.Name = strings.Join(words, " ")
+		return ing
+	}
+	ing.Quantity = qty
+	words = words[n:]
+
+	if len(words) > 1 {
+		if unit, ok := unitAliases[strings.ToLower(strings.TrimSuffix(words[0]+" "+words[1], "."))]; ok {
+			ing.Unit = unit
+			words = words[2:]
+		}
+	}
+	if ing.Unit == "" && len(words) > 1 {
+		word := strings.TrimSuffix(words[0], ".")
+		unit, ok := caseUnits[word]
+		if !ok {
+			unit, ok = unitAliases[strings.ToLower(word)]
+		}
+		if ok {
+			ing.Unit = unit
+			words = words[1:]
+		}
+	}
+	if len(words) > 0 && strings.EqualFold(words[0], "of") {
+		words = words[1:]
+	}
+	ing.Name = strings.Join(words, " ")
+	return ing
+}
+
+func joinNote(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	default:
+		return a + "; " + b
+	}
+}
+
+// parseQuantity reads a leading quantity from words, such as "2", "1.5",
+// "1/2", "1 1/2", "1½" or "½", and reports how many words it consumed.
+func parseQuantity(words []string) (float64, int) {
+	if len(words) == 0 {
+		return 0, 0
+	}
+	whole, ok := parseNumber(words[0])
+	if !ok {
+		return 0, 0
+	}
+	if len(words) > 1 && !strings.Contains(words[0], "/") && whole == float64(int(whole)) {
+		if frac, ok := parseNumber(words[1]); ok && frac < 1 {
+			return whole + frac, 2
+		}
+	}
+	return whole, 1
+}
+
+// parseNumber parses an integer, decimal, simple fraction, or a number with
+// a trailing Unicode vulgar fraction.
+func parseNumber(s string) (float64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	runes := []rune(s)
+	if v, ok := vulgarFractions[runes[len(runes)-1]]; ok {
+		if len(runes) == 1 {
+			return v, true
+		}
+		whole, err := strconv.Atoi(string(runes[:len(runes)-1]))
+		if err != nil {
+			return 0, false
+		}
+		return float64(whole) + v, true
+	}
+	if num, den, ok := strings.Cut(s, "/"); ok {
+		n, err1 := strconv.Atoi(num)
+		d, err2 := strconv.Atoi(den)
+		if err1 != nil || err2 != nil || d == 0 {
+			return 0, false
+		}
+		return float64(n) / float64(d), true
+	}
+	if !unicode.IsDigit(runes[0]) && runes[0] != '.' {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return v, true
+}
@@ -0,0 +1,31 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package ingredients
+
+import "github.com/aws-samples/recipe-share-dsql-go/internal/model"
+
+// Adjust scales ingredient quantities by factor and, when to is non-empty,
+// converts volume and mass units to that measurement system. Adjusted
+// quantities are rounded with Round; quantities that are neither scaled nor
+// converted are left exactly as written. Ingredients without a quantity are
+// unchanged. The input slice is not modified.
+func Adjust(list []model.Ingredient, factor float64, to System) []model.Ingredient {
+	out := make([]model.Ingredient, len(list))
+	for i, ing := range list {
+		if ing.Quantity > 0 {
+			changed := factor != 1
+			ing.Quantity *= factor
+			if to != "" {
+				var converted bool
+				ing.Quantity, ing.Unit, converted = Convert(ing.Quantity, ing.Unit, to)
+				changed = changed || converted
+			}
+			if changed {
+				ing.Quantity = Round(ing.Quantity, ing.Unit)
+			}
+		}
+		out[i] = ing
+	}
+	return out
+}
@@ -0,0 +1,147 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package ingredients
+
+import "math"
+
+// System is a measurement system that ingredient quantities can be
+// normalized to.
+type System string
+
+// Supported measurement systems.
+const (
+	Metric   System = "metric"
+	Imperial System = "imperial"
+)
+
+// ValidSystems lists the accepted values of the units query parameter.
+var ValidSystems = []System{Metric, Imperial}
+
+type dimension int
+
+const (
+	volume dimension = iota + 1
+	mass
+)
+
+// unitInfo describes a convertible unit by its size in the base unit of its
+// dimension (milliliters for volume, grams for mass).
+type unitInfo struct {
+	dim    dimension
+	base   float64
+	system System
+}
+
+var units = map[string]unitInfo{
+	"tsp":    {volume, 4.92892, Imperial},
+	"tbsp":   {volume, 14.7868, Imperial},
+	"fl oz":  {volume, 29.5735, Imperial},
+	"cup":    {volume, 236.588, Imperial},
+	"pint":   {volume, 473.176, Imperial},
+	"quart":  {volume, 946.353, Imperial},
+	"gallon": {volume, 3785.41, Imperial},
+	"ml":     {volume, 1, Metric},
+	"l":      {volume, 1000, Metric},
+	"oz":     {mass, 28.3495, Imperial},
+	"lb":     {mass, 453.592, Imperial},
+	"g":      {mass, 1, Metric},
+	"kg":     {mass, 1000, Metric},
+}
+
+// targetUnits lists, for each system and dimension, the units to express
+// quantities in, smallest first. A quantity uses the largest unit it fills
+// at least once.
+var targetUnits = map[System]map[dimension][]string{
+	Metric: {
+		volume: {"ml", "l"},
+		mass:   {"g", "kg"},
+	},
+	Imperial: {
+		volume: {"tsp", "tbsp", "cup"},
+		mass:   {"oz", "lb"},
+	},
+}
+
+// Convert expresses a quantity of a volume or mass unit in the most readable
+// unit of the target system, e.g. 48 tsp becomes 1 cup and 1.5 lb becomes
+// 680 g. Quantities in other units, such as "clove" or no unit, are returned
+// unchanged. It reports whether the quantity was converted.
+func Convert(qty float64, unit string, to System) (float64, string, bool) {
+	info, ok := units[unit]
+	if !ok {
+		return qty, unit, false
+	}
+	base := qty * info.base
+	candidates := targetUnits[to][info.dim]
+	best := candidates[0]
+	for _, u := range candidates[1:] {
+		if base >= units[u].base {
+			best = u
+		}
+	}
+	return base / units[best].base, best, true
+}
+
+// Milliliters expresses a quantity of a volume unit in milliliters. It
+// reports false for units that do not measure volume.
+func Milliliters(qty float64, unit string) (float64, bool) {
+	return toBase(qty, unit, volume)
+}
+
+// Grams expresses a quantity of a mass unit in grams. It reports false for
+// units that do not measure mass.
+func Grams(qty float64, unit string) (float64, bool) {
+	return toBase(qty, unit, mass)
+}
+
+func toBase(qty float64, unit string, dim dimension) (float64, bool) {
+	info, ok := units[unit]
+	if !ok || info.dim != dim {
+		return 0, false
+	}
+	return qty * info.base, true
+}
+
+// friendlyFractions are the fractional parts Round snaps to for quantities
+// measured by spoon, cup, or count.
+var friendlyFractions = []float64{0, 1.0 / 8, 1.0 / 4, 1.0 / 3, 1.0 / 2, 2.0 / 3, 3.0 / 4, 1}
+
+// Round rounds a quantity to a kitchen-friendly value for its unit. Grams and
+// milliliters round to whole numbers, then to the nearest 5 or 10 as they
+// grow; kilograms and liters to the nearest 0.05. Other units round to the
+// nearest common fraction below 10 and to the nearest half above. Positive
+// quantities never round to zero.
+func Round(qty float64, unit string) float64 {
+	if qty <= 0 {
+		return qty
+	}
+	var r float64
+	switch unit {
+	case "g", "ml":
+		switch {
+		case qty < 10:
+			r = math.Round(qty)
+		case qty < 100:
+			r = math.Round(qty/5) * 5
+		default:
+			r = math.Round(qty/10) * 10
+		}
+		return math.Max(r, 1)
+	case "kg", "l":
+		return math.Max(math.Round(qty*20)/20, 0.05)
+	}
+
+	if qty >= 10 {
+		return math.Round(qty*2) / 2
+	}
+	whole, frac := math.Modf(qty)
+	best := friendlyFractions[0]
+	for _, f := range friendlyFractions[1:] {
+		if math.Abs(frac-f) < math.Abs(frac-best) {
+			best = f
+		}
+	}
+	r = whole + best
+	return math.Max(r, friendlyFractions[1])
+}
@@ -5,11 +5,18 @@ package middleware
 
 import (
 	"log"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
 	"github.com/gin-gonic/gin"
 )
 
+// maxActorLength matches the width of the audit_events.actor column.
+const maxActorLength = 255
+
 // RequestLogger logs the method, path, status code, and latency for each request.
 func RequestLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -24,6 +31,26 @@ func RequestLogger() gin.HandlerFunc {
 	}
 }
 
+// Actor attributes the request's changes in the audit log to the caller
+// named by the X-Actor header, or to store.AnonymousActor when it is absent.
+// This sample has no authentication, so the header is taken on trust; a
+// production API would derive the actor from the authenticated identity.
+func Actor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := strings.TrimSpace(c.GetHeader("X-Actor"))
+		if len(actor) > maxActorLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, model.ErrorResponse{
+				Error: model.ErrorDetail{Code: "VALIDATION_ERROR", Message: "X-Actor must be at most 255 characters"},
+			})
+			return
+		}
+		if actor != "" {
+			c.Request = c.Request.WithContext(store.WithActor(c.Request.Context(), actor))
+		}
+		c.Next()
+	}
+}
+
 // CORS adds permissive Cross-Origin Resource Sharing headers.
 // This configuration allows all origins and is intentionally permissive for
 // this demonstration application. Production APIs should restrict the
@@ -32,7 +59,8 @@ func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, X-Actor")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -0,0 +1,107 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package migrations applies versioned schema migrations to Amazon Aurora
+// DSQL.
+//
+// Migrations are SQL files embedded from the sql directory and named
+// NNNN_description.sql, where NNNN is the version. They are applied in
+// version order and recorded in the recipe_share.schema_migrations table.
+//
+// Amazon Aurora DSQL runs each DDL statement in its own transaction and does
+// not allow DDL and DML in the same transaction, so every statement in a
+// migration is executed separately and progress is recorded after each one.
+// A migration interrupted part-way resumes at the first unapplied statement,
+// but the statement that was running may be repeated; write statements to be
+// idempotent (IF NOT EXISTS, guarded backfills) where possible.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:embed sql/*.sql
+var embedded embed.FS
+
+// Migration is one versioned schema change.
+type Migration struct {
+	Version    int
+	Name       string
+	Statements []string
+}
+
+// Load returns the embedded migrations in version order.
+func Load() ([]Migration, error) {
+	sub, err := fs.Sub(embedded, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return LoadFS(sub)
+}
+
+// LoadFS reads NNNN_description.sql files from the root of fsys and returns
+// them as migrations in version order. Versions must be unique and positive.
+func LoadFS(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Migration
+	seen := make(map[int]string)
+	for _, file := range names {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: file name must be NNNN_description.sql", file)
+		}
+		if prev, dup := seen[version]; dup {
+			return nil, fmt.Errorf("migration %s: version %d already used by %s", file, version, prev)
+		}
+		seen[version] = file
+
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", file, err)
+		}
+		stmts := SplitStatements(string(body))
+		if len(stmts) == 0 {
+			return nil, fmt.Errorf("migration %s: no statements", file)
+		}
+		out = append(out, Migration{Version: version, Name: name, Statements: stmts})
+	}
+	slices.SortFunc(out, func(a, b Migration) int { return a.Version - b.Version })
+	return out, nil
+}
+
+// SplitStatements splits a SQL script into statements on semicolons that end
+// a line. Full-line "--" comments and blank lines are dropped. Statements
+// must not contain a semicolon at the end of a line inside a string literal.
+func SplitStatements(script string) []string {
+	var stmts []string
+	var cur []string
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		if strings.HasSuffix(trimmed, ";") {
+			cur = append(cur, strings.TrimSuffix(strings.TrimRight(line, " \t\r"), ";"))
+			stmts = append(stmts, strings.TrimSpace(strings.Join(cur, "\n")))
+			cur = nil
+			continue
+		}
+		cur = append(cur, line)
+	}
+	if len(cur) > 0 {
+		stmts = append(stmts, strings.TrimSpace(strings.Join(cur, "\n")))
+	}
+	return stmts
+}
@@ -0,0 +1,346 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/pgerr"
+	"github.com/awslabs/aurora-dsql-connectors/go/pgx/occretry"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrLockTimeout is returned by Up when another process holds the migration
+// lock for longer than Migrator.LockWait.
+var ErrLockTimeout = errors.New("timed out waiting for migration lock")
+
+// Tracking tables. They live in the application schema and are created by
+// the migrator itself before any migration runs.
+var bootstrap = []string{
+	"CREATE SCHEMA IF NOT EXISTS recipe_share",
+	`CREATE TABLE IF NOT EXISTS recipe_share.schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		statements_applied INTEGER NOT NULL,
+		started_at TIMESTAMPTZ NOT NULL,
+		applied_at TIMESTAMPTZ
+	)`,
+	`CREATE TABLE IF NOT EXISTS recipe_share.schema_migrations_lock (
+		id INTEGER PRIMARY KEY,
+		owner TEXT NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	)`,
+}
+
+// lockID is the primary key of the single row in schema_migrations_lock.
+const lockID = 1
+
+// Status describes whether a migration has been applied.
+type Status struct {
+	Version           int        `json:"version"`
+	Name              string     `json:"name"`
+	Statements        int        `json:"statements"`
+	StatementsApplied int        `json:"statements_applied"`
+	AppliedAt         *time.Time `json:"applied_at,omitempty"`
+}
+
+// Applied reports whether every statement of the migration has run.
+func (s Status) Applied() bool {
+	return s.AppliedAt != nil
+}
+
+// Migrator applies migrations to Amazon Aurora DSQL.
+//
+// Amazon Aurora DSQL does not support advisory locks, so concurrent runners,
+// such as Lambda functions cold-starting at the same time, coordinate through
+// a lease row in schema_migrations_lock. Claiming the lease is a
+// read-then-write transaction on that one row: when two runners race, OCC
+// lets only one commit. The lease expires after LockTTL so a runner that
+// crashes cannot block migrations forever; the holder renews it between
+// statements.
+type Migrator struct {
+	db         occretry.DB
+	migrations []Migration
+	owner      string
+
+	// LockTTL is how long the migration lease lasts before it must be renewed.
+	LockTTL time.Duration
+	// LockWait is how long Up waits for another runner to release the lease.
+	LockWait time.Duration
+	// PollInterval is how often Up retries a held lease.
+	PollInterval time.Duration
+}
+
+// New creates a Migrator for the embedded migrations. The db should retry
+// OCC conflicts; schema changes made by other sessions surface as OC001
+// errors, which occretry retries automatically.
+func New(db occretry.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+	return NewWithMigrations(db, migrations), nil
+}
+
+// NewWithMigrations creates a Migrator for the given migrations, which must
+// be in version order.
+func NewWithMigrations(db occretry.DB, migrations []Migration) *Migrator {
+	return &Migrator{
+		db:           db,
+		migrations:   migrations,
+		owner:        uuid.New().String(),
+		LockTTL:      5 * time.Minute,
+		LockWait:     2 * time.Minute,
+		PollInterval: 2 * time.Second,
+	}
+}
+
+// Status returns the state of every known migration in version order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	recorded, err := m.recorded(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, len(m.migrations))
+	for i, mig := range m.migrations {
+		st := recorded[mig.Version]
+		st.Version, st.Name, st.Statements = mig.Version, mig.Name, len(mig.Statements)
+		out[i] = st
+	}
+	return out, nil
+}
+
+// Up applies all pending migrations and returns how many were applied.
+// When nothing is pending it returns without taking the lock, so it is cheap
+// to call on every cold start.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	pending, err := m.pending(ctx)
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	if err := m.acquire(ctx); err != nil {
+		return 0, err
+	}
+	defer func() {
+		// Release with a fresh context so a cancelled caller still frees the lease.
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		if err := m.release(releaseCtx); err != nil {
+			log.Printf("WARN release migration lock: %v", err)
+		}
+	}()
+
+	// Another runner may have applied migrations while we waited for the lock.
+	pending, err = m.pending(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range pending {
+		if err := m.apply(ctx, p); err != nil {
+			return 0, err
+		}
+	}
+	return len(pending), nil
+}
+
+// pendingMigration pairs a migration with how many of its statements have run.
+type pendingMigration struct {
+	Migration
+	started bool
+	applied int
+}
+
+// pending returns migrations not yet fully applied, creating the tracking
+// tables first if they do not exist.
+func (m *Migrator) pending(ctx context.Context) ([]pendingMigration, error) {
+	recorded, err := m.recorded(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if recorded == nil {
+		for _, stmt := range bootstrap {
+			if _, err := m.db.Exec(ctx, stmt); err != nil {
+				return nil, fmt.Errorf("create migration tables: %w", err)
+			}
+		}
+	}
+
+	var out []pendingMigration
+	for _, mig := range m.migrations {
+		st, ok := recorded[mig.Version]
+		if ok && st.Applied() {
+			continue
+		}
+		out = append(out, pendingMigration{Migration: mig, started: ok, applied: st.StatementsApplied})
+	}
+	return out, nil
+}
+
+// recorded reads schema_migrations. It returns a nil map if the tracking
+// table does not exist yet.
+func (m *Migrator) recorded(ctx context.Context) (map[int]Status, error) {
+	rows, err := m.db.Query(ctx,
+		`SELECT version, name, statements_applied, applied_at FROM recipe_share.schema_migrations`)
+	if isUndefined(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[int]Status)
+	for rows.Next() {
+		var st Status
+		if err := rows.Scan(&st.Version, &st.Name, &st.StatementsApplied, &st.AppliedAt); err != nil {
+			return nil, fmt.Errorf("scan schema_migrations: %w", err)
+		}
+		out[st.Version] = st
+	}
+	if err := rows.Err(); err != nil {
+		if isUndefined(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	return out, nil
+}
+
+// apply runs the remaining statements of one migration. Each statement runs
+// in its own implicit transaction, and progress is recorded in a separate
+// transaction afterwards because DDL and DML cannot share one.
+func (m *Migrator) apply(ctx context.Context, p pendingMigration) error {
+	if !p.started {
+		_, err := m.db.Exec(ctx,
+			`INSERT INTO recipe_share.schema_migrations (version, name, statements_applied, started_at)
+			 VALUES ($1, $2, 0, $3)`, p.Version, p.Name, time.Now().UTC())
+		if err != nil {
+			return fmt.Errorf("record migration %d: %w", p.Version, err)
+		}
+	}
+
+	for i := p.applied; i < len(p.Statements); i++ {
+		if err := m.renew(ctx); err != nil {
+			return err
+		}
+		if _, err := m.db.Exec(ctx, p.Statements[i]); err != nil {
+			return fmt.Errorf("migration %d_%s statement %d: %w", p.Version, p.Name, i+1, err)
+		}
+		_, err := m.db.Exec(ctx,
+			`UPDATE recipe_share.schema_migrations SET statements_applied = $1 WHERE version = $2`,
+			i+1, p.Version)
+		if err != nil {
+			return fmt.Errorf("record migration %d progress: %w", p.Version, err)
+		}
+	}
+
+	_, err := m.db.Exec(ctx,
+		`UPDATE recipe_share.schema_migrations SET applied_at = $1 WHERE version = $2`,
+		time.Now().UTC(), p.Version)
+	if err != nil {
+		return fmt.Errorf("record migration %d: %w", p.Version, err)
+	}
+	log.Printf("Applied migration %d_%s", p.Version, p.Name)
+	return nil
+}
+
+// acquire waits up to LockWait to claim the migration lease.
+func (m *Migrator) acquire(ctx context.Context) error {
+	deadline := time.Now().Add(m.LockWait)
+	for {
+		ok, err := m.tryLock(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(m.PollInterval):
+		}
+	}
+}
+
+// renew extends the lease held by this migrator. It fails if the lease was
+// lost, which happens only if a statement outlived LockTTL.
+func (m *Migrator) renew(ctx context.Context) error {
+	ok, err := m.tryLock(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("migration lock lost to another runner")
+	}
+	return nil
+}
+
+// tryLock claims or renews the lease if it is free, expired, or already held
+// by this migrator. It reports false if another runner holds it.
+func (m *Migrator) tryLock(ctx context.Context) (bool, error) {
+	var acquired bool
+	err := m.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		acquired = false
+		now := time.Now().UTC()
+		expires := now.Add(m.LockTTL)
+
+		var owner string
+		var expiresAt time.Time
+		err := tx.QueryRow(ctx,
+			`SELECT owner, expires_at FROM recipe_share.schema_migrations_lock WHERE id = $1`, lockID).
+			Scan(&owner, &expiresAt)
+		switch {
+		case err == pgx.ErrNoRows:
+			_, err = tx.Exec(ctx,
+				`INSERT INTO recipe_share.schema_migrations_lock (id, owner, expires_at) VALUES ($1, $2, $3)`,
+				lockID, m.owner, expires)
+		case err != nil:
+			return err
+		case owner == m.owner || now.After(expiresAt):
+			_, err = tx.Exec(ctx,
+				`UPDATE recipe_share.schema_migrations_lock SET owner = $1, expires_at = $2 WHERE id = $3`,
+				m.owner, expires, lockID)
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		acquired = true
+		return nil
+	})
+	// Two runners inserting the first lease row at once: the loser sees a
+	// unique violation rather than an OCC conflict. Treat it as held.
+	if pgerr.IsUniqueViolation(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("acquire migration lock: %w", err)
+	}
+	return acquired, nil
+}
+
+// release gives up the lease if this migrator still holds it.
+func (m *Migrator) release(ctx context.Context) error {
+	_, err := m.db.Exec(ctx,
+		`DELETE FROM recipe_share.schema_migrations_lock WHERE id = $1 AND owner = $2`, lockID, m.owner)
+	return err
+}
+
+// isUndefined reports whether err is an undefined schema or table error.
+func isUndefined(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == "42P01" || pgErr.Code == "3F000")
+}
@@ -0,0 +1,45 @@
+-- Chefs, recipes, and ratings. These statements use IF NOT EXISTS so that
+-- databases created by the former InitSchema are adopted without changes.
+
+CREATE SCHEMA IF NOT EXISTS recipe_share;
+
+CREATE TABLE IF NOT EXISTS recipe_share.chefs (
+    id TEXT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    specialty VARCHAR(100) DEFAULT '',
+    bio TEXT DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS recipe_share.recipes (
+    id TEXT PRIMARY KEY,
+    chef_id TEXT NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    description TEXT DEFAULT '',
+    ingredients TEXT NOT NULL,
+    instructions TEXT NOT NULL,
+    prep_time INTEGER DEFAULT 0,
+    cook_time INTEGER DEFAULT 0,
+    servings INTEGER DEFAULT 0,
+    difficulty VARCHAR(20) NOT NULL DEFAULT 'medium',
+    cuisine VARCHAR(50) DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS recipe_share.ratings (
+    id TEXT PRIMARY KEY,
+    recipe_id TEXT NOT NULL,
+    chef_id TEXT NOT NULL,
+    score INTEGER NOT NULL,
+    comment TEXT DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_recipes_chef_id ON recipe_share.recipes(chef_id);
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_ratings_recipe_id ON recipe_share.ratings(recipe_id);
@@ -0,0 +1,7 @@
+-- Indexes for (created_at, id) keyset pagination on list endpoints.
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_chefs_created_at ON recipe_share.chefs(created_at, id);
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_recipes_created_at ON recipe_share.recipes(created_at, id);
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_ratings_recipe_created_at ON recipe_share.ratings(recipe_id, created_at, id);
@@ -0,0 +1,12 @@
+-- Structured ingredient lines. The composite primary key keeps each recipe's
+-- lines together and ordered by position.
+
+CREATE TABLE IF NOT EXISTS recipe_share.recipe_ingredients (
+    recipe_id TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    quantity DOUBLE PRECISION NOT NULL DEFAULT 0,
+    unit VARCHAR(20) NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (recipe_id, position)
+);
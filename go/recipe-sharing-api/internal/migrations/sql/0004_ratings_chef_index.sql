@@ -0,0 +1,3 @@
+-- Ratings written by a chef are found by chef_id when the chef is deleted.
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_ratings_chef_id ON recipe_share.ratings(chef_id);
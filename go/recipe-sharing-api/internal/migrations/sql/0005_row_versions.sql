@@ -0,0 +1,7 @@
+-- Row versions for optimistic concurrency control on chef and recipe updates.
+-- Amazon Aurora DSQL cannot add a column with a default, so existing rows
+-- keep NULL and are read as version 1.
+
+ALTER TABLE recipe_share.chefs ADD COLUMN IF NOT EXISTS version BIGINT;
+
+ALTER TABLE recipe_share.recipes ADD COLUMN IF NOT EXISTS version BIGINT;
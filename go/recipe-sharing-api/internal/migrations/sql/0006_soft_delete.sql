@@ -0,0 +1,14 @@
+-- Soft delete. A NULL deleted_at marks a live row. The indexes let the purge
+-- job find rows past the retention period without scanning whole tables.
+
+ALTER TABLE recipe_share.chefs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+ALTER TABLE recipe_share.recipes ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+ALTER TABLE recipe_share.ratings ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_chefs_deleted_at ON recipe_share.chefs(deleted_at);
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_recipes_deleted_at ON recipe_share.recipes(deleted_at);
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_ratings_deleted_at ON recipe_share.ratings(deleted_at);
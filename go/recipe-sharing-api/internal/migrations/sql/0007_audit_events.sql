@@ -0,0 +1,16 @@
+-- Append-only audit log. Each event has a random UUID key and no counters or
+-- sequences are involved, so concurrent writers never contend on a shared
+-- row. Aurora DSQL has no JSON column type, so snapshots are stored as TEXT.
+
+CREATE TABLE IF NOT EXISTS recipe_share.audit_events (
+    id TEXT PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id TEXT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    before_json TEXT,
+    after_json TEXT,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_audit_events_entity ON recipe_share.audit_events(entity_id, created_at, id);
@@ -0,0 +1,5 @@
+-- Covering index for rating aggregates. Counts, averages, and histograms of a
+-- recipe's live ratings are answered from the index without reading the
+-- rating rows themselves.
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_ratings_recipe_score ON recipe_share.ratings(recipe_id, deleted_at, score);
@@ -0,0 +1,9 @@
+-- Case-insensitive unique chef emails. Aurora DSQL does not support indexes
+-- on expressions, so the lowercased email is stored in email_key and the
+-- unique index is built on that column. Deleted chefs have a NULL email_key
+-- so their address can be reused. Rows written before this migration are
+-- filled in by the backfill command.
+
+ALTER TABLE recipe_share.chefs ADD COLUMN IF NOT EXISTS email_key VARCHAR(255);
+
+CREATE UNIQUE INDEX ASYNC IF NOT EXISTS idx_chefs_email_key ON recipe_share.chefs(email_key);
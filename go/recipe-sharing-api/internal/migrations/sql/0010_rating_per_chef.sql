@@ -0,0 +1,9 @@
+-- One live rating per chef per recipe. live_chef_id holds the rating's
+-- chef_id while the rating is live and is NULL once it is deleted, so the
+-- unique index ignores deleted ratings and a chef can rate again after
+-- deleting a rating. Rows written before this migration are filled in by
+-- the backfill command.
+
+ALTER TABLE recipe_share.ratings ADD COLUMN IF NOT EXISTS live_chef_id TEXT;
+
+CREATE UNIQUE INDEX ASYNC IF NOT EXISTS idx_ratings_recipe_live_chef ON recipe_share.ratings(recipe_id, live_chef_id);
@@ -0,0 +1,21 @@
+-- Recipe tags. A tag is stored once under its unique slug, and recipe_tags
+-- links it to recipes. The composite primary key keeps each recipe's tags
+-- together; the tag_id index serves usage counts and tag filters.
+
+CREATE TABLE IF NOT EXISTS recipe_share.tags (
+    id TEXT PRIMARY KEY,
+    slug VARCHAR(50) NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX ASYNC IF NOT EXISTS idx_tags_slug ON recipe_share.tags(slug);
+
+CREATE TABLE IF NOT EXISTS recipe_share.recipe_tags (
+    recipe_id TEXT NOT NULL,
+    tag_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (recipe_id, tag_id)
+);
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_recipe_tags_tag_id ON recipe_share.recipe_tags(tag_id, recipe_id);
@@ -0,0 +1,25 @@
+-- Recipe collections. collection_items orders each collection's recipes by
+-- position and copies the owning chef_id from the collection, so favorite
+-- counts are aggregated from one table without a hot counter row.
+
+CREATE TABLE IF NOT EXISTS recipe_share.collections (
+    id TEXT PRIMARY KEY,
+    chef_id TEXT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_collections_chef ON recipe_share.collections(chef_id, created_at, id);
+
+CREATE TABLE IF NOT EXISTS recipe_share.collection_items (
+    collection_id TEXT NOT NULL,
+    recipe_id TEXT NOT NULL,
+    chef_id TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    added_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (collection_id, recipe_id)
+);
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_collection_items_recipe ON recipe_share.collection_items(recipe_id, chef_id);
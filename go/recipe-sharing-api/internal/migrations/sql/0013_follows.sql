@@ -0,0 +1,14 @@
+-- Follower relationships between chefs. The primary key answers whether one
+-- chef follows another and finds whom a chef follows for the feed; the two
+-- indexes list a chef's followers and followings newest first.
+
+CREATE TABLE IF NOT EXISTS recipe_share.follows (
+    follower_id TEXT NOT NULL,
+    followee_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (follower_id, followee_id)
+);
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_follows_followee ON recipe_share.follows(followee_id, created_at, follower_id);
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_follows_follower ON recipe_share.follows(follower_id, created_at, followee_id);
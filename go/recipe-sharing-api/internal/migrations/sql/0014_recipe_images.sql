@@ -0,0 +1,18 @@
+-- Metadata for recipe images. The image and thumbnail bytes live in a blob
+-- store under blob_key and thumbnail_key; the index lists a recipe's images
+-- in upload order.
+
+CREATE TABLE IF NOT EXISTS recipe_share.recipe_images (
+    id TEXT PRIMARY KEY,
+    recipe_id TEXT NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    blob_key TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL,
+    thumbnail_content_type VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_recipe_images_recipe ON recipe_share.recipe_images(recipe_id, created_at, id);
@@ -0,0 +1,7 @@
+-- Recipe forks. forked_from_id names the recipe a fork was copied from and
+-- is NULL for originals. The index lists a recipe's forks newest first and
+-- counts them.
+
+ALTER TABLE recipe_share.recipes ADD COLUMN IF NOT EXISTS forked_from_id TEXT;
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_recipes_forked_from ON recipe_share.recipes(forked_from_id, created_at, id);
@@ -0,0 +1,10 @@
+-- Recipe publishing. published_at records when a recipe was last published
+-- and is NULL while it is a draft. publish_at schedules a draft to be
+-- published; the index lets the publishing sweep find due drafts without
+-- scanning every recipe.
+
+ALTER TABLE recipe_share.recipes ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+
+ALTER TABLE recipe_share.recipes ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_recipes_publish_at ON recipe_share.recipes(publish_at, id);
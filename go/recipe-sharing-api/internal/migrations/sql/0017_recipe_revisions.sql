@@ -0,0 +1,13 @@
+-- Append-only recipe revision history. Each row is a snapshot of a recipe
+-- at one version, written in the same transaction as the update that
+-- replaced it. The key is the recipe and its version number, which OCC
+-- already makes unique, so no sequence or counter row is shared between
+-- writers. Aurora DSQL has no JSON column type, so snapshots are TEXT.
+
+CREATE TABLE IF NOT EXISTS recipe_share.recipe_revisions (
+    recipe_id TEXT NOT NULL,
+    revision BIGINT NOT NULL,
+    snapshot TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (recipe_id, revision)
+);
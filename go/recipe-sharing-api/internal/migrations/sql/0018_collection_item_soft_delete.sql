@@ -0,0 +1,6 @@
+-- Collection items are hidden while their recipe is deleted. deleted_at
+-- holds the recipe's deleted_at, so restoring the recipe brings back
+-- exactly the items hidden with it. Items of recipes deleted before this
+-- migration are hidden by their recipe's deleted_at instead.
+
+ALTER TABLE recipe_share.collection_items ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
@@ -0,0 +1,6 @@
+-- The feed lists the published recipes of followed chefs, most recently
+-- published first. This index answers it for each followed chef in
+-- (published_at, id) order. Recipes published before published_at existed
+-- are given one by the backfill command.
+
+CREATE INDEX ASYNC IF NOT EXISTS idx_recipes_chef_published ON recipe_share.recipes(chef_id, published_at, id);
@@ -0,0 +1,51 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Entity types recorded in the audit log.
+const (
+	EntityChef           = "chef"
+	EntityRecipe         = "recipe"
+	EntityRating         = "rating"
+	EntityTag            = "tag"
+	EntityRecipeTag      = "recipe_tag"
+	EntityCollection     = "collection"
+	EntityCollectionItem = "collection_item"
+	EntityFollow         = "follow"
+	EntityRecipeImage    = "recipe_image"
+)
+
+// Actions recorded in the audit log. Purge records the permanent removal
+// of a soft-deleted row.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+)
+
+// AuditEvent records one change to a chef, recipe, rating, tag,
+// collection, follow, or recipe image. Before and After hold the JSON form
+// of the entity on either side of the change; Before is null for a create,
+// and After is null for a purge and for the delete of a row that is removed
+// outright rather than soft-deleted. Tagging and untagging a recipe are
+// recorded as recipe_tag events under the recipe's ID, and changes to a
+// collection's items as collection_item events under the collection's ID,
+// so they appear in the history of the recipe or collection.
+type AuditEvent struct {
+	ID         string          `json:"id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Action     string          `json:"action"`
+	Actor      string          `json:"actor"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
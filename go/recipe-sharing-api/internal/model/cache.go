@@ -0,0 +1,16 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+// CacheStats counts the lookups of a caching store since it was created.
+// Hits and Misses count reads served from the cache and from the database,
+// Invalidations the mutations that invalidated cached reads, and Errors the
+// failed cache operations, each of which fell back to the database.
+type CacheStats struct {
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRate       float64 `json:"hit_rate"`
+	Invalidations uint64  `json:"invalidations"`
+	Errors        uint64  `json:"errors"`
+}
@@ -3,17 +3,22 @@
 
 package model
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Chef represents a person who creates and shares recipes.
 type Chef struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Specialty string    `json:"specialty,omitempty"`
-	Bio       string    `json:"bio,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Specialty string     `json:"specialty,omitempty"`
+	Bio       string     `json:"bio,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Version   int64      `json:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 // ChefWithRecipes includes a chef's profile along with their recipes.
@@ -31,9 +36,29 @@ type CreateChefInput struct {
 }
 
 // UpdateChefInput holds the fields that can be updated on a chef.
+// IfVersion is set from the If-Match header; when non-nil the update is
+// applied only if the chef's current version matches.
 type UpdateChefInput struct {
 	Name      *string `json:"name,omitempty"`
 	Email     *string `json:"email,omitempty" binding:"omitempty,email"`
 	Specialty *string `json:"specialty,omitempty"`
 	Bio       *string `json:"bio,omitempty"`
+	IfVersion *int64  `json:"-"`
+}
+
+// ChefDeletion reports the rows soft-deleted when a chef is deleted along
+// with their recipes, the ratings they wrote, and the ratings on their
+// recipes. Ingredients counts the ingredient lines of the deleted recipes,
+// which are hidden with them.
+type ChefDeletion struct {
+	Deleted     bool `json:"deleted"`
+	Recipes     int  `json:"recipes"`
+	Ratings     int  `json:"ratings"`
+	Ingredients int  `json:"ingredients"`
+}
+
+// EmailKey returns the form of an email address used to enforce uniqueness.
+// Emails are compared without regard to case or surrounding whitespace.
+func EmailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
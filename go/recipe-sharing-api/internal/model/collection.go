@@ -0,0 +1,64 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+import "time"
+
+// Collection is a named, ordered list of recipes saved by a chef, such as
+// "Weeknight" or "Holiday".
+type Collection struct {
+	ID          string    `json:"id"`
+	ChefID      string    `json:"chef_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CollectionItem places a recipe in a collection. Items are ordered by
+// Position, starting at 1. Title is filled in on reads of the collection.
+type CollectionItem struct {
+	CollectionID string    `json:"collection_id"`
+	RecipeID     string    `json:"recipe_id"`
+	Position     int       `json:"position"`
+	Title        string    `json:"title,omitempty"`
+	AddedAt      time.Time `json:"added_at"`
+}
+
+// CollectionWithItems includes a collection along with its items in order.
+// Items whose recipe is deleted are omitted.
+type CollectionWithItems struct {
+	Collection
+	Items []CollectionItem `json:"items"`
+}
+
+// CreateCollectionInput holds the fields required to create a collection.
+type CreateCollectionInput struct {
+	ChefID      string `json:"chef_id" binding:"required"`
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description,omitempty"`
+}
+
+// UpdateCollectionInput holds the fields that can be updated on a collection.
+type UpdateCollectionInput struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description,omitempty"`
+}
+
+// AddCollectionItemInput names the recipe to add to a collection. Position,
+// when set, is where the recipe is inserted; later items move down one
+// place. The recipe is appended by default.
+type AddCollectionItemInput struct {
+	RecipeID string `json:"recipe_id" binding:"required"`
+	Position int    `json:"position,omitempty" binding:"omitempty,min=1"`
+}
+
+// MoveCollectionItemInput holds the new position of a collection item.
+type MoveCollectionItemInput struct {
+	Position int `json:"position" binding:"required,min=1"`
+}
+
+// MaxCollectionItems caps the recipes in one collection, which bounds the
+// rows a reorder or a collection delete writes in one transaction.
+const MaxCollectionItems = 500
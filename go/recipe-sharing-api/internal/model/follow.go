@@ -0,0 +1,25 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+import "time"
+
+// Follow records that one chef follows another.
+type Follow struct {
+	FollowerID string    `json:"follower_id"`
+	FolloweeID string    `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// FollowInput names the chef who follows or unfollows another.
+type FollowInput struct {
+	FollowerID string `json:"follower_id" binding:"required"`
+}
+
+// FollowedChef is an entry in a follower or following list: the chef on the
+// other side of the follow and when the follow began.
+type FollowedChef struct {
+	Chef
+	FollowedAt time.Time `json:"followed_at"`
+}
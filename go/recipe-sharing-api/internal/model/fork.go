@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+// MaxLineageDepth caps the number of ancestors a lineage walks back.
+const MaxLineageDepth = 50
+
+// ForkRecipeInput names the chef who forks a recipe.
+type ForkRecipeInput struct {
+	ChefID string `json:"chef_id" binding:"required"`
+}
+
+// Lineage is the fork history of a recipe. Ancestors runs from the recipe
+// it was forked from back towards the original, and stops early at an
+// ancestor that has been deleted or after MaxLineageDepth recipes.
+// Descendants holds one page of the recipe's direct forks, newest first.
+type Lineage struct {
+	RecipeID    string   `json:"recipe_id"`
+	Ancestors   []Recipe `json:"ancestors"`
+	Descendants []Recipe `json:"descendants"`
+	NextCursor  string   `json:"next_cursor,omitempty"`
+}
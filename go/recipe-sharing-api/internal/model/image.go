@@ -0,0 +1,45 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+import "time"
+
+// Limits on recipe image uploads.
+const (
+	// MaxImageBytes is the largest image file accepted.
+	MaxImageBytes = 10 << 20
+	// MaxImagePixels bounds the decoded size of an image, so a small file
+	// cannot expand into an enormous bitmap.
+	MaxImagePixels = 40_000_000
+	// MaxRecipeImages is the most images a recipe can have.
+	MaxRecipeImages = 20
+	// ThumbnailSize is the length of the longer side of a thumbnail.
+	ThumbnailSize = 320
+)
+
+// ImageContentTypes lists the content types accepted for recipe images.
+var ImageContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// RecipeImage describes an image uploaded for a recipe. The image and its
+// thumbnail are stored in a blob store under BlobKey and ThumbnailKey; URL
+// and ThumbnailURL are the API paths that serve them.
+type RecipeImage struct {
+	ID                   string    `json:"id"`
+	RecipeID             string    `json:"recipe_id"`
+	ContentType          string    `json:"content_type"`
+	SizeBytes            int64     `json:"size_bytes"`
+	Width                int       `json:"width"`
+	Height               int       `json:"height"`
+	ThumbnailContentType string    `json:"thumbnail_content_type"`
+	BlobKey              string    `json:"-"`
+	ThumbnailKey         string    `json:"-"`
+	URL                  string    `json:"url,omitempty"`
+	ThumbnailURL         string    `json:"thumbnail_url,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+}
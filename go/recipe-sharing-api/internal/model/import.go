@@ -0,0 +1,45 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+// Limits on recipe imports.
+const (
+	// MaxImportRows is the most recipes one import request can hold.
+	MaxImportRows = 5000
+	// MaxImportBytes is the largest import request body accepted.
+	MaxImportBytes = 32 << 20
+	// MaxRecipeDocumentBytes is the largest HTML or JSON-LD document
+	// accepted when importing a single recipe.
+	MaxRecipeDocumentBytes = 5 << 20
+)
+
+// Bulk import and export formats.
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+// Import row outcomes.
+const (
+	ImportCreated = "created"
+	ImportFailed  = "failed"
+)
+
+// ImportRowResult reports the outcome of one row of an import. Row is the
+// line number of the row in the request body, counting from 1, so that it
+// points at the row in the uploaded file.
+type ImportRowResult struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportReport summarizes an import and lists the outcome of every row.
+type ImportReport struct {
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
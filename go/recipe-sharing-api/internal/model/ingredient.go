@@ -0,0 +1,23 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+// MaxIngredients caps the number of structured ingredient lines per recipe,
+// whether given as a list or parsed from free text. It matches the max
+// binding on the recipe input types.
+const MaxIngredients = 100
+
+// MaxIngredientName is the width of the recipe_ingredients name column, in
+// characters. It matches the max binding on Ingredient.Name.
+const MaxIngredientName = 200
+
+// Ingredient is one structured line of a recipe's ingredient list.
+// A zero Quantity means the amount is unspecified (e.g. "salt to taste").
+type Ingredient struct {
+	Position int     `json:"position"`
+	Name     string  `json:"name" binding:"required,max=200"`
+	Quantity float64 `json:"quantity,omitempty" binding:"gte=0"`
+	Unit     string  `json:"unit,omitempty" binding:"max=20"`
+	Note     string  `json:"note,omitempty"`
+}
@@ -0,0 +1,44 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+// Nutrients holds the energy, in kilocalories, and the macronutrients, in
+// grams, of an amount of food.
+type Nutrients struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein_g"`
+	Fat      float64 `json:"fat_g"`
+	Carbs    float64 `json:"carbs_g"`
+}
+
+// NutritionLine is the estimate for one ingredient line: the reference food
+// it matched, its weight in grams, and the nutrients of that weight.
+type NutritionLine struct {
+	Position   int     `json:"position"`
+	Ingredient string  `json:"ingredient"`
+	Food       string  `json:"food"`
+	Grams      float64 `json:"grams"`
+	Nutrients
+}
+
+// UnmatchedIngredient is an ingredient line left out of a nutrition
+// estimate, with the reason it was.
+type UnmatchedIngredient struct {
+	Position   int    `json:"position"`
+	Ingredient string `json:"ingredient"`
+	Reason     string `json:"reason"`
+}
+
+// Nutrition is the estimated nutrition of a recipe. Total sums the matched
+// lines, and PerServing divides it by Servings; it is omitted for recipes
+// that do not specify servings. Lines that could not be estimated are
+// listed in Unmatched and contribute nothing to the totals.
+type Nutrition struct {
+	RecipeID   string                `json:"recipe_id"`
+	Servings   int                   `json:"servings,omitempty"`
+	Total      Nutrients             `json:"total"`
+	PerServing *Nutrients            `json:"per_serving,omitempty"`
+	Lines      []NutritionLine       `json:"lines"`
+	Unmatched  []UnmatchedIngredient `json:"unmatched"`
+}
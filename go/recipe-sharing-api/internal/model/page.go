@@ -0,0 +1,72 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Page size limits for list endpoints.
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor identifies the last row of a page in (created_at, id) keyset order.
+// Orderings with leading sort keys carry them in Score, Rank, and Key:
+// search relevance uses Rank, rating order uses Score for the average and
+// Rank for the count, and tag order uses Key for the slug. The feed, which
+// is ordered by publication, carries published_at in CreatedAt. Clients
+// treat the encoded form as opaque.
+type Cursor struct {
+	Score     float64   `json:"s,omitempty"`
+	Rank      int       `json:"r,omitempty"`
+	Key       string    `json:"k,omitempty"`
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"i"`
+}
+
+// Encode returns the opaque, URL-safe string form of the cursor.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a cursor previously produced by Cursor.Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.ID == "" || c.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// PageRequest holds keyset pagination parameters for list operations.
+// A nil Cursor requests the first page.
+type PageRequest struct {
+	Limit  int
+	Cursor *Cursor
+}
+
+// EffectiveLimit returns the page size to use, applying the default and
+// maximum limits.
+func (p PageRequest) EffectiveLimit() int {
+	if p.Limit <= 0 {
+		return DefaultPageLimit
+	}
+	return min(p.Limit, MaxPageLimit)
+}
@@ -0,0 +1,61 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// Recipe status transitions. Publishing makes a draft or archived recipe
+// public, unpublishing returns a published recipe to draft, and archiving
+// retires a draft or published recipe.
+const (
+	TransitionPublish   = "publish"
+	TransitionUnpublish = "unpublish"
+	TransitionArchive   = "archive"
+)
+
+// transitions lists, for each transition, the statuses it starts from and
+// the status it leads to.
+var transitions = map[string]struct {
+	from []string
+	to   string
+}{
+	TransitionPublish:   {from: []string{StatusDraft, StatusArchived}, to: StatusPublished},
+	TransitionUnpublish: {from: []string{StatusPublished}, to: StatusDraft},
+	TransitionArchive:   {from: []string{StatusDraft, StatusPublished}, to: StatusArchived},
+}
+
+// NextStatus returns the status a recipe with status from moves to under a
+// transition, and false if the transition is not allowed from that status.
+func NextStatus(from, transition string) (string, bool) {
+	t, ok := transitions[transition]
+	if !ok || !slices.Contains(t.from, from) {
+		return "", false
+	}
+	return t.to, true
+}
+
+// CanChangeStatus reports whether a recipe may move from one status to
+// another, either because some transition allows it or because the status
+// does not change.
+func CanChangeStatus(from, to string) bool {
+	if from == to {
+		return true
+	}
+	for _, t := range transitions {
+		if t.to == to && slices.Contains(t.from, from) {
+			return true
+		}
+	}
+	return false
+}
+
+// PublishInput holds the optional body of a publish request. A PublishAt in
+// the future schedules a draft to be published then instead of publishing
+// it now.
+type PublishInput struct {
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+}
@@ -0,0 +1,19 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+// PurgeResult reports the rows permanently removed by a purge of
+// soft-deleted records. Images and Revisions count the images and revisions
+// of purged recipes, and Collections and Follows count the collections and
+// follows of purged chefs.
+type PurgeResult struct {
+	Chefs       int `json:"chefs"`
+	Recipes     int `json:"recipes"`
+	Ratings     int `json:"ratings"`
+	Ingredients int `json:"ingredients"`
+	Images      int `json:"images"`
+	Revisions   int `json:"revisions"`
+	Collections int `json:"collections"`
+	Follows     int `json:"follows"`
+}
@@ -7,13 +7,14 @@ import "time"
 
 // Rating represents a review of a recipe by a chef.
 type Rating struct {
-	ID        string    `json:"id"`
-	RecipeID  string    `json:"recipe_id"`
-	ChefID    string    `json:"chef_id"`
-	Score     int       `json:"score"`
-	Comment   string    `json:"comment,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        string     `json:"id"`
+	RecipeID  string     `json:"recipe_id"`
+	ChefID    string     `json:"chef_id"`
+	Score     int        `json:"score"`
+	Comment   string     `json:"comment,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 // CreateRatingInput holds the fields required to create a new rating.
@@ -22,3 +23,9 @@ type CreateRatingInput struct {
 	Score   int    `json:"score" binding:"required,min=1,max=5"`
 	Comment string `json:"comment,omitempty"`
 }
+
+// UpdateRatingInput holds the fields that can be updated on a rating.
+type UpdateRatingInput struct {
+	Score   *int    `json:"score,omitempty" binding:"omitempty,min=1,max=5"`
+	Comment *string `json:"comment,omitempty"`
+}
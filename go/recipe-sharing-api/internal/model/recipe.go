@@ -6,69 +6,140 @@ package model
 import "time"
 
 // Recipe represents a dish with ingredients, instructions, and metadata.
+// Ingredients holds the free-text form of the ingredient list and
+// IngredientList the structured form. IngredientList is populated when a
+// single recipe is fetched and omitted from list results, and so are the
+// slugs of its tags, in alphabetical order. The rating summary and
+// FavoritedCount, the number of live chefs who have saved the recipe to a
+// collection, are populated on reads and omitted from create and update
+// responses. ForkedFromID names the recipe this one was forked from, and
+// ForkCount, the number of live forks of the recipe, is populated when a
+// single recipe is fetched. PublishedAt is when the recipe was last
+// published, and PublishAt when a draft is scheduled to be published.
 type Recipe struct {
-	ID           string    `json:"id"`
-	ChefID       string    `json:"chef_id"`
-	Title        string    `json:"title"`
-	Description  string    `json:"description,omitempty"`
-	Ingredients  string    `json:"ingredients"`
-	Instructions string    `json:"instructions"`
-	PrepTime     int       `json:"prep_time,omitempty"`
-	CookTime     int       `json:"cook_time,omitempty"`
-	Servings     int       `json:"servings,omitempty"`
-	Difficulty   string    `json:"difficulty"`
-	Cuisine      string    `json:"cuisine,omitempty"`
-	Status       string    `json:"status"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID             string       `json:"id"`
+	ChefID         string       `json:"chef_id"`
+	Title          string       `json:"title"`
+	Description    string       `json:"description,omitempty"`
+	Ingredients    string       `json:"ingredients"`
+	IngredientList []Ingredient `json:"ingredient_list,omitempty"`
+	Instructions   string       `json:"instructions"`
+	PrepTime       int          `json:"prep_time,omitempty"`
+	CookTime       int          `json:"cook_time,omitempty"`
+	Servings       int          `json:"servings,omitempty"`
+	Difficulty     string       `json:"difficulty"`
+	Cuisine        string       `json:"cuisine,omitempty"`
+	Status         string       `json:"status"`
+	ForkedFromID   *string      `json:"forked_from_id,omitempty"`
+	PublishedAt    *time.Time   `json:"published_at,omitempty"`
+	PublishAt      *time.Time   `json:"publish_at,omitempty"`
+	Tags           []string     `json:"tags,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	Version        int64        `json:"version"`
+	DeletedAt      *time.Time   `json:"deleted_at,omitempty"`
+	FavoritedCount *int         `json:"favorited_count,omitempty"`
+	ForkCount      *int         `json:"fork_count,omitempty"`
+	*RatingSummary
 }
 
-// RecipeWithRatings includes a recipe along with its ratings and average score.
+// RatingSummary aggregates the live ratings of a recipe. AverageScore is 0
+// for a recipe with no ratings.
+type RatingSummary struct {
+	AverageScore float64 `json:"average_score"`
+	RatingCount  int     `json:"rating_count"`
+}
+
+// RecipeWithRatings includes a recipe along with its most recent ratings and
+// the distribution of all its live ratings. RatingHistogram maps each score
+// from 1 to 5 to the number of ratings with that score.
 type RecipeWithRatings struct {
 	Recipe
-	Ratings      []Rating `json:"ratings"`
-	AverageScore float64  `json:"average_score"`
-	RatingCount  int      `json:"rating_count"`
+	Ratings         []Rating    `json:"ratings"`
+	RatingHistogram map[int]int `json:"rating_histogram"`
 }
 
 // CreateRecipeInput holds the fields required to create a new recipe.
+// At least one of Ingredients or IngredientList is required. When only the
+// free text is given it is parsed into structured lines; when only the list
+// is given the free text is rendered from it. PublishAt schedules a draft
+// to be published at a future time.
 type CreateRecipeInput struct {
-	ChefID       string `json:"chef_id" binding:"required"`
-	Title        string `json:"title" binding:"required"`
-	Description  string `json:"description,omitempty"`
-	Ingredients  string `json:"ingredients" binding:"required"`
-	Instructions string `json:"instructions" binding:"required"`
-	PrepTime     int    `json:"prep_time,omitempty"`
-	CookTime     int    `json:"cook_time,omitempty"`
-	Servings     int    `json:"servings,omitempty"`
-	Difficulty   string `json:"difficulty,omitempty"`
-	Cuisine      string `json:"cuisine,omitempty"`
-	Status       string `json:"status,omitempty"`
+	ChefID         string       `json:"chef_id" binding:"required"`
+	Title          string       `json:"title" binding:"required"`
+	Description    string       `json:"description,omitempty"`
+	Ingredients    string       `json:"ingredients,omitempty"`
+	IngredientList []Ingredient `json:"ingredient_list,omitempty" binding:"omitempty,max=100,dive"`
+	Instructions   string       `json:"instructions" binding:"required"`
+	PrepTime       int          `json:"prep_time,omitempty"`
+	CookTime       int          `json:"cook_time,omitempty"`
+	Servings       int          `json:"servings,omitempty"`
+	Difficulty     string       `json:"difficulty,omitempty"`
+	Cuisine        string       `json:"cuisine,omitempty"`
+	Status         string       `json:"status,omitempty"`
+	PublishAt      *time.Time   `json:"publish_at,omitempty"`
 }
 
 // UpdateRecipeInput holds the fields that can be updated on a recipe.
+// Ingredients and IngredientList follow the same rules as on create.
+// A change of Status must be allowed by CanChangeStatus. IfVersion is set
+// from the If-Match header; when non-nil the update is applied only if the
+// recipe's current version matches.
 type UpdateRecipeInput struct {
-	Title        *string `json:"title,omitempty"`
-	Description  *string `json:"description,omitempty"`
-	Ingredients  *string `json:"ingredients,omitempty"`
-	Instructions *string `json:"instructions,omitempty"`
-	PrepTime     *int    `json:"prep_time,omitempty"`
-	CookTime     *int    `json:"cook_time,omitempty"`
-	Servings     *int    `json:"servings,omitempty"`
-	Difficulty   *string `json:"difficulty,omitempty"`
-	Cuisine      *string `json:"cuisine,omitempty"`
-	Status       *string `json:"status,omitempty"`
+	Title          *string       `json:"title,omitempty"`
+	Description    *string       `json:"description,omitempty"`
+	Ingredients    *string       `json:"ingredients,omitempty"`
+	IngredientList *[]Ingredient `json:"ingredient_list,omitempty" binding:"omitempty,max=100,dive"`
+	Instructions   *string       `json:"instructions,omitempty"`
+	PrepTime       *int          `json:"prep_time,omitempty"`
+	CookTime       *int          `json:"cook_time,omitempty"`
+	Servings       *int          `json:"servings,omitempty"`
+	Difficulty     *string       `json:"difficulty,omitempty"`
+	Cuisine        *string       `json:"cuisine,omitempty"`
+	Status         *string       `json:"status,omitempty"`
+	IfVersion      *int64        `json:"-"`
 }
 
-// RecipeFilter holds optional query parameters for filtering recipes.
+// RecipeFilter holds optional query parameters for filtering and ordering
+// recipes. MinRating, when non-zero, keeps recipes whose average score is at
+// least that value. Tags, when non-empty, keeps recipes carrying any or all
+// of the tag slugs, as TagMatch says. Sort selects the order of results.
 type RecipeFilter struct {
 	Cuisine    string
 	Difficulty string
 	Status     string
+	MinRating  float64
+	Tags       []string
+	TagMatch   string
+	Sort       string
 }
 
+// Recipe list orderings. SortNewest is the default. SortRating orders by
+// average score, then rating count, then creation date, all descending.
+const (
+	SortNewest = "newest"
+	SortRating = "rating"
+)
+
+// ValidSorts lists the accepted values of the sort query parameter.
+var ValidSorts = []string{SortNewest, SortRating}
+
+// Bounds of a rating score.
+const (
+	MinScore = 1
+	MaxScore = 5
+)
+
 // Valid difficulty levels for recipes.
 var ValidDifficulties = []string{"easy", "medium", "hard"}
 
+// Recipe status values. Only published recipes appear in feeds. A status
+// changes only by a transition that NextStatus allows.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
 // Valid status values for recipes.
-var ValidStatuses = []string{"draft", "published", "archived"}
+var ValidStatuses = []string{StatusDraft, StatusPublished, StatusArchived}
@@ -8,10 +8,12 @@ type SuccessResponse struct {
 	Data any `json:"data"`
 }
 
-// ListResponse wraps a collection of resources with a count.
+// ListResponse wraps a page of resources with a count and, when more
+// results are available, the cursor for the next page.
 type ListResponse struct {
-	Data  any `json:"data"`
-	Count int `json:"count"`
+	Data       any    `json:"data"`
+	Count      int    `json:"count"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // ErrorDetail holds the code and message for an API error.
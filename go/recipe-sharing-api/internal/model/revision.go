@@ -0,0 +1,53 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+import "time"
+
+// RecipeRevision is a snapshot of a recipe as it was at one version, taken
+// when that version was replaced. Revision equals the recipe's Version in
+// the snapshot, and CreatedAt is when it was replaced. The snapshot holds
+// the recipe's content, status, and ingredient lines, but not its tags or
+// read-time counts. List results omit the ingredient lines.
+type RecipeRevision struct {
+	RecipeID  string    `json:"recipe_id"`
+	Revision  int64     `json:"revision"`
+	Recipe    Recipe    `json:"recipe"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Line diff operations.
+const (
+	LineEqual  = "equal"
+	LineInsert = "insert"
+	LineDelete = "delete"
+)
+
+// LineChange is one line of a line diff: a line both versions share, or
+// one only the newer version has, or one only the older version had.
+type LineChange struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// FieldChange records a scalar field whose value differs between two
+// versions of a recipe. Field is the field's JSON name.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// RecipeDiff describes the changes from one version of a recipe to
+// another. Fields lists the changed scalar fields in a fixed order.
+// Ingredients and Instructions are line diffs of the ingredient and
+// instruction text, present only when that text changed.
+type RecipeDiff struct {
+	RecipeID     string        `json:"recipe_id"`
+	From         int64         `json:"from"`
+	To           int64         `json:"to"`
+	Fields       []FieldChange `json:"fields"`
+	Ingredients  []LineChange  `json:"ingredients,omitempty"`
+	Instructions []LineChange  `json:"instructions,omitempty"`
+}
@@ -0,0 +1,31 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxSearchTerms caps the number of terms in a recipe search query. Each term
+// adds predicates to the search SQL, so the cap bounds query cost.
+const MaxSearchTerms = 8
+
+// SearchTerms splits a free-text query into lower-cased, de-duplicated terms.
+// Any character that is not a letter or digit separates terms.
+func SearchTerms(q string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var terms []string
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		terms = append(terms, f)
+	}
+	return terms
+}
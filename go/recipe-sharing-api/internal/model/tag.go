@@ -0,0 +1,68 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package model
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Tag is a label that can be attached to any number of recipes. Slug is the
+// normalized form used in URLs and filters, and is unique; Name keeps the
+// display form the tag was first created with.
+type Tag struct {
+	ID        string    `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TagUsage is a tag with the number of live recipes that carry it.
+type TagUsage struct {
+	Tag
+	RecipeCount int `json:"recipe_count"`
+}
+
+// RecipeTag links a recipe to a tag. It is the entity recorded in the audit
+// log when a recipe is tagged or untagged.
+type RecipeTag struct {
+	RecipeID  string    `json:"recipe_id"`
+	TagID     string    `json:"tag_id"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddTagsInput holds the names of the tags to add to a recipe.
+type AddTagsInput struct {
+	Tags []string `json:"tags" binding:"required,min=1,max=20,dive,required,max=50"`
+}
+
+// MaxRecipeTags caps the number of tags on one recipe, which bounds the rows
+// a recipe purge deletes in one transaction. MaxTagFilters caps the tags in
+// one recipe list filter.
+const (
+	MaxRecipeTags = 20
+	MaxTagFilters = 10
+)
+
+// Tag filter modes. TagMatchAny, the default, keeps recipes with at least
+// one of the requested tags; TagMatchAll keeps recipes with every one.
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// ValidTagMatches lists the accepted values of the match query parameter.
+var ValidTagMatches = []string{TagMatchAny, TagMatchAll}
+
+// Slugify normalizes a tag name into its slug: lower-cased words of letters
+// and digits joined by hyphens. "Quick & Easy" and "quick-easy" share the
+// slug "quick-easy". It returns "" for a name with no letters or digits.
+func Slugify(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,136 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package nutrition
+
+import (
+	"math"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/ingredients"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+)
+
+// Weights of measures that are not volume or mass units.
+const (
+	gramsPerCan   = 400
+	gramsPerPinch = 0.35
+)
+
+// Reasons an ingredient line is left out of an estimate.
+const (
+	reasonNoFood     = "no matching food in the nutrition table"
+	reasonNoQuantity = "no quantity to estimate from"
+	reasonNoWeight   = "quantity cannot be converted to grams"
+)
+
+// Estimate estimates the total nutrition of a list of ingredients, and the
+// nutrition per serving when servings is positive. Each line is matched to
+// a reference food with Match and its quantity converted to grams: mass
+// units directly, volume units through the food's density, and counts,
+// cloves, and slices through the food's weight each. Lines that cannot be
+// matched or weighed are reported in Unmatched.
+func Estimate(list []model.Ingredient, servings int) model.Nutrition {
+	out := model.Nutrition{
+		Lines:     []model.NutritionLine{},
+		Unmatched: []model.UnmatchedIngredient{},
+	}
+	var total model.Nutrients
+	for i, ing := range list {
+		position := ing.Position
+		if position == 0 {
+			position = i + 1
+		}
+		text := ingredients.FormatLine(ing)
+
+		food, ok := Match(ing.Name)
+		reason := reasonNoFood
+		var grams float64
+		if ok {
+			grams, reason = weigh(ing, food)
+		}
+		if reason != "" {
+			out.Unmatched = append(out.Unmatched, model.UnmatchedIngredient{Position: position, Ingredient: text, Reason: reason})
+			continue
+		}
+
+		n := scale(food.Per100g, grams/100)
+		total = add(total, n)
+		out.Lines = append(out.Lines, model.NutritionLine{
+			Position:   position,
+			Ingredient: text,
+			Food:       food.Name,
+			Grams:      round(grams),
+			Nutrients:  roundAll(n),
+		})
+	}
+
+	out.Total = roundAll(total)
+	if servings > 0 {
+		out.Servings = servings
+		per := roundAll(scale(total, 1/float64(servings)))
+		out.PerServing = &per
+	}
+	return out
+}
+
+// weigh converts the quantity of an ingredient line to grams of a food. It
+// returns the reason it could not, or "".
+func weigh(ing model.Ingredient, food *Food) (float64, string) {
+	if ing.Quantity <= 0 {
+		return 0, reasonNoQuantity
+	}
+	if g, ok := ingredients.Grams(ing.Quantity, ing.Unit); ok {
+		return g, ""
+	}
+	if ml, ok := ingredients.Milliliters(ing.Quantity, ing.Unit); ok {
+		if food.GramsPerML == 0 {
+			return 0, reasonNoWeight
+		}
+		return ml * food.GramsPerML, ""
+	}
+	switch ing.Unit {
+	case "", "clove", "slice":
+		if food.GramsEach == 0 {
+			return 0, reasonNoWeight
+		}
+		return ing.Quantity * food.GramsEach, ""
+	case "can":
+		return ing.Quantity * gramsPerCan, ""
+	case "pinch":
+		return ing.Quantity * gramsPerPinch, ""
+	}
+	return 0, reasonNoWeight
+}
+
+func scale(n model.Nutrients, factor float64) model.Nutrients {
+	return model.Nutrients{
+		Calories: n.Calories * factor,
+		Protein:  n.Protein * factor,
+		Fat:      n.Fat * factor,
+		Carbs:    n.Carbs * factor,
+	}
+}
+
+func add(a, b model.Nutrients) model.Nutrients {
+	return model.Nutrients{
+		Calories: a.Calories + b.Calories,
+		Protein:  a.Protein + b.Protein,
+		Fat:      a.Fat + b.Fat,
+		Carbs:    a.Carbs + b.Carbs,
+	}
+}
+
+// roundAll rounds calories to whole kilocalories and macronutrients to
+// tenths of a gram.
+func roundAll(n model.Nutrients) model.Nutrients {
+	return model.Nutrients{
+		Calories: math.Round(n.Calories),
+		Protein:  round(n.Protein),
+		Fat:      round(n.Fat),
+		Carbs:    round(n.Carbs),
+	}
+}
+
+func round(x float64) float64 {
+	return math.Round(x*10) / 10
+}
@@ -0,0 +1,67 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package nutrition estimates the nutrition of recipes from a bundled
+// reference table of foods.
+package nutrition
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+)
+
+//go:embed foods.csv
+var foodsCSV string
+
+// Food is one entry of the reference table. Per100g holds the nutrients of
+// 100 g of the food. GramsPerML converts volume measures to weight, and
+// GramsEach is the weight of one item, clove, or slice; either is 0 when
+// the food is not measured that way.
+type Food struct {
+	Name       string
+	Aliases    []string
+	Per100g    model.Nutrients
+	GramsPerML float64
+	GramsEach  float64
+}
+
+// foods is the parsed reference table. It is embedded in the binary, so a
+// malformed table is a programming error.
+var foods = mustParseFoods(foodsCSV)
+
+func mustParseFoods(data string) []Food {
+	r := csv.NewReader(strings.NewReader(data))
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("parse foods table: %v", err))
+	}
+	var out []Food
+	for i, rec := range records[1:] {
+		nums := make([]float64, len(rec)-2)
+		for j, field := range rec[2:] {
+			if field == "" {
+				continue
+			}
+			if nums[j], err = strconv.ParseFloat(field, 64); err != nil {
+				panic(fmt.Sprintf("parse foods table row %d: %v", i+2, err))
+			}
+		}
+		f := Food{
+			Name:       rec[0],
+			Per100g:    model.Nutrients{Calories: nums[0], Protein: nums[1], Fat: nums[2], Carbs: nums[3]},
+			GramsPerML: nums[4],
+			GramsEach:  nums[5],
+		}
+		if rec[1] != "" {
+			f.Aliases = strings.Split(rec[1], ";")
+		}
+		out = append(out, f)
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package nutrition
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minSimilarity is the least similarity, from 0 to 1, at which a misspelt
+// name such as "parmesean" still matches a food.
+const minSimilarity = 0.8
+
+// term is a normalized food name or alias.
+type term struct {
+	words []string
+	text  string
+	food  *Food
+}
+
+var terms = buildTerms(foods)
+
+func buildTerms(table []Food) []term {
+	var out []term
+	for i := range table {
+		for _, name := range append([]string{table[i].Name}, table[i].Aliases...) {
+			words := normalize(name)
+			out = append(out, term{words: words, text: strings.Join(words, " "), food: &table[i]})
+		}
+	}
+	return out
+}
+
+// Match finds the reference food for an ingredient name. A name matches a
+// food when it contains the food's name or one of its aliases as whole
+// words, ignoring case, punctuation, and plurals, so "finely chopped red
+// onions" matches onion. The longest matching name wins, so "peanut butter"
+// is not butter. Failing that, a name within a small edit distance of a
+// food name matches, which catches common misspellings.
+func Match(name string) (*Food, bool) {
+	words := normalize(name)
+	if len(words) == 0 {
+		return nil, false
+	}
+
+	var best *term
+	for i := range terms {
+		t := &terms[i]
+		if containsRun(words, t.words) && (best == nil || longer(t, best)) {
+			best = t
+		}
+	}
+	if best != nil {
+		return best.food, true
+	}
+
+	bestSim := 0.0
+	for i := range terms {
+		t := &terms[i]
+		if len(t.words) > len(words) || len(t.text) < 4 {
+			continue
+		}
+		for j := 0; j+len(t.words) <= len(words); j++ {
+			sim := similarity(strings.Join(words[j:j+len(t.words)], " "), t.text)
+			if sim >= minSimilarity && (sim > bestSim || (sim == bestSim && longer(t, best))) {
+				best, bestSim = t, sim
+			}
+		}
+	}
+	if best != nil {
+		return best.food, true
+	}
+	return nil, false
+}
+
+// longer reports whether term a is more specific than term b: it has more
+// words, or as many words and more letters.
+func longer(a, b *term) bool {
+	if len(a.words) != len(b.words) {
+		return len(a.words) > len(b.words)
+	}
+	return len(a.text) > len(b.text)
+}
+
+// normalize splits a name into lower-case words of letters, reducing each
+// to its singular form.
+func normalize(name string) []string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool { return !unicode.IsLetter(r) })
+	for i, w := range words {
+		words[i] = singular(w)
+	}
+	return words
+}
+
+// singular strips common English plural endings. It only needs to map a
+// word and its plural to the same form, not to produce a real word.
+func singular(w string) string {
+	switch {
+	case len(w) <= 3 || strings.HasSuffix(w, "ss"):
+		return w
+	case strings.HasSuffix(w, "ies"):
+		return w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "oes"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// containsRun reports whether words contains run as consecutive words.
+func containsRun(words, run []string) bool {
+	for i := 0; i+len(run) <= len(words); i++ {
+		match := true
+		for j := range run {
+			if words[i+j] != run[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// similarity scores two strings from 0 to 1 by their Levenshtein distance
+// relative to the longer string.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range ra {
+		cur[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
@@ -12,12 +12,15 @@ import (
 
 // New creates a Gin engine with middleware and all API routes registered.
 // The store parameter allows the router to work with any implementation
-// of the Store interface (e.g., the Amazon Aurora DSQL store).
-func New(s store.Store) *gin.Engine {
+// of the Store interface (e.g., the Amazon Aurora DSQL store), and blobs
+// holds uploaded recipe images.
+func New(s store.Store, blobs store.BlobStore) *gin.Engine {
 	r := gin.New()
 	r.Use(gin.Recovery())
+	r.Use(middleware.Tracing())
 	r.Use(middleware.RequestLogger())
 	r.Use(middleware.CORS())
+	r.Use(middleware.Actor())
 
 	// Health check endpoint for Amazon API Gateway or load balancer probes.
 	r.GET("/health", handler.Health)
@@ -31,17 +34,77 @@ func New(s store.Store) *gin.Engine {
 	v1.GET("/chefs/:id", chefH.Get)
 	v1.PUT("/chefs/:id", chefH.Update)
 	v1.DELETE("/chefs/:id", chefH.Delete)
+	v1.POST("/chefs/:id/restore", chefH.Restore)
 
 	recipeH := &handler.RecipeHandler{Store: s}
 	v1.GET("/recipes", recipeH.List)
+	v1.GET("/recipes/search", recipeH.Search)
 	v1.POST("/recipes", recipeH.Create)
+	v1.POST("/recipes/import", recipeH.ImportDocument)
 	v1.GET("/recipes/:id", recipeH.Get)
 	v1.PUT("/recipes/:id", recipeH.Update)
 	v1.DELETE("/recipes/:id", recipeH.Delete)
+	v1.POST("/recipes/:id/restore", recipeH.Restore)
+	v1.POST("/recipes/:id/publish", recipeH.Publish)
+	v1.POST("/recipes/:id/unpublish", recipeH.Unpublish)
+	v1.POST("/recipes/:id/archive", recipeH.Archive)
+	v1.GET("/recipes/:id/revisions", recipeH.Revisions)
+	v1.GET("/recipes/:id/revisions/:n", recipeH.Revision)
+	v1.GET("/recipes/:id/revisions/:n/diff", recipeH.Diff)
+	v1.POST("/recipes/:id/revisions/:n/revert", recipeH.Revert)
+	v1.POST("/recipes/:id/fork", recipeH.Fork)
+	v1.GET("/recipes/:id/lineage", recipeH.Lineage)
 
 	ratingH := &handler.RatingHandler{Store: s}
 	v1.GET("/recipes/:id/ratings", ratingH.List)
 	v1.POST("/recipes/:id/ratings", ratingH.Create)
+	v1.PUT("/recipes/:id/ratings/:ratingId", ratingH.Update)
+	v1.DELETE("/recipes/:id/ratings/:ratingId", ratingH.Delete)
+	v1.POST("/recipes/:id/ratings/:ratingId/restore", ratingH.Restore)
+
+	imageH := &handler.ImageHandler{Store: s, Blobs: blobs}
+	v1.GET("/recipes/:id/images", imageH.List)
+	v1.POST("/recipes/:id/images", imageH.Upload)
+	v1.GET("/recipes/:id/images/:imageId", imageH.Get)
+	v1.GET("/recipes/:id/images/:imageId/thumbnail", imageH.Thumbnail)
+
+	nutritionH := &handler.NutritionHandler{Store: s}
+	v1.GET("/recipes/:id/nutrition", nutritionH.Get)
+
+	tagH := &handler.TagHandler{Store: s}
+	v1.GET("/tags", tagH.List)
+	v1.POST("/recipes/:id/tags", tagH.Add)
+	v1.DELETE("/recipes/:id/tags/:slug", tagH.Remove)
+
+	collectionH := &handler.CollectionHandler{Store: s}
+	v1.GET("/chefs/:id/collections", collectionH.List)
+	v1.POST("/collections", collectionH.Create)
+	v1.GET("/collections/:id", collectionH.Get)
+	v1.PUT("/collections/:id", collectionH.Update)
+	v1.DELETE("/collections/:id", collectionH.Delete)
+	v1.POST("/collections/:id/items", collectionH.AddItem)
+	v1.PUT("/collections/:id/items/:recipeId", collectionH.MoveItem)
+	v1.DELETE("/collections/:id/items/:recipeId", collectionH.RemoveItem)
+
+	importH := &handler.ImportHandler{Store: s}
+	v1.POST("/import", importH.Import)
+	v1.GET("/export", importH.Export)
+
+	followH := &handler.FollowHandler{Store: s}
+	v1.POST("/chefs/:id/follow", followH.Follow)
+	v1.DELETE("/chefs/:id/follow", followH.Unfollow)
+	v1.GET("/chefs/:id/followers", followH.Followers)
+	v1.GET("/chefs/:id/following", followH.Following)
+	v1.GET("/feed", followH.Feed)
+
+	auditH := &handler.AuditHandler{Store: s}
+	v1.GET("/audit", auditH.List)
+
+	// Cache statistics are served only when the store is cached.
+	if cached, ok := store.As[*store.CachingStore](s); ok {
+		cacheH := &handler.CacheHandler{Store: cached}
+		v1.GET("/cache/stats", cacheH.Stats)
+	}
 
 	return r
 }
@@ -0,0 +1,69 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package schemaorg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FormatDuration renders a number of minutes as an ISO 8601 duration such
+// as "PT1H30M". Zero renders as "PT0M".
+func FormatDuration(minutes int) string {
+	h, m := minutes/60, minutes%60
+	switch {
+	case h > 0 && m > 0:
+		return fmt.Sprintf("PT%dH%dM", h, m)
+	case h > 0:
+		return fmt.Sprintf("PT%dH", h)
+	default:
+		return fmt.Sprintf("PT%dM", m)
+	}
+}
+
+// ParseDuration parses an ISO 8601 duration into whole minutes, rounding
+// seconds to the nearest minute. It accepts the day and time components
+// recipes use, such as "PT45M", "PT1H30M", and "P1DT2H"; years, months,
+// and weeks are rejected because their length in minutes is ambiguous.
+func ParseDuration(s string) (int, error) {
+	rest, ok := strings.CutPrefix(strings.ToUpper(strings.TrimSpace(s)), "P")
+	if !ok || rest == "" {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+	}
+	var total float64
+	inTime, parts := false, 0
+	for rest != "" {
+		if rest[0] == 'T' && !inTime {
+			inTime, rest, parts = true, rest[1:], 0
+			continue
+		}
+		i := strings.IndexFunc(rest, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+		}
+		n, err := strconv.ParseFloat(rest[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+		}
+		switch unit := rest[i]; {
+		case unit == 'D' && !inTime:
+			total += n * 24 * 60
+		case unit == 'H' && inTime:
+			total += n * 60
+		case unit == 'M' && inTime:
+			total += n
+		case unit == 'S' && inTime:
+			total += n / 60
+		default:
+			return 0, fmt.Errorf("unsupported ISO 8601 duration %q", s)
+		}
+		rest, parts = rest[i+1:], parts+1
+	}
+	if parts == 0 {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+	}
+	return int(math.Round(total)), nil
+}
@@ -0,0 +1,281 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package schemaorg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrNoRecipe is returned by Extract when a document holds no schema.org
+// Recipe.
+var ErrNoRecipe = errors.New("document contains no schema.org Recipe")
+
+var (
+	// tagPattern matches markup that some sites leave in JSON-LD text.
+	tagPattern = regexp.MustCompile(`<[^>]*>`)
+	// yieldPattern matches the first whole number of a recipe yield such as
+	// "Serves 4" or "4-6 portions".
+	yieldPattern = regexp.MustCompile(`\d+`)
+)
+
+// Extract finds the first schema.org Recipe in a document and maps it to
+// the input for a new draft recipe. The document is either a JSON-LD blob
+// or an HTML page carrying JSON-LD in <script type="application/ld+json">
+// elements; Recipe objects nested in arrays, @graph, or other objects are
+// found too. The ChefID of the result is left for the caller to set.
+func Extract(doc []byte) (model.CreateRecipeInput, error) {
+	trimmed := bytes.TrimSpace(doc)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		var v any
+		if err := json.Unmarshal(trimmed, &v); err != nil {
+			return model.CreateRecipeInput{}, fmt.Errorf("invalid JSON-LD: %w", err)
+		}
+		if obj := findRecipe(v); obj != nil {
+			return toInput(obj)
+		}
+		return model.CreateRecipeInput{}, ErrNoRecipe
+	}
+
+	for _, block := range scripts(doc) {
+		var v any
+		// Pages often carry several blocks, so one that is malformed or
+		// describes something other than a recipe is skipped.
+		if json.Unmarshal([]byte(block), &v) != nil {
+			continue
+		}
+		if obj := findRecipe(v); obj != nil {
+			return toInput(obj)
+		}
+	}
+	return model.CreateRecipeInput{}, ErrNoRecipe
+}
+
+// scripts returns the contents of the JSON-LD script elements of an HTML
+// document, in document order.
+func scripts(doc []byte) []string {
+	var blocks []string
+	z := xhtml.NewTokenizer(bytes.NewReader(doc))
+	for {
+		switch z.Next() {
+		case xhtml.ErrorToken:
+			return blocks
+		case xhtml.StartTagToken:
+			name, hasAttr := z.TagName()
+			if atom.Lookup(name) != atom.Script || !hasAttr || !isJSONLDScript(z) {
+				continue
+			}
+			if z.Next() == xhtml.TextToken {
+				blocks = append(blocks, string(z.Text()))
+			}
+		}
+	}
+}
+
+// isJSONLDScript reports whether the attributes of the current script tag
+// declare JSON-LD content.
+func isJSONLDScript(z *xhtml.Tokenizer) bool {
+	for {
+		key, val, more := z.TagAttr()
+		if string(key) == "type" {
+			mediaType, _, _ := strings.Cut(string(val), ";")
+			return strings.EqualFold(strings.TrimSpace(mediaType), MediaType)
+		}
+		if !more {
+			return false
+		}
+	}
+}
+
+// findRecipe searches a decoded JSON-LD value depth first for an object
+// whose @type is or includes Recipe.
+func findRecipe(v any) map[string]any {
+	switch v := v.(type) {
+	case map[string]any:
+		if isRecipe(v["@type"]) {
+			return v
+		}
+		if obj := findRecipe(v["@graph"]); obj != nil {
+			return obj
+		}
+		// Keys are visited in order so that the result is deterministic.
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			if key == "@graph" {
+				continue
+			}
+			if obj := findRecipe(v[key]); obj != nil {
+				return obj
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if obj := findRecipe(child); obj != nil {
+				return obj
+			}
+		}
+	}
+	return nil
+}
+
+// isRecipe reports whether an @type value names the schema.org Recipe
+// type, in compact or full IRI form.
+func isRecipe(t any) bool {
+	switch t := t.(type) {
+	case string:
+		t = strings.TrimPrefix(t, "schema:")
+		t = strings.TrimPrefix(strings.TrimPrefix(t, "https://schema.org/"), "http://schema.org/")
+		return t == "Recipe"
+	case []any:
+		for _, item := range t {
+			if isRecipe(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toInput maps a schema.org Recipe object to the input for a draft recipe.
+func toInput(obj map[string]any) (model.CreateRecipeInput, error) {
+	input := model.CreateRecipeInput{
+		Title:        text(obj["name"]),
+		Description:  text(obj["description"]),
+		Ingredients:  strings.Join(texts(firstOf(obj, "recipeIngredient", "ingredients")), "\n"),
+		Instructions: strings.Join(instructions(obj["recipeInstructions"]), "\n"),
+		Cuisine:      first(texts(obj["recipeCuisine"])),
+		Servings:     servings(obj["recipeYield"]),
+		Status:       model.StatusDraft,
+	}
+	var err error
+	if input.PrepTime, err = duration(obj["prepTime"]); err != nil {
+		return model.CreateRecipeInput{}, fmt.Errorf("prepTime: %w", err)
+	}
+	if input.CookTime, err = duration(obj["cookTime"]); err != nil {
+		return model.CreateRecipeInput{}, fmt.Errorf("cookTime: %w", err)
+	}
+	// A recipe that gives only a total time is treated as all cooking.
+	if input.PrepTime == 0 && input.CookTime == 0 {
+		if input.CookTime, err = duration(obj["totalTime"]); err != nil {
+			return model.CreateRecipeInput{}, fmt.Errorf("totalTime: %w", err)
+		}
+	}
+	return input, nil
+}
+
+func firstOf(obj map[string]any, keys ...string) any {
+	for _, key := range keys {
+		if v, ok := obj[key]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func first(items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+	return items[0]
+}
+
+// text returns a JSON-LD text value with HTML entities decoded, markup
+// removed, and whitespace collapsed. Non-string values yield "".
+func text(v any) string {
+	s, ok := v.(string)
+	if !ok {
+		return ""
+	}
+	s = html.UnescapeString(tagPattern.ReplaceAllString(s, " "))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// texts returns the non-empty text values of a value that is either a
+// single text or an array of them.
+func texts(v any) []string {
+	items, ok := v.([]any)
+	if !ok {
+		items = []any{v}
+	}
+	var out []string
+	for _, item := range items {
+		if s := text(item); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// instructions flattens recipeInstructions into one line per step. It
+// accepts a single text, which may hold several lines, an array of texts,
+// HowToStep objects, and HowToSection objects grouping further steps.
+func instructions(v any) []string {
+	switch v := v.(type) {
+	case string:
+		var out []string
+		for _, line := range lines(v) {
+			if s := text(line); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	case []any:
+		var out []string
+		for _, item := range v {
+			out = append(out, instructions(item)...)
+		}
+		return out
+	case map[string]any:
+		if items, ok := v["itemListElement"]; ok {
+			return instructions(items)
+		}
+		if s := text(v["text"]); s != "" {
+			return []string{s}
+		}
+		return texts(v["name"])
+	}
+	return nil
+}
+
+// servings reads the number of servings from a recipeYield, which may be
+// a number, a text such as "Serves 4", or an array of either.
+func servings(v any) int {
+	switch v := v.(type) {
+	case float64:
+		if v >= 1 {
+			return int(v)
+		}
+	case string:
+		if n, err := strconv.Atoi(yieldPattern.FindString(v)); err == nil {
+			return n
+		}
+	case []any:
+		for _, item := range v {
+			if n := servings(item); n > 0 {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// duration reads an optional ISO 8601 duration in minutes.
+func duration(v any) (int, error) {
+	s := text(v)
+	if s == "" {
+		return 0, nil
+	}
+	return ParseDuration(s)
+}
@@ -0,0 +1,136 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package schemaorg maps recipes to and from the schema.org Recipe type in
+// JSON-LD, the structured data format search engines and recipe sites use.
+package schemaorg
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/ingredients"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+)
+
+// MediaType is the media type of JSON-LD documents.
+const MediaType = "application/ld+json"
+
+// Recipe is a schema.org Recipe as emitted in JSON-LD.
+type Recipe struct {
+	Context            string           `json:"@context"`
+	Type               string           `json:"@type"`
+	Identifier         string           `json:"identifier"`
+	Name               string           `json:"name"`
+	Description        string           `json:"description,omitempty"`
+	Author             *Person          `json:"author,omitempty"`
+	DateCreated        string           `json:"dateCreated"`
+	DateModified       string           `json:"dateModified"`
+	DatePublished      string           `json:"datePublished,omitempty"`
+	PrepTime           string           `json:"prepTime,omitempty"`
+	CookTime           string           `json:"cookTime,omitempty"`
+	TotalTime          string           `json:"totalTime,omitempty"`
+	RecipeYield        string           `json:"recipeYield,omitempty"`
+	RecipeCuisine      string           `json:"recipeCuisine,omitempty"`
+	Keywords           string           `json:"keywords,omitempty"`
+	RecipeIngredient   []string         `json:"recipeIngredient"`
+	RecipeInstructions []HowToStep      `json:"recipeInstructions"`
+	AggregateRating    *AggregateRating `json:"aggregateRating,omitempty"`
+}
+
+// Person is a schema.org Person, used for the recipe author.
+type Person struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// HowToStep is one schema.org HowToStep of the recipe instructions.
+type HowToStep struct {
+	Type string `json:"@type"`
+	Text string `json:"text"`
+}
+
+// AggregateRating is a schema.org AggregateRating summarizing the ratings
+// of a recipe.
+type AggregateRating struct {
+	Type        string  `json:"@type"`
+	RatingValue float64 `json:"ratingValue"`
+	RatingCount int     `json:"ratingCount"`
+	BestRating  int     `json:"bestRating"`
+	WorstRating int     `json:"worstRating"`
+}
+
+// FromRecipe maps a recipe to a schema.org Recipe. Prep and cook times
+// become ISO 8601 durations, servings the yield, and each ingredient line
+// and instruction line an entry of its own. The author is omitted when
+// authorName is empty, and the rating when the recipe has none.
+func FromRecipe(r model.Recipe, authorName string) Recipe {
+	out := Recipe{
+		Context:            "https://schema.org",
+		Type:               "Recipe",
+		Identifier:         r.ID,
+		Name:               r.Title,
+		Description:        r.Description,
+		DateCreated:        r.CreatedAt.UTC().Format(time.RFC3339),
+		DateModified:       r.UpdatedAt.UTC().Format(time.RFC3339),
+		RecipeCuisine:      r.Cuisine,
+		Keywords:           strings.Join(r.Tags, ","),
+		RecipeIngredient:   []string{},
+		RecipeInstructions: []HowToStep{},
+	}
+	if r.PublishedAt != nil {
+		out.DatePublished = r.PublishedAt.UTC().Format(time.RFC3339)
+	}
+	if authorName != "" {
+		out.Author = &Person{Type: "Person", Name: authorName}
+	}
+	if r.PrepTime > 0 {
+		out.PrepTime = FormatDuration(r.PrepTime)
+	}
+	if r.CookTime > 0 {
+		out.CookTime = FormatDuration(r.CookTime)
+	}
+	if r.PrepTime > 0 || r.CookTime > 0 {
+		out.TotalTime = FormatDuration(r.PrepTime + r.CookTime)
+	}
+	if r.Servings > 0 {
+		out.RecipeYield = strconv.Itoa(r.Servings) + " servings"
+		if r.Servings == 1 {
+			out.RecipeYield = "1 serving"
+		}
+	}
+
+	if len(r.IngredientList) > 0 {
+		for _, ing := range r.IngredientList {
+			out.RecipeIngredient = append(out.RecipeIngredient, ingredients.FormatLine(ing))
+		}
+	} else {
+		out.RecipeIngredient = append(out.RecipeIngredient, lines(r.Ingredients)...)
+	}
+	for _, step := range lines(r.Instructions) {
+		out.RecipeInstructions = append(out.RecipeInstructions, HowToStep{Type: "HowToStep", Text: step})
+	}
+
+	if r.RatingSummary != nil && r.RatingCount > 0 {
+		out.AggregateRating = &AggregateRating{
+			Type:        "AggregateRating",
+			RatingValue: r.AverageScore,
+			RatingCount: r.RatingCount,
+			BestRating:  model.MaxScore,
+			WorstRating: model.MinScore,
+		}
+	}
+	return out
+}
+
+// lines splits text into its trimmed, non-blank lines.
+func lines(text string) []string {
+	var out []string
+	for line := range strings.Lines(text) {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
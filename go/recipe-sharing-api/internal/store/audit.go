@@ -0,0 +1,208 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// AnonymousActor is recorded on audit events when the context carries no actor.
+const AnonymousActor = "anonymous"
+
+type actorKey struct{}
+
+// WithActor returns a context whose mutations are attributed to actor in
+// the audit log.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// actorFrom returns the actor set by WithActor, or AnonymousActor.
+func actorFrom(ctx context.Context) string {
+	if v, _ := ctx.Value(actorKey{}).(string); v != "" {
+		return v
+	}
+	return AnonymousActor
+}
+
+// newAuditEvent builds an audit event for a change made under ctx. A nil
+// before or after is recorded as JSON null.
+func newAuditEvent[T any](ctx context.Context, entityType, entityID, action string, before, after *T, at time.Time) model.AuditEvent {
+	return model.AuditEvent{
+		ID:         uuid.New().String(),
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Actor:      actorFrom(ctx),
+		Before:     snapshot(before),
+		After:      snapshot(after),
+		CreatedAt:  at,
+	}
+}
+
+// snapshot returns the JSON form of v, or nil when v is nil. The model types
+// always marshal successfully.
+func snapshot[T any](v *T) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	b, _ := json.Marshal(v)
+	return b
+}
+
+// insertAuditEvents writes audit events in a single multi-row INSERT. It is
+// called with the transaction that makes the change, so an event is recorded
+// if and only if the change commits.
+func insertAuditEvents(ctx context.Context, q querier, events ...model.AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	values := make([]string, len(events))
+	args := make([]any, 0, len(events)*8)
+	for i, e := range events {
+		n := i * 8
+		values[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8)
+		args = append(args, e.ID, e.EntityType, e.EntityID, e.Action, e.Actor,
+			nullableJSON(e.Before), nullableJSON(e.After), e.CreatedAt)
+	}
+	_, err := q.Exec(ctx,
+		fmt.Sprintf(`INSERT INTO %s.audit_events (id, entity_type, entity_id, action, actor,
+		                      before_json, after_json, created_at)
+		 VALUES `, schemaName)+strings.Join(values, ", "), args...)
+	if err != nil {
+		return fmt.Errorf("insert audit events: %w", err)
+	}
+	return nil
+}
+
+// nullableJSON converts a snapshot to a TEXT parameter, mapping nil to NULL.
+func nullableJSON(raw json.RawMessage) *string {
+	if raw == nil {
+		return nil
+	}
+	s := string(raw)
+	return &s
+}
+
+// ListAuditEvents returns one page of audit events for an entity from
+// Amazon Aurora DSQL, newest first.
+func (s *DSQLStore) ListAuditEvents(ctx context.Context, entityID string, page model.PageRequest) ([]model.AuditEvent, string, error) {
+	where, suffix, pageArgs := keysetClause(page, 2)
+	rows, err := s.db.Query(ctx,
+		fmt.Sprintf(`SELECT id, entity_type, entity_id, action, actor, before_json, after_json, created_at
+		 FROM %s.audit_events WHERE entity_id = $1`, schemaName)+where+suffix,
+		append([]any{entityID}, pageArgs...)...)
+	if err != nil {
+		return nil, "", fmt.Errorf("list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []model.AuditEvent
+	for rows.Next() {
+		var e model.AuditEvent
+		var before, after *string
+		if err := rows.Scan(&e.ID, &e.EntityType, &e.EntityID, &e.Action, &e.Actor,
+			&before, &after, &e.CreatedAt); err != nil {
+			return nil, "", fmt.Errorf("scan audit event: %w", err)
+		}
+		if before != nil {
+			e.Before = json.RawMessage(*before)
+		}
+		if after != nil {
+			e.After = json.RawMessage(*after)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("list audit events: %w", err)
+	}
+	events, next := trimPage(events, page.EffectiveLimit(), auditCursor)
+	return events, next, nil
+}
+
+func auditCursor(e model.AuditEvent) model.Cursor {
+	return model.Cursor{CreatedAt: e.CreatedAt, ID: e.ID}
+}
+
+// auditTable describes how the batched cascade and purge operations read
+// rows of one table back for the audit log.
+type auditTable[T any] struct {
+	name    string
+	entity  string
+	columns string
+	scan    func(pgx.Row, *T) error
+	// key returns the row's ID and a pointer to its DeletedAt field.
+	key func(*T) (string, **time.Time)
+	// load, if set, fills in data stored outside the table. The batched
+	// operations skip it to keep their transactions small.
+	load func(context.Context, querier, *T) error
+	// onDelete and onRestore, if set, are extra assignments made alongside
+	// deleted_at when a row is soft-deleted or restored.
+	onDelete, onRestore string
+}
+
+var (
+	chefTable = auditTable[model.Chef]{
+		name: "chefs", entity: model.EntityChef, columns: chefColumns,
+		scan: func(row pgx.Row, c *model.Chef) error { return scanChef(row, c) },
+		key:  func(c *model.Chef) (string, **time.Time) { return c.ID, &c.DeletedAt },
+	}
+	recipeTable = auditTable[model.Recipe]{
+		name: "recipes", entity: model.EntityRecipe, columns: recipeColumns,
+		scan: func(row pgx.Row, r *model.Recipe) error { return scanRecipe(row, r) },
+		key:  func(r *model.Recipe) (string, **time.Time) { return r.ID, &r.DeletedAt },
+		load: func(ctx context.Context, q querier, r *model.Recipe) error {
+			var err error
+			r.IngredientList, err = listIngredients(ctx, q, r.ID)
+			return err
+		},
+	}
+	ratingTable = auditTable[model.Rating]{
+		name: "ratings", entity: model.EntityRating, columns: ratingColumns,
+		scan: scanRating,
+		key:  func(r *model.Rating) (string, **time.Time) { return r.ID, &r.DeletedAt },
+		// A rating holds its chef's slot in the unique index only while live.
+		onDelete:  "live_chef_id = NULL",
+		onRestore: "live_chef_id = chef_id",
+	}
+)
+
+// softDeleteSet returns the SET list that moves a row of t to deletedAt,
+// where deletedAt is the SQL expression for the new value and restore reports
+// whether that value is NULL.
+func (t auditTable[T]) softDeleteSet(deletedAt string, restore bool) string {
+	extra := t.onDelete
+	if restore {
+		extra = t.onRestore
+	}
+	if extra == "" {
+		return "deleted_at = " + deletedAt
+	}
+	return "deleted_at = " + deletedAt + ", " + extra
+}
+
+// collectReturning scans the rows returned by an UPDATE or DELETE with a
+// RETURNING clause of t.columns.
+func collectReturning[T any](rows pgx.Rows, t auditTable[T]) ([]T, error) {
+	defer rows.Close()
+
+	var out []T
+	for rows.Next() {
+		var v T
+		if err := t.scan(rows, &v); err != nil {
+			return nil, fmt.Errorf("scan %s: %w", t.name, err)
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,116 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrBlobNotFound is returned by BlobStore.Open when no blob has the key.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore holds binary objects such as recipe images by key. Keys are
+// slash-separated relative paths like "recipes/<id>/images/<id>", which map
+// directly onto file paths or Amazon S3 object keys, so a bucket-backed
+// implementation can replace FSBlobStore without changing callers. The
+// blobs of a deleted recipe stay in place until the recipe is purged.
+type BlobStore interface {
+	// Put stores the contents of r under key, replacing any existing blob.
+	// The content type is recorded by backends that keep object metadata.
+	Put(ctx context.Context, key, contentType string, r io.Reader) error
+	// Open returns a reader for the blob stored under key, or an error
+	// wrapping ErrBlobNotFound. The caller must close it.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key. Deleting a missing blob is
+	// not an error, so interrupted cleanups can be repeated.
+	Delete(ctx context.Context, key string) error
+}
+
+// FSBlobStore is a BlobStore that keeps each blob as a file under a root
+// directory. It suits local development and single-host deployments; on
+// AWS Lambda the filesystem is ephemeral, so production deployments should
+// use an Amazon S3 backed implementation.
+type FSBlobStore struct {
+	root string
+}
+
+// NewFSBlobStore creates a filesystem blob store rooted at dir, creating
+// the directory if needed.
+func NewFSBlobStore(dir string) (*FSBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create blob directory: %w", err)
+	}
+	return &FSBlobStore{root: dir}, nil
+}
+
+// path maps a key to a file path under the root, rejecting keys that would
+// escape it.
+func (b *FSBlobStore) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(b.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes the blob to a temporary file and renames it into place, so
+// readers never see a partially written blob.
+func (b *FSBlobStore) Put(ctx context.Context, key, contentType string, r io.Reader) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create blob directory: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("create blob: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("write blob: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write blob: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("store blob: %w", err)
+	}
+	return nil
+}
+
+// Open opens the file holding the blob.
+func (b *FSBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("open blob %q: %w", key, ErrBlobNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open blob: %w", err)
+	}
+	return f, nil
+}
+
+// Delete removes the file holding the blob.
+func (b *FSBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("delete blob: %w", err)
+	}
+	return nil
+}
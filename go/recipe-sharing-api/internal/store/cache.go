@@ -0,0 +1,121 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache holds encoded values by key, each with its own time to live. It is
+// the backend of CachingStore. LRUCache keeps values in process; a shared
+// backend such as Redis or Amazon ElastiCache can implement the same three
+// operations, so that every instance of the API sees the same entries and
+// invalidations. A failed operation is reported as an error, and
+// CachingStore then falls back to the database.
+type Cache interface {
+	// Get returns the value stored under key and true, or false if there is
+	// none or it has expired. The caller must not modify the value.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key, replacing any existing value. A ttl of
+	// zero means the value does not expire, though it may still be evicted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the values stored under the keys. Deleting a missing
+	// key is not an error.
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// LRUCache is an in-process Cache that holds at most a fixed number of
+// entries, evicting the least recently used when full. An expired entry is
+// dropped when it is next read, if it has not been evicted by then. It is
+// safe for concurrent use.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // of *lruEntry, most recently used first
+	entries  map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time // zero if the entry does not expire
+}
+
+// NewLRUCache creates an in-process cache that holds at most capacity
+// entries. A capacity below one is treated as one.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: max(capacity, 1),
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value stored under key and marks it recently used.
+func (c *LRUCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*lruEntry)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		c.remove(el)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(el)
+	return e.value, true, nil
+}
+
+// Set stores value under key as the most recently used entry, evicting
+// entries from the end of the list while the cache is over capacity.
+func (c *LRUCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+// Delete removes the entries stored under the keys.
+func (c *LRUCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if el, ok := c.entries[key]; ok {
+			c.remove(el)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries in the cache, including expired
+// entries that have not been dropped yet.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// remove drops an entry. The caller holds c.mu.
+func (c *LRUCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*lruEntry).key)
+}
@@ -0,0 +1,429 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+)
+
+// Cache scopes. Every cached read belongs to scopeAll and to one or more
+// narrower scopes, and every mutation invalidates the scopes whose reads it
+// can change. Reads of a single entity belong to that entity's scope, such
+// as "recipe:<id>"; lists of chefs belong to scopeChefs and lists drawn
+// from many recipes, such as searches, tags, and a chef's recipes, to
+// scopeRecipes.
+const (
+	scopeAll     = "*"
+	scopeChefs   = "chefs"
+	scopeRecipes = "recipes"
+)
+
+func chefScope(id string) string   { return "chef:" + id }
+func recipeScope(id string) string { return "recipe:" + id }
+
+// CachingStore is a Store that serves gets and lists from a Cache and
+// passes everything else to the Store it wraps. Values are cached as JSON
+// for the configured TTL, under keys that include a generation for each of
+// their scopes. A mutation invalidates its scopes by deleting their
+// generations, so later reads look up new keys and the old entries age out
+// of the cache; a read that raced the mutation stores its result under the
+// old generation, where it is never found. Reads under IncludeDeleted are
+// not cached.
+//
+// Single ratings, feeds, followers, collections, lineage, images, and the
+// audit log are read through to the wrapped store, since they combine rows
+// that change independently of one another.
+//
+// With the in-process LRUCache, invalidations reach only the instance that
+// made the change, so other instances may serve stale reads for up to the
+// TTL. A shared Cache backend avoids this.
+type CachingStore struct {
+	Store
+	cache Cache
+	ttl   time.Duration
+
+	hits, misses, invalidations, errors atomic.Uint64
+}
+
+// NewCachingStore wraps a store with a read-through cache whose entries
+// expire after ttl.
+func NewCachingStore(s Store, cache Cache, ttl time.Duration) *CachingStore {
+	return &CachingStore{Store: s, cache: cache, ttl: ttl}
+}
+
+// Unwrap returns the store that s caches.
+func (s *CachingStore) Unwrap() Store { return s.Store }
+
+// Stats returns the number of cache hits, misses, invalidations, and
+// failed cache operations so far.
+func (s *CachingStore) Stats() model.CacheStats {
+	stats := model.CacheStats{
+		Hits:          s.hits.Load(),
+		Misses:        s.misses.Load(),
+		Invalidations: s.invalidations.Load(),
+		Errors:        s.errors.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// page is a cached page of a list.
+type page[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next"`
+}
+
+// cached returns the value cached under op and args in the given scopes,
+// calling load and caching its result on a miss. Errors from load are
+// returned and not cached; errors from the cache are counted and fall back
+// to load.
+func cached[T any](ctx context.Context, s *CachingStore, op string, scopes []string, args []any, load func() (T, error)) (T, error) {
+	if includeDeleted(ctx) {
+		return load()
+	}
+	key, err := s.key(ctx, op, scopes, args)
+	if err != nil {
+		s.errors.Add(1)
+		return load()
+	}
+	b, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		s.errors.Add(1)
+	}
+	if ok {
+		var v T
+		if err := json.Unmarshal(b, &v); err == nil {
+			s.hits.Add(1)
+			return v, nil
+		}
+		s.errors.Add(1)
+	}
+
+	s.misses.Add(1)
+	v, err := load()
+	if err != nil {
+		return v, err
+	}
+	if b, err := json.Marshal(v); err != nil || s.cache.Set(ctx, key, b, s.ttl) != nil {
+		s.errors.Add(1)
+	}
+	return v, nil
+}
+
+// cachedPage is cached for list operations that return a page and the
+// cursor of the next one.
+func cachedPage[T any](ctx context.Context, s *CachingStore, op string, scopes []string, args []any, load func() ([]T, string, error)) ([]T, string, error) {
+	p, err := cached(ctx, s, op, scopes, args, func() (page[T], error) {
+		items, next, err := load()
+		return page[T]{Items: items, Next: next}, err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return p.Items, p.Next, nil
+}
+
+// key builds the cache key of a read from its operation, the current
+// generations of scopeAll and its scopes, and its arguments. A scope with
+// no generation, because it was invalidated or evicted, is given a new
+// random one, so a generation is never reused.
+func (s *CachingStore) key(ctx context.Context, op string, scopes []string, args []any) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(op)
+	for _, scope := range append([]string{scopeAll}, scopes...) {
+		genKey := "gen:" + scope
+		gen, ok, err := s.cache.Get(ctx, genKey)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			gen = []byte(strconv.FormatUint(rand.Uint64(), 36))
+			if err := s.cache.Set(ctx, genKey, gen, 0); err != nil {
+				return "", err
+			}
+		}
+		sb.WriteByte('|')
+		sb.Write(gen)
+	}
+	b, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteByte('|')
+	sb.Write(b)
+	return sb.String(), nil
+}
+
+// invalidate deletes the generations of the given scopes. It is called
+// after the mutation, whether or not it succeeded, since a failed mutation
+// may have been partly applied. A failed invalidation leaves stale entries
+// until they expire, so it is logged as well as counted.
+func (s *CachingStore) invalidate(ctx context.Context, scopes ...string) {
+	keys := make([]string, len(scopes))
+	for i, scope := range scopes {
+		keys[i] = "gen:" + scope
+	}
+	s.invalidations.Add(1)
+	if err := s.cache.Delete(ctx, keys...); err != nil {
+		s.errors.Add(1)
+		log.Printf("ERROR failed to invalidate cache scopes %v: %v", scopes, err)
+	}
+}
+
+// Chef reads.
+
+func (s *CachingStore) ListChefs(ctx context.Context, p model.PageRequest) ([]model.Chef, string, error) {
+	return cachedPage(ctx, s, "ListChefs", []string{scopeChefs}, []any{p}, func() ([]model.Chef, string, error) {
+		return s.Store.ListChefs(ctx, p)
+	})
+}
+
+func (s *CachingStore) GetChef(ctx context.Context, id string) (*model.Chef, error) {
+	return cached(ctx, s, "GetChef", []string{chefScope(id)}, []any{id}, func() (*model.Chef, error) {
+		return s.Store.GetChef(ctx, id)
+	})
+}
+
+func (s *CachingStore) GetChefWithRecipes(ctx context.Context, id string) (*model.ChefWithRecipes, error) {
+	return cached(ctx, s, "GetChefWithRecipes", []string{chefScope(id), scopeRecipes}, []any{id}, func() (*model.ChefWithRecipes, error) {
+		return s.Store.GetChefWithRecipes(ctx, id)
+	})
+}
+
+// Recipe reads.
+
+func (s *CachingStore) ListRecipes(ctx context.Context, filter model.RecipeFilter, p model.PageRequest) ([]model.Recipe, string, error) {
+	return cachedPage(ctx, s, "ListRecipes", []string{scopeRecipes}, []any{filter, p}, func() ([]model.Recipe, string, error) {
+		return s.Store.ListRecipes(ctx, filter, p)
+	})
+}
+
+func (s *CachingStore) SearchRecipes(ctx context.Context, terms []string, p model.PageRequest) ([]model.Recipe, string, error) {
+	return cachedPage(ctx, s, "SearchRecipes", []string{scopeRecipes}, []any{terms, p}, func() ([]model.Recipe, string, error) {
+		return s.Store.SearchRecipes(ctx, terms, p)
+	})
+}
+
+func (s *CachingStore) GetRecipe(ctx context.Context, id string) (*model.Recipe, error) {
+	return cached(ctx, s, "GetRecipe", []string{recipeScope(id)}, []any{id}, func() (*model.Recipe, error) {
+		return s.Store.GetRecipe(ctx, id)
+	})
+}
+
+func (s *CachingStore) GetRecipeWithRatings(ctx context.Context, id string) (*model.RecipeWithRatings, error) {
+	return cached(ctx, s, "GetRecipeWithRatings", []string{recipeScope(id)}, []any{id}, func() (*model.RecipeWithRatings, error) {
+		return s.Store.GetRecipeWithRatings(ctx, id)
+	})
+}
+
+func (s *CachingStore) ListRecipeRevisions(ctx context.Context, recipeID string, p model.PageRequest) ([]model.RecipeRevision, string, error) {
+	return cachedPage(ctx, s, "ListRecipeRevisions", []string{recipeScope(recipeID)}, []any{recipeID, p}, func() ([]model.RecipeRevision, string, error) {
+		return s.Store.ListRecipeRevisions(ctx, recipeID, p)
+	})
+}
+
+func (s *CachingStore) GetRecipeRevision(ctx context.Context, recipeID string, revision int64) (*model.RecipeRevision, error) {
+	return cached(ctx, s, "GetRecipeRevision", []string{recipeScope(recipeID)}, []any{recipeID, revision}, func() (*model.RecipeRevision, error) {
+		return s.Store.GetRecipeRevision(ctx, recipeID, revision)
+	})
+}
+
+// Rating and tag reads. A single rating is read through, since whether it
+// is visible depends on its recipe and chef.
+
+func (s *CachingStore) ListRatings(ctx context.Context, recipeID string, p model.PageRequest) ([]model.Rating, string, error) {
+	return cachedPage(ctx, s, "ListRatings", []string{recipeScope(recipeID)}, []any{recipeID, p}, func() ([]model.Rating, string, error) {
+		return s.Store.ListRatings(ctx, recipeID, p)
+	})
+}
+
+func (s *CachingStore) ListTags(ctx context.Context, p model.PageRequest) ([]model.TagUsage, string, error) {
+	return cachedPage(ctx, s, "ListTags", []string{scopeRecipes}, []any{p}, func() ([]model.TagUsage, string, error) {
+		return s.Store.ListTags(ctx, p)
+	})
+}
+
+// Chef mutations. Deleting or restoring a chef cascades to their recipes
+// and ratings and to the favorites of other recipes, so it invalidates
+// everything.
+
+func (s *CachingStore) CreateChef(ctx context.Context, input model.CreateChefInput) (*model.Chef, error) {
+	defer s.invalidate(ctx, scopeChefs)
+	return s.Store.CreateChef(ctx, input)
+}
+
+func (s *CachingStore) UpdateChef(ctx context.Context, id string, input model.UpdateChefInput) (*model.Chef, error) {
+	defer s.invalidate(ctx, chefScope(id), scopeChefs)
+	return s.Store.UpdateChef(ctx, id, input)
+}
+
+func (s *CachingStore) DeleteChef(ctx context.Context, id string) (*model.ChefDeletion, error) {
+	defer s.invalidate(ctx, scopeAll)
+	return s.Store.DeleteChef(ctx, id)
+}
+
+func (s *CachingStore) RestoreChef(ctx context.Context, id string) (*model.Chef, error) {
+	defer s.invalidate(ctx, scopeAll)
+	return s.Store.RestoreChef(ctx, id)
+}
+
+// Recipe mutations. A change to a recipe also changes the lists it
+// appears in.
+
+func (s *CachingStore) CreateRecipe(ctx context.Context, input model.CreateRecipeInput) (*model.Recipe, error) {
+	defer s.invalidate(ctx, scopeRecipes)
+	return s.Store.CreateRecipe(ctx, input)
+}
+
+func (s *CachingStore) CreateRecipes(ctx context.Context, inputs []model.CreateRecipeInput) ([]model.Recipe, error) {
+	defer s.invalidate(ctx, scopeRecipes)
+	return s.Store.CreateRecipes(ctx, inputs)
+}
+
+func (s *CachingStore) UpdateRecipe(ctx context.Context, id string, input model.UpdateRecipeInput) (*model.Recipe, error) {
+	defer s.invalidate(ctx, recipeScope(id), scopeRecipes)
+	return s.Store.UpdateRecipe(ctx, id, input)
+}
+
+// DeleteRecipe reads the deleted recipe back to find the recipe it was
+// forked from, whose fork count changes.
+func (s *CachingStore) DeleteRecipe(ctx context.Context, id string) error {
+	err := s.Store.DeleteRecipe(ctx, id)
+	recipe, getErr := s.Store.GetRecipe(IncludeDeleted(ctx), id)
+	if getErr != nil {
+		s.invalidate(ctx, scopeAll)
+		return err
+	}
+	s.invalidateRecipe(ctx, id, recipe)
+	return err
+}
+
+func (s *CachingStore) RestoreRecipe(ctx context.Context, id string) (*model.Recipe, error) {
+	recipe, err := s.Store.RestoreRecipe(ctx, id)
+	s.invalidateRecipe(ctx, id, recipe)
+	return recipe, err
+}
+
+// invalidateRecipe invalidates a recipe that was deleted or restored, the
+// lists it appears in, and the recipe it was forked from, if any.
+func (s *CachingStore) invalidateRecipe(ctx context.Context, id string, recipe *model.Recipe) {
+	scopes := []string{recipeScope(id), scopeRecipes}
+	if recipe != nil && recipe.ForkedFromID != nil {
+		scopes = append(scopes, recipeScope(*recipe.ForkedFromID))
+	}
+	s.invalidate(ctx, scopes...)
+}
+
+// ForkRecipe also invalidates the original, whose fork count changes.
+func (s *CachingStore) ForkRecipe(ctx context.Context, id, chefID string) (*model.Recipe, error) {
+	defer s.invalidate(ctx, recipeScope(id), scopeRecipes)
+	return s.Store.ForkRecipe(ctx, id, chefID)
+}
+
+func (s *CachingStore) TransitionRecipe(ctx context.Context, id, transition string, publishAt *time.Time, ifVersion *int64) (*model.Recipe, error) {
+	defer s.invalidate(ctx, recipeScope(id), scopeRecipes)
+	return s.Store.TransitionRecipe(ctx, id, transition, publishAt, ifVersion)
+}
+
+// PublishDue invalidates everything if it published any recipe, since it
+// does not report which.
+func (s *CachingStore) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	n, err := s.Store.PublishDue(ctx, now)
+	if n > 0 {
+		s.invalidate(ctx, scopeAll)
+	}
+	return n, err
+}
+
+// Rating mutations. A rating changes the rating summary of its recipe,
+// which appears in recipe lists.
+
+func (s *CachingStore) CreateRating(ctx context.Context, recipeID string, input model.CreateRatingInput) (*model.Rating, error) {
+	defer s.invalidate(ctx, recipeScope(recipeID), scopeRecipes)
+	return s.Store.CreateRating(ctx, recipeID, input)
+}
+
+func (s *CachingStore) UpsertRating(ctx context.Context, recipeID string, input model.CreateRatingInput) (*model.Rating, bool, error) {
+	defer s.invalidate(ctx, recipeScope(recipeID), scopeRecipes)
+	return s.Store.UpsertRating(ctx, recipeID, input)
+}
+
+func (s *CachingStore) UpdateRating(ctx context.Context, id string, input model.UpdateRatingInput) (*model.Rating, error) {
+	rating, err := s.Store.UpdateRating(ctx, id, input)
+	s.invalidateRating(ctx, rating)
+	return rating, err
+}
+
+// DeleteRating reads the deleted rating back to find its recipe.
+func (s *CachingStore) DeleteRating(ctx context.Context, id string) error {
+	err := s.Store.DeleteRating(ctx, id)
+	rating, getErr := s.Store.GetRating(IncludeDeleted(ctx), id)
+	if getErr != nil {
+		s.invalidate(ctx, scopeAll)
+		return err
+	}
+	s.invalidateRating(ctx, rating)
+	return err
+}
+
+func (s *CachingStore) RestoreRating(ctx context.Context, id string) (*model.Rating, error) {
+	rating, err := s.Store.RestoreRating(ctx, id)
+	s.invalidateRating(ctx, rating)
+	return rating, err
+}
+
+// invalidateRating invalidates the recipe of a rating. A rating that was
+// not found changed nothing.
+func (s *CachingStore) invalidateRating(ctx context.Context, rating *model.Rating) {
+	if rating != nil {
+		s.invalidate(ctx, recipeScope(rating.RecipeID), scopeRecipes)
+	}
+}
+
+// Tag and collection mutations. Tags and favorite counts are part of a
+// recipe and its lists; collections themselves are not cached, so the
+// other collection mutations pass through.
+
+func (s *CachingStore) AddRecipeTags(ctx context.Context, recipeID string, names []string) ([]string, error) {
+	defer s.invalidate(ctx, recipeScope(recipeID), scopeRecipes)
+	return s.Store.AddRecipeTags(ctx, recipeID, names)
+}
+
+func (s *CachingStore) RemoveRecipeTag(ctx context.Context, recipeID, slug string) (bool, error) {
+	defer s.invalidate(ctx, recipeScope(recipeID), scopeRecipes)
+	return s.Store.RemoveRecipeTag(ctx, recipeID, slug)
+}
+
+func (s *CachingStore) AddCollectionItem(ctx context.Context, collectionID string, input model.AddCollectionItemInput) (*model.CollectionItem, error) {
+	defer s.invalidate(ctx, recipeScope(input.RecipeID), scopeRecipes)
+	return s.Store.AddCollectionItem(ctx, collectionID, input)
+}
+
+func (s *CachingStore) RemoveCollectionItem(ctx context.Context, collectionID, recipeID string) (bool, error) {
+	defer s.invalidate(ctx, recipeScope(recipeID), scopeRecipes)
+	return s.Store.RemoveCollectionItem(ctx, collectionID, recipeID)
+}
+
+// DeleteCollection invalidates everything, since it changes the favorite
+// counts of every recipe in the collection.
+func (s *CachingStore) DeleteCollection(ctx context.Context, id string) (bool, error) {
+	defer s.invalidate(ctx, scopeAll)
+	return s.Store.DeleteCollection(ctx, id)
+}
+
+func (s *CachingStore) PurgeDeleted(ctx context.Context, before time.Time) (*model.PurgeResult, error) {
+	defer s.invalidate(ctx, scopeAll)
+	return s.Store.PurgeDeleted(ctx, before)
+}
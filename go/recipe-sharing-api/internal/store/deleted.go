@@ -0,0 +1,38 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"time"
+)
+
+type includeDeletedKey struct{}
+
+// IncludeDeleted returns a context under which list and get operations also
+// return soft-deleted rows. Updates never apply to soft-deleted rows.
+func IncludeDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// includeDeleted reports whether ctx was marked by IncludeDeleted.
+func includeDeleted(ctx context.Context) bool {
+	v, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return v
+}
+
+// liveOnly returns the SQL predicate that hides soft-deleted rows, or an
+// empty string when ctx includes them.
+func liveOnly(ctx context.Context) string {
+	if includeDeleted(ctx) {
+		return ""
+	}
+	return " AND deleted_at IS NULL"
+}
+
+// visible reports whether a row with the given deleted_at is returned under
+// ctx. It mirrors liveOnly for the in-memory store.
+func visible(ctx context.Context, deletedAt *time.Time) bool {
+	return deletedAt == nil || includeDeleted(ctx)
+}
@@ -6,9 +6,13 @@ package store
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/aws-samples/recipe-share-dsql-go/internal/migrations"
 	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/pgerr"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/telemetry"
 	"github.com/awslabs/aurora-dsql-connectors/go/pgx/dsql"
 	"github.com/awslabs/aurora-dsql-connectors/go/pgx/occretry"
 	"github.com/google/uuid"
@@ -20,8 +24,9 @@ const schemaName = "recipe_share"
 
 // DSQLStore implements the Store interface using Amazon Aurora DSQL.
 type DSQLStore struct {
-	pool *pgxpool.Pool
-	db   occretry.DB
+	pool  *pgxpool.Pool
+	db    occretry.DB
+	blobs BlobStore
 }
 
 // NewDSQLStore creates a connection pool to Amazon Aurora DSQL using IAM
@@ -35,6 +40,7 @@ func NewDSQLStore(ctx context.Context, endpoint string) (*DSQLStore, error) {
 	poolCfg.MaxConns = 5
 	poolCfg.MinConns = 0                       // Connections are created on demand; BeforeConnect generates a fresh IAM token.
 	poolCfg.MaxConnLifetime = 50 * time.Minute // Amazon Aurora DSQL timeout is 60 min.
+	poolCfg.ConnConfig.Tracer = telemetry.QueryTracer{}
 
 	// Create a connection pool using the Aurora DSQL connector.
 	// The connector handles IAM token generation via BeforeConnect,
@@ -46,58 +52,26 @@ func NewDSQLStore(ctx context.Context, endpoint string) (*DSQLStore, error) {
 		return nil, fmt.Errorf("create Aurora DSQL connection pool: %w", err)
 	}
 
-	db := occretry.New(pool, occretry.DefaultConfig())
+	// OCC retries are recorded as events on the calling store method's span.
+	db := telemetry.NewRetryDB(pool, occretry.DefaultConfig())
 	return &DSQLStore{pool: pool, db: db}, nil
 }
 
-// InitSchema creates the recipe_share schema and tables if they do not exist.
-// Each DDL statement runs in its own transaction because Amazon Aurora DSQL
-// does not support DDL and DML in the same transaction.
-func (s *DSQLStore) InitSchema(ctx context.Context) error {
-	statements := []string{
-		fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", schemaName),
-		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.chefs (
-			id TEXT PRIMARY KEY,
-			name VARCHAR(100) NOT NULL,
-			email VARCHAR(255) NOT NULL,
-			specialty VARCHAR(100) DEFAULT '',
-			bio TEXT DEFAULT '',
-			created_at TIMESTAMPTZ NOT NULL,
-			updated_at TIMESTAMPTZ NOT NULL
-		)`, schemaName),
-		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.recipes (
-			id TEXT PRIMARY KEY,
-			chef_id TEXT NOT NULL,
-			title VARCHAR(200) NOT NULL,
-			description TEXT DEFAULT '',
-			ingredients TEXT NOT NULL,
-			instructions TEXT NOT NULL,
-			prep_time INTEGER DEFAULT 0,
-			cook_time INTEGER DEFAULT 0,
-			servings INTEGER DEFAULT 0,
-			difficulty VARCHAR(20) NOT NULL DEFAULT 'medium',
-			cuisine VARCHAR(50) DEFAULT '',
-			status VARCHAR(20) NOT NULL DEFAULT 'draft',
-			created_at TIMESTAMPTZ NOT NULL,
-			updated_at TIMESTAMPTZ NOT NULL
-		)`, schemaName),
-		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.ratings (
-			id TEXT PRIMARY KEY,
-			recipe_id TEXT NOT NULL,
-			chef_id TEXT NOT NULL,
-			score INTEGER NOT NULL,
-			comment TEXT DEFAULT '',
-			created_at TIMESTAMPTZ NOT NULL,
-			updated_at TIMESTAMPTZ NOT NULL
-		)`, schemaName),
-		fmt.Sprintf("CREATE INDEX ASYNC IF NOT EXISTS idx_recipes_chef_id ON %s.recipes(chef_id)", schemaName),
-		fmt.Sprintf("CREATE INDEX ASYNC IF NOT EXISTS idx_ratings_recipe_id ON %s.ratings(recipe_id)", schemaName),
-	}
-
-	for _, stmt := range statements {
-		if _, err := s.db.Exec(ctx, stmt); err != nil {
-			return fmt.Errorf("init schema: %w", err)
-		}
+// Migrator returns a schema migrator that shares the store's connection pool
+// and OCC retry settings.
+func (s *DSQLStore) Migrator() (*migrations.Migrator, error) {
+	return migrations.New(s.db)
+}
+
+// Migrate applies any pending schema migrations. It is safe to call from
+// several processes at once; see migrations.Migrator.
+func (s *DSQLStore) Migrate(ctx context.Context) error {
+	m, err := s.Migrator()
+	if err != nil {
+		return err
+	}
+	if _, err := m.Up(ctx); err != nil {
+		return fmt.Errorf("migrate schema: %w", err)
 	}
 	return nil
 }
@@ -108,38 +82,88 @@ func (s *DSQLStore) Close() error {
 	return nil
 }
 
+// Columns read by scanChef, scanRecipe, and scanRating. Rows written before
+// the version column was added read as version 1.
+const (
+	chefColumns   = `id, name, email, specialty, bio, created_at, updated_at, COALESCE(version, 1), deleted_at`
+	recipeColumns = `id, chef_id, title, description, ingredients, instructions,
+	        prep_time, cook_time, servings, difficulty, cuisine, status,
+	        created_at, updated_at, COALESCE(version, 1), deleted_at, forked_from_id,
+	        published_at, publish_at`
+	ratingColumns = `id, recipe_id, chef_id, score, comment, created_at, updated_at, deleted_at`
+)
+
+// scanChef scans a row selected with chefColumns, followed by any extra columns.
+func scanChef(row pgx.Row, c *model.Chef, extra ...any) error {
+	return row.Scan(append([]any{&c.ID, &c.Name, &c.Email, &c.Specialty, &c.Bio,
+		&c.CreatedAt, &c.UpdatedAt, &c.Version, &c.DeletedAt}, extra...)...)
+}
+
+// scanRecipe scans a row selected with recipeColumns, followed by any extra columns.
+func scanRecipe(row pgx.Row, r *model.Recipe, extra ...any) error {
+	return row.Scan(append([]any{&r.ID, &r.ChefID, &r.Title, &r.Description,
+		&r.Ingredients, &r.Instructions, &r.PrepTime, &r.CookTime,
+		&r.Servings, &r.Difficulty, &r.Cuisine, &r.Status,
+		&r.CreatedAt, &r.UpdatedAt, &r.Version, &r.DeletedAt, &r.ForkedFromID,
+		&r.PublishedAt, &r.PublishAt}, extra...)...)
+}
+
+// scanRating scans a row selected with ratingColumns.
+func scanRating(row pgx.Row, r *model.Rating) error {
+	return row.Scan(&r.ID, &r.RecipeID, &r.ChefID, &r.Score, &r.Comment,
+		&r.CreatedAt, &r.UpdatedAt, &r.DeletedAt)
+}
+
 // ---------------------------------------------------------------------------
 // Chef operations
 // ---------------------------------------------------------------------------
 
-// ListChefs returns all chefs from Amazon Aurora DSQL ordered by creation date.
-func (s *DSQLStore) ListChefs(ctx context.Context) ([]model.Chef, error) {
+// keysetClause returns the predicate and ORDER BY/LIMIT suffix for one page
+// of (created_at, id) keyset pagination. Placeholders are numbered from
+// argIdx, and the returned args must be appended to the query arguments.
+// One extra row is requested so callers can detect whether a next page exists.
+func keysetClause(page model.PageRequest, argIdx int) (where, suffix string, args []any) {
+	if page.Cursor != nil {
+		where = fmt.Sprintf(" AND (created_at, id) < ($%d, $%d)", argIdx, argIdx+1)
+		args = []any{page.Cursor.CreatedAt, page.Cursor.ID}
+	}
+	suffix = fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT %d", page.EffectiveLimit()+1)
+	return where, suffix, args
+}
+
+// ListChefs returns one page of chefs from Amazon Aurora DSQL ordered by creation date.
+func (s *DSQLStore) ListChefs(ctx context.Context, page model.PageRequest) ([]model.Chef, string, error) {
+	where, suffix, args := keysetClause(page, 1)
 	rows, err := s.db.Query(ctx,
-		fmt.Sprintf(`SELECT id, name, email, specialty, bio, created_at, updated_at
-		 FROM %s.chefs ORDER BY created_at DESC`, schemaName))
+		fmt.Sprintf(`SELECT %s FROM %s.chefs WHERE 1=1`, chefColumns, schemaName)+liveOnly(ctx)+where+suffix, args...)
 	if err != nil {
-		return nil, fmt.Errorf("list chefs: %w", err)
+		return nil, "", fmt.Errorf("list chefs: %w", err)
 	}
 	defer rows.Close()
 
 	var chefs []model.Chef
 	for rows.Next() {
 		var c model.Chef
-		if err := rows.Scan(&c.ID, &c.Name, &c.Email, &c.Specialty, &c.Bio, &c.CreatedAt, &c.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("scan chef: %w", err)
+		if err := scanChef(rows, &c); err != nil {
+			return nil, "", fmt.Errorf("scan chef: %w", err)
 		}
 		chefs = append(chefs, c)
 	}
-	return chefs, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("list chefs: %w", err)
+	}
+	chefs, next := trimPage(chefs, page.EffectiveLimit(), func(c model.Chef) model.Cursor {
+		return model.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+	})
+	return chefs, next, nil
 }
 
 // GetChef returns a single chef by ID from Amazon Aurora DSQL, or nil if not found.
 func (s *DSQLStore) GetChef(ctx context.Context, id string) (*model.Chef, error) {
 	var c model.Chef
-	err := s.db.QueryRow(ctx,
-		fmt.Sprintf(`SELECT id, name, email, specialty, bio, created_at, updated_at
-		 FROM %s.chefs WHERE id = $1`, schemaName), id).
-		Scan(&c.ID, &c.Name, &c.Email, &c.Specialty, &c.Bio, &c.CreatedAt, &c.UpdatedAt)
+	row := s.db.QueryRow(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s.chefs WHERE id = $1`, chefColumns, schemaName)+liveOnly(ctx), id)
+	err := scanChef(row, &c)
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
@@ -157,10 +181,8 @@ func (s *DSQLStore) GetChefWithRecipes(ctx context.Context, id string) (*model.C
 	}
 
 	rows, err := s.db.Query(ctx,
-		fmt.Sprintf(`SELECT id, chef_id, title, description, ingredients, instructions,
-		        prep_time, cook_time, servings, difficulty, cuisine, status,
-		        created_at, updated_at
-		 FROM %s.recipes WHERE chef_id = $1 ORDER BY created_at DESC`, schemaName), id)
+		fmt.Sprintf(`SELECT %s FROM %s.recipes WHERE chef_id = $1%s ORDER BY created_at DESC`,
+			recipeColumns, schemaName, liveOnly(ctx)), id)
 	if err != nil {
 		return nil, fmt.Errorf("get chef recipes: %w", err)
 	}
@@ -169,21 +191,29 @@ func (s *DSQLStore) GetChefWithRecipes(ctx context.Context, id string) (*model.C
 	result := &model.ChefWithRecipes{Chef: *chef}
 	for rows.Next() {
 		var r model.Recipe
-		if err := rows.Scan(&r.ID, &r.ChefID, &r.Title, &r.Description,
-			&r.Ingredients, &r.Instructions, &r.PrepTime, &r.CookTime,
-			&r.Servings, &r.Difficulty, &r.Cuisine, &r.Status,
-			&r.CreatedAt, &r.UpdatedAt); err != nil {
+		if err := scanRecipe(rows, &r); err != nil {
 			return nil, fmt.Errorf("scan recipe: %w", err)
 		}
 		result.Recipes = append(result.Recipes, r)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get chef recipes: %w", err)
+	}
 	if result.Recipes == nil {
 		result.Recipes = []model.Recipe{}
 	}
-	return result, rows.Err()
+	if err := attachRatingSummaries(ctx, s.db, result.Recipes); err != nil {
+		return nil, err
+	}
+	if err := attachFavoritedCounts(ctx, s.db, result.Recipes); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 // CreateChef inserts a new chef record into Amazon Aurora DSQL with a generated UUID.
+// It returns an error wrapping ErrConflict if another chef has the same email,
+// ignoring case.
 func (s *DSQLStore) CreateChef(ctx context.Context, input model.CreateChefInput) (*model.Chef, error) {
 	now := time.Now().UTC()
 	c := model.Chef{
@@ -194,39 +224,71 @@ func (s *DSQLStore) CreateChef(ctx context.Context, input model.CreateChefInput)
 		Bio:       input.Bio,
 		CreatedAt: now,
 		UpdatedAt: now,
+		Version:   1,
 	}
 
-	_, err := s.db.Exec(ctx,
-		fmt.Sprintf(`INSERT INTO %s.chefs (id, name, email, specialty, bio, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7)`, schemaName),
-		c.ID, c.Name, c.Email, c.Specialty, c.Bio, c.CreatedAt, c.UpdatedAt)
+	key := model.EmailKey(c.Email)
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		taken, err := emailTaken(ctx, tx, key, c.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return errEmailTaken
+		}
+		_, err = tx.Exec(ctx,
+			fmt.Sprintf(`INSERT INTO %s.chefs (id, name, email, email_key, specialty, bio, created_at, updated_at, version)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`, schemaName),
+			c.ID, c.Name, c.Email, key, c.Specialty, c.Bio, c.CreatedAt, c.UpdatedAt, c.Version)
+		if err != nil {
+			return fmt.Errorf("create chef: %w", err)
+		}
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityChef, c.ID, model.ActionCreate, nil, &c, now))
+	})
+	if pgerr.IsUniqueViolation(err) {
+		return nil, errEmailTaken
+	}
 	if err != nil {
-		return nil, fmt.Errorf("create chef: %w", err)
+		return nil, err
 	}
 	return &c, nil
 }
 
 // UpdateChef applies partial updates to an existing chef in Amazon Aurora DSQL.
-// The read-modify-write is wrapped in a transaction with OCC retry.
+// The read-modify-write is wrapped in a transaction with OCC retry, and the
+// If-Match version check runs inside it so a concurrent update that commits
+// first is detected on retry. Changing the email to one held by another chef
+// returns an error wrapping ErrConflict.
 func (s *DSQLStore) UpdateChef(ctx context.Context, id string, input model.UpdateChefInput) (*model.Chef, error) {
 	var chef *model.Chef
 	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
 		var c model.Chef
-		err := tx.QueryRow(ctx,
-			fmt.Sprintf(`SELECT id, name, email, specialty, bio, created_at, updated_at
-			 FROM %s.chefs WHERE id = $1`, schemaName), id).
-			Scan(&c.ID, &c.Name, &c.Email, &c.Specialty, &c.Bio, &c.CreatedAt, &c.UpdatedAt)
+		row := tx.QueryRow(ctx,
+			fmt.Sprintf(`SELECT %s FROM %s.chefs WHERE id = $1 AND deleted_at IS NULL`, chefColumns, schemaName), id)
+		err := scanChef(row, &c)
 		if err == pgx.ErrNoRows {
 			return nil
 		}
 		if err != nil {
 			return fmt.Errorf("get chef: %w", err)
 		}
+		if input.IfVersion != nil && *input.IfVersion != c.Version {
+			return ErrPreconditionFailed
+		}
+		before := c
 		if input.Name != nil {
 			c.Name = *input.Name
 		}
 		if input.Email != nil {
 			c.Email = *input.Email
+			taken, err := emailTaken(ctx, tx, model.EmailKey(c.Email), id)
+			if err != nil {
+				return err
+			}
+			if taken {
+				return errEmailTaken
+			}
 		}
 		if input.Specialty != nil {
 			c.Specialty = *input.Specialty
@@ -235,42 +297,42 @@ func (s *DSQLStore) UpdateChef(ctx context.Context, id string, input model.Updat
 			c.Bio = *input.Bio
 		}
 		c.UpdatedAt = time.Now().UTC()
+		c.Version++
 		_, err = tx.Exec(ctx,
-			fmt.Sprintf(`UPDATE %s.chefs SET name = $1, email = $2, specialty = $3, bio = $4, updated_at = $5
-			 WHERE id = $6`, schemaName),
-			c.Name, c.Email, c.Specialty, c.Bio, c.UpdatedAt, id)
+			fmt.Sprintf(`UPDATE %s.chefs SET name = $1, email = $2, email_key = $3, specialty = $4, bio = $5,
+			        updated_at = $6, version = $7
+			 WHERE id = $8`, schemaName),
+			c.Name, c.Email, model.EmailKey(c.Email), c.Specialty, c.Bio, c.UpdatedAt, c.Version, id)
 		if err != nil {
 			return fmt.Errorf("update chef: %w", err)
 		}
 		chef = &c
-		return nil
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityChef, id, model.ActionUpdate, &before, &c, c.UpdatedAt))
 	})
+	if pgerr.IsUniqueViolation(err) {
+		return nil, errEmailTaken
+	}
 	if err != nil {
 		return nil, err
 	}
 	return chef, nil
 }
 
-// DeleteChef removes a chef by ID from Amazon Aurora DSQL.
-func (s *DSQLStore) DeleteChef(ctx context.Context, id string) error {
-	_, err := s.db.Exec(ctx,
-		fmt.Sprintf(`DELETE FROM %s.chefs WHERE id = $1`, schemaName), id)
-	if err != nil {
-		return fmt.Errorf("delete chef: %w", err)
-	}
-	return nil
-}
-
 // ---------------------------------------------------------------------------
 // Recipe operations
 // ---------------------------------------------------------------------------
 
-// ListRecipes returns recipes from Amazon Aurora DSQL matching the optional filter criteria.
-func (s *DSQLStore) ListRecipes(ctx context.Context, filter model.RecipeFilter) ([]model.Recipe, error) {
-	query := fmt.Sprintf(`SELECT id, chef_id, title, description, ingredients, instructions,
-	                  prep_time, cook_time, servings, difficulty, cuisine, status,
-	                  created_at, updated_at
-	           FROM %s.recipes WHERE 1=1`, schemaName)
+// ListRecipes returns one page of recipes from Amazon Aurora DSQL matching the
+// optional filter criteria, each with its rating summary.
+func (s *DSQLStore) ListRecipes(ctx context.Context, filter model.RecipeFilter, page model.PageRequest) ([]model.Recipe, string, error) {
+	byRating := filter.Sort == model.SortRating
+	rated := byRating || filter.MinRating > 0
+	from, columns := schemaName+".recipes", recipeColumns
+	if rated {
+		from, columns = ratedRecipes, recipeColumns+", average_score, rating_count"
+	}
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE 1=1`, columns, from) + liveOnly(ctx)
 	var args []any
 	argIdx := 1
 
@@ -289,57 +351,104 @@ func (s *DSQLStore) ListRecipes(ctx context.Context, filter model.RecipeFilter)
 		args = append(args, filter.Status)
 		argIdx++
 	}
-	query += " ORDER BY created_at DESC"
+	if filter.MinRating > 0 {
+		query += fmt.Sprintf(" AND average_score >= $%d", argIdx)
+		args = append(args, filter.MinRating)
+		argIdx++
+	}
+	if len(filter.Tags) > 0 {
+		where, tagArgs := tagFilter(filter, argIdx)
+		query += where
+		args = append(args, tagArgs...)
+		argIdx += len(tagArgs)
+	}
+	if byRating {
+		if page.Cursor != nil {
+			query += fmt.Sprintf(" AND (average_score, rating_count, created_at, id) < ($%d, $%d, $%d, $%d)",
+				argIdx, argIdx+1, argIdx+2, argIdx+3)
+			args = append(args, page.Cursor.Score, page.Cursor.Rank, page.Cursor.CreatedAt, page.Cursor.ID)
+		}
+		query += fmt.Sprintf(" ORDER BY average_score DESC, rating_count DESC, created_at DESC, id DESC LIMIT %d",
+			page.EffectiveLimit()+1)
+	} else {
+		where, suffix, pageArgs := keysetClause(page, argIdx)
+		query += where + suffix
+		args = append(args, pageArgs...)
+	}
 
 	rows, err := s.db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("list recipes: %w", err)
+		return nil, "", fmt.Errorf("list recipes: %w", err)
 	}
 	defer rows.Close()
 
 	var recipes []model.Recipe
 	for rows.Next() {
 		var r model.Recipe
-		if err := rows.Scan(&r.ID, &r.ChefID, &r.Title, &r.Description,
-			&r.Ingredients, &r.Instructions, &r.PrepTime, &r.CookTime,
-			&r.Servings, &r.Difficulty, &r.Cuisine, &r.Status,
-			&r.CreatedAt, &r.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("scan recipe: %w", err)
+		var extra []any
+		if rated {
+			r.RatingSummary = &model.RatingSummary{}
+			extra = []any{&r.AverageScore, &r.RatingCount}
+		}
+		if err := scanRecipe(rows, &r, extra...); err != nil {
+			return nil, "", fmt.Errorf("scan recipe: %w", err)
 		}
 		recipes = append(recipes, r)
 	}
-	return recipes, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("list recipes: %w", err)
+	}
+	recipes, next := trimPage(recipes, page.EffectiveLimit(), func(r model.Recipe) model.Cursor {
+		if byRating {
+			return ratingOrderCursor(r)
+		}
+		return model.Cursor{CreatedAt: r.CreatedAt, ID: r.ID}
+	})
+	if !rated {
+		if err := attachRatingSummaries(ctx, s.db, recipes); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := attachFavoritedCounts(ctx, s.db, recipes); err != nil {
+		return nil, "", err
+	}
+	return recipes, next, nil
 }
 
 // GetRecipe returns a single recipe by ID from Amazon Aurora DSQL, or nil if not found.
 func (s *DSQLStore) GetRecipe(ctx context.Context, id string) (*model.Recipe, error) {
 	var r model.Recipe
-	err := s.db.QueryRow(ctx,
-		fmt.Sprintf(`SELECT id, chef_id, title, description, ingredients, instructions,
-		        prep_time, cook_time, servings, difficulty, cuisine, status,
-		        created_at, updated_at
-		 FROM %s.recipes WHERE id = $1`, schemaName), id).
-		Scan(&r.ID, &r.ChefID, &r.Title, &r.Description,
-			&r.Ingredients, &r.Instructions, &r.PrepTime, &r.CookTime,
-			&r.Servings, &r.Difficulty, &r.Cuisine, &r.Status,
-			&r.CreatedAt, &r.UpdatedAt)
+	row := s.db.QueryRow(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s.recipes WHERE id = $1`, recipeColumns, schemaName)+liveOnly(ctx), id)
+	err := scanRecipe(row, &r)
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("get recipe: %w", err)
 	}
+	if r.IngredientList, err = listIngredients(ctx, s.db, id); err != nil {
+		return nil, err
+	}
+	tags, err := recipeTags(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	r.Tags = tagSlugs(tags)
 	return &r, nil
 }
 
-// GetRecipeWithRatings returns a recipe with its ratings and computed average score from Amazon Aurora DSQL.
+// GetRecipeWithRatings returns a recipe with its most recent ratings and its
+// rating summary and histogram from Amazon Aurora DSQL. The aggregates are
+// computed in SQL, so the cost of the read does not grow with the number of
+// ratings.
 func (s *DSQLStore) GetRecipeWithRatings(ctx context.Context, id string) (*model.RecipeWithRatings, error) {
 	recipe, err := s.GetRecipe(ctx, id)
 	if err != nil || recipe == nil {
 		return nil, err
 	}
 
-	ratings, err := s.ListRatings(ctx, id)
+	ratings, _, err := s.ListRatings(ctx, id, model.PageRequest{})
 	if err != nil {
 		return nil, err
 	}
@@ -347,97 +456,133 @@ func (s *DSQLStore) GetRecipeWithRatings(ctx context.Context, id string) (*model
 		ratings = []model.Rating{}
 	}
 
-	var total int
-	for _, r := range ratings {
-		total += r.Score
+	// Deleted ratings are listed under IncludeDeleted but never counted.
+	histogram, sum, err := ratingHistogram(ctx, s.db, id)
+	if err != nil {
+		return nil, err
 	}
-	var avg float64
-	if len(ratings) > 0 {
-		avg = float64(total) / float64(len(ratings))
+	recipe.RatingSummary = &sum
+	counts, err := favoritedCounts(ctx, s.db, []string{id})
+	if err != nil {
+		return nil, err
 	}
+	favorited := counts[id]
+	recipe.FavoritedCount = &favorited
+	forks, err := forkCounts(ctx, s.db, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	forked := forks[id]
+	recipe.ForkCount = &forked
 
 	return &model.RecipeWithRatings{
-		Recipe:       *recipe,
-		Ratings:      ratings,
-		AverageScore: avg,
-		RatingCount:  len(ratings),
+		Recipe:          *recipe,
+		Ratings:         ratings,
+		RatingHistogram: histogram,
 	}, nil
 }
 
 // CreateRecipe inserts a new recipe record into Amazon Aurora DSQL with a generated UUID.
 func (s *DSQLStore) CreateRecipe(ctx context.Context, input model.CreateRecipeInput) (*model.Recipe, error) {
-	now := time.Now().UTC()
+	r := newRecipe(input, time.Now().UTC())
 
+	// The recipe and its ingredient lines are written in one transaction so
+	// readers never see a recipe without its structured ingredients.
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		if err := insertRecipe(ctx, tx, &r); err != nil {
+			return err
+		}
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityRecipe, r.ID, model.ActionCreate, nil, &r, r.CreatedAt))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// newRecipe builds a new recipe from the create input, applying the default
+// difficulty and status and resolving the two forms of the ingredients. A
+// recipe created published is published at now.
+func newRecipe(input model.CreateRecipeInput, now time.Time) model.Recipe {
 	difficulty := input.Difficulty
 	if difficulty == "" {
 		difficulty = "medium"
 	}
 	status := input.Status
 	if status == "" {
-		status = "draft"
+		status = model.StatusDraft
 	}
 
+	text, list := resolveIngredients(input.Ingredients, input.IngredientList)
 	r := model.Recipe{
-		ID:           uuid.New().String(),
-		ChefID:       input.ChefID,
-		Title:        input.Title,
-		Description:  input.Description,
-		Ingredients:  input.Ingredients,
-		Instructions: input.Instructions,
-		PrepTime:     input.PrepTime,
-		CookTime:     input.CookTime,
-		Servings:     input.Servings,
-		Difficulty:   difficulty,
-		Cuisine:      input.Cuisine,
-		Status:       status,
-		CreatedAt:    now,
-		UpdatedAt:    now,
-	}
-
-	_, err := s.db.Exec(ctx,
+		ID:             uuid.New().String(),
+		ChefID:         input.ChefID,
+		Title:          input.Title,
+		Description:    input.Description,
+		Ingredients:    text,
+		IngredientList: list,
+		Instructions:   input.Instructions,
+		PrepTime:       input.PrepTime,
+		CookTime:       input.CookTime,
+		Servings:       input.Servings,
+		Difficulty:     difficulty,
+		Cuisine:        input.Cuisine,
+		Status:         status,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Version:        1,
+	}
+	if status == model.StatusPublished {
+		r.PublishedAt = &now
+	}
+	if input.PublishAt != nil && status == model.StatusDraft {
+		publishAt := input.PublishAt.UTC()
+		r.PublishAt = &publishAt
+	}
+	return r
+}
+
+// insertRecipe writes a new recipe row and its ingredient lines.
+func insertRecipe(ctx context.Context, q querier, r *model.Recipe) error {
+	_, err := q.Exec(ctx,
 		fmt.Sprintf(`INSERT INTO %s.recipes (id, chef_id, title, description, ingredients, instructions,
 		                      prep_time, cook_time, servings, difficulty, cuisine, status,
-		                      created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`, schemaName),
+		                      created_at, updated_at, version, forked_from_id, published_at, publish_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`, schemaName),
 		r.ID, r.ChefID, r.Title, r.Description, r.Ingredients, r.Instructions,
 		r.PrepTime, r.CookTime, r.Servings, r.Difficulty, r.Cuisine, r.Status,
-		r.CreatedAt, r.UpdatedAt)
+		r.CreatedAt, r.UpdatedAt, r.Version, r.ForkedFromID, r.PublishedAt, r.PublishAt)
 	if err != nil {
-		return nil, fmt.Errorf("create recipe: %w", err)
+		return fmt.Errorf("create recipe: %w", err)
 	}
-	return &r, nil
+	return insertIngredients(ctx, q, r.ID, r.IngredientList)
 }
 
 // UpdateRecipe applies partial updates to an existing recipe in Amazon Aurora DSQL.
-// The read-modify-write is wrapped in a transaction with OCC retry.
+// The read-modify-write is wrapped in a transaction with OCC retry, and the
+// If-Match version check runs inside it so a concurrent update that commits
+// first is detected on retry. A status change that the publishing state
+// machine does not allow returns an error wrapping ErrInvalidTransition.
 func (s *DSQLStore) UpdateRecipe(ctx context.Context, id string, input model.UpdateRecipeInput) (*model.Recipe, error) {
 	var recipe *model.Recipe
 	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
-		var r model.Recipe
-		err := tx.QueryRow(ctx,
-			fmt.Sprintf(`SELECT id, chef_id, title, description, ingredients, instructions,
-			        prep_time, cook_time, servings, difficulty, cuisine, status,
-			        created_at, updated_at
-			 FROM %s.recipes WHERE id = $1`, schemaName), id).
-			Scan(&r.ID, &r.ChefID, &r.Title, &r.Description,
-				&r.Ingredients, &r.Instructions, &r.PrepTime, &r.CookTime,
-				&r.Servings, &r.Difficulty, &r.Cuisine, &r.Status,
-				&r.CreatedAt, &r.UpdatedAt)
-		if err == pgx.ErrNoRows {
-			return nil
+		found, err := recipeForUpdate(ctx, tx, id, input.IfVersion)
+		if err != nil || found == nil {
+			return err
 		}
-		if err != nil {
-			return fmt.Errorf("get recipe: %w", err)
+		r := *found
+		if input.Status != nil && !model.CanChangeStatus(r.Status, *input.Status) {
+			return fmt.Errorf("%w: cannot change a recipe from %s to %s", ErrInvalidTransition, r.Status, *input.Status)
 		}
+		before := r
 		if input.Title != nil {
 			r.Title = *input.Title
 		}
 		if input.Description != nil {
 			r.Description = *input.Description
 		}
-		if input.Ingredients != nil {
-			r.Ingredients = *input.Ingredients
-		}
+		ingredientsChanged := resolveIngredientUpdate(&r, input)
 		if input.Instructions != nil {
 			r.Instructions = *input.Instructions
 		}
@@ -456,23 +601,25 @@ func (s *DSQLStore) UpdateRecipe(ctx context.Context, id string, input model.Upd
 		if input.Cuisine != nil {
 			r.Cuisine = *input.Cuisine
 		}
+		r.UpdatedAt = time.Now().UTC()
 		if input.Status != nil {
-			r.Status = *input.Status
+			changeStatus(&r, *input.Status, r.UpdatedAt)
 		}
-		r.UpdatedAt = time.Now().UTC()
-		_, err = tx.Exec(ctx,
-			fmt.Sprintf(`UPDATE %s.recipes SET title = $1, description = $2, ingredients = $3, instructions = $4,
-			        prep_time = $5, cook_time = $6, servings = $7, difficulty = $8, cuisine = $9,
-			        status = $10, updated_at = $11
-			 WHERE id = $12`, schemaName),
-			r.Title, r.Description, r.Ingredients, r.Instructions,
-			r.PrepTime, r.CookTime, r.Servings, r.Difficulty, r.Cuisine,
-			r.Status, r.UpdatedAt, id)
-		if err != nil {
-			return fmt.Errorf("update recipe: %w", err)
+		r.Version++
+		if err := updateRecipeRow(ctx, tx, before, r); err != nil {
+			return err
+		}
+		if ingredientsChanged {
+			if err := deleteIngredients(ctx, tx, id); err != nil {
+				return err
+			}
+			if err := insertIngredients(ctx, tx, id, r.IngredientList); err != nil {
+				return err
+			}
 		}
 		recipe = &r
-		return nil
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityRecipe, id, model.ActionUpdate, &before, &r, r.UpdatedAt))
 	})
 	if err != nil {
 		return nil, err
@@ -480,60 +627,375 @@ func (s *DSQLStore) UpdateRecipe(ctx context.Context, id string, input model.Upd
 	return recipe, nil
 }
 
-// DeleteRecipe removes a recipe by ID from Amazon Aurora DSQL.
-func (s *DSQLStore) DeleteRecipe(ctx context.Context, id string) error {
-	_, err := s.db.Exec(ctx,
-		fmt.Sprintf(`DELETE FROM %s.recipes WHERE id = $1`, schemaName), id)
+// recipeForUpdate reads a live recipe with its ingredient lines inside a
+// transaction, or returns nil if it does not exist. It returns
+// ErrPreconditionFailed if ifVersion is non-nil and does not match.
+func recipeForUpdate(ctx context.Context, tx pgx.Tx, id string, ifVersion *int64) (*model.Recipe, error) {
+	var r model.Recipe
+	row := tx.QueryRow(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s.recipes WHERE id = $1 AND deleted_at IS NULL`, recipeColumns, schemaName), id)
+	err := scanRecipe(row, &r)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		return fmt.Errorf("delete recipe: %w", err)
+		return nil, fmt.Errorf("get recipe: %w", err)
+	}
+	if ifVersion != nil && *ifVersion != r.Version {
+		return nil, ErrPreconditionFailed
+	}
+	if r.IngredientList, err = listIngredients(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// updateRecipeRow writes every mutable column of r to the recipe's row and
+// records before, the version it replaces, as a revision.
+func updateRecipeRow(ctx context.Context, tx pgx.Tx, before, r model.Recipe) error {
+	if err := insertRevision(ctx, tx, newRevision(before, r.UpdatedAt)); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx,
+		fmt.Sprintf(`UPDATE %s.recipes SET title = $1, description = $2, ingredients = $3, instructions = $4,
+		        prep_time = $5, cook_time = $6, servings = $7, difficulty = $8, cuisine = $9,
+		        status = $10, updated_at = $11, version = $12, published_at = $13, publish_at = $14
+		 WHERE id = $15`, schemaName),
+		r.Title, r.Description, r.Ingredients, r.Instructions,
+		r.PrepTime, r.CookTime, r.Servings, r.Difficulty, r.Cuisine,
+		r.Status, r.UpdatedAt, r.Version, r.PublishedAt, r.PublishAt, r.ID)
+	if err != nil {
+		return fmt.Errorf("update recipe: %w", err)
 	}
 	return nil
 }
 
+// DeleteRecipe soft-deletes a recipe in Amazon Aurora DSQL. Its ingredient
+// lines and ratings are kept and hidden with it until it is restored or
+// purged, and its collection items are then hidden in batches.
+func (s *DSQLStore) DeleteRecipe(ctx context.Context, id string) error {
+	now := deletedNow()
+	if err := setDeletedAt(ctx, s, recipeTable, id, &now); err != nil {
+		return err
+	}
+	return s.hideMemberships(ctx, "r.id = $2", id, now)
+}
+
+// RestoreRecipe undoes DeleteRecipe in Amazon Aurora DSQL. The collection
+// items hidden with the recipe are restored first, so that an interrupted
+// restore leaves the recipe deleted and can be retried.
+func (s *DSQLStore) RestoreRecipe(ctx context.Context, id string) (*model.Recipe, error) {
+	var deletedAt *time.Time
+	err := s.db.QueryRow(ctx,
+		fmt.Sprintf(`SELECT deleted_at FROM %s.recipes WHERE id = $1`, schemaName), id).Scan(&deletedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get recipe: %w", err)
+	}
+	if deletedAt == nil {
+		return s.GetRecipe(ctx, id)
+	}
+	if err := s.restoreMemberships(ctx, "r.id = $2", id, *deletedAt); err != nil {
+		return nil, err
+	}
+	if err := setDeletedAt(ctx, s, recipeTable, id, nil); err != nil {
+		return nil, err
+	}
+	return s.GetRecipe(ctx, id)
+}
+
+// SearchRecipes returns one page of recipes from Amazon Aurora DSQL that
+// contain every search term. Matching uses lower() and LIKE because Amazon
+// Aurora DSQL does not support PostgreSQL full-text search types or GIN
+// indexes. Results are ranked by where the terms appear.
+func (s *DSQLStore) SearchRecipes(ctx context.Context, terms []string, page model.PageRequest) ([]model.Recipe, string, error) {
+	var args []any
+	var matches, ranks []string
+	for i, term := range terms {
+		p := i + 1
+		args = append(args, likePattern(term))
+		matches = append(matches, fmt.Sprintf(
+			`(lower(title) LIKE $%[1]d OR lower(ingredients) LIKE $%[1]d OR lower(description) LIKE $%[1]d)`, p))
+		ranks = append(ranks, fmt.Sprintf(
+			`CASE WHEN lower(title) LIKE $%[1]d THEN %[2]d ELSE 0 END
+			 + CASE WHEN lower(ingredients) LIKE $%[1]d THEN %[3]d ELSE 0 END
+			 + CASE WHEN lower(description) LIKE $%[1]d THEN %[4]d ELSE 0 END`,
+			p, searchWeightTitle, searchWeightIngredients, searchWeightDescription))
+	}
+
+	query := fmt.Sprintf(`SELECT %s, rank
+	           FROM (
+	               SELECT *, %s AS rank
+	               FROM %s.recipes
+	               WHERE %s%s
+	           ) matched WHERE 1=1`,
+		recipeColumns, strings.Join(ranks, " + "), schemaName, strings.Join(matches, " AND "), liveOnly(ctx))
+	if page.Cursor != nil {
+		n := len(args) + 1
+		query += fmt.Sprintf(" AND (rank, created_at, id) < ($%d, $%d, $%d)", n, n+1, n+2)
+		args = append(args, page.Cursor.Rank, page.Cursor.CreatedAt, page.Cursor.ID)
+	}
+	query += fmt.Sprintf(" ORDER BY rank DESC, created_at DESC, id DESC LIMIT %d", page.EffectiveLimit()+1)
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("search recipes: %w", err)
+	}
+	defer rows.Close()
+
+	var recipes []model.Recipe
+	var keys []model.Cursor
+	for rows.Next() {
+		var r model.Recipe
+		var rank int
+		if err := scanRecipe(rows, &r, &rank); err != nil {
+			return nil, "", fmt.Errorf("scan recipe: %w", err)
+		}
+		recipes = append(recipes, r)
+		keys = append(keys, model.Cursor{Rank: rank, CreatedAt: r.CreatedAt, ID: r.ID})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("search recipes: %w", err)
+	}
+	keys, next := trimPage(keys, page.EffectiveLimit(), func(k model.Cursor) model.Cursor { return k })
+	recipes = recipes[:len(keys)]
+	if err := attachRatingSummaries(ctx, s.db, recipes); err != nil {
+		return nil, "", err
+	}
+	if err := attachFavoritedCounts(ctx, s.db, recipes); err != nil {
+		return nil, "", err
+	}
+	return recipes, next, nil
+}
+
 // ---------------------------------------------------------------------------
 // Rating operations
 // ---------------------------------------------------------------------------
 
-// ListRatings returns all ratings for a given recipe from Amazon Aurora DSQL.
-func (s *DSQLStore) ListRatings(ctx context.Context, recipeID string) ([]model.Rating, error) {
+// ListRatings returns one page of ratings for a given recipe from Amazon Aurora DSQL.
+func (s *DSQLStore) ListRatings(ctx context.Context, recipeID string, page model.PageRequest) ([]model.Rating, string, error) {
+	where, suffix, pageArgs := keysetClause(page, 2)
 	rows, err := s.db.Query(ctx,
-		fmt.Sprintf(`SELECT id, recipe_id, chef_id, score, comment, created_at, updated_at
-		 FROM %s.ratings WHERE recipe_id = $1 ORDER BY created_at DESC`, schemaName), recipeID)
+		fmt.Sprintf(`SELECT %s FROM %s.ratings WHERE recipe_id = $1`, ratingColumns, schemaName)+liveOnly(ctx)+where+suffix,
+		append([]any{recipeID}, pageArgs...)...)
 	if err != nil {
-		return nil, fmt.Errorf("list ratings: %w", err)
+		return nil, "", fmt.Errorf("list ratings: %w", err)
+	}
+	ratings, err := scanRatings(rows)
+	if err != nil {
+		return nil, "", err
 	}
+	ratings, next := trimPage(ratings, page.EffectiveLimit(), func(r model.Rating) model.Cursor {
+		return model.Cursor{CreatedAt: r.CreatedAt, ID: r.ID}
+	})
+	return ratings, next, nil
+}
+
+// scanRatings reads all rating rows and closes rows.
+func scanRatings(rows pgx.Rows) ([]model.Rating, error) {
 	defer rows.Close()
 
 	var ratings []model.Rating
 	for rows.Next() {
 		var r model.Rating
-		if err := rows.Scan(&r.ID, &r.RecipeID, &r.ChefID, &r.Score, &r.Comment, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		if err := scanRating(rows, &r); err != nil {
 			return nil, fmt.Errorf("scan rating: %w", err)
 		}
 		ratings = append(ratings, r)
 	}
-	return ratings, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list ratings: %w", err)
+	}
+	return ratings, nil
 }
 
-// CreateRating inserts a new rating record into Amazon Aurora DSQL with a generated UUID.
+// CreateRating inserts a new rating record into Amazon Aurora DSQL with a
+// generated UUID. It returns an error wrapping ErrConflict if the chef
+// already has a live rating on the recipe.
 func (s *DSQLStore) CreateRating(ctx context.Context, recipeID string, input model.CreateRatingInput) (*model.Rating, error) {
-	now := time.Now().UTC()
-	r := model.Rating{
-		ID:        uuid.New().String(),
-		RecipeID:  recipeID,
-		ChefID:    input.ChefID,
-		Score:     input.Score,
-		Comment:   input.Comment,
-		CreatedAt: now,
-		UpdatedAt: now,
+	r, _, err := s.writeRating(ctx, recipeID, input, false)
+	return r, err
+}
+
+// UpsertRating creates the chef's rating on a recipe, or replaces the score
+// and comment of the chef's existing live rating. It reports whether the
+// rating was created.
+func (s *DSQLStore) UpsertRating(ctx context.Context, recipeID string, input model.CreateRatingInput) (*model.Rating, bool, error) {
+	r, created, err := s.writeRating(ctx, recipeID, input, true)
+	if pgerr.IsUniqueViolation(err) {
+		// A concurrent request inserted the chef's rating after this one
+		// looked for it. Run again to update that rating instead.
+		r, created, err = s.writeRating(ctx, recipeID, input, true)
+	}
+	return r, created, err
+}
+
+// writeRating inserts the chef's rating on a recipe. If the chef already has
+// a live rating there, it updates that rating when upsert is set and returns
+// an error wrapping ErrConflict otherwise.
+func (s *DSQLStore) writeRating(ctx context.Context, recipeID string, input model.CreateRatingInput, upsert bool) (*model.Rating, bool, error) {
+	var rating *model.Rating
+	var created bool
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		existing, err := ratingByChef(ctx, tx, recipeID, input.ChefID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			if !upsert {
+				return errRatingExists
+			}
+			created = false
+			rating, err = updateRating(ctx, tx, *existing, model.UpdateRatingInput{
+				Score:   &input.Score,
+				Comment: &input.Comment,
+			})
+			return err
+		}
+
+		now := time.Now().UTC()
+		r := model.Rating{
+			ID:        uuid.New().String(),
+			RecipeID:  recipeID,
+			ChefID:    input.ChefID,
+			Score:     input.Score,
+			Comment:   input.Comment,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		_, err = tx.Exec(ctx,
+			fmt.Sprintf(`INSERT INTO %s.ratings (id, recipe_id, chef_id, live_chef_id, score, comment, created_at, updated_at)
+			 VALUES ($1, $2, $3, $3, $4, $5, $6, $7)`, schemaName),
+			r.ID, r.RecipeID, r.ChefID, r.Score, r.Comment, r.CreatedAt, r.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("create rating: %w", err)
+		}
+		created, rating = true, &r
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityRating, r.ID, model.ActionCreate, nil, &r, now))
+	})
+	if pgerr.IsUniqueViolation(err) && !upsert {
+		return nil, false, errRatingExists
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return rating, created, nil
+}
+
+// ratingByChef returns the chef's live rating on a recipe, or nil if there
+// is none. It matches on chef_id rather than live_chef_id so that ratings
+// written before live_chef_id existed are found too.
+func ratingByChef(ctx context.Context, q querier, recipeID, chefID string) (*model.Rating, error) {
+	var r model.Rating
+	row := q.QueryRow(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s.ratings
+		 WHERE recipe_id = $1 AND chef_id = $2 AND deleted_at IS NULL LIMIT 1`, ratingColumns, schemaName),
+		recipeID, chefID)
+	err := scanRating(row, &r)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get chef rating: %w", err)
+	}
+	return &r, nil
+}
+
+// updateRating applies a partial update to a live rating and records it in
+// the audit log.
+func updateRating(ctx context.Context, tx pgx.Tx, r model.Rating, input model.UpdateRatingInput) (*model.Rating, error) {
+	before := r
+	if input.Score != nil {
+		r.Score = *input.Score
+	}
+	if input.Comment != nil {
+		r.Comment = *input.Comment
+	}
+	r.UpdatedAt = time.Now().UTC()
+	_, err := tx.Exec(ctx,
+		fmt.Sprintf(`UPDATE %s.ratings SET score = $1, comment = $2, updated_at = $3 WHERE id = $4`, schemaName),
+		r.Score, r.Comment, r.UpdatedAt, r.ID)
+	if err != nil {
+		return nil, fmt.Errorf("update rating: %w", err)
+	}
+	if err := insertAuditEvents(ctx, tx,
+		newAuditEvent(ctx, model.EntityRating, r.ID, model.ActionUpdate, &before, &r, r.UpdatedAt)); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// UpdateRating applies partial updates to a live rating in Amazon Aurora
+// DSQL, or returns nil if there is no such rating.
+func (s *DSQLStore) UpdateRating(ctx context.Context, id string, input model.UpdateRatingInput) (*model.Rating, error) {
+	var rating *model.Rating
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		rating = nil
+		var r model.Rating
+		row := tx.QueryRow(ctx,
+			fmt.Sprintf(`SELECT %s FROM %s.ratings WHERE id = $1 AND deleted_at IS NULL`, ratingColumns, schemaName), id)
+		err := scanRating(row, &r)
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("get rating: %w", err)
+		}
+		rating, err = updateRating(ctx, tx, r, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return rating, nil
+}
 
-	_, err := s.db.Exec(ctx,
-		fmt.Sprintf(`INSERT INTO %s.ratings (id, recipe_id, chef_id, score, comment, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7)`, schemaName),
-		r.ID, r.RecipeID, r.ChefID, r.Score, r.Comment, r.CreatedAt, r.UpdatedAt)
+// GetRating returns a single rating by ID from Amazon Aurora DSQL, or nil if not found.
+func (s *DSQLStore) GetRating(ctx context.Context, id string) (*model.Rating, error) {
+	var r model.Rating
+	row := s.db.QueryRow(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s.ratings WHERE id = $1`, ratingColumns, schemaName)+liveOnly(ctx), id)
+	err := scanRating(row, &r)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("create rating: %w", err)
+		return nil, fmt.Errorf("get rating: %w", err)
 	}
 	return &r, nil
 }
+
+// DeleteRating soft-deletes a rating in Amazon Aurora DSQL.
+func (s *DSQLStore) DeleteRating(ctx context.Context, id string) error {
+	now := deletedNow()
+	return setDeletedAt(ctx, s, ratingTable, id, &now)
+}
+
+// RestoreRating undoes DeleteRating in Amazon Aurora DSQL. It returns an
+// error wrapping ErrConflict if the chef has rated the recipe again since.
+func (s *DSQLStore) RestoreRating(ctx context.Context, id string) (*model.Rating, error) {
+	deleted, err := s.GetRating(IncludeDeleted(ctx), id)
+	if err != nil || deleted == nil {
+		return nil, err
+	}
+	if deleted.DeletedAt != nil {
+		existing, err := ratingByChef(ctx, s.db, deleted.RecipeID, deleted.ChefID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, errRatingExists
+		}
+	}
+	err = setDeletedAt(ctx, s, ratingTable, id, nil)
+	if pgerr.IsUniqueViolation(err) {
+		return nil, errRatingExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRating(ctx, id)
+}
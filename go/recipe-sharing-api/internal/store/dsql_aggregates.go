@@ -0,0 +1,110 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+)
+
+// ratedRecipes is a derived table of recipes with the aggregate of their
+// live ratings. It is used only when a list filters or sorts by rating,
+// because it aggregates the ratings of every recipe; other reads summarize
+// just the page they return with ratingSummaries. The average is cast to
+// float8 so that it compares exactly with cursor values read back from it.
+var ratedRecipes = fmt.Sprintf(`(
+	SELECT r.*, COALESCE(a.average_score, 0) AS average_score, COALESCE(a.rating_count, 0) AS rating_count
+	FROM %[1]s.recipes r
+	LEFT JOIN (
+		SELECT recipe_id, AVG(score)::float8 AS average_score, COUNT(*) AS rating_count
+		FROM %[1]s.ratings WHERE deleted_at IS NULL GROUP BY recipe_id
+	) a ON a.recipe_id = r.id
+) rated`, schemaName)
+
+// ratingSummaries returns the rating summary of each recipe in ids, computed
+// in one aggregate query. Recipes without live ratings are absent from the
+// map.
+func ratingSummaries(ctx context.Context, q querier, ids []string) (map[string]model.RatingSummary, error) {
+	summaries := make(map[string]model.RatingSummary, len(ids))
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+	rows, err := q.Query(ctx,
+		fmt.Sprintf(`SELECT recipe_id, AVG(score)::float8, COUNT(*)
+		 FROM %s.ratings WHERE recipe_id = ANY($1) AND deleted_at IS NULL
+		 GROUP BY recipe_id`, schemaName), ids)
+	if err != nil {
+		return nil, fmt.Errorf("summarize ratings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var sum model.RatingSummary
+		if err := rows.Scan(&id, &sum.AverageScore, &sum.RatingCount); err != nil {
+			return nil, fmt.Errorf("scan rating summary: %w", err)
+		}
+		summaries[id] = sum
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("summarize ratings: %w", err)
+	}
+	return summaries, nil
+}
+
+// attachRatingSummaries sets the rating summary of each recipe.
+func attachRatingSummaries(ctx context.Context, q querier, recipes []model.Recipe) error {
+	ids := make([]string, len(recipes))
+	for i, r := range recipes {
+		ids[i] = r.ID
+	}
+	summaries, err := ratingSummaries(ctx, q, ids)
+	if err != nil {
+		return err
+	}
+	for i := range recipes {
+		sum := summaries[recipes[i].ID]
+		recipes[i].RatingSummary = &sum
+	}
+	return nil
+}
+
+// ratingHistogram returns the number of live ratings of a recipe at each
+// score, along with the summary derived from those counts. Only one row per
+// score leaves the database, however many ratings the recipe has.
+func ratingHistogram(ctx context.Context, q querier, recipeID string) (map[int]int, model.RatingSummary, error) {
+	histogram := make(map[int]int, model.MaxScore)
+	for score := model.MinScore; score <= model.MaxScore; score++ {
+		histogram[score] = 0
+	}
+	var sum model.RatingSummary
+
+	rows, err := q.Query(ctx,
+		fmt.Sprintf(`SELECT score, COUNT(*) FROM %s.ratings
+		 WHERE recipe_id = $1 AND deleted_at IS NULL GROUP BY score`, schemaName), recipeID)
+	if err != nil {
+		return nil, sum, fmt.Errorf("rating histogram: %w", err)
+	}
+	defer rows.Close()
+
+	total := 0
+	for rows.Next() {
+		var score, count int
+		if err := rows.Scan(&score, &count); err != nil {
+			return nil, sum, fmt.Errorf("scan rating histogram: %w", err)
+		}
+		histogram[score] = count
+		total += score * count
+		sum.RatingCount += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, sum, fmt.Errorf("rating histogram: %w", err)
+	}
+	if sum.RatingCount > 0 {
+		sum.AverageScore = float64(total) / float64(sum.RatingCount)
+	}
+	return histogram, sum, nil
+}
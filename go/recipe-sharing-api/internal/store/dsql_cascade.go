@@ -0,0 +1,436 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/pgerr"
+	"github.com/jackc/pgx/v5"
+)
+
+// Amazon Aurora DSQL limits a transaction to 3,000 modified rows and 10 MiB
+// of data. Cascading deletes, restores, and purges run in batches well under
+// both. Each changed row also writes an audit event holding up to two copies
+// of it, and recipes and ratings carry free text, so batches are kept small
+// enough that even long rows fit in 10 MiB.
+const (
+	chefBatch       = 500
+	ratingBatch     = 500
+	recipeBatch     = 50
+	membershipBatch = 500
+	followBatch     = 500
+)
+
+// restoreMembershipBatch is the number of hidden collection items restored
+// per transaction. Restoring an item renumbers its collection, which can
+// move up to model.MaxCollectionItems rows.
+const restoreMembershipBatch = 5
+
+// deletedNow returns the deleted_at timestamp for a new soft delete. It is
+// truncated to the microsecond precision of TIMESTAMPTZ so that a cascade's
+// rows can later be matched exactly against the parent's deleted_at.
+func deletedNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// setDeletedAt soft-deletes a live row of t, or restores a deleted one when
+// deletedAt is nil, and records the change in the audit log in the same
+// transaction. It does nothing if the row is missing or already in the
+// requested state.
+func setDeletedAt[T any](ctx context.Context, s *DSQLStore, t auditTable[T], id string, deletedAt *time.Time) error {
+	action, match := model.ActionDelete, "deleted_at IS NULL"
+	if deletedAt == nil {
+		action, match = model.ActionRestore, "deleted_at IS NOT NULL"
+	}
+	return s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		var v T
+		row := tx.QueryRow(ctx,
+			fmt.Sprintf(`SELECT %s FROM %s.%s WHERE id = $1 AND %s`, t.columns, schemaName, t.name, match), id)
+		err := t.scan(row, &v)
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("get %s: %w", t.entity, err)
+		}
+		if t.load != nil {
+			if err := t.load(ctx, tx, &v); err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec(ctx,
+			fmt.Sprintf(`UPDATE %s.%s SET %s WHERE id = $2`, schemaName, t.name, t.softDeleteSet("$1", deletedAt == nil)),
+			deletedAt, id)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", action, t.entity, err)
+		}
+		after := v
+		_, field := t.key(&after)
+		*field = deletedAt
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, t.entity, id, action, &v, &after, time.Now().UTC()))
+	})
+}
+
+// DeleteChef soft-deletes a chef, their recipes, the ratings they wrote, and
+// the ratings other chefs left on their recipes.
+//
+// The chef row is marked first so the chef disappears immediately, and its
+// deleted_at is then stamped on every row of the cascade, in batches that
+// each commit in their own transaction. If a batch fails, calling
+// DeleteChef again reuses the chef's deleted_at and finishes the cascade;
+// RestoreChef relies on the shared timestamp to undo exactly this cascade.
+// The returned counts cover only the rows deleted by this call.
+func (s *DSQLStore) DeleteChef(ctx context.Context, id string) (*model.ChefDeletion, error) {
+	var result model.ChefDeletion
+	var marker *time.Time
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		marker = nil
+		var deletedAt *time.Time
+		err := tx.QueryRow(ctx,
+			fmt.Sprintf(`SELECT deleted_at FROM %s.chefs WHERE id = $1`, schemaName), id).Scan(&deletedAt)
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("get chef: %w", err)
+		}
+		if deletedAt != nil {
+			marker = deletedAt
+			return nil
+		}
+		now := deletedNow()
+		var after model.Chef
+		row := tx.QueryRow(ctx,
+			fmt.Sprintf(`UPDATE %s.chefs SET deleted_at = $1, email_key = NULL WHERE id = $2 RETURNING %s`,
+				schemaName, chefColumns), now, id)
+		if err := scanChef(row, &after); err != nil {
+			return fmt.Errorf("delete chef: %w", err)
+		}
+		before := after
+		before.DeletedAt = nil
+		marker = &now
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityChef, id, model.ActionDelete, &before, &after, now))
+	})
+	if err != nil || marker == nil {
+		return &result, err
+	}
+	result.Deleted = true
+
+	recipes, err := markBatched(ctx, s, recipeTable, "chef_id = $2", id, nil, marker, recipeBatch)
+	result.Recipes += len(recipes)
+	if err != nil {
+		return &result, err
+	}
+	if err := s.hideMemberships(ctx, "r.chef_id = $2", id, *marker); err != nil {
+		return &result, err
+	}
+	result.Ingredients, err = s.countIngredients(ctx, recipes)
+	if err != nil {
+		return &result, err
+	}
+	ratings, err := markBatched(ctx, s, ratingTable, "chef_id = $2", id, nil, marker, ratingBatch)
+	result.Ratings += len(ratings)
+	if err != nil {
+		return &result, err
+	}
+	ratings, err = markBatched(ctx, s, ratingTable, onChefRecipes, id, nil, marker, ratingBatch)
+	result.Ratings += len(ratings)
+	return &result, err
+}
+
+// onChefRecipes matches the ratings on the recipes of the chef whose ID is $2.
+var onChefRecipes = fmt.Sprintf(`recipe_id IN (SELECT id FROM %s.recipes WHERE chef_id = $2)`, schemaName)
+
+// countIngredients returns the number of ingredient lines of the recipes,
+// counted a batch of recipes at a time.
+func (s *DSQLStore) countIngredients(ctx context.Context, recipeIDs []string) (int, error) {
+	total := 0
+	for batch := range slices.Chunk(recipeIDs, recipeBatch) {
+		var n int
+		err := s.db.QueryRow(ctx,
+			fmt.Sprintf(`SELECT count(*) FROM %s.recipe_ingredients WHERE recipe_id = ANY($1)`, schemaName),
+			batch).Scan(&n)
+		if err != nil {
+			return total, fmt.Errorf("count ingredients: %w", err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// RestoreChef undoes DeleteChef. Only rows deleted by the chef's cascade,
+// which share the chef's deleted_at, are restored; recipes and ratings
+// deleted separately beforehand stay deleted. The chef row is restored last
+// so an interrupted restore can be retried. If another chef has taken the
+// chef's email in the meantime, nothing is restored and the returned error
+// wraps ErrConflict.
+func (s *DSQLStore) RestoreChef(ctx context.Context, id string) (*model.Chef, error) {
+	chef, err := s.GetChef(IncludeDeleted(ctx), id)
+	if err != nil || chef == nil || chef.DeletedAt == nil {
+		return chef, err
+	}
+	taken, err := emailTaken(ctx, s.db, model.EmailKey(chef.Email), id)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, errEmailTaken
+	}
+
+	if err := s.restoreMemberships(ctx, "r.chef_id = $2", id, *chef.DeletedAt); err != nil {
+		return nil, err
+	}
+	if _, err := markBatched(ctx, s, recipeTable, "chef_id = $2", id, chef.DeletedAt, nil, recipeBatch); err != nil {
+		return nil, err
+	}
+	if _, err := markBatched(ctx, s, ratingTable, "chef_id = $2", id, chef.DeletedAt, nil, ratingBatch); err != nil {
+		return nil, err
+	}
+	if _, err := markBatched(ctx, s, ratingTable, onChefRecipes, id, chef.DeletedAt, nil, ratingBatch); err != nil {
+		return nil, err
+	}
+	err = s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx,
+			fmt.Sprintf(`UPDATE %s.chefs SET deleted_at = NULL, email_key = $1 WHERE id = $2 AND deleted_at = $3`, schemaName),
+			model.EmailKey(chef.Email), id, *chef.DeletedAt)
+		if err != nil {
+			return fmt.Errorf("restore chef: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+		after := *chef
+		after.DeletedAt = nil
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityChef, id, model.ActionRestore, chef, &after, time.Now().UTC()))
+	})
+	if pgerr.IsUniqueViolation(err) {
+		return nil, errEmailTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	chef.DeletedAt = nil
+	return chef, nil
+}
+
+// markBatched sets deleted_at to to on rows of t matching where, whose
+// parameter $2 is value, and whose deleted_at equals from, one batch per
+// transaction, and returns the IDs of the rows changed. A nil from matches
+// live rows, and a nil to restores the rows instead of deleting them. Each
+// changed row gets a delete or restore audit event in the same transaction.
+func markBatched[T any](ctx context.Context, s *DSQLStore, t auditTable[T], where, value string, from, to *time.Time, batch int) ([]string, error) {
+	match := "deleted_at IS NULL"
+	args := []any{to, value, batch}
+	if from != nil {
+		match = "deleted_at = $4"
+		args = append(args, *from)
+	}
+	action := model.ActionDelete
+	if to == nil {
+		action = model.ActionRestore
+	}
+	query := fmt.Sprintf(`UPDATE %[1]s.%[2]s SET %[6]s WHERE id IN (
+		SELECT id FROM %[1]s.%[2]s WHERE %[3]s AND %[4]s LIMIT $3)
+		RETURNING %[5]s`, schemaName, t.name, where, match, t.columns, t.softDeleteSet("$1", to == nil))
+
+	var ids []string
+	for {
+		var batchIDs []string
+		err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+			rows, err := tx.Query(ctx, query, args...)
+			if err != nil {
+				return fmt.Errorf("update %s deleted_at: %w", t.name, err)
+			}
+			changed, err := collectReturning(rows, t)
+			if err != nil {
+				return fmt.Errorf("update %s deleted_at: %w", t.name, err)
+			}
+			batchIDs = make([]string, len(changed))
+			now := time.Now().UTC()
+			events := make([]model.AuditEvent, len(changed))
+			for i := range changed {
+				before := changed[i]
+				id, deletedAt := t.key(&before)
+				*deletedAt = from
+				batchIDs[i] = id
+				events[i] = newAuditEvent(ctx, t.entity, id, action, &before, &changed[i], now)
+			}
+			return insertAuditEvents(ctx, tx, events...)
+		})
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, batchIDs...)
+		if len(batchIDs) < batch {
+			return ids, nil
+		}
+	}
+}
+
+// PurgeDeleted permanently removes rows soft-deleted before the cutoff.
+// Purged recipes take their ingredient lines, tag links, collection
+// memberships, images, revisions, and all their ratings with them, and
+// purged chefs take their collections and follows. Each batch commits in
+// its own transaction and children are removed before parents, so an
+// interrupted purge leaves no orphans and the next run continues where it
+// stopped.
+func (s *DSQLStore) PurgeDeleted(ctx context.Context, before time.Time) (*model.PurgeResult, error) {
+	var result model.PurgeResult
+
+	for {
+		ids, err := s.purgeableRecipeIDs(ctx, before)
+		if err != nil {
+			return &result, err
+		}
+		for _, id := range ids {
+			n, err := deleteBatched(ctx, s, ratingTable, "recipe_id = $1", id, ratingBatch)
+			result.Ratings += n
+			if err != nil {
+				return &result, err
+			}
+			if _, err := s.deleteMemberships(ctx, id); err != nil {
+				return &result, err
+			}
+			n, err = s.deleteRecipeImages(ctx, id)
+			result.Images += n
+			if err != nil {
+				return &result, err
+			}
+			n, err = s.deleteRevisions(ctx, id)
+			result.Revisions += n
+			if err != nil {
+				return &result, err
+			}
+			n, err = s.deleteRecipeRow(ctx, id)
+			if err != nil {
+				return &result, err
+			}
+			result.Recipes++
+			result.Ingredients += n
+		}
+		if len(ids) < recipeBatch {
+			break
+		}
+	}
+
+	n, err := deleteBatched(ctx, s, ratingTable, "deleted_at < $1", before, ratingBatch)
+	result.Ratings += n
+	if err != nil {
+		return &result, err
+	}
+	n, err = s.purgeChefCollections(ctx, before)
+	result.Collections += n
+	if err != nil {
+		return &result, err
+	}
+	n, err = s.deleteFollows(ctx, before)
+	result.Follows += n
+	if err != nil {
+		return &result, err
+	}
+	n, err = deleteBatched(ctx, s, chefTable, "deleted_at < $1", before, chefBatch)
+	result.Chefs += n
+	return &result, err
+}
+
+// purgeableRecipeIDs returns up to one batch of IDs of recipes soft-deleted
+// before the cutoff.
+func (s *DSQLStore) purgeableRecipeIDs(ctx context.Context, before time.Time) ([]string, error) {
+	rows, err := s.db.Query(ctx,
+		fmt.Sprintf(`SELECT id FROM %s.recipes WHERE deleted_at < $1 LIMIT $2`, schemaName), before, recipeBatch)
+	if err != nil {
+		return nil, fmt.Errorf("list purgeable recipes: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("scan purgeable recipes: %w", err)
+	}
+	return ids, nil
+}
+
+// deleteBatched deletes rows of t matching where, whose single parameter is
+// arg, one batch per transaction, and returns how many were deleted. Each
+// deleted row gets a purge audit event in the same transaction.
+func deleteBatched[T any](ctx context.Context, s *DSQLStore, t auditTable[T], where string, arg any, batch int) (int, error) {
+	query := fmt.Sprintf(`DELETE FROM %[1]s.%[2]s WHERE id IN (
+		SELECT id FROM %[1]s.%[2]s WHERE %[3]s LIMIT $2)
+		RETURNING %[4]s`, schemaName, t.name, where, t.columns)
+
+	total := 0
+	for {
+		var n int
+		err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+			rows, err := tx.Query(ctx, query, arg, batch)
+			if err != nil {
+				return fmt.Errorf("delete %s: %w", t.name, err)
+			}
+			deleted, err := collectReturning(rows, t)
+			if err != nil {
+				return fmt.Errorf("delete %s: %w", t.name, err)
+			}
+			n = len(deleted)
+			now := time.Now().UTC()
+			events := make([]model.AuditEvent, n)
+			for i := range deleted {
+				id, _ := t.key(&deleted[i])
+				events[i] = newAuditEvent(ctx, t.entity, id, model.ActionPurge, &deleted[i], nil, now)
+			}
+			return insertAuditEvents(ctx, tx, events...)
+		})
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < batch {
+			return total, nil
+		}
+	}
+}
+
+// deleteRecipeRow deletes a recipe with its ingredient lines and tag links
+// in one transaction, with a purge audit event, and returns the number of
+// ingredient lines deleted. A recipe has at most model.MaxIngredients lines
+// and model.MaxRecipeTags tags, so this stays within the limits.
+func (s *DSQLStore) deleteRecipeRow(ctx context.Context, id string) (int, error) {
+	var n int
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		list, err := listIngredients(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		n = len(list)
+		if err := deleteIngredients(ctx, tx, id); err != nil {
+			return err
+		}
+		tags, err := recipeTags(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := deleteRecipeTags(ctx, tx, id); err != nil {
+			return err
+		}
+		var r model.Recipe
+		row := tx.QueryRow(ctx,
+			fmt.Sprintf(`DELETE FROM %s.recipes WHERE id = $1 RETURNING %s`, schemaName, recipeColumns), id)
+		err = scanRecipe(row, &r)
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("delete recipe: %w", err)
+		}
+		r.IngredientList, r.Tags = list, tagSlugs(tags)
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityRecipe, id, model.ActionPurge, &r, nil, time.Now().UTC()))
+	})
+	return n, err
+}
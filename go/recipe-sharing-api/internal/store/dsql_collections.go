@@ -0,0 +1,602 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/pgerr"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const collectionColumns = `id, chef_id, name, description, created_at, updated_at`
+
+// scanCollection scans a row selected with collectionColumns.
+func scanCollection(row pgx.Row, c *model.Collection) error {
+	return row.Scan(&c.ID, &c.ChefID, &c.Name, &c.Description, &c.CreatedAt, &c.UpdatedAt)
+}
+
+// ListCollections returns one page of a chef's collections from Amazon
+// Aurora DSQL, newest first.
+func (s *DSQLStore) ListCollections(ctx context.Context, chefID string, page model.PageRequest) ([]model.Collection, string, error) {
+	where, suffix, args := keysetClause(page, 2)
+	rows, err := s.db.Query(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s.collections WHERE chef_id = $1`, collectionColumns, schemaName)+where+suffix,
+		append([]any{chefID}, args...)...)
+	if err != nil {
+		return nil, "", fmt.Errorf("list collections: %w", err)
+	}
+	defer rows.Close()
+
+	var collections []model.Collection
+	for rows.Next() {
+		var c model.Collection
+		if err := scanCollection(rows, &c); err != nil {
+			return nil, "", fmt.Errorf("scan collection: %w", err)
+		}
+		collections = append(collections, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("list collections: %w", err)
+	}
+	collections, next := trimPage(collections, page.EffectiveLimit(), collectionCursor)
+	return collections, next, nil
+}
+
+func collectionCursor(c model.Collection) model.Cursor {
+	return model.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+}
+
+// getCollection returns a collection by ID, or nil if there is none.
+func getCollection(ctx context.Context, q querier, id string) (*model.Collection, error) {
+	var c model.Collection
+	err := scanCollection(q.QueryRow(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s.collections WHERE id = $1`, collectionColumns, schemaName), id), &c)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get collection: %w", err)
+	}
+	return &c, nil
+}
+
+// GetCollection returns a collection with the items of live recipes from
+// Amazon Aurora DSQL, numbered from 1, or nil if not found.
+func (s *DSQLStore) GetCollection(ctx context.Context, id string) (*model.CollectionWithItems, error) {
+	c, err := getCollection(ctx, s.db, id)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(ctx,
+		fmt.Sprintf(`SELECT ci.collection_id, ci.recipe_id, ci.position, r.title, ci.added_at
+		 FROM %[1]s.collection_items ci JOIN %[1]s.recipes r ON r.id = ci.recipe_id
+		 WHERE ci.collection_id = $1 AND ci.deleted_at IS NULL AND r.deleted_at IS NULL
+		 ORDER BY ci.position, ci.added_at`, schemaName), id)
+	if err != nil {
+		return nil, fmt.Errorf("list collection items: %w", err)
+	}
+	items, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CollectionItem, error) {
+		var it model.CollectionItem
+		err := row.Scan(&it.CollectionID, &it.RecipeID, &it.Position, &it.Title, &it.AddedAt)
+		return it, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan collection items: %w", err)
+	}
+	if items == nil {
+		items = []model.CollectionItem{}
+	}
+	for i := range items {
+		items[i].Position = i + 1
+	}
+	return &model.CollectionWithItems{Collection: *c, Items: items}, nil
+}
+
+// CreateCollection inserts a new collection into Amazon Aurora DSQL with a
+// generated UUID.
+func (s *DSQLStore) CreateCollection(ctx context.Context, input model.CreateCollectionInput) (*model.Collection, error) {
+	now := time.Now().UTC()
+	c := model.Collection{
+		ID:          uuid.New().String(),
+		ChefID:      input.ChefID,
+		Name:        input.Name,
+		Description: input.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			fmt.Sprintf(`INSERT INTO %s.collections (%s) VALUES ($1, $2, $3, $4, $5, $6)`, schemaName, collectionColumns),
+			c.ID, c.ChefID, c.Name, c.Description, c.CreatedAt, c.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("create collection: %w", err)
+		}
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityCollection, c.ID, model.ActionCreate, nil, &c, now))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// UpdateCollection applies partial updates to a collection in Amazon Aurora
+// DSQL, or returns nil if not found.
+func (s *DSQLStore) UpdateCollection(ctx context.Context, id string, input model.UpdateCollectionInput) (*model.Collection, error) {
+	var collection *model.Collection
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		c, err := getCollection(ctx, tx, id)
+		if err != nil || c == nil {
+			collection = nil
+			return err
+		}
+		before := *c
+		if input.Name != nil {
+			c.Name = *input.Name
+		}
+		if input.Description != nil {
+			c.Description = *input.Description
+		}
+		c.UpdatedAt = time.Now().UTC()
+		_, err = tx.Exec(ctx,
+			fmt.Sprintf(`UPDATE %s.collections SET name = $1, description = $2, updated_at = $3 WHERE id = $4`, schemaName),
+			c.Name, c.Description, c.UpdatedAt, id)
+		if err != nil {
+			return fmt.Errorf("update collection: %w", err)
+		}
+		collection = c
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityCollection, id, model.ActionUpdate, &before, c, c.UpdatedAt))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// DeleteCollection permanently deletes a collection and its items from
+// Amazon Aurora DSQL in one transaction, and reports whether it existed. A
+// collection has at most model.MaxCollectionItems items, so this stays
+// within the limits.
+func (s *DSQLStore) DeleteCollection(ctx context.Context, id string) (bool, error) {
+	var deleted bool
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		c, err := getCollection(ctx, tx, id)
+		if err != nil || c == nil {
+			deleted = false
+			return err
+		}
+		items, err := collectionItems(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx,
+			fmt.Sprintf(`DELETE FROM %s.collection_items WHERE collection_id = $1`, schemaName), id); err != nil {
+			return fmt.Errorf("delete collection items: %w", err)
+		}
+		if _, err := tx.Exec(ctx,
+			fmt.Sprintf(`DELETE FROM %s.collections WHERE id = $1`, schemaName), id); err != nil {
+			return fmt.Errorf("delete collection: %w", err)
+		}
+		deleted = true
+		snapshot := model.CollectionWithItems{Collection: *c, Items: items}
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityCollection, id, model.ActionDelete, &snapshot, nil, time.Now().UTC()))
+	})
+	return deleted, err
+}
+
+// collectionItems returns the items of live recipes in a collection in
+// order, numbered from 1. Items hidden with a deleted recipe keep their
+// stored position so that a restored recipe returns to its old place.
+func collectionItems(ctx context.Context, q querier, collectionID string) ([]model.CollectionItem, error) {
+	return queryItems(ctx, q, "r.deleted_at IS NULL", collectionID)
+}
+
+// queryItems returns the visible items of a collection whose recipes match
+// recipeMatch, in order and numbered from 1. The collection ID is $1.
+func queryItems(ctx context.Context, q querier, recipeMatch string, args ...any) ([]model.CollectionItem, error) {
+	rows, err := q.Query(ctx,
+		fmt.Sprintf(`SELECT ci.collection_id, ci.recipe_id, ci.position, ci.added_at
+		 FROM %[1]s.collection_items ci JOIN %[1]s.recipes r ON r.id = ci.recipe_id
+		 WHERE ci.collection_id = $1 AND ci.deleted_at IS NULL AND %[2]s
+		 ORDER BY ci.position, ci.added_at`, schemaName, recipeMatch), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list collection items: %w", err)
+	}
+	items, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CollectionItem, error) {
+		var it model.CollectionItem
+		err := row.Scan(&it.CollectionID, &it.RecipeID, &it.Position, &it.AddedAt)
+		return it, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan collection items: %w", err)
+	}
+	for i := range items {
+		items[i].Position = i + 1
+	}
+	return items, nil
+}
+
+// renumberItems sets the positions of a collection's items to 1, 2, 3, ...
+// in the order of items, in one statement. Rows already in place, and rows
+// not in items, are left untouched.
+func renumberItems(ctx context.Context, q querier, collectionID string, items []model.CollectionItem) error {
+	ids := make([]string, len(items))
+	for i, it := range items {
+		ids[i] = it.RecipeID
+	}
+	_, err := q.Exec(ctx,
+		fmt.Sprintf(`UPDATE %s.collection_items SET position = array_position($2::text[], recipe_id)
+		 WHERE collection_id = $1 AND position <> array_position($2::text[], recipe_id)`, schemaName),
+		collectionID, ids)
+	if err != nil {
+		return fmt.Errorf("reorder collection items: %w", err)
+	}
+	return nil
+}
+
+// placeAt clamps a requested 1-based position to the n available places,
+// treating 0 as the last place.
+func placeAt(position, n int) int {
+	if position == 0 || position > n {
+		return n
+	}
+	return position
+}
+
+// AddCollectionItem adds a recipe to a collection in Amazon Aurora DSQL and
+// returns the new item, or nil if the collection does not exist. It returns
+// an error wrapping ErrConflict if the recipe is already in the collection,
+// and ErrCollectionFull if the collection holds model.MaxCollectionItems.
+func (s *DSQLStore) AddCollectionItem(ctx context.Context, collectionID string, input model.AddCollectionItemInput) (*model.CollectionItem, error) {
+	var item *model.CollectionItem
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		item = nil
+		c, err := getCollection(ctx, tx, collectionID)
+		if err != nil || c == nil {
+			return err
+		}
+		items, err := collectionItems(ctx, tx, collectionID)
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(items, func(it model.CollectionItem) bool { return it.RecipeID == input.RecipeID }) {
+			return errInCollection
+		}
+		if len(items) >= model.MaxCollectionItems {
+			return ErrCollectionFull
+		}
+
+		pos := placeAt(input.Position, len(items)+1)
+		it := model.CollectionItem{
+			CollectionID: collectionID,
+			RecipeID:     input.RecipeID,
+			Position:     pos,
+			AddedAt:      time.Now().UTC(),
+		}
+		_, err = tx.Exec(ctx,
+			fmt.Sprintf(`INSERT INTO %s.collection_items (collection_id, recipe_id, chef_id, position, added_at)
+			 VALUES ($1, $2, $3, $4, $5)`, schemaName),
+			it.CollectionID, it.RecipeID, c.ChefID, it.Position, it.AddedAt)
+		if err != nil {
+			return fmt.Errorf("add collection item: %w", err)
+		}
+		if err := renumberItems(ctx, tx, collectionID, slices.Insert(items, pos-1, it)); err != nil {
+			return err
+		}
+		item = &it
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityCollectionItem, collectionID, model.ActionCreate, nil, &it, it.AddedAt))
+	})
+	if pgerr.IsUniqueViolation(err) {
+		return nil, errInCollection
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// MoveCollectionItem moves a recipe to a new position in a collection in
+// Amazon Aurora DSQL, shifting the items in between, and returns the moved
+// item, or nil if the recipe is not in the collection.
+func (s *DSQLStore) MoveCollectionItem(ctx context.Context, collectionID, recipeID string, position int) (*model.CollectionItem, error) {
+	var item *model.CollectionItem
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		item = nil
+		items, err := collectionItems(ctx, tx, collectionID)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(items, func(it model.CollectionItem) bool { return it.RecipeID == recipeID })
+		if i < 0 {
+			return nil
+		}
+		before := items[i]
+		it := before
+		it.Position = placeAt(position, len(items))
+		items = slices.Insert(slices.Delete(items, i, i+1), it.Position-1, it)
+		if err := renumberItems(ctx, tx, collectionID, items); err != nil {
+			return err
+		}
+		item = &it
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityCollectionItem, collectionID, model.ActionUpdate, &before, &it, time.Now().UTC()))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// RemoveCollectionItem removes a recipe from a collection in Amazon Aurora
+// DSQL, closing the gap it leaves, and reports whether it was there.
+func (s *DSQLStore) RemoveCollectionItem(ctx context.Context, collectionID, recipeID string) (bool, error) {
+	var removed bool
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		it := model.CollectionItem{CollectionID: collectionID, RecipeID: recipeID}
+		err := tx.QueryRow(ctx,
+			fmt.Sprintf(`DELETE FROM %s.collection_items
+			 WHERE collection_id = $1 AND recipe_id = $2 AND deleted_at IS NULL
+			 RETURNING position, added_at`, schemaName), collectionID, recipeID).
+			Scan(&it.Position, &it.AddedAt)
+		if err == pgx.ErrNoRows {
+			removed = false
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("remove collection item: %w", err)
+		}
+		removed = true
+		items, err := collectionItems(ctx, tx, collectionID)
+		if err != nil {
+			return err
+		}
+		if err := renumberItems(ctx, tx, collectionID, items); err != nil {
+			return err
+		}
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityCollectionItem, collectionID, model.ActionDelete, &it, nil, time.Now().UTC()))
+	})
+	return removed, err
+}
+
+// favoritedCounts returns the number of live chefs who have saved each
+// recipe in ids to at least one collection, computed in one aggregate query.
+// Recipes saved by no one are absent from the map.
+func favoritedCounts(ctx context.Context, q querier, ids []string) (map[string]int, error) {
+	counts := make(map[string]int, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+	rows, err := q.Query(ctx,
+		fmt.Sprintf(`SELECT ci.recipe_id, COUNT(DISTINCT ci.chef_id)
+		 FROM %[1]s.collection_items ci JOIN %[1]s.chefs c ON c.id = ci.chef_id
+		 WHERE ci.recipe_id = ANY($1) AND ci.deleted_at IS NULL AND c.deleted_at IS NULL
+		 GROUP BY ci.recipe_id`, schemaName), ids)
+	if err != nil {
+		return nil, fmt.Errorf("count favorites: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, fmt.Errorf("scan favorite count: %w", err)
+		}
+		counts[id] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("count favorites: %w", err)
+	}
+	return counts, nil
+}
+
+// attachFavoritedCounts sets the favorited count of each recipe.
+func attachFavoritedCounts(ctx context.Context, q querier, recipes []model.Recipe) error {
+	ids := make([]string, len(recipes))
+	for i, r := range recipes {
+		ids[i] = r.ID
+	}
+	counts, err := favoritedCounts(ctx, q, ids)
+	if err != nil {
+		return err
+	}
+	for i := range recipes {
+		n := counts[recipes[i].ID]
+		recipes[i].FavoritedCount = &n
+	}
+	return nil
+}
+
+// hideMemberships hides the collection items of the recipes matching where,
+// whose parameter $2 is value, that were deleted at deletedAt, one batch
+// per transaction. Each item is stamped with the recipe's deleted_at, so it
+// no longer counts toward its collection's size or takes up a position but
+// keeps its stored position for restoreMemberships. Items are hidden after
+// their recipes are deleted; until then the recipe's deleted_at already
+// hides them from reads.
+func (s *DSQLStore) hideMemberships(ctx context.Context, where, value string, deletedAt time.Time) error {
+	query := fmt.Sprintf(`UPDATE %[1]s.collection_items SET deleted_at = $1 WHERE (collection_id, recipe_id) IN (
+		SELECT ci.collection_id, ci.recipe_id FROM %[1]s.collection_items ci JOIN %[1]s.recipes r ON r.id = ci.recipe_id
+		 WHERE %[2]s AND r.deleted_at = $1 AND ci.deleted_at IS NULL LIMIT $3)`, schemaName, where)
+	for {
+		var n int64
+		err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+			tag, err := tx.Exec(ctx, query, deletedAt, value, membershipBatch)
+			if err != nil {
+				return fmt.Errorf("hide collection items: %w", err)
+			}
+			n = tag.RowsAffected()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if n < membershipBatch {
+			return nil
+		}
+	}
+}
+
+// restoreMemberships brings back the collection items hidden at deletedAt
+// with the recipes matching where, whose parameter $2 is value, one batch
+// per transaction. It runs before the recipes are restored, so each item is
+// reinserted among the live items at its stored position, clamped to the
+// end, and its collection renumbered, as MemoryStore does. An item whose
+// collection has filled up in the meantime is removed instead, with a
+// delete audit event under its collection's ID.
+func (s *DSQLStore) restoreMemberships(ctx context.Context, where, value string, deletedAt time.Time) error {
+	query := fmt.Sprintf(`SELECT ci.collection_id, ci.recipe_id, ci.position, ci.added_at
+		 FROM %[1]s.collection_items ci JOIN %[1]s.recipes r ON r.id = ci.recipe_id
+		 WHERE %[2]s AND r.deleted_at = $1 AND ci.deleted_at = $1
+		 ORDER BY ci.collection_id, ci.position, ci.added_at LIMIT $3`, schemaName, where)
+	for {
+		var n int
+		err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+			rows, err := tx.Query(ctx, query, deletedAt, value, restoreMembershipBatch)
+			if err != nil {
+				return fmt.Errorf("list hidden collection items: %w", err)
+			}
+			hidden, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CollectionItem, error) {
+				var it model.CollectionItem
+				err := row.Scan(&it.CollectionID, &it.RecipeID, &it.Position, &it.AddedAt)
+				return it, err
+			})
+			if err != nil {
+				return fmt.Errorf("list hidden collection items: %w", err)
+			}
+			n = len(hidden)
+			var events []model.AuditEvent
+			for _, it := range hidden {
+				dropped, err := restoreMembership(ctx, tx, it, deletedAt)
+				if err != nil {
+					return err
+				}
+				if dropped {
+					events = append(events,
+						newAuditEvent(ctx, model.EntityCollectionItem, it.CollectionID, model.ActionDelete, &it, nil, time.Now().UTC()))
+				}
+			}
+			return insertAuditEvents(ctx, tx, events...)
+		})
+		if err != nil {
+			return err
+		}
+		if n < restoreMembershipBatch {
+			return nil
+		}
+	}
+}
+
+// restoreMembership brings back one collection item hidden at deletedAt and
+// reports whether it was removed instead because its collection is full.
+// Items already brought back for recipes still deleted at deletedAt count
+// as live, since they are restored together.
+func restoreMembership(ctx context.Context, q querier, it model.CollectionItem, deletedAt time.Time) (bool, error) {
+	items, err := queryItems(ctx, q, "(r.deleted_at IS NULL OR r.deleted_at = $2)", it.CollectionID, deletedAt)
+	if err != nil {
+		return false, err
+	}
+	if len(items) >= model.MaxCollectionItems {
+		_, err := q.Exec(ctx,
+			fmt.Sprintf(`DELETE FROM %s.collection_items WHERE collection_id = $1 AND recipe_id = $2`, schemaName),
+			it.CollectionID, it.RecipeID)
+		if err != nil {
+			return false, fmt.Errorf("remove collection item: %w", err)
+		}
+		return true, nil
+	}
+	_, err = q.Exec(ctx,
+		fmt.Sprintf(`UPDATE %s.collection_items SET deleted_at = NULL WHERE collection_id = $1 AND recipe_id = $2`, schemaName),
+		it.CollectionID, it.RecipeID)
+	if err != nil {
+		return false, fmt.Errorf("restore collection item: %w", err)
+	}
+	it.Position = placeAt(it.Position, len(items)+1)
+	return false, renumberItems(ctx, q, it.CollectionID, slices.Insert(items, it.Position-1, it))
+}
+
+// deleteMemberships removes a recipe from every collection, one batch per
+// transaction, and returns how many memberships were removed. Each gets a
+// purge audit event under its collection's ID. The items that remain keep
+// their order; the gaps left behind are closed the next time each
+// collection is reordered.
+func (s *DSQLStore) deleteMemberships(ctx context.Context, recipeID string) (int, error) {
+	query := fmt.Sprintf(`DELETE FROM %[1]s.collection_items WHERE (collection_id, recipe_id) IN (
+		SELECT collection_id, recipe_id FROM %[1]s.collection_items WHERE recipe_id = $1 LIMIT $2)
+		RETURNING collection_id, recipe_id, position, added_at`, schemaName)
+
+	total := 0
+	for {
+		var n int
+		err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+			rows, err := tx.Query(ctx, query, recipeID, membershipBatch)
+			if err != nil {
+				return fmt.Errorf("delete collection items: %w", err)
+			}
+			items, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CollectionItem, error) {
+				var it model.CollectionItem
+				err := row.Scan(&it.CollectionID, &it.RecipeID, &it.Position, &it.AddedAt)
+				return it, err
+			})
+			if err != nil {
+				return fmt.Errorf("delete collection items: %w", err)
+			}
+			n = len(items)
+			now := time.Now().UTC()
+			events := make([]model.AuditEvent, n)
+			for i := range items {
+				events[i] = newAuditEvent(ctx, model.EntityCollectionItem, items[i].CollectionID, model.ActionPurge, &items[i], nil, now)
+			}
+			return insertAuditEvents(ctx, tx, events...)
+		})
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < membershipBatch {
+			return total, nil
+		}
+	}
+}
+
+// purgeChefCollections deletes the collections of chefs soft-deleted before
+// the cutoff, one collection per transaction, and returns how many were
+// deleted.
+func (s *DSQLStore) purgeChefCollections(ctx context.Context, before time.Time) (int, error) {
+	total := 0
+	for {
+		rows, err := s.db.Query(ctx,
+			fmt.Sprintf(`SELECT id FROM %[1]s.collections WHERE chef_id IN (
+				SELECT id FROM %[1]s.chefs WHERE deleted_at < $1) LIMIT $2`, schemaName), before, recipeBatch)
+		if err != nil {
+			return total, fmt.Errorf("list purgeable collections: %w", err)
+		}
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return total, fmt.Errorf("scan purgeable collections: %w", err)
+		}
+		for _, id := range ids {
+			deleted, err := s.DeleteCollection(ctx, id)
+			if err != nil {
+				return total, err
+			}
+			if deleted {
+				total++
+			}
+		}
+		if len(ids) < recipeBatch {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/pgerr"
+)
+
+// emailTaken reports whether a chef other than id holds the email key.
+// The unique index on email_key is what guarantees uniqueness between
+// concurrent writers; this check gives a clear error in the common case and
+// covers the window while the asynchronous index is still being built.
+func emailTaken(ctx context.Context, q querier, key, id string) (bool, error) {
+	var taken bool
+	err := q.QueryRow(ctx,
+		fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s.chefs WHERE email_key = $1 AND id <> $2)`, schemaName),
+		key, id).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("check chef email: %w", err)
+	}
+	return taken, nil
+}
+
+// BackfillEmailKeys sets email_key on live chefs created before emails were
+// unique. Chefs are visited in ID order, batchSize at a time, and each is
+// updated in its own transaction. A chef whose email duplicates one already
+// claimed is left without a key, to be resolved by hand; it does not stop
+// the backfill. It returns the number of chefs updated and the IDs of the
+// duplicates found.
+func (s *DSQLStore) BackfillEmailKeys(ctx context.Context, batchSize int) (updated int, duplicates []string, err error) {
+	lastID := ""
+	for {
+		rows, err := s.db.Query(ctx,
+			fmt.Sprintf(`SELECT id, email FROM %s.chefs
+			 WHERE id > $1 AND email_key IS NULL AND deleted_at IS NULL ORDER BY id LIMIT %d`, schemaName, batchSize), lastID)
+		if err != nil {
+			return updated, duplicates, fmt.Errorf("backfill email keys: %w", err)
+		}
+		type legacy struct{ id, email string }
+		var batch []legacy
+		for rows.Next() {
+			var l legacy
+			if err := rows.Scan(&l.id, &l.email); err != nil {
+				rows.Close()
+				return updated, duplicates, fmt.Errorf("scan chef: %w", err)
+			}
+			batch = append(batch, l)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return updated, duplicates, fmt.Errorf("backfill email keys: %w", err)
+		}
+		if len(batch) == 0 {
+			return updated, duplicates, nil
+		}
+
+		for _, l := range batch {
+			key := model.EmailKey(l.email)
+			taken, err := emailTaken(ctx, s.db, key, l.id)
+			if err == nil && !taken {
+				_, err = s.db.Exec(ctx,
+					fmt.Sprintf(`UPDATE %s.chefs SET email_key = $1 WHERE id = $2 AND email_key IS NULL`, schemaName),
+					key, l.id)
+				taken = pgerr.IsUniqueViolation(err)
+			}
+			switch {
+			case taken:
+				duplicates = append(duplicates, l.id)
+			case err != nil:
+				return updated, duplicates, fmt.Errorf("backfill chef %s: %w", l.id, err)
+			default:
+				updated++
+			}
+		}
+		lastID = batch[len(batch)-1].id
+	}
+}
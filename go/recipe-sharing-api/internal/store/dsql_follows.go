@@ -0,0 +1,220 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/pgerr"
+	"github.com/jackc/pgx/v5"
+)
+
+// Follow records in Amazon Aurora DSQL that followerID follows followeeID,
+// and reports whether the follow is new. Following a chef twice is not an
+// error.
+func (s *DSQLStore) Follow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	var created bool
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		var exists bool
+		err := tx.QueryRow(ctx,
+			fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s.follows WHERE follower_id = $1 AND followee_id = $2)`, schemaName),
+			followerID, followeeID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("check follow: %w", err)
+		}
+		if created = !exists; !created {
+			return nil
+		}
+		f := model.Follow{FollowerID: followerID, FolloweeID: followeeID, CreatedAt: time.Now().UTC()}
+		_, err = tx.Exec(ctx,
+			fmt.Sprintf(`INSERT INTO %s.follows (follower_id, followee_id, created_at) VALUES ($1, $2, $3)`, schemaName),
+			f.FollowerID, f.FolloweeID, f.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("follow chef: %w", err)
+		}
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityFollow, followerID, model.ActionCreate, nil, &f, f.CreatedAt))
+	})
+	if pgerr.IsUniqueViolation(err) {
+		// A concurrent request created the same follow first.
+		return false, nil
+	}
+	return created, err
+}
+
+// Unfollow removes a follow from Amazon Aurora DSQL and reports whether it
+// existed.
+func (s *DSQLStore) Unfollow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	var removed bool
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		f := model.Follow{FollowerID: followerID, FolloweeID: followeeID}
+		err := tx.QueryRow(ctx,
+			fmt.Sprintf(`DELETE FROM %s.follows WHERE follower_id = $1 AND followee_id = $2 RETURNING created_at`, schemaName),
+			followerID, followeeID).Scan(&f.CreatedAt)
+		if err == pgx.ErrNoRows {
+			removed = false
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unfollow chef: %w", err)
+		}
+		removed = true
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityFollow, followerID, model.ActionDelete, &f, nil, time.Now().UTC()))
+	})
+	return removed, err
+}
+
+// ListFollowers returns one page of the live chefs who follow a chef, most
+// recent follow first.
+func (s *DSQLStore) ListFollowers(ctx context.Context, chefID string, page model.PageRequest) ([]model.FollowedChef, string, error) {
+	return s.listFollows(ctx, "followee_id", "follower_id", chefID, page)
+}
+
+// ListFollowing returns one page of the live chefs a chef follows, most
+// recent follow first.
+func (s *DSQLStore) ListFollowing(ctx context.Context, chefID string, page model.PageRequest) ([]model.FollowedChef, string, error) {
+	return s.listFollows(ctx, "follower_id", "followee_id", chefID, page)
+}
+
+// listFollows lists the chefs on the other side of chefID's follows, where
+// side is the follows column holding chefID and other the column holding
+// the chefs to list. The follow time is exposed as followed_at so that the
+// chef columns keep their names.
+func (s *DSQLStore) listFollows(ctx context.Context, side, other, chefID string, page model.PageRequest) ([]model.FollowedChef, string, error) {
+	query := fmt.Sprintf(`SELECT %[1]s, followed_at FROM (
+		SELECT c.*, f.created_at AS followed_at
+		FROM %[2]s.follows f JOIN %[2]s.chefs c ON c.id = f.%[4]s
+		WHERE f.%[3]s = $1 AND c.deleted_at IS NULL
+	) followed`, chefColumns, schemaName, side, other)
+	args := []any{chefID}
+	if page.Cursor != nil {
+		query += " WHERE (followed_at, id) < ($2, $3)"
+		args = append(args, page.Cursor.CreatedAt, page.Cursor.ID)
+	}
+	query += fmt.Sprintf(" ORDER BY followed_at DESC, id DESC LIMIT %d", page.EffectiveLimit()+1)
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("list follows: %w", err)
+	}
+	defer rows.Close()
+
+	var chefs []model.FollowedChef
+	for rows.Next() {
+		var c model.FollowedChef
+		if err := scanChef(rows, &c.Chef, &c.FollowedAt); err != nil {
+			return nil, "", fmt.Errorf("scan followed chef: %w", err)
+		}
+		chefs = append(chefs, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("list follows: %w", err)
+	}
+	chefs, next := trimPage(chefs, page.EffectiveLimit(), followCursor)
+	return chefs, next, nil
+}
+
+func followCursor(c model.FollowedChef) model.Cursor {
+	return model.Cursor{CreatedAt: c.FollowedAt, ID: c.ID}
+}
+
+// Feed returns one page of the published, live recipes of the chefs that
+// chefID follows from Amazon Aurora DSQL, most recently published first,
+// each with its rating summary and favorited count. Pages are keyed by
+// (published_at, id), which idx_recipes_chef_published answers for each
+// followed chef. Recipes published before published_at existed are left
+// out until the backfill sets it.
+func (s *DSQLStore) Feed(ctx context.Context, chefID string, page model.PageRequest) ([]model.Recipe, string, error) {
+	args := []any{chefID, model.StatusPublished}
+	var where string
+	if page.Cursor != nil {
+		where = " AND (published_at, id) < ($3, $4)"
+		args = append(args, page.Cursor.CreatedAt, page.Cursor.ID)
+	}
+	rows, err := s.db.Query(ctx,
+		fmt.Sprintf(`SELECT %[1]s FROM %[2]s.recipes
+		 WHERE chef_id IN (SELECT followee_id FROM %[2]s.follows WHERE follower_id = $1)
+		 AND status = $2 AND published_at IS NOT NULL AND deleted_at IS NULL`, recipeColumns, schemaName)+
+			where+fmt.Sprintf(" ORDER BY published_at DESC, id DESC LIMIT %d", page.EffectiveLimit()+1),
+		args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("list feed: %w", err)
+	}
+	defer rows.Close()
+
+	var recipes []model.Recipe
+	for rows.Next() {
+		var r model.Recipe
+		if err := scanRecipe(rows, &r); err != nil {
+			return nil, "", fmt.Errorf("scan recipe: %w", err)
+		}
+		recipes = append(recipes, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("list feed: %w", err)
+	}
+	recipes, next := trimPage(recipes, page.EffectiveLimit(), feedCursor)
+	if err := attachRatingSummaries(ctx, s.db, recipes); err != nil {
+		return nil, "", err
+	}
+	if err := attachFavoritedCounts(ctx, s.db, recipes); err != nil {
+		return nil, "", err
+	}
+	return recipes, next, nil
+}
+
+// feedCursor keys a feed recipe by (published_at, id), carrying published_at
+// in the cursor's CreatedAt. The recipe must have been published.
+func feedCursor(r model.Recipe) model.Cursor {
+	return model.Cursor{CreatedAt: *r.PublishedAt, ID: r.ID}
+}
+
+// deleteFollows removes the follows of chefs soft-deleted before the
+// cutoff, in either direction, one batch per transaction, and returns how
+// many were removed. Each gets a purge audit event under the follower's ID.
+func (s *DSQLStore) deleteFollows(ctx context.Context, before time.Time) (int, error) {
+	query := fmt.Sprintf(`DELETE FROM %[1]s.follows WHERE (follower_id, followee_id) IN (
+		SELECT follower_id, followee_id FROM %[1]s.follows
+		WHERE follower_id IN (SELECT id FROM %[1]s.chefs WHERE deleted_at < $1)
+		   OR followee_id IN (SELECT id FROM %[1]s.chefs WHERE deleted_at < $1)
+		LIMIT $2)
+		RETURNING follower_id, followee_id, created_at`, schemaName)
+
+	total := 0
+	for {
+		var n int
+		err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+			rows, err := tx.Query(ctx, query, before, followBatch)
+			if err != nil {
+				return fmt.Errorf("delete follows: %w", err)
+			}
+			follows, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Follow, error) {
+				var f model.Follow
+				err := row.Scan(&f.FollowerID, &f.FolloweeID, &f.CreatedAt)
+				return f, err
+			})
+			if err != nil {
+				return fmt.Errorf("delete follows: %w", err)
+			}
+			n = len(follows)
+			now := time.Now().UTC()
+			events := make([]model.AuditEvent, n)
+			for i := range follows {
+				events[i] = newAuditEvent(ctx, model.EntityFollow, follows[i].FollowerID, model.ActionPurge, &follows[i], nil, now)
+			}
+			return insertAuditEvents(ctx, tx, events...)
+		})
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < followBatch {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// newFork builds a draft copy of a recipe for a chef, with its own ID,
+// timestamps, and version, and pointing back at the recipe it copies.
+func newFork(src model.Recipe, chefID string, now time.Time) model.Recipe {
+	fork := src
+	fork.ID = uuid.New().String()
+	fork.ChefID = chefID
+	fork.Status = model.StatusDraft
+	fork.ForkedFromID = &src.ID
+	fork.IngredientList = slices.Clone(src.IngredientList)
+	fork.Tags = slices.Clone(src.Tags)
+	fork.CreatedAt, fork.UpdatedAt = now, now
+	fork.Version = 1
+	fork.DeletedAt = nil
+	fork.PublishedAt, fork.PublishAt = nil, nil
+	fork.RatingSummary, fork.FavoritedCount, fork.ForkCount = nil, nil, nil
+	return fork
+}
+
+// ForkRecipe copies a live, published recipe into a new draft owned by
+// chefID in Amazon Aurora DSQL and returns the copy, or nil if the recipe
+// does not exist. The recipe, its ingredient lines, and its tags are read
+// and copied in one transaction with OCC retry, so the fork is a consistent
+// snapshot of the original even while it is being edited. Images are not
+// copied. It returns ErrNotPublished if the recipe is not published.
+func (s *DSQLStore) ForkRecipe(ctx context.Context, id, chefID string) (*model.Recipe, error) {
+	var fork *model.Recipe
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		fork = nil
+		var src model.Recipe
+		err := scanRecipe(tx.QueryRow(ctx,
+			fmt.Sprintf(`SELECT %s FROM %s.recipes WHERE id = $1 AND deleted_at IS NULL`, recipeColumns, schemaName), id), &src)
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("get recipe: %w", err)
+		}
+		if src.Status != model.StatusPublished {
+			return ErrNotPublished
+		}
+		if src.IngredientList, err = listIngredients(ctx, tx, id); err != nil {
+			return err
+		}
+		tags, err := recipeTags(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		src.Tags = tagSlugs(tags)
+
+		now := time.Now().UTC()
+		r := newFork(src, chefID, now)
+		if err := insertRecipe(ctx, tx, &r); err != nil {
+			return err
+		}
+		events := []model.AuditEvent{newAuditEvent(ctx, model.EntityRecipe, r.ID, model.ActionCreate, nil, &r, now)}
+		for _, tag := range tags {
+			link := model.RecipeTag{RecipeID: r.ID, TagID: tag.ID, Slug: tag.Slug, CreatedAt: now}
+			_, err := tx.Exec(ctx,
+				fmt.Sprintf(`INSERT INTO %s.recipe_tags (recipe_id, tag_id, created_at) VALUES ($1, $2, $3)`, schemaName),
+				link.RecipeID, link.TagID, link.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("tag recipe: %w", err)
+			}
+			events = append(events, newAuditEvent(ctx, model.EntityRecipeTag, r.ID, model.ActionCreate, nil, &link, now))
+		}
+		if err := insertAuditEvents(ctx, tx, events...); err != nil {
+			return err
+		}
+		fork = &r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fork, nil
+}
+
+// RecipeLineage returns the fork history of a recipe from Amazon Aurora
+// DSQL, or nil if the recipe does not exist. Ancestors are read one at a
+// time by primary key, following forked_from_id; descendants are one page
+// of the recipe's direct forks, found through idx_recipes_forked_from.
+// Every recipe listed carries its rating summary and fork count.
+func (s *DSQLStore) RecipeLineage(ctx context.Context, id string, page model.PageRequest) (*model.Lineage, error) {
+	var recipe model.Recipe
+	err := scanRecipe(s.db.QueryRow(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s.recipes WHERE id = $1`, recipeColumns, schemaName)+liveOnly(ctx), id), &recipe)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get recipe: %w", err)
+	}
+
+	lineage := &model.Lineage{RecipeID: id, Ancestors: []model.Recipe{}}
+	seen := map[string]bool{id: true}
+	for parent := recipe.ForkedFromID; parent != nil && !seen[*parent] && len(lineage.Ancestors) < model.MaxLineageDepth; {
+		var a model.Recipe
+		err := scanRecipe(s.db.QueryRow(ctx,
+			fmt.Sprintf(`SELECT %s FROM %s.recipes WHERE id = $1`, recipeColumns, schemaName)+liveOnly(ctx), *parent), &a)
+		if err == pgx.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get ancestor: %w", err)
+		}
+		seen[a.ID] = true
+		lineage.Ancestors = append(lineage.Ancestors, a)
+		parent = a.ForkedFromID
+	}
+
+	where, suffix, args := keysetClause(page, 2)
+	rows, err := s.db.Query(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s.recipes WHERE forked_from_id = $1`, recipeColumns, schemaName)+liveOnly(ctx)+where+suffix,
+		append([]any{id}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("list forks: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r model.Recipe
+		if err := scanRecipe(rows, &r); err != nil {
+			return nil, fmt.Errorf("scan recipe: %w", err)
+		}
+		lineage.Descendants = append(lineage.Descendants, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list forks: %w", err)
+	}
+	lineage.Descendants, lineage.NextCursor = trimPage(lineage.Descendants, page.EffectiveLimit(), recipeCursor)
+	if lineage.Descendants == nil {
+		lineage.Descendants = []model.Recipe{}
+	}
+
+	for _, list := range [][]model.Recipe{lineage.Ancestors, lineage.Descendants} {
+		if err := attachRatingSummaries(ctx, s.db, list); err != nil {
+			return nil, err
+		}
+		if err := attachForkCounts(ctx, s.db, list); err != nil {
+			return nil, err
+		}
+	}
+	return lineage, nil
+}
+
+// forkCounts returns the number of live forks of each recipe in ids,
+// computed in one aggregate query. Recipes with no forks are absent from
+// the map.
+func forkCounts(ctx context.Context, q querier, ids []string) (map[string]int, error) {
+	counts := make(map[string]int, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+	rows, err := q.Query(ctx,
+		fmt.Sprintf(`SELECT forked_from_id, COUNT(*) FROM %s.recipes
+		 WHERE forked_from_id = ANY($1) AND deleted_at IS NULL
+		 GROUP BY forked_from_id`, schemaName), ids)
+	if err != nil {
+		return nil, fmt.Errorf("count forks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, fmt.Errorf("scan fork count: %w", err)
+		}
+		counts[id] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("count forks: %w", err)
+	}
+	return counts, nil
+}
+
+// attachForkCounts sets the fork count of each recipe.
+func attachForkCounts(ctx context.Context, q querier, recipes []model.Recipe) error {
+	ids := make([]string, len(recipes))
+	for i, r := range recipes {
+		ids[i] = r.ID
+	}
+	counts, err := forkCounts(ctx, q, ids)
+	if err != nil {
+		return err
+	}
+	for i := range recipes {
+		n := counts[recipes[i].ID]
+		recipes[i].ForkCount = &n
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+const imageColumns = `id, recipe_id, content_type, size_bytes, width, height, blob_key, thumbnail_key, thumbnail_content_type, created_at`
+
+// scanImage scans a row selected with imageColumns.
+func scanImage(row pgx.Row, img *model.RecipeImage) error {
+	return row.Scan(&img.ID, &img.RecipeID, &img.ContentType, &img.SizeBytes, &img.Width, &img.Height,
+		&img.BlobKey, &img.ThumbnailKey, &img.ThumbnailContentType, &img.CreatedAt)
+}
+
+// SetBlobStore sets the blob store holding recipe images, from which
+// PurgeDeleted removes the images of purged recipes. Without one, purged
+// recipes' image rows are removed but their blobs are left in place.
+func (s *DSQLStore) SetBlobStore(b BlobStore) {
+	s.blobs = b
+}
+
+// ListRecipeImages returns the images of a recipe from Amazon Aurora DSQL in
+// upload order.
+func (s *DSQLStore) ListRecipeImages(ctx context.Context, recipeID string) ([]model.RecipeImage, error) {
+	return listImages(ctx, s.db, recipeID)
+}
+
+func listImages(ctx context.Context, q querier, recipeID string) ([]model.RecipeImage, error) {
+	rows, err := q.Query(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s.recipe_images WHERE recipe_id = $1 ORDER BY created_at, id`, imageColumns, schemaName),
+		recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("list recipe images: %w", err)
+	}
+	images, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.RecipeImage, error) {
+		var img model.RecipeImage
+		err := scanImage(row, &img)
+		return img, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list recipe images: %w", err)
+	}
+	return images, nil
+}
+
+// GetRecipeImage returns one image of a recipe from Amazon Aurora DSQL, or
+// nil if not found.
+func (s *DSQLStore) GetRecipeImage(ctx context.Context, recipeID, imageID string) (*model.RecipeImage, error) {
+	var img model.RecipeImage
+	err := scanImage(s.db.QueryRow(ctx,
+		fmt.Sprintf(`SELECT %s FROM %s.recipe_images WHERE id = $1 AND recipe_id = $2`, imageColumns, schemaName),
+		imageID, recipeID), &img)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get recipe image: %w", err)
+	}
+	return &img, nil
+}
+
+// CreateRecipeImage records the metadata of an image whose blobs have
+// already been stored. It returns ErrTooManyImages if the recipe already
+// has model.MaxRecipeImages images.
+func (s *DSQLStore) CreateRecipeImage(ctx context.Context, img model.RecipeImage) (*model.RecipeImage, error) {
+	img.CreatedAt = time.Now().UTC()
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		var n int
+		err := tx.QueryRow(ctx,
+			fmt.Sprintf(`SELECT COUNT(*) FROM %s.recipe_images WHERE recipe_id = $1`, schemaName), img.RecipeID).Scan(&n)
+		if err != nil {
+			return fmt.Errorf("count recipe images: %w", err)
+		}
+		if n >= model.MaxRecipeImages {
+			return ErrTooManyImages
+		}
+		_, err = tx.Exec(ctx,
+			fmt.Sprintf(`INSERT INTO %s.recipe_images (%s) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`, schemaName, imageColumns),
+			img.ID, img.RecipeID, img.ContentType, img.SizeBytes, img.Width, img.Height,
+			img.BlobKey, img.ThumbnailKey, img.ThumbnailContentType, img.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("create recipe image: %w", err)
+		}
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityRecipeImage, img.RecipeID, model.ActionCreate, nil, &img, img.CreatedAt))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+// deleteRecipeImages removes the images of a purged recipe and returns how
+// many there were. The blobs go first, so that an interrupted purge still
+// finds the rows and retries them; deleting a missing blob is not an error.
+func (s *DSQLStore) deleteRecipeImages(ctx context.Context, recipeID string) (int, error) {
+	images, err := listImages(ctx, s.db, recipeID)
+	if err != nil || len(images) == 0 {
+		return 0, err
+	}
+	if err := deleteImageBlobs(ctx, s.blobs, images); err != nil {
+		return 0, err
+	}
+	err = s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			fmt.Sprintf(`DELETE FROM %s.recipe_images WHERE recipe_id = $1`, schemaName), recipeID)
+		if err != nil {
+			return fmt.Errorf("delete recipe images: %w", err)
+		}
+		now := time.Now().UTC()
+		events := make([]model.AuditEvent, len(images))
+		for i := range images {
+			events[i] = newAuditEvent(ctx, model.EntityRecipeImage, recipeID, model.ActionPurge, &images[i], nil, now)
+		}
+		return insertAuditEvents(ctx, tx, events...)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(images), nil
+}
+
+// deleteImageBlobs removes the image and thumbnail blobs of images from b,
+// if there is one.
+func deleteImageBlobs(ctx context.Context, b BlobStore, images []model.RecipeImage) error {
+	if b == nil {
+		return nil
+	}
+	for _, img := range images {
+		for _, key := range []string{img.BlobKey, img.ThumbnailKey} {
+			if err := b.Delete(ctx, key); err != nil {
+				return fmt.Errorf("delete image %s: %w", img.ID, err)
+			}
+		}
+	}
+	return nil
+}
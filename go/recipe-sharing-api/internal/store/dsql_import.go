@@ -0,0 +1,109 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// Imported recipes are written in chunks that stay well inside the Amazon
+// Aurora DSQL limits of 3,000 modified rows and 10 MiB per transaction.
+// Each recipe writes its own row, one row per ingredient line, and an audit
+// event holding another copy of the recipe, so chunks are bounded by rows
+// and by an estimate of the bytes written as well as by count.
+const (
+	importRowBudget  = 2500
+	importByteBudget = 4 << 20
+	exportBatch      = 500
+)
+
+// CreateRecipes inserts new recipes into Amazon Aurora DSQL in input order,
+// in as few transactions as the limits allow. Each chunk commits on its
+// own; if one fails, CreateRecipes returns the recipes committed so far,
+// which are always a prefix of the input, along with the error.
+func (s *DSQLStore) CreateRecipes(ctx context.Context, inputs []model.CreateRecipeInput) ([]model.Recipe, error) {
+	recipes := make([]model.Recipe, len(inputs))
+	for i, input := range inputs {
+		recipes[i] = newRecipe(input, time.Now().UTC())
+	}
+	for done := 0; done < len(recipes); {
+		chunk := recipes[done : done+importChunk(recipes[done:])]
+		err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+			events := make([]model.AuditEvent, len(chunk))
+			for i := range chunk {
+				if err := insertRecipe(ctx, tx, &chunk[i]); err != nil {
+					return err
+				}
+				events[i] = newAuditEvent(ctx, model.EntityRecipe, chunk[i].ID, model.ActionCreate, nil, &chunk[i], chunk[i].CreatedAt)
+			}
+			return insertAuditEvents(ctx, tx, events...)
+		})
+		if err != nil {
+			return recipes[:done], fmt.Errorf("import recipes: %w", err)
+		}
+		done += len(chunk)
+	}
+	return recipes, nil
+}
+
+// importChunk returns how many of the leading recipes fit in one import
+// transaction. It is always at least one.
+func importChunk(recipes []model.Recipe) int {
+	rows, size := 0, 0
+	for i, r := range recipes {
+		rows += 2 + len(r.IngredientList)
+		// The row and the audit event's copy, with room for the JSON and
+		// structured ingredient overhead.
+		size += 3 * (len(r.Title) + len(r.Description) + len(r.Ingredients) + len(r.Instructions) + len(r.Cuisine) + 512)
+		if i > 0 && (i == recipeBatch || rows > importRowBudget || size > importByteBudget) {
+			return i
+		}
+	}
+	return len(recipes)
+}
+
+// ExportRecipes calls fn for every live recipe in Amazon Aurora DSQL, oldest
+// first, stopping at the first error. Recipes are read in keyset-paginated
+// batches rather than one long query, so memory use stays flat and no
+// statement outlives the Aurora DSQL transaction time limit.
+func (s *DSQLStore) ExportRecipes(ctx context.Context, fn func(model.Recipe) error) error {
+	var after *model.Cursor
+	for {
+		query := fmt.Sprintf(`SELECT %s FROM %s.recipes WHERE deleted_at IS NULL`, recipeColumns, schemaName)
+		args := []any{}
+		if after != nil {
+			query += " AND (created_at, id) > ($1, $2)"
+			args = append(args, after.CreatedAt, after.ID)
+		}
+		query += fmt.Sprintf(" ORDER BY created_at, id LIMIT %d", exportBatch)
+
+		rows, err := s.db.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("export recipes: %w", err)
+		}
+		batch, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Recipe, error) {
+			var r model.Recipe
+			err := scanRecipe(row, &r)
+			return r, err
+		})
+		if err != nil {
+			return fmt.Errorf("export recipes: %w", err)
+		}
+		for _, r := range batch {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		if len(batch) < exportBatch {
+			return nil
+		}
+		last := batch[len(batch)-1]
+		after = &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
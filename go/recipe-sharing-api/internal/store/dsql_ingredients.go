@@ -0,0 +1,144 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/ingredients"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// querier is satisfied by both occretry.DB and pgx.Tx, so helpers can run
+// inside or outside a transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// listIngredients returns the structured ingredient lines of a recipe in position order.
+func listIngredients(ctx context.Context, q querier, recipeID string) ([]model.Ingredient, error) {
+	rows, err := q.Query(ctx,
+		fmt.Sprintf(`SELECT position, name, quantity, unit, note
+		 FROM %s.recipe_ingredients WHERE recipe_id = $1 ORDER BY position`, schemaName), recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("list ingredients: %w", err)
+	}
+	defer rows.Close()
+
+	var list []model.Ingredient
+	for rows.Next() {
+		var ing model.Ingredient
+		if err := rows.Scan(&ing.Position, &ing.Name, &ing.Quantity, &ing.Unit, &ing.Note); err != nil {
+			return nil, fmt.Errorf("scan ingredient: %w", err)
+		}
+		list = append(list, ing)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list ingredients: %w", err)
+	}
+	return list, nil
+}
+
+// insertIngredients writes a recipe's ingredient lines in a single
+// multi-row INSERT.
+func insertIngredients(ctx context.Context, q querier, recipeID string, list []model.Ingredient) error {
+	if len(list) == 0 {
+		return nil
+	}
+	values := make([]string, len(list))
+	args := make([]any, 0, len(list)*6)
+	for i, ing := range list {
+		n := i * 6
+		values[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6)
+		args = append(args, recipeID, ing.Position, ing.Name, ing.Quantity, ing.Unit, ing.Note)
+	}
+	_, err := q.Exec(ctx,
+		fmt.Sprintf(`INSERT INTO %s.recipe_ingredients (recipe_id, position, name, quantity, unit, note)
+		 VALUES `, schemaName)+strings.Join(values, ", "), args...)
+	if err != nil {
+		return fmt.Errorf("insert ingredients: %w", err)
+	}
+	return nil
+}
+
+// deleteIngredients removes all ingredient lines of a recipe.
+func deleteIngredients(ctx context.Context, q querier, recipeID string) error {
+	_, err := q.Exec(ctx,
+		fmt.Sprintf(`DELETE FROM %s.recipe_ingredients WHERE recipe_id = $1`, schemaName), recipeID)
+	if err != nil {
+		return fmt.Errorf("delete ingredients: %w", err)
+	}
+	return nil
+}
+
+// BackfillIngredients parses the free-text ingredients of recipes created
+// before structured ingredients existed and writes them as recipe_ingredients
+// rows. Recipes are visited in ID order, batchSize at a time, and each
+// recipe is converted in its own transaction, so the backfill stays well
+// under Amazon Aurora DSQL transaction limits and can be safely re-run.
+// It returns the number of recipes converted.
+func (s *DSQLStore) BackfillIngredients(ctx context.Context, batchSize int) (int, error) {
+	converted := 0
+	lastID := ""
+	for {
+		rows, err := s.db.Query(ctx,
+			fmt.Sprintf(`SELECT id, ingredients FROM %s.recipes
+			 WHERE id > $1 ORDER BY id LIMIT %d`, schemaName, batchSize), lastID)
+		if err != nil {
+			return converted, fmt.Errorf("backfill ingredients: %w", err)
+		}
+		type legacy struct{ id, text string }
+		var batch []legacy
+		for rows.Next() {
+			var l legacy
+			if err := rows.Scan(&l.id, &l.text); err != nil {
+				rows.Close()
+				return converted, fmt.Errorf("scan recipe: %w", err)
+			}
+			batch = append(batch, l)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return converted, fmt.Errorf("backfill ingredients: %w", err)
+		}
+		if len(batch) == 0 {
+			return converted, nil
+		}
+
+		for _, l := range batch {
+			list := ingredients.Parse(l.text)
+			if len(list) == 0 {
+				continue
+			}
+			var wrote bool
+			err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+				wrote = false
+				var exists bool
+				if err := tx.QueryRow(ctx,
+					fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s.recipe_ingredients WHERE recipe_id = $1)`, schemaName),
+					l.id).Scan(&exists); err != nil {
+					return fmt.Errorf("check ingredients: %w", err)
+				}
+				if exists {
+					return nil
+				}
+				wrote = true
+				return insertIngredients(ctx, tx, l.id, list)
+			})
+			if err != nil {
+				return converted, fmt.Errorf("backfill recipe %s: %w", l.id, err)
+			}
+			if wrote {
+				converted++
+			}
+		}
+		lastID = batch[len(batch)-1].id
+	}
+}
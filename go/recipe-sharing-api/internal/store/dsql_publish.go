@@ -0,0 +1,181 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// publishBatch is the number of due drafts PublishDue reads at a time.
+const publishBatch = 100
+
+// changeStatus moves a recipe to a new status, keeping its publishing
+// timestamps in step: publishing records when, unpublishing clears it, and
+// any change of status cancels a scheduled publish. The caller checks that
+// the change is allowed.
+func changeStatus(r *model.Recipe, to string, now time.Time) {
+	if to == r.Status {
+		return
+	}
+	switch to {
+	case model.StatusPublished:
+		r.PublishedAt = &now
+	case model.StatusDraft:
+		r.PublishedAt = nil
+	}
+	r.PublishAt = nil
+	r.Status = to
+}
+
+// applyTransition applies a named transition to a recipe. Publishing a
+// draft with a publishAt in the future schedules it instead of publishing
+// it, and unpublishing a scheduled draft cancels the schedule. It returns an
+// error wrapping ErrInvalidTransition if the recipe's status does not allow
+// the transition.
+func applyTransition(r *model.Recipe, transition string, publishAt *time.Time, now time.Time) error {
+	switch {
+	case transition == model.TransitionPublish && publishAt != nil && publishAt.After(now):
+		if r.Status != model.StatusDraft {
+			return fmt.Errorf("%w: only a draft can be scheduled for publishing", ErrInvalidTransition)
+		}
+		at := publishAt.UTC()
+		r.PublishAt = &at
+		return nil
+	case transition == model.TransitionUnpublish && r.Status == model.StatusDraft && r.PublishAt != nil:
+		r.PublishAt = nil
+		return nil
+	}
+	to, ok := model.NextStatus(r.Status, transition)
+	if !ok {
+		return fmt.Errorf("%w: cannot %s a recipe that is %s", ErrInvalidTransition, transition, r.Status)
+	}
+	changeStatus(r, to, now)
+	return nil
+}
+
+// TransitionRecipe applies a publish, unpublish, or archive transition to a
+// live recipe in Amazon Aurora DSQL and returns the updated recipe, or nil
+// if it does not exist. The check and the write run in one transaction with
+// OCC retry, so two concurrent transitions cannot both start from the same
+// status.
+func (s *DSQLStore) TransitionRecipe(ctx context.Context, id, transition string, publishAt *time.Time, ifVersion *int64) (*model.Recipe, error) {
+	var recipe *model.Recipe
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		r, err := recipeForUpdate(ctx, tx, id, ifVersion)
+		if err != nil || r == nil {
+			return err
+		}
+		before := *r
+		now := time.Now().UTC()
+		if err := applyTransition(r, transition, publishAt, now); err != nil {
+			return err
+		}
+		r.UpdatedAt = now
+		r.Version++
+		if err := updateRecipeRow(ctx, tx, before, *r); err != nil {
+			return err
+		}
+		recipe = r
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityRecipe, id, model.ActionUpdate, &before, r, now))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recipe, nil
+}
+
+// PublishDue publishes the live drafts in Amazon Aurora DSQL whose
+// publish_at is at or before now, earliest first, and returns how many it
+// published. Due drafts are found through idx_recipes_publish_at a batch at
+// a time, and each is published in its own transaction that checks again
+// that it is still a due draft, so sweeps that overlap, or that race an
+// unpublish or an edit, publish each recipe at most once.
+func (s *DSQLStore) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	published := 0
+	for {
+		rows, err := s.db.Query(ctx,
+			fmt.Sprintf(`SELECT id FROM %s.recipes
+			 WHERE publish_at <= $1 AND status = $2 AND deleted_at IS NULL
+			 ORDER BY publish_at, id LIMIT $3`, schemaName),
+			now, model.StatusDraft, publishBatch)
+		if err != nil {
+			return published, fmt.Errorf("list due recipes: %w", err)
+		}
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return published, fmt.Errorf("scan due recipes: %w", err)
+		}
+
+		for _, id := range ids {
+			ok, err := s.publishIfDue(ctx, id, now)
+			if err != nil {
+				return published, fmt.Errorf("publish recipe %s: %w", id, err)
+			}
+			if ok {
+				published++
+			}
+		}
+		if len(ids) < publishBatch {
+			return published, nil
+		}
+	}
+}
+
+// publishIfDue publishes a recipe if it is still a live draft due by now,
+// and reports whether it did.
+func (s *DSQLStore) publishIfDue(ctx context.Context, id string, now time.Time) (bool, error) {
+	var published bool
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		published = false
+		r, err := recipeForUpdate(ctx, tx, id, nil)
+		if err != nil || r == nil || !isDue(*r, now) {
+			return err
+		}
+		before := *r
+		r.UpdatedAt = time.Now().UTC()
+		changeStatus(r, model.StatusPublished, r.UpdatedAt)
+		r.Version++
+		if err := updateRecipeRow(ctx, tx, before, *r); err != nil {
+			return err
+		}
+		published = true
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityRecipe, id, model.ActionUpdate, &before, r, r.UpdatedAt))
+	})
+	return published, err
+}
+
+// isDue reports whether a recipe is a live draft scheduled to be published
+// at or before now.
+func isDue(r model.Recipe, now time.Time) bool {
+	return r.Status == model.StatusDraft && r.DeletedAt == nil && r.PublishAt != nil && !r.PublishAt.After(now)
+}
+
+// BackfillPublishedAt sets published_at to created_at on published recipes
+// written before published_at existed, so that they appear in feeds.
+// Recipes are updated batchSize at a time, each batch in its own
+// transaction, and the backfill can be safely re-run. It returns the number
+// of recipes updated.
+func (s *DSQLStore) BackfillPublishedAt(ctx context.Context, batchSize int) (int, error) {
+	query := fmt.Sprintf(`UPDATE %[1]s.recipes SET published_at = created_at WHERE id IN (
+		SELECT id FROM %[1]s.recipes WHERE status = $1 AND published_at IS NULL LIMIT %[2]d)`, schemaName, batchSize)
+	updated := 0
+	for {
+		tag, err := s.db.Exec(ctx, query, model.StatusPublished)
+		if err != nil {
+			return updated, fmt.Errorf("backfill published_at: %w", err)
+		}
+		n := int(tag.RowsAffected())
+		updated += n
+		if n < batchSize {
+			return updated, nil
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/pgerr"
+)
+
+// BackfillRatingKeys sets live_chef_id on live ratings written before a chef
+// could rate a recipe only once. Ratings are visited in ID order, batchSize
+// at a time, and each is updated in its own statement. A rating whose chef
+// already has a keyed rating on the same recipe is left without a key, to be
+// resolved by hand; it does not stop the backfill. It returns the number of
+// ratings updated and the IDs of the duplicates found.
+func (s *DSQLStore) BackfillRatingKeys(ctx context.Context, batchSize int) (updated int, duplicates []string, err error) {
+	lastID := ""
+	for {
+		rows, err := s.db.Query(ctx,
+			fmt.Sprintf(`SELECT id, recipe_id, chef_id FROM %s.ratings
+			 WHERE id > $1 AND live_chef_id IS NULL AND deleted_at IS NULL ORDER BY id LIMIT %d`, schemaName, batchSize), lastID)
+		if err != nil {
+			return updated, duplicates, fmt.Errorf("backfill rating keys: %w", err)
+		}
+		type legacy struct{ id, recipeID, chefID string }
+		var batch []legacy
+		for rows.Next() {
+			var l legacy
+			if err := rows.Scan(&l.id, &l.recipeID, &l.chefID); err != nil {
+				rows.Close()
+				return updated, duplicates, fmt.Errorf("scan rating: %w", err)
+			}
+			batch = append(batch, l)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return updated, duplicates, fmt.Errorf("backfill rating keys: %w", err)
+		}
+		if len(batch) == 0 {
+			return updated, duplicates, nil
+		}
+
+		for _, l := range batch {
+			var taken bool
+			err := s.db.QueryRow(ctx,
+				fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s.ratings WHERE recipe_id = $1 AND live_chef_id = $2)`, schemaName),
+				l.recipeID, l.chefID).Scan(&taken)
+			if err == nil && !taken {
+				_, err = s.db.Exec(ctx,
+					fmt.Sprintf(`UPDATE %s.ratings SET live_chef_id = chef_id WHERE id = $1 AND live_chef_id IS NULL`, schemaName),
+					l.id)
+				taken = pgerr.IsUniqueViolation(err)
+			}
+			switch {
+			case taken:
+				duplicates = append(duplicates, l.id)
+			case err != nil:
+				return updated, duplicates, fmt.Errorf("backfill rating %s: %w", l.id, err)
+			default:
+				updated++
+			}
+		}
+		lastID = batch[len(batch)-1].id
+	}
+}
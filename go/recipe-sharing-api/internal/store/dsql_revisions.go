@@ -0,0 +1,135 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// revisionBatch is the number of revisions purged per transaction. Each
+// snapshot is a few kilobytes, so a batch stays far below 10 MiB.
+const revisionBatch = 200
+
+// newRevision snapshots a recipe at its current version, replaced at the
+// given time. Tags and read-time counts are not part of the snapshot.
+func newRevision(r model.Recipe, at time.Time) model.RecipeRevision {
+	r.Tags = nil
+	r.RatingSummary, r.FavoritedCount, r.ForkCount = nil, nil, nil
+	return model.RecipeRevision{RecipeID: r.ID, Revision: r.Version, Recipe: r, CreatedAt: at}
+}
+
+// revisionCursor returns the cursor key of a revision. Revisions are listed
+// by revision number, which the cursor carries in Rank.
+func revisionCursor(rev model.RecipeRevision) model.Cursor {
+	return model.Cursor{Rank: int(rev.Revision), CreatedAt: rev.CreatedAt, ID: rev.RecipeID}
+}
+
+// insertRevision writes a revision. It is called with the transaction that
+// replaces the version, so the revision is recorded if and only if the
+// update commits, and a retried update cannot record it twice.
+func insertRevision(ctx context.Context, q querier, rev model.RecipeRevision) error {
+	b, err := json.Marshal(rev.Recipe)
+	if err != nil {
+		return fmt.Errorf("encode revision: %w", err)
+	}
+	_, err = q.Exec(ctx,
+		fmt.Sprintf(`INSERT INTO %s.recipe_revisions (recipe_id, revision, snapshot, created_at)
+		 VALUES ($1, $2, $3, $4)`, schemaName),
+		rev.RecipeID, rev.Revision, string(b), rev.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create revision: %w", err)
+	}
+	return nil
+}
+
+// scanRevision scans a row of recipe_id, revision, snapshot, created_at.
+func scanRevision(row pgx.Row, rev *model.RecipeRevision) error {
+	var snap string
+	if err := row.Scan(&rev.RecipeID, &rev.Revision, &snap, &rev.CreatedAt); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(snap), &rev.Recipe); err != nil {
+		return fmt.Errorf("decode revision %d: %w", rev.Revision, err)
+	}
+	return nil
+}
+
+// ListRecipeRevisions returns one page of the revisions of a recipe from
+// Amazon Aurora DSQL, newest first, through the primary key.
+func (s *DSQLStore) ListRecipeRevisions(ctx context.Context, recipeID string, page model.PageRequest) ([]model.RecipeRevision, string, error) {
+	query := fmt.Sprintf(`SELECT recipe_id, revision, snapshot, created_at FROM %s.recipe_revisions
+	 WHERE recipe_id = $1`, schemaName)
+	args := []any{recipeID}
+	if page.Cursor != nil {
+		query += " AND revision < $2"
+		args = append(args, page.Cursor.Rank)
+	}
+	query += fmt.Sprintf(" ORDER BY revision DESC LIMIT %d", page.EffectiveLimit()+1)
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("list revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []model.RecipeRevision
+	for rows.Next() {
+		var rev model.RecipeRevision
+		if err := scanRevision(rows, &rev); err != nil {
+			return nil, "", fmt.Errorf("scan revision: %w", err)
+		}
+		rev.Recipe.IngredientList = nil
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("list revisions: %w", err)
+	}
+	revisions, next := trimPage(revisions, page.EffectiveLimit(), revisionCursor)
+	return revisions, next, nil
+}
+
+// GetRecipeRevision returns one revision of a recipe from Amazon Aurora
+// DSQL, or nil if it does not exist.
+func (s *DSQLStore) GetRecipeRevision(ctx context.Context, recipeID string, revision int64) (*model.RecipeRevision, error) {
+	var rev model.RecipeRevision
+	row := s.db.QueryRow(ctx,
+		fmt.Sprintf(`SELECT recipe_id, revision, snapshot, created_at FROM %s.recipe_revisions
+		 WHERE recipe_id = $1 AND revision = $2`, schemaName), recipeID, revision)
+	err := scanRevision(row, &rev)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get revision: %w", err)
+	}
+	return &rev, nil
+}
+
+// deleteRevisions deletes the revisions of a purged recipe, one batch per
+// transaction, and returns how many were deleted. Revisions are a history
+// of the recipe rather than entities of their own, so the recipe's purge
+// event stands for them in the audit log.
+func (s *DSQLStore) deleteRevisions(ctx context.Context, recipeID string) (int, error) {
+	query := fmt.Sprintf(`DELETE FROM %[1]s.recipe_revisions WHERE (recipe_id, revision) IN (
+		SELECT recipe_id, revision FROM %[1]s.recipe_revisions WHERE recipe_id = $1 LIMIT $2)`, schemaName)
+
+	total := 0
+	for {
+		tag, err := s.db.Exec(ctx, query, recipeID, revisionBatch)
+		if err != nil {
+			return total, fmt.Errorf("delete revisions: %w", err)
+		}
+		n := int(tag.RowsAffected())
+		total += n
+		if n < revisionBatch {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/pgerr"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ListTags returns one page of tags in slug order from Amazon Aurora DSQL,
+// each with the number of live recipes that carry it.
+func (s *DSQLStore) ListTags(ctx context.Context, page model.PageRequest) ([]model.TagUsage, string, error) {
+	after := ""
+	if page.Cursor != nil {
+		after = page.Cursor.Key
+	}
+	rows, err := s.db.Query(ctx,
+		fmt.Sprintf(`SELECT t.id, t.slug, t.name, t.created_at, COUNT(r.id)
+		 FROM %[1]s.tags t
+		 LEFT JOIN %[1]s.recipe_tags rt ON rt.tag_id = t.id
+		 LEFT JOIN %[1]s.recipes r ON r.id = rt.recipe_id AND r.deleted_at IS NULL
+		 WHERE t.slug > $1
+		 GROUP BY t.id, t.slug, t.name, t.created_at
+		 ORDER BY t.slug LIMIT %[2]d`, schemaName, page.EffectiveLimit()+1), after)
+	if err != nil {
+		return nil, "", fmt.Errorf("list tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []model.TagUsage
+	for rows.Next() {
+		var t model.TagUsage
+		if err := rows.Scan(&t.ID, &t.Slug, &t.Name, &t.CreatedAt, &t.RecipeCount); err != nil {
+			return nil, "", fmt.Errorf("scan tag: %w", err)
+		}
+		tags = append(tags, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("list tags: %w", err)
+	}
+	tags, next := trimPage(tags, page.EffectiveLimit(), tagCursor)
+	return tags, next, nil
+}
+
+// tagCursor returns the cursor key of a tag, which is listed in slug order.
+// Slugs are unique, so the slug alone positions the page.
+func tagCursor(t model.TagUsage) model.Cursor {
+	return model.Cursor{Key: t.Slug, CreatedAt: t.CreatedAt, ID: t.ID}
+}
+
+// recipeTags returns the tags of a recipe in slug order.
+func recipeTags(ctx context.Context, q querier, recipeID string) ([]model.Tag, error) {
+	rows, err := q.Query(ctx,
+		fmt.Sprintf(`SELECT t.id, t.slug, t.name, t.created_at
+		 FROM %[1]s.recipe_tags rt JOIN %[1]s.tags t ON t.id = rt.tag_id
+		 WHERE rt.recipe_id = $1 ORDER BY t.slug`, schemaName), recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("list recipe tags: %w", err)
+	}
+	tags, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Tag, error) {
+		var t model.Tag
+		err := row.Scan(&t.ID, &t.Slug, &t.Name, &t.CreatedAt)
+		return t, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan recipe tags: %w", err)
+	}
+	return tags, nil
+}
+
+// tagSlugs returns the slugs of tags, or nil if there are none.
+func tagSlugs(tags []model.Tag) []string {
+	var slugs []string
+	for _, t := range tags {
+		slugs = append(slugs, t.Slug)
+	}
+	return slugs
+}
+
+// AddRecipeTags tags a recipe in Amazon Aurora DSQL, creating any tags that
+// do not exist yet, and returns the slugs of all the recipe's tags. Names
+// that normalize to a slug the recipe already has are skipped. It returns
+// ErrTooManyTags if the recipe would end up with more than
+// model.MaxRecipeTags tags.
+func (s *DSQLStore) AddRecipeTags(ctx context.Context, recipeID string, names []string) ([]string, error) {
+	slugs, err := s.addRecipeTags(ctx, recipeID, names)
+	if pgerr.IsUniqueViolation(err) {
+		// A concurrent request created one of the tags, or added it to the
+		// recipe, after this one looked for it. Run again to reuse its rows.
+		slugs, err = s.addRecipeTags(ctx, recipeID, names)
+	}
+	return slugs, err
+}
+
+func (s *DSQLStore) addRecipeTags(ctx context.Context, recipeID string, names []string) ([]string, error) {
+	var slugs []string
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		current, err := recipeTags(ctx, tx, recipeID)
+		if err != nil {
+			return err
+		}
+		slugs = tagSlugs(current)
+
+		now := time.Now().UTC()
+		var events []model.AuditEvent
+		for _, name := range names {
+			slug := model.Slugify(name)
+			if slug == "" || slices.Contains(slugs, slug) {
+				continue
+			}
+			if len(slugs) == model.MaxRecipeTags {
+				return ErrTooManyTags
+			}
+			tag, err := tagBySlug(ctx, tx, slug)
+			if err != nil {
+				return err
+			}
+			if tag == nil {
+				tag = &model.Tag{ID: uuid.New().String(), Slug: slug, Name: name, CreatedAt: now}
+				_, err := tx.Exec(ctx,
+					fmt.Sprintf(`INSERT INTO %s.tags (id, slug, name, created_at) VALUES ($1, $2, $3, $4)`, schemaName),
+					tag.ID, tag.Slug, tag.Name, tag.CreatedAt)
+				if err != nil {
+					return fmt.Errorf("create tag: %w", err)
+				}
+				events = append(events, newAuditEvent(ctx, model.EntityTag, tag.ID, model.ActionCreate, nil, tag, now))
+			}
+
+			link := model.RecipeTag{RecipeID: recipeID, TagID: tag.ID, Slug: slug, CreatedAt: now}
+			_, err = tx.Exec(ctx,
+				fmt.Sprintf(`INSERT INTO %s.recipe_tags (recipe_id, tag_id, created_at) VALUES ($1, $2, $3)`, schemaName),
+				link.RecipeID, link.TagID, link.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("tag recipe: %w", err)
+			}
+			events = append(events, newAuditEvent(ctx, model.EntityRecipeTag, recipeID, model.ActionCreate, nil, &link, now))
+			slugs = append(slugs, slug)
+		}
+		slices.Sort(slugs)
+		return insertAuditEvents(ctx, tx, events...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slugs, nil
+}
+
+// tagBySlug returns the tag with a slug, or nil if there is none.
+func tagBySlug(ctx context.Context, q querier, slug string) (*model.Tag, error) {
+	var t model.Tag
+	err := q.QueryRow(ctx,
+		fmt.Sprintf(`SELECT id, slug, name, created_at FROM %s.tags WHERE slug = $1`, schemaName), slug).
+		Scan(&t.ID, &t.Slug, &t.Name, &t.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get tag: %w", err)
+	}
+	return &t, nil
+}
+
+// RemoveRecipeTag removes a tag from a recipe in Amazon Aurora DSQL and
+// reports whether the recipe had it. The tag itself is kept, with one fewer
+// recipe counted against it.
+func (s *DSQLStore) RemoveRecipeTag(ctx context.Context, recipeID, slug string) (bool, error) {
+	var removed bool
+	err := s.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		link := model.RecipeTag{RecipeID: recipeID, Slug: slug}
+		err := tx.QueryRow(ctx,
+			fmt.Sprintf(`DELETE FROM %[1]s.recipe_tags
+			 WHERE recipe_id = $1 AND tag_id = (SELECT id FROM %[1]s.tags WHERE slug = $2)
+			 RETURNING tag_id, created_at`, schemaName), recipeID, slug).
+			Scan(&link.TagID, &link.CreatedAt)
+		if err == pgx.ErrNoRows {
+			removed = false
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("untag recipe: %w", err)
+		}
+		removed = true
+		return insertAuditEvents(ctx, tx,
+			newAuditEvent(ctx, model.EntityRecipeTag, recipeID, model.ActionDelete, &link, nil, time.Now().UTC()))
+	})
+	return removed, err
+}
+
+// deleteRecipeTags removes all tags from a recipe.
+func deleteRecipeTags(ctx context.Context, q querier, recipeID string) error {
+	_, err := q.Exec(ctx,
+		fmt.Sprintf(`DELETE FROM %s.recipe_tags WHERE recipe_id = $1`, schemaName), recipeID)
+	if err != nil {
+		return fmt.Errorf("delete recipe tags: %w", err)
+	}
+	return nil
+}
+
+// tagFilter returns the SQL condition that keeps recipes carrying any or all
+// of the tag slugs in filter, using parameters starting at argIdx, along with
+// its arguments. Each recipe has at most one link to a tag, so counting the
+// matching links tells how many of the requested tags it has.
+func tagFilter(filter model.RecipeFilter, argIdx int) (string, []any) {
+	need := 1
+	if filter.TagMatch == model.TagMatchAll {
+		need = len(filter.Tags)
+	}
+	return fmt.Sprintf(` AND id IN (
+		SELECT rt.recipe_id FROM %[1]s.recipe_tags rt JOIN %[1]s.tags t ON t.id = rt.tag_id
+		WHERE t.slug = ANY($%[2]d) GROUP BY rt.recipe_id HAVING COUNT(*) >= $%[3]d)`, schemaName, argIdx, argIdx+1),
+		[]any{filter.Tags, need}
+}
@@ -0,0 +1,49 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+)
+
+// ErrPreconditionFailed is returned by update operations when the caller
+// supplied an expected version and the stored row has a different one.
+var ErrPreconditionFailed = errors.New("precondition failed: version mismatch")
+
+// ErrTooManyTags is returned when tagging a recipe would give it more than
+// model.MaxRecipeTags tags.
+var ErrTooManyTags = fmt.Errorf("a recipe can have at most %d tags", model.MaxRecipeTags)
+
+// ErrCollectionFull is returned when adding a recipe to a collection that
+// already holds model.MaxCollectionItems recipes.
+var ErrCollectionFull = fmt.Errorf("a collection can hold at most %d recipes", model.MaxCollectionItems)
+
+// ErrTooManyImages is returned when adding an image to a recipe that
+// already has model.MaxRecipeImages images.
+var ErrTooManyImages = fmt.Errorf("a recipe can have at most %d images", model.MaxRecipeImages)
+
+// ErrNotPublished is returned when forking a recipe that is not published.
+var ErrNotPublished = errors.New("only published recipes can be forked")
+
+// ErrInvalidTransition is returned when a recipe's status cannot change
+// as asked from its current status. Errors returned by the store wrap it
+// with a description of the transition.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// ErrConflict is returned when a write would violate a uniqueness rule.
+// Errors returned by the store wrap it with a description of the conflict,
+// so callers should test for it with errors.Is.
+var ErrConflict = errors.New("conflict")
+
+// errEmailTaken is returned when another live chef already uses an email.
+var errEmailTaken = fmt.Errorf("%w: a chef with this email already exists", ErrConflict)
+
+// errRatingExists is returned when a chef already has a live rating on a recipe.
+var errRatingExists = fmt.Errorf("%w: the chef has already rated this recipe", ErrConflict)
+
+// errInCollection is returned when a recipe is already in a collection.
+var errInCollection = fmt.Errorf("%w: the recipe is already in this collection", ErrConflict)
@@ -0,0 +1,45 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"github.com/aws-samples/recipe-share-dsql-go/internal/ingredients"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+)
+
+// resolveIngredients returns the free-text and structured forms of a recipe's
+// ingredients. A non-empty list is kept, renumbered from 1, and rendered to
+// text if no text was given; otherwise the text is parsed into a list.
+func resolveIngredients(text string, list []model.Ingredient) (string, []model.Ingredient) {
+	if len(list) == 0 {
+		return text, ingredients.Parse(text)
+	}
+	out := make([]model.Ingredient, len(list))
+	for i, ing := range list {
+		ing.Position = i + 1
+		out[i] = ing
+	}
+	if text == "" {
+		text = ingredients.Format(out)
+	}
+	return text, out
+}
+
+// resolveIngredientUpdate applies the ingredient fields of an update to r.
+// It reports whether the structured list changed and must be rewritten.
+func resolveIngredientUpdate(r *model.Recipe, input model.UpdateRecipeInput) bool {
+	if input.Ingredients == nil && input.IngredientList == nil {
+		return false
+	}
+	var text string
+	if input.Ingredients != nil {
+		text = *input.Ingredients
+	}
+	var list []model.Ingredient
+	if input.IngredientList != nil {
+		list = *input.IngredientList
+	}
+	r.Ingredients, r.IngredientList = resolveIngredients(text, list)
+	return true
+}
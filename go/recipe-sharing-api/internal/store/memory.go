@@ -0,0 +1,1588 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/google/uuid"
+)
+
+// MemoryStore implements the Store interface in process memory. It is
+// intended for offline development and handler tests, and mirrors the
+// behavior of DSQLStore: lookups return nil when a record is not found,
+// lists are ordered by creation date (newest first), and updates are partial.
+// MemoryStore is safe for concurrent use.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	chefs   map[string]model.Chef
+	recipes map[string]model.Recipe
+	ratings map[string]model.Rating
+
+	// ingredients holds structured ingredient lines by recipe ID, mirroring
+	// the recipe_ingredients table. Recipes in the recipes map never carry
+	// an IngredientList.
+	ingredients map[string][]model.Ingredient
+
+	// tags holds tags by slug, and recipeTags the links of each recipe to
+	// its tags by recipe ID, mirroring the tags and recipe_tags tables.
+	tags       map[string]model.Tag
+	recipeTags map[string][]model.RecipeTag
+
+	// collections holds collections by ID, and collectionItems the items of
+	// each collection in order by collection ID. hiddenItems holds the items
+	// of deleted recipes by recipe ID, with their old positions, until the
+	// recipe is restored or purged.
+	collections     map[string]model.Collection
+	collectionItems map[string][]model.CollectionItem
+	hiddenItems     map[string][]model.CollectionItem
+
+	// follows holds follows by follower and followee ID, mirroring the
+	// follows table.
+	follows map[followKey]model.Follow
+
+	// images holds the metadata of each recipe's images in upload order by
+	// recipe ID, and blobs the store holding their bytes.
+	images map[string][]model.RecipeImage
+	blobs  BlobStore
+
+	// revisions holds the revisions of each recipe in revision order by
+	// recipe ID, mirroring the recipe_revisions table.
+	revisions map[string][]model.RecipeRevision
+
+	// events is the audit log, in the order events were recorded.
+	events []model.AuditEvent
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		chefs:       make(map[string]model.Chef),
+		recipes:     make(map[string]model.Recipe),
+		ratings:     make(map[string]model.Rating),
+		ingredients: make(map[string][]model.Ingredient),
+		tags:        make(map[string]model.Tag),
+		recipeTags:  make(map[string][]model.RecipeTag),
+
+		collections:     make(map[string]model.Collection),
+		collectionItems: make(map[string][]model.CollectionItem),
+		hiddenItems:     make(map[string][]model.CollectionItem),
+		follows:         make(map[followKey]model.Follow),
+		images:          make(map[string][]model.RecipeImage),
+		revisions:       make(map[string][]model.RecipeRevision),
+	}
+}
+
+// Close is a no-op for the in-memory store.
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// newestFirst orders records by creation date descending, breaking ties by
+// ID so that results are deterministic.
+func newestFirst(aCreated time.Time, aID string, bCreated time.Time, bID string) int {
+	if c := bCreated.Compare(aCreated); c != 0 {
+		return c
+	}
+	if aID > bID {
+		return -1
+	}
+	if aID < bID {
+		return 1
+	}
+	return 0
+}
+
+// record appends an audit event. The caller must hold s.mu for writing.
+func record[T any](ctx context.Context, s *MemoryStore, entityType, entityID, action string, before, after *T) {
+	s.events = append(s.events, newAuditEvent(ctx, entityType, entityID, action, before, after, time.Now().UTC()))
+}
+
+// ---------------------------------------------------------------------------
+// Chef operations
+// ---------------------------------------------------------------------------
+
+// ListChefs returns one page of chefs ordered by creation date.
+func (s *MemoryStore) ListChefs(ctx context.Context, page model.PageRequest) ([]model.Chef, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var chefs []model.Chef
+	for _, c := range s.chefs {
+		if visible(ctx, c.DeletedAt) && afterCursor(page.Cursor, chefCursor(c)) {
+			chefs = append(chefs, c)
+		}
+	}
+	slices.SortFunc(chefs, func(a, b model.Chef) int {
+		return newestFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	chefs, next := trimPage(chefs, page.EffectiveLimit(), chefCursor)
+	return chefs, next, nil
+}
+
+func chefCursor(c model.Chef) model.Cursor {
+	return model.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+}
+
+// GetChef returns a single chef by ID, or nil if not found.
+func (s *MemoryStore) GetChef(ctx context.Context, id string) (*model.Chef, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.chefs[id]
+	if !ok || !visible(ctx, c.DeletedAt) {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// GetChefWithRecipes returns a chef with their associated recipes.
+func (s *MemoryStore) GetChefWithRecipes(ctx context.Context, id string) (*model.ChefWithRecipes, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.chefs[id]
+	if !ok || !visible(ctx, c.DeletedAt) {
+		return nil, nil
+	}
+	result := &model.ChefWithRecipes{Chef: c, Recipes: []model.Recipe{}}
+	for _, r := range s.recipes {
+		if r.ChefID == id && visible(ctx, r.DeletedAt) {
+			r.RatingSummary = s.ratingSummary(r.ID)
+			r.FavoritedCount = s.favoritedCount(r.ID)
+			result.Recipes = append(result.Recipes, r)
+		}
+	}
+	slices.SortFunc(result.Recipes, func(a, b model.Recipe) int {
+		return newestFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	return result, nil
+}
+
+// CreateChef stores a new chef with a generated UUID.
+func (s *MemoryStore) CreateChef(ctx context.Context, input model.CreateChefInput) (*model.Chef, error) {
+	now := time.Now().UTC()
+	c := model.Chef{
+		ID:        uuid.New().String(),
+		Name:      input.Name,
+		Email:     input.Email,
+		Specialty: input.Specialty,
+		Bio:       input.Bio,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Version:   1,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.emailTaken(c.Email, c.ID) {
+		return nil, errEmailTaken
+	}
+	s.chefs[c.ID] = c
+	record(ctx, s, model.EntityChef, c.ID, model.ActionCreate, nil, &c)
+	return &c, nil
+}
+
+// UpdateChef applies partial updates to an existing chef.
+func (s *MemoryStore) UpdateChef(ctx context.Context, id string, input model.UpdateChefInput) (*model.Chef, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.chefs[id]
+	if !ok || c.DeletedAt != nil {
+		return nil, nil
+	}
+	if input.IfVersion != nil && *input.IfVersion != c.Version {
+		return nil, ErrPreconditionFailed
+	}
+	before := c
+	if input.Name != nil {
+		c.Name = *input.Name
+	}
+	if input.Email != nil {
+		if s.emailTaken(*input.Email, id) {
+			return nil, errEmailTaken
+		}
+		c.Email = *input.Email
+	}
+	if input.Specialty != nil {
+		c.Specialty = *input.Specialty
+	}
+	if input.Bio != nil {
+		c.Bio = *input.Bio
+	}
+	c.UpdatedAt = time.Now().UTC()
+	c.Version++
+	s.chefs[id] = c
+	record(ctx, s, model.EntityChef, id, model.ActionUpdate, &before, &c)
+	return &c, nil
+}
+
+// emailTaken reports whether a live chef other than id has the email,
+// ignoring case, as the unique email_key index does in Amazon Aurora DSQL.
+// The caller must hold s.mu.
+func (s *MemoryStore) emailTaken(email, id string) bool {
+	key := model.EmailKey(email)
+	for _, c := range s.chefs {
+		if c.ID != id && c.DeletedAt == nil && model.EmailKey(c.Email) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteChef soft-deletes a chef, their recipes, the ratings they wrote, and
+// the ratings on their recipes, stamping every row with the chef's deleted_at
+// as DSQLStore.DeleteChef does.
+func (s *MemoryStore) DeleteChef(ctx context.Context, id string) (*model.ChefDeletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result model.ChefDeletion
+	c, ok := s.chefs[id]
+	if !ok {
+		return &result, nil
+	}
+	if c.DeletedAt == nil {
+		before := c
+		now := deletedNow()
+		c.DeletedAt = &now
+		s.chefs[id] = c
+		record(ctx, s, model.EntityChef, id, model.ActionDelete, &before, &c)
+	}
+	result.Deleted = true
+	for rid, r := range s.recipes {
+		if r.ChefID == id && r.DeletedAt == nil {
+			before := r
+			r.DeletedAt = c.DeletedAt
+			s.recipes[rid] = r
+			record(ctx, s, model.EntityRecipe, rid, model.ActionDelete, &before, &r)
+			s.hideItems(rid)
+			result.Recipes++
+			result.Ingredients += len(s.ingredients[rid])
+		}
+	}
+	for rid, r := range s.ratings {
+		if (r.ChefID == id || s.recipes[r.RecipeID].ChefID == id) && r.DeletedAt == nil {
+			before := r
+			r.DeletedAt = c.DeletedAt
+			s.ratings[rid] = r
+			record(ctx, s, model.EntityRating, rid, model.ActionDelete, &before, &r)
+			result.Ratings++
+		}
+	}
+	return &result, nil
+}
+
+// RestoreChef undoes DeleteChef, restoring only the rows stamped with the
+// chef's deleted_at.
+func (s *MemoryStore) RestoreChef(ctx context.Context, id string) (*model.Chef, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.chefs[id]
+	if !ok {
+		return nil, nil
+	}
+	if c.DeletedAt == nil {
+		return &c, nil
+	}
+	if s.emailTaken(c.Email, id) {
+		return nil, errEmailTaken
+	}
+	var restored []string
+	for rid, r := range s.recipes {
+		if r.ChefID == id && r.DeletedAt != nil && r.DeletedAt.Equal(*c.DeletedAt) {
+			before := r
+			r.DeletedAt = nil
+			s.recipes[rid] = r
+			record(ctx, s, model.EntityRecipe, rid, model.ActionRestore, &before, &r)
+			restored = append(restored, rid)
+		}
+	}
+	s.restoreItems(ctx, restored...)
+	for rid, r := range s.ratings {
+		if (r.ChefID == id || s.recipes[r.RecipeID].ChefID == id) && r.DeletedAt != nil && r.DeletedAt.Equal(*c.DeletedAt) {
+			before := r
+			r.DeletedAt = nil
+			s.ratings[rid] = r
+			record(ctx, s, model.EntityRating, rid, model.ActionRestore, &before, &r)
+		}
+	}
+	before := c
+	c.DeletedAt = nil
+	s.chefs[id] = c
+	record(ctx, s, model.EntityChef, id, model.ActionRestore, &before, &c)
+	return &c, nil
+}
+
+// ---------------------------------------------------------------------------
+// Recipe operations
+// ---------------------------------------------------------------------------
+
+// ListRecipes returns one page of recipes matching the optional filter
+// criteria, each with its rating summary, in the order DSQLStore.ListRecipes
+// uses.
+func (s *MemoryStore) ListRecipes(ctx context.Context, filter model.RecipeFilter, page model.PageRequest) ([]model.Recipe, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order, key := byCreation, recipeCursor
+	if filter.Sort == model.SortRating {
+		order, key = byRating, ratingOrderCursor
+	}
+	var recipes []model.Recipe
+	for _, r := range s.recipes {
+		if !visible(ctx, r.DeletedAt) {
+			continue
+		}
+		r.RatingSummary = s.ratingSummary(r.ID)
+		r.FavoritedCount = s.favoritedCount(r.ID)
+		if page.Cursor != nil && order(key(r), *page.Cursor) <= 0 {
+			continue
+		}
+		if filter.MinRating > 0 && r.AverageScore < filter.MinRating {
+			continue
+		}
+		if filter.Cuisine != "" && r.Cuisine != filter.Cuisine {
+			continue
+		}
+		if filter.Difficulty != "" && r.Difficulty != filter.Difficulty {
+			continue
+		}
+		if filter.Status != "" && r.Status != filter.Status {
+			continue
+		}
+		if len(filter.Tags) > 0 && !s.hasTags(r.ID, filter) {
+			continue
+		}
+		recipes = append(recipes, r)
+	}
+	slices.SortFunc(recipes, func(a, b model.Recipe) int {
+		return order(key(a), key(b))
+	})
+	recipes, next := trimPage(recipes, page.EffectiveLimit(), key)
+	return recipes, next, nil
+}
+
+// byRating orders cursor keys as model.SortRating does: by average score,
+// then rating count, then creation date and ID, all descending.
+func byRating(a, b model.Cursor) int {
+	if c := cmp.Compare(b.Score, a.Score); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(b.Rank, a.Rank); c != 0 {
+		return c
+	}
+	return byCreation(a, b)
+}
+
+// byCreation orders cursor keys newest first.
+func byCreation(a, b model.Cursor) int {
+	return newestFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+}
+
+// ratingSummary aggregates the live ratings of a recipe. The caller must
+// hold s.mu.
+func (s *MemoryStore) ratingSummary(recipeID string) *model.RatingSummary {
+	var sum model.RatingSummary
+	total := 0
+	for _, r := range s.ratings {
+		if r.RecipeID == recipeID && r.DeletedAt == nil {
+			total += r.Score
+			sum.RatingCount++
+		}
+	}
+	if sum.RatingCount > 0 {
+		sum.AverageScore = float64(total) / float64(sum.RatingCount)
+	}
+	return &sum
+}
+
+func recipeCursor(r model.Recipe) model.Cursor {
+	return model.Cursor{CreatedAt: r.CreatedAt, ID: r.ID}
+}
+
+// GetRecipe returns a single recipe by ID, or nil if not found.
+func (s *MemoryStore) GetRecipe(ctx context.Context, id string) (*model.Recipe, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.recipes[id]
+	if !ok || !visible(ctx, r.DeletedAt) {
+		return nil, nil
+	}
+	r.IngredientList = slices.Clone(s.ingredients[id])
+	r.Tags = s.tagSlugs(id)
+	return &r, nil
+}
+
+// GetRecipeWithRatings returns a recipe with its most recent ratings and its
+// rating summary and histogram.
+func (s *MemoryStore) GetRecipeWithRatings(ctx context.Context, id string) (*model.RecipeWithRatings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	recipe, ok := s.recipes[id]
+	if !ok || !visible(ctx, recipe.DeletedAt) {
+		return nil, nil
+	}
+	recipe.IngredientList = slices.Clone(s.ingredients[id])
+	recipe.Tags = s.tagSlugs(id)
+	recipe.RatingSummary = s.ratingSummary(id)
+	recipe.FavoritedCount = s.favoritedCount(id)
+	recipe.ForkCount = s.forkCount(id)
+
+	ratings, _ := trimPage(s.ratingsFor(ctx, id, nil), model.DefaultPageLimit, ratingCursor)
+	if ratings == nil {
+		ratings = []model.Rating{}
+	}
+
+	histogram := make(map[int]int, model.MaxScore)
+	for score := model.MinScore; score <= model.MaxScore; score++ {
+		histogram[score] = 0
+	}
+	for _, r := range s.ratings {
+		if r.RecipeID == id && r.DeletedAt == nil {
+			histogram[r.Score]++
+		}
+	}
+
+	return &model.RecipeWithRatings{
+		Recipe:          recipe,
+		Ratings:         ratings,
+		RatingHistogram: histogram,
+	}, nil
+}
+
+// CreateRecipe stores a new recipe with a generated UUID, applying the same
+// difficulty and status defaults as the Amazon Aurora DSQL schema.
+func (s *MemoryStore) CreateRecipe(ctx context.Context, input model.CreateRecipeInput) (*model.Recipe, error) {
+	r := newRecipe(input, time.Now().UTC())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.insertRecipe(ctx, &r)
+	return &r, nil
+}
+
+// CreateRecipes stores new recipes in input order.
+func (s *MemoryStore) CreateRecipes(ctx context.Context, inputs []model.CreateRecipeInput) ([]model.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recipes := make([]model.Recipe, len(inputs))
+	for i, input := range inputs {
+		recipes[i] = newRecipe(input, time.Now().UTC())
+		s.insertRecipe(ctx, &recipes[i])
+	}
+	return recipes, nil
+}
+
+// ExportRecipes calls fn for every live recipe, oldest first, stopping at
+// the first error. The recipes are copied before fn is called, so fn may
+// use the store.
+func (s *MemoryStore) ExportRecipes(ctx context.Context, fn func(model.Recipe) error) error {
+	s.mu.RLock()
+	var recipes []model.Recipe
+	for _, r := range s.recipes {
+		if r.DeletedAt == nil {
+			recipes = append(recipes, r)
+		}
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(recipes, func(a, b model.Recipe) int {
+		return newestFirst(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+	})
+	for _, r := range recipes {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertRecipe stores a new recipe and its ingredient lines. The caller
+// must hold s.mu.
+func (s *MemoryStore) insertRecipe(ctx context.Context, r *model.Recipe) {
+	list := r.IngredientList
+	r.IngredientList = nil
+	s.recipes[r.ID] = *r
+	s.ingredients[r.ID] = list
+	r.IngredientList = slices.Clone(list)
+	record(ctx, s, model.EntityRecipe, r.ID, model.ActionCreate, nil, r)
+}
+
+// ForkRecipe copies a live, published recipe, with its ingredients and
+// tags, into a new draft owned by chefID.
+func (s *MemoryStore) ForkRecipe(ctx context.Context, id, chefID string) (*model.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	src, ok := s.recipes[id]
+	if !ok || src.DeletedAt != nil {
+		return nil, nil
+	}
+	if src.Status != model.StatusPublished {
+		return nil, ErrNotPublished
+	}
+	src.IngredientList = s.ingredients[id]
+	src.Tags = s.tagSlugs(id)
+
+	now := time.Now().UTC()
+	r := newFork(src, chefID, now)
+	// Tags live in recipeTags, like ingredient lines in ingredients.
+	tags := r.Tags
+	r.Tags = nil
+	s.insertRecipe(ctx, &r)
+	r.Tags = tags
+	var links []model.RecipeTag
+	for _, l := range s.recipeTags[id] {
+		link := model.RecipeTag{RecipeID: r.ID, TagID: l.TagID, Slug: l.Slug, CreatedAt: now}
+		links = append(links, link)
+		record(ctx, s, model.EntityRecipeTag, r.ID, model.ActionCreate, nil, &link)
+	}
+	if links != nil {
+		s.recipeTags[r.ID] = links
+	}
+	return &r, nil
+}
+
+// RecipeLineage returns the ancestors of a recipe, nearest first, and one
+// page of its direct forks, newest first, or nil if the recipe does not
+// exist.
+func (s *MemoryStore) RecipeLineage(ctx context.Context, id string, page model.PageRequest) (*model.Lineage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	recipe, ok := s.recipes[id]
+	if !ok || !visible(ctx, recipe.DeletedAt) {
+		return nil, nil
+	}
+
+	lineage := &model.Lineage{RecipeID: id, Ancestors: []model.Recipe{}}
+	seen := map[string]bool{id: true}
+	for parent := recipe.ForkedFromID; parent != nil && !seen[*parent] && len(lineage.Ancestors) < model.MaxLineageDepth; {
+		a, ok := s.recipes[*parent]
+		if !ok || !visible(ctx, a.DeletedAt) {
+			break
+		}
+		seen[a.ID] = true
+		a.RatingSummary = s.ratingSummary(a.ID)
+		a.ForkCount = s.forkCount(a.ID)
+		lineage.Ancestors = append(lineage.Ancestors, a)
+		parent = a.ForkedFromID
+	}
+
+	for _, r := range s.recipes {
+		if r.ForkedFromID == nil || *r.ForkedFromID != id || !visible(ctx, r.DeletedAt) || !afterCursor(page.Cursor, recipeCursor(r)) {
+			continue
+		}
+		r.RatingSummary = s.ratingSummary(r.ID)
+		r.ForkCount = s.forkCount(r.ID)
+		lineage.Descendants = append(lineage.Descendants, r)
+	}
+	slices.SortFunc(lineage.Descendants, func(a, b model.Recipe) int {
+		return newestFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	lineage.Descendants, lineage.NextCursor = trimPage(lineage.Descendants, page.EffectiveLimit(), recipeCursor)
+	if lineage.Descendants == nil {
+		lineage.Descendants = []model.Recipe{}
+	}
+	return lineage, nil
+}
+
+// forkCount returns the number of live forks of a recipe.
+func (s *MemoryStore) forkCount(recipeID string) *int {
+	n := 0
+	for _, r := range s.recipes {
+		if r.ForkedFromID != nil && *r.ForkedFromID == recipeID && r.DeletedAt == nil {
+			n++
+		}
+	}
+	return &n
+}
+
+// UpdateRecipe applies partial updates to an existing recipe.
+func (s *MemoryStore) UpdateRecipe(ctx context.Context, id string, input model.UpdateRecipeInput) (*model.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.recipes[id]
+	if !ok || r.DeletedAt != nil {
+		return nil, nil
+	}
+	if input.IfVersion != nil && *input.IfVersion != r.Version {
+		return nil, ErrPreconditionFailed
+	}
+	if input.Status != nil && !model.CanChangeStatus(r.Status, *input.Status) {
+		return nil, fmt.Errorf("%w: cannot change a recipe from %s to %s", ErrInvalidTransition, r.Status, *input.Status)
+	}
+	r.IngredientList = s.ingredients[id]
+	before := r
+	if input.Title != nil {
+		r.Title = *input.Title
+	}
+	if input.Description != nil {
+		r.Description = *input.Description
+	}
+	if resolveIngredientUpdate(&r, input) {
+		s.ingredients[id] = r.IngredientList
+	}
+	if input.Instructions != nil {
+		r.Instructions = *input.Instructions
+	}
+	if input.PrepTime != nil {
+		r.PrepTime = *input.PrepTime
+	}
+	if input.CookTime != nil {
+		r.CookTime = *input.CookTime
+	}
+	if input.Servings != nil {
+		r.Servings = *input.Servings
+	}
+	if input.Difficulty != nil {
+		r.Difficulty = *input.Difficulty
+	}
+	if input.Cuisine != nil {
+		r.Cuisine = *input.Cuisine
+	}
+	r.UpdatedAt = time.Now().UTC()
+	if input.Status != nil {
+		changeStatus(&r, *input.Status, r.UpdatedAt)
+	}
+	r.Version++
+	r.IngredientList = slices.Clone(r.IngredientList)
+	s.updateRecipe(ctx, before, r)
+	return &r, nil
+}
+
+// TransitionRecipe publishes, unpublishes, or archives a live recipe.
+func (s *MemoryStore) TransitionRecipe(ctx context.Context, id, transition string, publishAt *time.Time, ifVersion *int64) (*model.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.recipes[id]
+	if !ok || r.DeletedAt != nil {
+		return nil, nil
+	}
+	if ifVersion != nil && *ifVersion != r.Version {
+		return nil, ErrPreconditionFailed
+	}
+	r.IngredientList = s.ingredients[id]
+	before := r
+	now := time.Now().UTC()
+	if err := applyTransition(&r, transition, publishAt, now); err != nil {
+		return nil, err
+	}
+	r.UpdatedAt = now
+	r.Version++
+	s.updateRecipe(ctx, before, r)
+	return &r, nil
+}
+
+// PublishDue publishes the live drafts scheduled for now or earlier,
+// earliest first.
+func (s *MemoryStore) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []model.Recipe
+	for _, r := range s.recipes {
+		if isDue(r, now) {
+			due = append(due, r)
+		}
+	}
+	slices.SortFunc(due, func(a, b model.Recipe) int {
+		return cmp.Or(a.PublishAt.Compare(*b.PublishAt), cmp.Compare(a.ID, b.ID))
+	})
+	for _, r := range due {
+		r.IngredientList = s.ingredients[r.ID]
+		before := r
+		r.UpdatedAt = time.Now().UTC()
+		changeStatus(&r, model.StatusPublished, r.UpdatedAt)
+		r.Version++
+		s.updateRecipe(ctx, before, r)
+	}
+	return len(due), nil
+}
+
+// updateRecipe stores an updated recipe, records the update, and keeps the
+// version it replaces as a revision. The caller must hold s.mu for writing.
+func (s *MemoryStore) updateRecipe(ctx context.Context, before, after model.Recipe) {
+	record(ctx, s, model.EntityRecipe, after.ID, model.ActionUpdate, &before, &after)
+	before.IngredientList = slices.Clone(before.IngredientList)
+	s.revisions[after.ID] = append(s.revisions[after.ID], newRevision(before, after.UpdatedAt))
+	after.IngredientList = nil
+	s.recipes[after.ID] = after
+}
+
+// ListRecipeRevisions returns one page of the revisions of a recipe,
+// newest first.
+func (s *MemoryStore) ListRecipeRevisions(ctx context.Context, recipeID string, page model.PageRequest) ([]model.RecipeRevision, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var revisions []model.RecipeRevision
+	for _, rev := range slices.Backward(s.revisions[recipeID]) {
+		if page.Cursor == nil || rev.Revision < int64(page.Cursor.Rank) {
+			rev.Recipe.IngredientList = nil
+			revisions = append(revisions, rev)
+		}
+	}
+	revisions, next := trimPage(revisions, page.EffectiveLimit(), revisionCursor)
+	return revisions, next, nil
+}
+
+// GetRecipeRevision returns one revision of a recipe, or nil if it does not
+// exist.
+func (s *MemoryStore) GetRecipeRevision(ctx context.Context, recipeID string, revision int64) (*model.RecipeRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rev := range s.revisions[recipeID] {
+		if rev.Revision == revision {
+			rev.Recipe.IngredientList = slices.Clone(rev.Recipe.IngredientList)
+			return &rev, nil
+		}
+	}
+	return nil, nil
+}
+
+// DeleteRecipe soft-deletes a recipe by ID.
+func (s *MemoryStore) DeleteRecipe(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.recipes[id]; ok && r.DeletedAt == nil {
+		r.IngredientList = s.ingredients[id]
+		before := r
+		now := deletedNow()
+		r.DeletedAt = &now
+		record(ctx, s, model.EntityRecipe, id, model.ActionDelete, &before, &r)
+		r.IngredientList = nil
+		s.recipes[id] = r
+		s.hideItems(id)
+	}
+	return nil
+}
+
+// RestoreRecipe undoes DeleteRecipe.
+func (s *MemoryStore) RestoreRecipe(ctx context.Context, id string) (*model.Recipe, error) {
+	s.mu.Lock()
+	r, ok := s.recipes[id]
+	if ok && r.DeletedAt != nil {
+		r.IngredientList = s.ingredients[id]
+		before := r
+		r.DeletedAt = nil
+		record(ctx, s, model.EntityRecipe, id, model.ActionRestore, &before, &r)
+		r.IngredientList = nil
+		s.recipes[id] = r
+		s.restoreItems(ctx, id)
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	return s.GetRecipe(ctx, id)
+}
+
+// SearchRecipes returns one page of recipes containing every search term,
+// ranked the same way as DSQLStore.SearchRecipes.
+func (s *MemoryStore) SearchRecipes(ctx context.Context, terms []string, page model.PageRequest) ([]model.Recipe, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type ranked struct {
+		recipe model.Recipe
+		key    model.Cursor
+	}
+	var matches []ranked
+	for _, r := range s.recipes {
+		if !visible(ctx, r.DeletedAt) {
+			continue
+		}
+		rank, ok := searchRank(r, terms)
+		if !ok {
+			continue
+		}
+		key := model.Cursor{Rank: rank, CreatedAt: r.CreatedAt, ID: r.ID}
+		if page.Cursor != nil && compareRanked(key, *page.Cursor) <= 0 {
+			continue
+		}
+		matches = append(matches, ranked{recipe: r, key: key})
+	}
+	slices.SortFunc(matches, func(a, b ranked) int { return compareRanked(a.key, b.key) })
+	matches, next := trimPage(matches, page.EffectiveLimit(), func(m ranked) model.Cursor { return m.key })
+
+	recipes := make([]model.Recipe, len(matches))
+	for i, m := range matches {
+		recipes[i] = m.recipe
+		recipes[i].RatingSummary = s.ratingSummary(m.recipe.ID)
+		recipes[i].FavoritedCount = s.favoritedCount(m.recipe.ID)
+	}
+	return recipes, next, nil
+}
+
+// ---------------------------------------------------------------------------
+// Rating operations
+// ---------------------------------------------------------------------------
+
+// ListRatings returns one page of ratings for a given recipe.
+func (s *MemoryStore) ListRatings(ctx context.Context, recipeID string, page model.PageRequest) ([]model.Rating, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ratings, next := trimPage(s.ratingsFor(ctx, recipeID, page.Cursor), page.EffectiveLimit(), ratingCursor)
+	return ratings, next, nil
+}
+
+// ratingsFor returns the ratings for a recipe that sort after the cursor,
+// newest first. The caller must hold s.mu.
+func (s *MemoryStore) ratingsFor(ctx context.Context, recipeID string, cursor *model.Cursor) []model.Rating {
+	var ratings []model.Rating
+	for _, r := range s.ratings {
+		if r.RecipeID == recipeID && visible(ctx, r.DeletedAt) && afterCursor(cursor, ratingCursor(r)) {
+			ratings = append(ratings, r)
+		}
+	}
+	slices.SortFunc(ratings, func(a, b model.Rating) int {
+		return newestFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	return ratings
+}
+
+func ratingCursor(r model.Rating) model.Cursor {
+	return model.Cursor{CreatedAt: r.CreatedAt, ID: r.ID}
+}
+
+// CreateRating stores a new rating with a generated UUID, or returns an
+// error wrapping ErrConflict if the chef has already rated the recipe.
+func (s *MemoryStore) CreateRating(ctx context.Context, recipeID string, input model.CreateRatingInput) (*model.Rating, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ratingByChef(recipeID, input.ChefID) != nil {
+		return nil, errRatingExists
+	}
+	return s.insertRating(ctx, recipeID, input), nil
+}
+
+// UpsertRating creates the chef's rating on a recipe, or updates the chef's
+// existing live rating. It reports whether the rating was created.
+func (s *MemoryStore) UpsertRating(ctx context.Context, recipeID string, input model.CreateRatingInput) (*model.Rating, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r := s.ratingByChef(recipeID, input.ChefID); r != nil {
+		return s.updateRating(ctx, *r, model.UpdateRatingInput{Score: &input.Score, Comment: &input.Comment}), false, nil
+	}
+	return s.insertRating(ctx, recipeID, input), true, nil
+}
+
+func (s *MemoryStore) insertRating(ctx context.Context, recipeID string, input model.CreateRatingInput) *model.Rating {
+	now := time.Now().UTC()
+	r := model.Rating{
+		ID:        uuid.New().String(),
+		RecipeID:  recipeID,
+		ChefID:    input.ChefID,
+		Score:     input.Score,
+		Comment:   input.Comment,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.ratings[r.ID] = r
+	record(ctx, s, model.EntityRating, r.ID, model.ActionCreate, nil, &r)
+	return &r
+}
+
+// ratingByChef returns the chef's live rating on a recipe, or nil.
+func (s *MemoryStore) ratingByChef(recipeID, chefID string) *model.Rating {
+	for _, r := range s.ratings {
+		if r.RecipeID == recipeID && r.ChefID == chefID && r.DeletedAt == nil {
+			return &r
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) updateRating(ctx context.Context, r model.Rating, input model.UpdateRatingInput) *model.Rating {
+	before := r
+	if input.Score != nil {
+		r.Score = *input.Score
+	}
+	if input.Comment != nil {
+		r.Comment = *input.Comment
+	}
+	r.UpdatedAt = time.Now().UTC()
+	s.ratings[r.ID] = r
+	record(ctx, s, model.EntityRating, r.ID, model.ActionUpdate, &before, &r)
+	return &r
+}
+
+// UpdateRating applies partial updates to a live rating, or returns nil if
+// there is no such rating.
+func (s *MemoryStore) UpdateRating(ctx context.Context, id string, input model.UpdateRatingInput) (*model.Rating, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.ratings[id]
+	if !ok || r.DeletedAt != nil {
+		return nil, nil
+	}
+	return s.updateRating(ctx, r, input), nil
+}
+
+// GetRating returns a single rating by ID, or nil if not found.
+func (s *MemoryStore) GetRating(ctx context.Context, id string) (*model.Rating, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.ratings[id]
+	if !ok || !visible(ctx, r.DeletedAt) {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+// DeleteRating soft-deletes a rating by ID.
+func (s *MemoryStore) DeleteRating(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.ratings[id]; ok && r.DeletedAt == nil {
+		before := r
+		now := deletedNow()
+		r.DeletedAt = &now
+		s.ratings[id] = r
+		record(ctx, s, model.EntityRating, id, model.ActionDelete, &before, &r)
+	}
+	return nil
+}
+
+// RestoreRating undoes DeleteRating, or returns an error wrapping
+// ErrConflict if the chef has rated the recipe again since.
+func (s *MemoryStore) RestoreRating(ctx context.Context, id string) (*model.Rating, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.ratings[id]
+	if !ok {
+		return nil, nil
+	}
+	if r.DeletedAt != nil {
+		if s.ratingByChef(r.RecipeID, r.ChefID) != nil {
+			return nil, errRatingExists
+		}
+		before := r
+		r.DeletedAt = nil
+		s.ratings[id] = r
+		record(ctx, s, model.EntityRating, id, model.ActionRestore, &before, &r)
+	}
+	return &r, nil
+}
+
+// ListTags returns one page of tags in slug order, each with the number of
+// live recipes that carry it.
+func (s *MemoryStore) ListTags(ctx context.Context, page model.PageRequest) ([]model.TagUsage, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for id, links := range s.recipeTags {
+		if r, ok := s.recipes[id]; ok && r.DeletedAt == nil {
+			for _, l := range links {
+				counts[l.Slug]++
+			}
+		}
+	}
+	var tags []model.TagUsage
+	for slug, t := range s.tags {
+		if page.Cursor == nil || slug > page.Cursor.Key {
+			tags = append(tags, model.TagUsage{Tag: t, RecipeCount: counts[slug]})
+		}
+	}
+	slices.SortFunc(tags, func(a, b model.TagUsage) int {
+		return cmp.Compare(a.Slug, b.Slug)
+	})
+	tags, next := trimPage(tags, page.EffectiveLimit(), tagCursor)
+	return tags, next, nil
+}
+
+// AddRecipeTags tags a recipe, creating any tags that do not exist yet, and
+// returns the slugs of all the recipe's tags.
+func (s *MemoryStore) AddRecipeTags(ctx context.Context, recipeID string, names []string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	links := slices.Clone(s.recipeTags[recipeID])
+	now := time.Now().UTC()
+	type change struct {
+		tag  *model.Tag
+		link model.RecipeTag
+	}
+	var changes []change
+	for _, name := range names {
+		slug := model.Slugify(name)
+		if slug == "" || slices.ContainsFunc(links, func(l model.RecipeTag) bool { return l.Slug == slug }) {
+			continue
+		}
+		if len(links) == model.MaxRecipeTags {
+			return nil, ErrTooManyTags
+		}
+		var created *model.Tag
+		tag, ok := s.tags[slug]
+		if !ok {
+			tag = model.Tag{ID: uuid.New().String(), Slug: slug, Name: name, CreatedAt: now}
+			created = &tag
+		}
+		link := model.RecipeTag{RecipeID: recipeID, TagID: tag.ID, Slug: slug, CreatedAt: now}
+		links = append(links, link)
+		changes = append(changes, change{created, link})
+	}
+
+	// Nothing is written until the whole request is known to fit.
+	for _, c := range changes {
+		if c.tag != nil {
+			s.tags[c.tag.Slug] = *c.tag
+			record(ctx, s, model.EntityTag, c.tag.ID, model.ActionCreate, nil, c.tag)
+		}
+		record(ctx, s, model.EntityRecipeTag, recipeID, model.ActionCreate, nil, &c.link)
+	}
+	s.recipeTags[recipeID] = links
+	return s.tagSlugs(recipeID), nil
+}
+
+// RemoveRecipeTag removes a tag from a recipe and reports whether the recipe
+// had it.
+func (s *MemoryStore) RemoveRecipeTag(ctx context.Context, recipeID, slug string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	links := s.recipeTags[recipeID]
+	i := slices.IndexFunc(links, func(l model.RecipeTag) bool { return l.Slug == slug })
+	if i < 0 {
+		return false, nil
+	}
+	link := links[i]
+	s.recipeTags[recipeID] = slices.Delete(slices.Clone(links), i, i+1)
+	record(ctx, s, model.EntityRecipeTag, recipeID, model.ActionDelete, &link, nil)
+	return true, nil
+}
+
+// tagSlugs returns the slugs of a recipe's tags in alphabetical order, or nil
+// if it has none.
+func (s *MemoryStore) tagSlugs(recipeID string) []string {
+	var slugs []string
+	for _, l := range s.recipeTags[recipeID] {
+		slugs = append(slugs, l.Slug)
+	}
+	slices.Sort(slugs)
+	return slugs
+}
+
+// hasTags reports whether a recipe carries any or all of the tags in
+// filter, as filter.TagMatch says.
+func (s *MemoryStore) hasTags(recipeID string, filter model.RecipeFilter) bool {
+	matched := 0
+	for _, l := range s.recipeTags[recipeID] {
+		if slices.Contains(filter.Tags, l.Slug) {
+			matched++
+		}
+	}
+	if filter.TagMatch == model.TagMatchAll {
+		return matched == len(filter.Tags)
+	}
+	return matched > 0
+}
+
+// favoritedCount returns the number of live chefs who have saved a recipe
+// to at least one collection.
+func (s *MemoryStore) favoritedCount(recipeID string) *int {
+	chefs := make(map[string]bool)
+	for cid, items := range s.collectionItems {
+		c := s.collections[cid]
+		if chef, ok := s.chefs[c.ChefID]; !ok || chef.DeletedAt != nil {
+			continue
+		}
+		if slices.ContainsFunc(items, func(it model.CollectionItem) bool { return it.RecipeID == recipeID }) {
+			chefs[c.ChefID] = true
+		}
+	}
+	n := len(chefs)
+	return &n
+}
+
+// ListCollections returns one page of a chef's collections, newest first.
+func (s *MemoryStore) ListCollections(ctx context.Context, chefID string, page model.PageRequest) ([]model.Collection, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var collections []model.Collection
+	for _, c := range s.collections {
+		if c.ChefID == chefID && afterCursor(page.Cursor, collectionCursor(c)) {
+			collections = append(collections, c)
+		}
+	}
+	slices.SortFunc(collections, func(a, b model.Collection) int {
+		return newestFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	collections, next := trimPage(collections, page.EffectiveLimit(), collectionCursor)
+	return collections, next, nil
+}
+
+// GetCollection returns a collection with the items of live recipes, or nil
+// if not found.
+func (s *MemoryStore) GetCollection(ctx context.Context, id string) (*model.CollectionWithItems, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.collections[id]
+	if !ok {
+		return nil, nil
+	}
+	items := []model.CollectionItem{}
+	for _, it := range s.collectionItems[id] {
+		if r, ok := s.recipes[it.RecipeID]; ok && r.DeletedAt == nil {
+			it.Title = r.Title
+			it.Position = len(items) + 1
+			items = append(items, it)
+		}
+	}
+	return &model.CollectionWithItems{Collection: c, Items: items}, nil
+}
+
+// CreateCollection stores a new collection with a generated UUID.
+func (s *MemoryStore) CreateCollection(ctx context.Context, input model.CreateCollectionInput) (*model.Collection, error) {
+	now := time.Now().UTC()
+	c := model.Collection{
+		ID:          uuid.New().String(),
+		ChefID:      input.ChefID,
+		Name:        input.Name,
+		Description: input.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collections[c.ID] = c
+	record(ctx, s, model.EntityCollection, c.ID, model.ActionCreate, nil, &c)
+	return &c, nil
+}
+
+// UpdateCollection applies partial updates to a collection, or returns nil
+// if not found.
+func (s *MemoryStore) UpdateCollection(ctx context.Context, id string, input model.UpdateCollectionInput) (*model.Collection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.collections[id]
+	if !ok {
+		return nil, nil
+	}
+	before := c
+	if input.Name != nil {
+		c.Name = *input.Name
+	}
+	if input.Description != nil {
+		c.Description = *input.Description
+	}
+	c.UpdatedAt = time.Now().UTC()
+	s.collections[id] = c
+	record(ctx, s, model.EntityCollection, id, model.ActionUpdate, &before, &c)
+	return &c, nil
+}
+
+// DeleteCollection deletes a collection and its items, and reports whether
+// it existed.
+func (s *MemoryStore) DeleteCollection(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.collections[id]; !ok {
+		return false, nil
+	}
+	s.deleteCollection(ctx, id)
+	return true, nil
+}
+
+func (s *MemoryStore) deleteCollection(ctx context.Context, id string) {
+	snapshot := model.CollectionWithItems{Collection: s.collections[id], Items: s.collectionItems[id]}
+	delete(s.collections, id)
+	delete(s.collectionItems, id)
+	for rid, items := range s.hiddenItems {
+		s.hiddenItems[rid] = slices.DeleteFunc(items, func(it model.CollectionItem) bool { return it.CollectionID == id })
+	}
+	record(ctx, s, model.EntityCollection, id, model.ActionDelete, &snapshot, nil)
+}
+
+// renumber sets the positions of items to 1, 2, 3, ... in order.
+func renumber(items []model.CollectionItem) []model.CollectionItem {
+	for i := range items {
+		items[i].Position = i + 1
+	}
+	return items
+}
+
+// hideItems takes a deleted recipe out of every collection and keeps its
+// items in hiddenItems. Like DSQLStore, it leaves the stored positions of
+// the other items alone; reads number the items from 1.
+func (s *MemoryStore) hideItems(recipeID string) {
+	for cid, items := range s.collectionItems {
+		i := slices.IndexFunc(items, func(it model.CollectionItem) bool { return it.RecipeID == recipeID })
+		if i < 0 {
+			continue
+		}
+		s.hiddenItems[recipeID] = append(s.hiddenItems[recipeID], items[i])
+		s.collectionItems[cid] = slices.Delete(slices.Clone(items), i, i+1)
+	}
+}
+
+// restoreItems puts restored recipes back into their collections, in the
+// order DSQLStore uses: by collection, then stored position. Each item is
+// reinserted at its stored position, clamped to the end, and its collection
+// renumbered. An item whose collection has filled up in the meantime is
+// removed instead.
+func (s *MemoryStore) restoreItems(ctx context.Context, recipeIDs ...string) {
+	var hidden []model.CollectionItem
+	for _, id := range recipeIDs {
+		hidden = append(hidden, s.hiddenItems[id]...)
+		delete(s.hiddenItems, id)
+	}
+	slices.SortFunc(hidden, func(a, b model.CollectionItem) int {
+		return cmp.Or(cmp.Compare(a.CollectionID, b.CollectionID),
+			cmp.Compare(a.Position, b.Position), a.AddedAt.Compare(b.AddedAt))
+	})
+	for _, it := range hidden {
+		items := renumber(slices.Clone(s.collectionItems[it.CollectionID]))
+		if len(items) >= model.MaxCollectionItems {
+			record(ctx, s, model.EntityCollectionItem, it.CollectionID, model.ActionDelete, &it, nil)
+			continue
+		}
+		it.Position = placeAt(it.Position, len(items)+1)
+		s.collectionItems[it.CollectionID] = renumber(slices.Insert(items, it.Position-1, it))
+	}
+}
+
+// AddCollectionItem adds a recipe to a collection and returns the new item,
+// or nil if the collection does not exist.
+func (s *MemoryStore) AddCollectionItem(ctx context.Context, collectionID string, input model.AddCollectionItemInput) (*model.CollectionItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.collections[collectionID]; !ok {
+		return nil, nil
+	}
+	items := slices.Clone(s.collectionItems[collectionID])
+	if slices.ContainsFunc(items, func(it model.CollectionItem) bool { return it.RecipeID == input.RecipeID }) {
+		return nil, errInCollection
+	}
+	if len(items) >= model.MaxCollectionItems {
+		return nil, ErrCollectionFull
+	}
+	it := model.CollectionItem{
+		CollectionID: collectionID,
+		RecipeID:     input.RecipeID,
+		Position:     placeAt(input.Position, len(items)+1),
+		AddedAt:      time.Now().UTC(),
+	}
+	s.collectionItems[collectionID] = renumber(slices.Insert(items, it.Position-1, it))
+	record(ctx, s, model.EntityCollectionItem, collectionID, model.ActionCreate, nil, &it)
+	return &it, nil
+}
+
+// MoveCollectionItem moves a recipe to a new position in a collection and
+// returns the moved item, or nil if the recipe is not in the collection.
+func (s *MemoryStore) MoveCollectionItem(ctx context.Context, collectionID, recipeID string, position int) (*model.CollectionItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := renumber(slices.Clone(s.collectionItems[collectionID]))
+	i := slices.IndexFunc(items, func(it model.CollectionItem) bool { return it.RecipeID == recipeID })
+	if i < 0 {
+		return nil, nil
+	}
+	before := items[i]
+	it := before
+	it.Position = placeAt(position, len(items))
+	items = slices.Insert(slices.Delete(items, i, i+1), it.Position-1, it)
+	s.collectionItems[collectionID] = renumber(items)
+	record(ctx, s, model.EntityCollectionItem, collectionID, model.ActionUpdate, &before, &it)
+	return &it, nil
+}
+
+// RemoveCollectionItem removes a recipe from a collection and reports
+// whether it was there.
+func (s *MemoryStore) RemoveCollectionItem(ctx context.Context, collectionID, recipeID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := s.collectionItems[collectionID]
+	i := slices.IndexFunc(items, func(it model.CollectionItem) bool { return it.RecipeID == recipeID })
+	if i < 0 {
+		return false, nil
+	}
+	it := items[i]
+	s.collectionItems[collectionID] = renumber(slices.Delete(slices.Clone(items), i, i+1))
+	record(ctx, s, model.EntityCollectionItem, collectionID, model.ActionDelete, &it, nil)
+	return true, nil
+}
+
+type followKey struct {
+	follower, followee string
+}
+
+// Follow records that followerID follows followeeID and reports whether
+// the follow is new.
+func (s *MemoryStore) Follow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := followKey{followerID, followeeID}
+	if _, ok := s.follows[key]; ok {
+		return false, nil
+	}
+	f := model.Follow{FollowerID: followerID, FolloweeID: followeeID, CreatedAt: time.Now().UTC()}
+	s.follows[key] = f
+	record(ctx, s, model.EntityFollow, followerID, model.ActionCreate, nil, &f)
+	return true, nil
+}
+
+// Unfollow removes a follow and reports whether it existed.
+func (s *MemoryStore) Unfollow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := followKey{followerID, followeeID}
+	f, ok := s.follows[key]
+	if !ok {
+		return false, nil
+	}
+	delete(s.follows, key)
+	record(ctx, s, model.EntityFollow, followerID, model.ActionDelete, &f, nil)
+	return true, nil
+}
+
+// ListFollowers returns one page of the live chefs who follow a chef, most
+// recent follow first.
+func (s *MemoryStore) ListFollowers(ctx context.Context, chefID string, page model.PageRequest) ([]model.FollowedChef, string, error) {
+	return s.listFollows(page, func(f model.Follow) (string, bool) {
+		return f.FollowerID, f.FolloweeID == chefID
+	})
+}
+
+// ListFollowing returns one page of the live chefs a chef follows, most
+// recent follow first.
+func (s *MemoryStore) ListFollowing(ctx context.Context, chefID string, page model.PageRequest) ([]model.FollowedChef, string, error) {
+	return s.listFollows(page, func(f model.Follow) (string, bool) {
+		return f.FolloweeID, f.FollowerID == chefID
+	})
+}
+
+// listFollows lists the live chefs on the other side of the follows that
+// match accepts, where match returns the ID of that chef.
+func (s *MemoryStore) listFollows(page model.PageRequest, match func(model.Follow) (string, bool)) ([]model.FollowedChef, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var chefs []model.FollowedChef
+	for _, f := range s.follows {
+		id, ok := match(f)
+		if !ok {
+			continue
+		}
+		c, ok := s.chefs[id]
+		if !ok || c.DeletedAt != nil {
+			continue
+		}
+		fc := model.FollowedChef{Chef: c, FollowedAt: f.CreatedAt}
+		if afterCursor(page.Cursor, followCursor(fc)) {
+			chefs = append(chefs, fc)
+		}
+	}
+	slices.SortFunc(chefs, func(a, b model.FollowedChef) int {
+		return newestFirst(a.FollowedAt, a.ID, b.FollowedAt, b.ID)
+	})
+	chefs, next := trimPage(chefs, page.EffectiveLimit(), followCursor)
+	return chefs, next, nil
+}
+
+// Feed returns one page of the published, live recipes of the chefs that
+// chefID follows, most recently published first, each with its rating
+// summary and favorited count.
+func (s *MemoryStore) Feed(ctx context.Context, chefID string, page model.PageRequest) ([]model.Recipe, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var recipes []model.Recipe
+	for _, r := range s.recipes {
+		if _, ok := s.follows[followKey{chefID, r.ChefID}]; !ok {
+			continue
+		}
+		if r.Status != model.StatusPublished || r.PublishedAt == nil || r.DeletedAt != nil ||
+			!afterCursor(page.Cursor, feedCursor(r)) {
+			continue
+		}
+		r.RatingSummary = s.ratingSummary(r.ID)
+		r.FavoritedCount = s.favoritedCount(r.ID)
+		recipes = append(recipes, r)
+	}
+	slices.SortFunc(recipes, func(a, b model.Recipe) int {
+		return newestFirst(*a.PublishedAt, a.ID, *b.PublishedAt, b.ID)
+	})
+	recipes, next := trimPage(recipes, page.EffectiveLimit(), feedCursor)
+	return recipes, next, nil
+}
+
+// SetBlobStore sets the blob store holding recipe images, from which
+// PurgeDeleted removes the images of purged recipes.
+func (s *MemoryStore) SetBlobStore(b BlobStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs = b
+}
+
+// ListRecipeImages returns the images of a recipe in upload order.
+func (s *MemoryStore) ListRecipeImages(ctx context.Context, recipeID string) ([]model.RecipeImage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.images[recipeID]), nil
+}
+
+// GetRecipeImage returns one image of a recipe, or nil if not found.
+func (s *MemoryStore) GetRecipeImage(ctx context.Context, recipeID, imageID string) (*model.RecipeImage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := slices.IndexFunc(s.images[recipeID], func(img model.RecipeImage) bool { return img.ID == imageID })
+	if i < 0 {
+		return nil, nil
+	}
+	img := s.images[recipeID][i]
+	return &img, nil
+}
+
+// CreateRecipeImage records the metadata of an image whose blobs have
+// already been stored.
+func (s *MemoryStore) CreateRecipeImage(ctx context.Context, img model.RecipeImage) (*model.RecipeImage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.images[img.RecipeID]) >= model.MaxRecipeImages {
+		return nil, ErrTooManyImages
+	}
+	img.CreatedAt = time.Now().UTC()
+	s.images[img.RecipeID] = append(s.images[img.RecipeID], img)
+	record(ctx, s, model.EntityRecipeImage, img.RecipeID, model.ActionCreate, nil, &img)
+	return &img, nil
+}
+
+// ListAuditEvents returns one page of audit events for an entity, newest first.
+func (s *MemoryStore) ListAuditEvents(ctx context.Context, entityID string, page model.PageRequest) ([]model.AuditEvent, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []model.AuditEvent
+	for _, e := range s.events {
+		if e.EntityID == entityID && afterCursor(page.Cursor, auditCursor(e)) {
+			events = append(events, e)
+		}
+	}
+	slices.SortFunc(events, func(a, b model.AuditEvent) int {
+		return newestFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	events, next := trimPage(events, page.EffectiveLimit(), auditCursor)
+	return events, next, nil
+}
+
+// PurgeDeleted permanently removes rows soft-deleted before the cutoff, along
+// with the ingredient lines, tag links, collection memberships, images, and
+// ratings of purged recipes and the collections and follows of purged chefs.
+// Image blobs are removed from the blob store set with SetBlobStore.
+func (s *MemoryStore) PurgeDeleted(ctx context.Context, before time.Time) (*model.PurgeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := func(deletedAt *time.Time) bool {
+		return deletedAt != nil && deletedAt.Before(before)
+	}
+	var result model.PurgeResult
+	purged := make(map[string]bool)
+	for id, r := range s.recipes {
+		if expired(r.DeletedAt) {
+			purged[id] = true
+			result.Recipes++
+			result.Ingredients += len(s.ingredients[id])
+			r.IngredientList, r.Tags = s.ingredients[id], s.tagSlugs(id)
+			record(ctx, s, model.EntityRecipe, id, model.ActionPurge, &r, nil)
+			delete(s.recipes, id)
+			delete(s.ingredients, id)
+			delete(s.recipeTags, id)
+		}
+	}
+	for id := range purged {
+		images := s.images[id]
+		if err := deleteImageBlobs(ctx, s.blobs, images); err != nil {
+			return &result, err
+		}
+		for i := range images {
+			result.Images++
+			record(ctx, s, model.EntityRecipeImage, id, model.ActionPurge, &images[i], nil)
+		}
+		delete(s.images, id)
+		result.Revisions += len(s.revisions[id])
+		delete(s.revisions, id)
+	}
+	for cid, items := range s.collectionItems {
+		kept := items[:0:0]
+		for _, it := range items {
+			if purged[it.RecipeID] {
+				record(ctx, s, model.EntityCollectionItem, cid, model.ActionPurge, &it, nil)
+				continue
+			}
+			kept = append(kept, it)
+		}
+		s.collectionItems[cid] = kept
+	}
+	for rid, items := range s.hiddenItems {
+		if !purged[rid] {
+			continue
+		}
+		for _, it := range items {
+			record(ctx, s, model.EntityCollectionItem, it.CollectionID, model.ActionPurge, &it, nil)
+		}
+		delete(s.hiddenItems, rid)
+	}
+	for id, r := range s.ratings {
+		if purged[r.RecipeID] || expired(r.DeletedAt) {
+			result.Ratings++
+			record(ctx, s, model.EntityRating, id, model.ActionPurge, &r, nil)
+			delete(s.ratings, id)
+		}
+	}
+	for id, c := range s.collections {
+		if chef, ok := s.chefs[c.ChefID]; ok && expired(chef.DeletedAt) {
+			result.Collections++
+			s.deleteCollection(ctx, id)
+		}
+	}
+	for key, f := range s.follows {
+		follower, followee := s.chefs[key.follower], s.chefs[key.followee]
+		if expired(follower.DeletedAt) || expired(followee.DeletedAt) {
+			result.Follows++
+			record(ctx, s, model.EntityFollow, key.follower, model.ActionPurge, &f, nil)
+			delete(s.follows, key)
+		}
+	}
+	for id, c := range s.chefs {
+		if expired(c.DeletedAt) {
+			result.Chefs++
+			record(ctx, s, model.EntityChef, id, model.ActionPurge, &c, nil)
+			delete(s.chefs, id)
+		}
+	}
+	return &result, nil
+}
@@ -0,0 +1,32 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import "github.com/aws-samples/recipe-share-dsql-go/internal/model"
+
+// trimPage cuts items, which were fetched with one extra row beyond limit,
+// down to the page size and returns the encoded cursor for the next page.
+// The cursor is empty when there are no further rows.
+func trimPage[T any](items []T, limit int, key func(T) model.Cursor) ([]T, string) {
+	if len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, key(items[limit-1]).Encode()
+}
+
+// afterCursor reports whether a row with the given key sorts after the cursor
+// in (created_at DESC, id DESC) order. A nil cursor matches every row.
+func afterCursor(c *model.Cursor, key model.Cursor) bool {
+	if c == nil {
+		return true
+	}
+	return newestFirst(key.CreatedAt, key.ID, c.CreatedAt, c.ID) > 0
+}
+
+// ratingOrderCursor returns the cursor key of a recipe in model.SortRating order.
+// The recipe must carry its rating summary.
+func ratingOrderCursor(r model.Recipe) model.Cursor {
+	return model.Cursor{Score: r.AverageScore, Rank: r.RatingCount, CreatedAt: r.CreatedAt, ID: r.ID}
+}
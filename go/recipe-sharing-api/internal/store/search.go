@@ -0,0 +1,66 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"strings"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+)
+
+// Relevance weights for recipe search. Each matching term contributes the
+// weight of every field it appears in, so a title match outranks a match in
+// both the ingredients and the description.
+const (
+	searchWeightTitle       = 4
+	searchWeightIngredients = 2
+	searchWeightDescription = 1
+)
+
+// likeEscaper escapes LIKE wildcards so search terms match literally.
+// Backslash is the default LIKE escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePattern returns a LIKE pattern that matches term anywhere in a value.
+func likePattern(term string) string {
+	return "%" + likeEscaper.Replace(term) + "%"
+}
+
+// searchRank scores a recipe against lower-cased search terms. It reports
+// false if any term is missing from all searchable fields.
+func searchRank(r model.Recipe, terms []string) (int, bool) {
+	title := strings.ToLower(r.Title)
+	ingredients := strings.ToLower(r.Ingredients)
+	description := strings.ToLower(r.Description)
+
+	rank := 0
+	for _, term := range terms {
+		matched := false
+		if strings.Contains(title, term) {
+			rank += searchWeightTitle
+			matched = true
+		}
+		if strings.Contains(ingredients, term) {
+			rank += searchWeightIngredients
+			matched = true
+		}
+		if strings.Contains(description, term) {
+			rank += searchWeightDescription
+			matched = true
+		}
+		if !matched {
+			return 0, false
+		}
+	}
+	return rank, true
+}
+
+// compareRanked orders search results by rank descending, then by creation
+// date descending and ID descending.
+func compareRanked(a, b model.Cursor) int {
+	if a.Rank != b.Rank {
+		return b.Rank - a.Rank
+	}
+	return newestFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+}
@@ -5,36 +5,183 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
 )
 
 // Store defines the database operations for the recipe sharing API.
 // The Amazon Aurora DSQL implementation satisfies this interface.
+//
+// List operations use keyset pagination ordered by (created_at, id), newest
+// first. They return at most one page of results along with the encoded
+// cursor for the next page, which is empty on the last page.
+//
+// Deletes are soft: they set deleted_at, and list and get operations hide
+// soft-deleted rows unless the context comes from IncludeDeleted. Restore
+// operations undo a delete, and PurgeDeleted removes rows permanently once
+// they have been deleted for longer than the retention period. Restore
+// operations return nil if the row does not exist.
 type Store interface {
-	// InitSchema creates the database tables if they do not already exist.
-	InitSchema(ctx context.Context) error
-
 	// Close releases any resources held by the store.
 	Close() error
 
 	// Chef operations
-	ListChefs(ctx context.Context) ([]model.Chef, error)
+	ListChefs(ctx context.Context, page model.PageRequest) ([]model.Chef, string, error)
 	GetChef(ctx context.Context, id string) (*model.Chef, error)
 	GetChefWithRecipes(ctx context.Context, id string) (*model.ChefWithRecipes, error)
 	CreateChef(ctx context.Context, input model.CreateChefInput) (*model.Chef, error)
 	UpdateChef(ctx context.Context, id string, input model.UpdateChefInput) (*model.Chef, error)
-	DeleteChef(ctx context.Context, id string) error
+	// DeleteChef also deletes the chef's recipes, the ratings the chef
+	// wrote, and the ratings on the chef's recipes, and reports how many
+	// rows of each kind were deleted. Calling it again on a
+	// deleted chef finishes an interrupted cascade.
+	DeleteChef(ctx context.Context, id string) (*model.ChefDeletion, error)
+	// RestoreChef undoes DeleteChef, restoring the rows its cascade deleted.
+	RestoreChef(ctx context.Context, id string) (*model.Chef, error)
 
 	// Recipe operations
-	ListRecipes(ctx context.Context, filter model.RecipeFilter) ([]model.Recipe, error)
+	ListRecipes(ctx context.Context, filter model.RecipeFilter, page model.PageRequest) ([]model.Recipe, string, error)
 	GetRecipe(ctx context.Context, id string) (*model.Recipe, error)
 	GetRecipeWithRatings(ctx context.Context, id string) (*model.RecipeWithRatings, error)
 	CreateRecipe(ctx context.Context, input model.CreateRecipeInput) (*model.Recipe, error)
 	UpdateRecipe(ctx context.Context, id string, input model.UpdateRecipeInput) (*model.Recipe, error)
 	DeleteRecipe(ctx context.Context, id string) error
+	RestoreRecipe(ctx context.Context, id string) (*model.Recipe, error)
+
+	// CreateRecipes inserts recipes in bulk, in input order, committing in
+	// chunks sized to the Aurora DSQL transaction limits. On error it
+	// returns the recipes created so far, a prefix of the input.
+	// ExportRecipes calls fn for every live recipe, oldest first, reading
+	// them in batches so the whole set is never held in memory.
+	CreateRecipes(ctx context.Context, inputs []model.CreateRecipeInput) ([]model.Recipe, error)
+	ExportRecipes(ctx context.Context, fn func(model.Recipe) error) error
+
+	// ForkRecipe copies a live, published recipe, with its ingredients and
+	// tags, into a new draft owned by chefID, in one transaction. It returns
+	// nil if the recipe does not exist and ErrNotPublished if it is not
+	// published. RecipeLineage returns the ancestors of a recipe, nearest
+	// first, and one page of its direct forks, newest first, or nil if the
+	// recipe does not exist.
+	ForkRecipe(ctx context.Context, id, chefID string) (*model.Recipe, error)
+	RecipeLineage(ctx context.Context, id string, page model.PageRequest) (*model.Lineage, error)
+
+	// TransitionRecipe applies one of the model.Transition constants to a
+	// live recipe and returns the updated recipe, or nil if it does not
+	// exist. Publishing a draft with a publishAt in the future schedules it
+	// instead. It returns an error wrapping ErrInvalidTransition if the
+	// recipe's status does not allow the transition, and
+	// ErrPreconditionFailed if ifVersion is non-nil and does not match.
+	// PublishDue publishes the live drafts scheduled for now or earlier and
+	// returns how many it published; a draft is published once however many
+	// sweeps overlap.
+	TransitionRecipe(ctx context.Context, id, transition string, publishAt *time.Time, ifVersion *int64) (*model.Recipe, error)
+	PublishDue(ctx context.Context, now time.Time) (int, error)
+
+	// Recipe revision operations. Every update of a recipe, including a
+	// status transition, records the version it replaces as a revision
+	// numbered by that version, in the same transaction. Revisions are never
+	// changed and are removed only when the recipe is purged.
+	// ListRecipeRevisions returns one page of a recipe's revisions, newest
+	// first, without their ingredient lines. GetRecipeRevision returns nil
+	// if the recipe has no such revision.
+	ListRecipeRevisions(ctx context.Context, recipeID string, page model.PageRequest) ([]model.RecipeRevision, string, error)
+	GetRecipeRevision(ctx context.Context, recipeID string, revision int64) (*model.RecipeRevision, error)
+
+	// SearchRecipes returns one page of recipes matching every term
+	// case-insensitively in the title, description, or ingredients, ordered
+	// by relevance and then by creation date.
+	SearchRecipes(ctx context.Context, terms []string, page model.PageRequest) ([]model.Recipe, string, error)
 
 	// Rating operations
-	ListRatings(ctx context.Context, recipeID string) ([]model.Rating, error)
+	ListRatings(ctx context.Context, recipeID string, page model.PageRequest) ([]model.Rating, string, error)
+	GetRating(ctx context.Context, id string) (*model.Rating, error)
+	// A chef has at most one live rating per recipe. CreateRating returns an
+	// error wrapping ErrConflict if the chef has already rated the recipe;
+	// UpsertRating updates that rating instead and reports whether it created
+	// a new one. RestoreRating also returns ErrConflict if the chef has rated
+	// the recipe again since the rating was deleted.
 	CreateRating(ctx context.Context, recipeID string, input model.CreateRatingInput) (*model.Rating, error)
+	UpsertRating(ctx context.Context, recipeID string, input model.CreateRatingInput) (*model.Rating, bool, error)
+	UpdateRating(ctx context.Context, id string, input model.UpdateRatingInput) (*model.Rating, error)
+	DeleteRating(ctx context.Context, id string) error
+	RestoreRating(ctx context.Context, id string) (*model.Rating, error)
+
+	// Tag operations. ListTags returns one page of tags in slug order rather
+	// than newest first. AddRecipeTags creates tags by name as needed and
+	// returns the slugs of all the recipe's tags; RemoveRecipeTag reports
+	// whether the recipe had the tag.
+	ListTags(ctx context.Context, page model.PageRequest) ([]model.TagUsage, string, error)
+	AddRecipeTags(ctx context.Context, recipeID string, names []string) ([]string, error)
+	RemoveRecipeTag(ctx context.Context, recipeID, slug string) (bool, error)
+
+	// Collection operations. Collections are deleted outright rather than
+	// soft-deleted, along with their items. Item positions start at 1 and a
+	// position past the end means the end. AddCollectionItem returns an
+	// error wrapping ErrConflict if the recipe is already in the collection
+	// and ErrCollectionFull if the collection is full; it and
+	// MoveCollectionItem return nil if the collection or item does not exist.
+	// A deleted recipe's items are hidden, freeing their places and
+	// positions, and come back at their old positions when the recipe is
+	// restored unless their collection has filled up in the meantime.
+	ListCollections(ctx context.Context, chefID string, page model.PageRequest) ([]model.Collection, string, error)
+	GetCollection(ctx context.Context, id string) (*model.CollectionWithItems, error)
+	CreateCollection(ctx context.Context, input model.CreateCollectionInput) (*model.Collection, error)
+	UpdateCollection(ctx context.Context, id string, input model.UpdateCollectionInput) (*model.Collection, error)
+	DeleteCollection(ctx context.Context, id string) (bool, error)
+	AddCollectionItem(ctx context.Context, collectionID string, input model.AddCollectionItemInput) (*model.CollectionItem, error)
+	MoveCollectionItem(ctx context.Context, collectionID, recipeID string, position int) (*model.CollectionItem, error)
+	RemoveCollectionItem(ctx context.Context, collectionID, recipeID string) (bool, error)
+
+	// Follow operations. Follow reports whether the follow is new;
+	// following a chef twice is not an error. Unfollow reports whether the
+	// follow existed. ListFollowers and ListFollowing return live chefs,
+	// most recent follow first. Feed returns the published, live recipes of
+	// the chefs a chef follows, most recently published first.
+	Follow(ctx context.Context, followerID, followeeID string) (bool, error)
+	Unfollow(ctx context.Context, followerID, followeeID string) (bool, error)
+	ListFollowers(ctx context.Context, chefID string, page model.PageRequest) ([]model.FollowedChef, string, error)
+	ListFollowing(ctx context.Context, chefID string, page model.PageRequest) ([]model.FollowedChef, string, error)
+	Feed(ctx context.Context, chefID string, page model.PageRequest) ([]model.Recipe, string, error)
+
+	// Recipe image operations. Images are listed in upload order. The image
+	// bytes live in a BlobStore; the store keeps only their metadata and
+	// blob keys. CreateRecipeImage returns ErrTooManyImages if the recipe
+	// already has model.MaxRecipeImages images. DeleteRecipe keeps a
+	// recipe's images and their blobs so that RestoreRecipe brings them
+	// back; only PurgeDeleted deletes the blobs.
+	ListRecipeImages(ctx context.Context, recipeID string) ([]model.RecipeImage, error)
+	GetRecipeImage(ctx context.Context, recipeID, imageID string) (*model.RecipeImage, error)
+	CreateRecipeImage(ctx context.Context, img model.RecipeImage) (*model.RecipeImage, error)
+
+	// ListAuditEvents returns one page of the audit events recorded for an
+	// entity. Every create, update, delete, restore, and purge records an
+	// event attributed to the actor set with WithActor.
+	ListAuditEvents(ctx context.Context, entityID string, page model.PageRequest) ([]model.AuditEvent, string, error)
+
+	// PurgeDeleted permanently removes rows soft-deleted before the cutoff,
+	// along with the ingredient lines, tag links, collection memberships,
+	// images, revisions, and ratings of purged recipes and the collections
+	// and follows of purged chefs. Image blobs are deleted from the blob
+	// store before their rows, so an interrupted purge never leaves a blob
+	// without a row pointing to it.
+	PurgeDeleted(ctx context.Context, before time.Time) (*model.PurgeResult, error)
+}
+
+// As reports whether s, or a store that s decorates, has type T, and
+// returns the first such store. Decorators such as CachingStore and
+// TracingStore expose the store they wrap with an Unwrap method.
+func As[T Store](s Store) (T, bool) {
+	for s != nil {
+		if t, ok := s.(T); ok {
+			return t, true
+		}
+		u, ok := s.(interface{ Unwrap() Store })
+		if !ok {
+			break
+		}
+		s = u.Unwrap()
+	}
+	var zero T
+	return zero, false
 }
@@ -0,0 +1,371 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerScope = "github.com/aws-samples/recipe-share-dsql-go/internal/store"
+
+// Attributes identifying what a store method operates on.
+var (
+	chefIDKey       = attribute.Key("app.chef.id")
+	followeeIDKey   = attribute.Key("app.followee.id")
+	recipeIDKey     = attribute.Key("app.recipe.id")
+	ratingIDKey     = attribute.Key("app.rating.id")
+	collectionIDKey = attribute.Key("app.collection.id")
+	entityIDKey     = attribute.Key("app.entity.id")
+	transitionKey   = attribute.Key("app.recipe.transition")
+	revisionKey     = attribute.Key("app.recipe.revision")
+	countKey        = attribute.Key("app.count")
+)
+
+// TracingStore is a Store decorator that records a span for every method
+// call, named like "Store.GetRecipe" and tagged with the IDs it was given.
+// A returned error is recorded on the span and marks it as failed. The
+// spans of the statements a call runs, and any OCC retries, appear beneath
+// it. Every method is implemented explicitly, so a method added to Store
+// cannot go untraced.
+type TracingStore struct {
+	next Store
+}
+
+var _ Store = (*TracingStore)(nil)
+
+// NewTracingStore wraps s so that its method calls are traced through the
+// global tracer provider.
+func NewTracingStore(s Store) *TracingStore {
+	return &TracingStore{next: s}
+}
+
+// Unwrap returns the store that s decorates.
+func (s *TracingStore) Unwrap() Store { return s.next }
+
+// startSpan starts the span of the store method name.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerScope).Start(ctx, "Store."+name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err, if any, ends span, and returns err.
+func endSpan(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	return err
+}
+
+func (s *TracingStore) Close() error { return s.next.Close() }
+
+func (s *TracingStore) ListChefs(ctx context.Context, page model.PageRequest) ([]model.Chef, string, error) {
+	ctx, span := startSpan(ctx, "ListChefs")
+	v, next, err := s.next.ListChefs(ctx, page)
+	return v, next, endSpan(span, err)
+}
+
+func (s *TracingStore) GetChef(ctx context.Context, id string) (*model.Chef, error) {
+	ctx, span := startSpan(ctx, "GetChef", chefIDKey.String(id))
+	v, err := s.next.GetChef(ctx, id)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) GetChefWithRecipes(ctx context.Context, id string) (*model.ChefWithRecipes, error) {
+	ctx, span := startSpan(ctx, "GetChefWithRecipes", chefIDKey.String(id))
+	v, err := s.next.GetChefWithRecipes(ctx, id)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) CreateChef(ctx context.Context, input model.CreateChefInput) (*model.Chef, error) {
+	ctx, span := startSpan(ctx, "CreateChef")
+	v, err := s.next.CreateChef(ctx, input)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) UpdateChef(ctx context.Context, id string, input model.UpdateChefInput) (*model.Chef, error) {
+	ctx, span := startSpan(ctx, "UpdateChef", chefIDKey.String(id))
+	v, err := s.next.UpdateChef(ctx, id, input)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) DeleteChef(ctx context.Context, id string) (*model.ChefDeletion, error) {
+	ctx, span := startSpan(ctx, "DeleteChef", chefIDKey.String(id))
+	v, err := s.next.DeleteChef(ctx, id)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) RestoreChef(ctx context.Context, id string) (*model.Chef, error) {
+	ctx, span := startSpan(ctx, "RestoreChef", chefIDKey.String(id))
+	v, err := s.next.RestoreChef(ctx, id)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) ListRecipes(ctx context.Context, filter model.RecipeFilter, page model.PageRequest) ([]model.Recipe, string, error) {
+	ctx, span := startSpan(ctx, "ListRecipes")
+	v, next, err := s.next.ListRecipes(ctx, filter, page)
+	return v, next, endSpan(span, err)
+}
+
+func (s *TracingStore) GetRecipe(ctx context.Context, id string) (*model.Recipe, error) {
+	ctx, span := startSpan(ctx, "GetRecipe", recipeIDKey.String(id))
+	v, err := s.next.GetRecipe(ctx, id)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) GetRecipeWithRatings(ctx context.Context, id string) (*model.RecipeWithRatings, error) {
+	ctx, span := startSpan(ctx, "GetRecipeWithRatings", recipeIDKey.String(id))
+	v, err := s.next.GetRecipeWithRatings(ctx, id)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) CreateRecipe(ctx context.Context, input model.CreateRecipeInput) (*model.Recipe, error) {
+	ctx, span := startSpan(ctx, "CreateRecipe")
+	v, err := s.next.CreateRecipe(ctx, input)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) UpdateRecipe(ctx context.Context, id string, input model.UpdateRecipeInput) (*model.Recipe, error) {
+	ctx, span := startSpan(ctx, "UpdateRecipe", recipeIDKey.String(id))
+	v, err := s.next.UpdateRecipe(ctx, id, input)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) DeleteRecipe(ctx context.Context, id string) error {
+	ctx, span := startSpan(ctx, "DeleteRecipe", recipeIDKey.String(id))
+	return endSpan(span, s.next.DeleteRecipe(ctx, id))
+}
+
+func (s *TracingStore) RestoreRecipe(ctx context.Context, id string) (*model.Recipe, error) {
+	ctx, span := startSpan(ctx, "RestoreRecipe", recipeIDKey.String(id))
+	v, err := s.next.RestoreRecipe(ctx, id)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) CreateRecipes(ctx context.Context, inputs []model.CreateRecipeInput) ([]model.Recipe, error) {
+	ctx, span := startSpan(ctx, "CreateRecipes", countKey.Int(len(inputs)))
+	v, err := s.next.CreateRecipes(ctx, inputs)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) ExportRecipes(ctx context.Context, fn func(model.Recipe) error) error {
+	ctx, span := startSpan(ctx, "ExportRecipes")
+	return endSpan(span, s.next.ExportRecipes(ctx, fn))
+}
+
+func (s *TracingStore) ForkRecipe(ctx context.Context, id, chefID string) (*model.Recipe, error) {
+	ctx, span := startSpan(ctx, "ForkRecipe", recipeIDKey.String(id), chefIDKey.String(chefID))
+	v, err := s.next.ForkRecipe(ctx, id, chefID)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) RecipeLineage(ctx context.Context, id string, page model.PageRequest) (*model.Lineage, error) {
+	ctx, span := startSpan(ctx, "RecipeLineage", recipeIDKey.String(id))
+	v, err := s.next.RecipeLineage(ctx, id, page)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) TransitionRecipe(ctx context.Context, id, transition string, publishAt *time.Time, ifVersion *int64) (*model.Recipe, error) {
+	ctx, span := startSpan(ctx, "TransitionRecipe", recipeIDKey.String(id), transitionKey.String(transition))
+	v, err := s.next.TransitionRecipe(ctx, id, transition, publishAt, ifVersion)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := startSpan(ctx, "PublishDue")
+	v, err := s.next.PublishDue(ctx, now)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) ListRecipeRevisions(ctx context.Context, recipeID string, page model.PageRequest) ([]model.RecipeRevision, string, error) {
+	ctx, span := startSpan(ctx, "ListRecipeRevisions", recipeIDKey.String(recipeID))
+	v, next, err := s.next.ListRecipeRevisions(ctx, recipeID, page)
+	return v, next, endSpan(span, err)
+}
+
+func (s *TracingStore) GetRecipeRevision(ctx context.Context, recipeID string, revision int64) (*model.RecipeRevision, error) {
+	ctx, span := startSpan(ctx, "GetRecipeRevision", recipeIDKey.String(recipeID), revisionKey.Int64(revision))
+	v, err := s.next.GetRecipeRevision(ctx, recipeID, revision)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) SearchRecipes(ctx context.Context, terms []string, page model.PageRequest) ([]model.Recipe, string, error) {
+	ctx, span := startSpan(ctx, "SearchRecipes")
+	v, next, err := s.next.SearchRecipes(ctx, terms, page)
+	return v, next, endSpan(span, err)
+}
+
+func (s *TracingStore) ListRatings(ctx context.Context, recipeID string, page model.PageRequest) ([]model.Rating, string, error) {
+	ctx, span := startSpan(ctx, "ListRatings", recipeIDKey.String(recipeID))
+	v, next, err := s.next.ListRatings(ctx, recipeID, page)
+	return v, next, endSpan(span, err)
+}
+
+func (s *TracingStore) GetRating(ctx context.Context, id string) (*model.Rating, error) {
+	ctx, span := startSpan(ctx, "GetRating", ratingIDKey.String(id))
+	v, err := s.next.GetRating(ctx, id)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) CreateRating(ctx context.Context, recipeID string, input model.CreateRatingInput) (*model.Rating, error) {
+	ctx, span := startSpan(ctx, "CreateRating", recipeIDKey.String(recipeID))
+	v, err := s.next.CreateRating(ctx, recipeID, input)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) UpsertRating(ctx context.Context, recipeID string, input model.CreateRatingInput) (*model.Rating, bool, error) {
+	ctx, span := startSpan(ctx, "UpsertRating", recipeIDKey.String(recipeID))
+	v, ok, err := s.next.UpsertRating(ctx, recipeID, input)
+	return v, ok, endSpan(span, err)
+}
+
+func (s *TracingStore) UpdateRating(ctx context.Context, id string, input model.UpdateRatingInput) (*model.Rating, error) {
+	ctx, span := startSpan(ctx, "UpdateRating", ratingIDKey.String(id))
+	v, err := s.next.UpdateRating(ctx, id, input)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) DeleteRating(ctx context.Context, id string) error {
+	ctx, span := startSpan(ctx, "DeleteRating", ratingIDKey.String(id))
+	return endSpan(span, s.next.DeleteRating(ctx, id))
+}
+
+func (s *TracingStore) RestoreRating(ctx context.Context, id string) (*model.Rating, error) {
+	ctx, span := startSpan(ctx, "RestoreRating", ratingIDKey.String(id))
+	v, err := s.next.RestoreRating(ctx, id)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) ListTags(ctx context.Context, page model.PageRequest) ([]model.TagUsage, string, error) {
+	ctx, span := startSpan(ctx, "ListTags")
+	v, next, err := s.next.ListTags(ctx, page)
+	return v, next, endSpan(span, err)
+}
+
+func (s *TracingStore) AddRecipeTags(ctx context.Context, recipeID string, names []string) ([]string, error) {
+	ctx, span := startSpan(ctx, "AddRecipeTags", recipeIDKey.String(recipeID))
+	v, err := s.next.AddRecipeTags(ctx, recipeID, names)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) RemoveRecipeTag(ctx context.Context, recipeID, slug string) (bool, error) {
+	ctx, span := startSpan(ctx, "RemoveRecipeTag", recipeIDKey.String(recipeID))
+	v, err := s.next.RemoveRecipeTag(ctx, recipeID, slug)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) ListCollections(ctx context.Context, chefID string, page model.PageRequest) ([]model.Collection, string, error) {
+	ctx, span := startSpan(ctx, "ListCollections", chefIDKey.String(chefID))
+	v, next, err := s.next.ListCollections(ctx, chefID, page)
+	return v, next, endSpan(span, err)
+}
+
+func (s *TracingStore) GetCollection(ctx context.Context, id string) (*model.CollectionWithItems, error) {
+	ctx, span := startSpan(ctx, "GetCollection", collectionIDKey.String(id))
+	v, err := s.next.GetCollection(ctx, id)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) CreateCollection(ctx context.Context, input model.CreateCollectionInput) (*model.Collection, error) {
+	ctx, span := startSpan(ctx, "CreateCollection")
+	v, err := s.next.CreateCollection(ctx, input)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) UpdateCollection(ctx context.Context, id string, input model.UpdateCollectionInput) (*model.Collection, error) {
+	ctx, span := startSpan(ctx, "UpdateCollection", collectionIDKey.String(id))
+	v, err := s.next.UpdateCollection(ctx, id, input)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) DeleteCollection(ctx context.Context, id string) (bool, error) {
+	ctx, span := startSpan(ctx, "DeleteCollection", collectionIDKey.String(id))
+	v, err := s.next.DeleteCollection(ctx, id)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) AddCollectionItem(ctx context.Context, collectionID string, input model.AddCollectionItemInput) (*model.CollectionItem, error) {
+	ctx, span := startSpan(ctx, "AddCollectionItem", collectionIDKey.String(collectionID))
+	v, err := s.next.AddCollectionItem(ctx, collectionID, input)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) MoveCollectionItem(ctx context.Context, collectionID, recipeID string, position int) (*model.CollectionItem, error) {
+	ctx, span := startSpan(ctx, "MoveCollectionItem", collectionIDKey.String(collectionID), recipeIDKey.String(recipeID))
+	v, err := s.next.MoveCollectionItem(ctx, collectionID, recipeID, position)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) RemoveCollectionItem(ctx context.Context, collectionID, recipeID string) (bool, error) {
+	ctx, span := startSpan(ctx, "RemoveCollectionItem", collectionIDKey.String(collectionID), recipeIDKey.String(recipeID))
+	v, err := s.next.RemoveCollectionItem(ctx, collectionID, recipeID)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) Follow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	ctx, span := startSpan(ctx, "Follow", chefIDKey.String(followerID), followeeIDKey.String(followeeID))
+	v, err := s.next.Follow(ctx, followerID, followeeID)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) Unfollow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	ctx, span := startSpan(ctx, "Unfollow", chefIDKey.String(followerID), followeeIDKey.String(followeeID))
+	v, err := s.next.Unfollow(ctx, followerID, followeeID)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) ListFollowers(ctx context.Context, chefID string, page model.PageRequest) ([]model.FollowedChef, string, error) {
+	ctx, span := startSpan(ctx, "ListFollowers", chefIDKey.String(chefID))
+	v, next, err := s.next.ListFollowers(ctx, chefID, page)
+	return v, next, endSpan(span, err)
+}
+
+func (s *TracingStore) ListFollowing(ctx context.Context, chefID string, page model.PageRequest) ([]model.FollowedChef, string, error) {
+	ctx, span := startSpan(ctx, "ListFollowing", chefIDKey.String(chefID))
+	v, next, err := s.next.ListFollowing(ctx, chefID, page)
+	return v, next, endSpan(span, err)
+}
+
+func (s *TracingStore) Feed(ctx context.Context, chefID string, page model.PageRequest) ([]model.Recipe, string, error) {
+	ctx, span := startSpan(ctx, "Feed", chefIDKey.String(chefID))
+	v, next, err := s.next.Feed(ctx, chefID, page)
+	return v, next, endSpan(span, err)
+}
+
+func (s *TracingStore) ListRecipeImages(ctx context.Context, recipeID string) ([]model.RecipeImage, error) {
+	ctx, span := startSpan(ctx, "ListRecipeImages", recipeIDKey.String(recipeID))
+	v, err := s.next.ListRecipeImages(ctx, recipeID)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) GetRecipeImage(ctx context.Context, recipeID, imageID string) (*model.RecipeImage, error) {
+	ctx, span := startSpan(ctx, "GetRecipeImage", recipeIDKey.String(recipeID))
+	v, err := s.next.GetRecipeImage(ctx, recipeID, imageID)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) CreateRecipeImage(ctx context.Context, img model.RecipeImage) (*model.RecipeImage, error) {
+	ctx, span := startSpan(ctx, "CreateRecipeImage", recipeIDKey.String(img.RecipeID))
+	v, err := s.next.CreateRecipeImage(ctx, img)
+	return v, endSpan(span, err)
+}
+
+func (s *TracingStore) ListAuditEvents(ctx context.Context, entityID string, page model.PageRequest) ([]model.AuditEvent, string, error) {
+	ctx, span := startSpan(ctx, "ListAuditEvents", entityIDKey.String(entityID))
+	v, next, err := s.next.ListAuditEvents(ctx, entityID, page)
+	return v, next, endSpan(span, err)
+}
+
+func (s *TracingStore) PurgeDeleted(ctx context.Context, before time.Time) (*model.PurgeResult, error) {
+	ctx, span := startSpan(ctx, "PurgeDeleted")
+	v, err := s.next.PurgeDeleted(ctx, before)
+	return v, endSpan(span, err)
+}
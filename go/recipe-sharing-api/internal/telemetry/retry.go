@@ -0,0 +1,99 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package telemetry
+
+import (
+	"context"
+
+	"github.com/awslabs/aurora-dsql-connectors/go/pgx/occretry"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Pool is the part of a *pgxpool.Pool that a retrying DB uses.
+type Pool interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// NewRetryDB returns an occretry.DB that retries optimistic concurrency
+// conflicts like the one occretry.New returns, and records every retry as
+// an "occ.retry" event on the span in the context, with the attempt number
+// and the conflict that caused it. It does not honor occretry.NoRetry.
+func NewRetryDB(pool Pool, config occretry.Config) occretry.DB {
+	return &retryDB{pool: pool, config: config}
+}
+
+type retryDB struct {
+	pool   Pool
+	config occretry.Config
+}
+
+func (d *retryDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	var tag pgconn.CommandTag
+	a := attempts{span: trace.SpanFromContext(ctx)}
+	err := occretry.Retry(ctx, d.config, func() error {
+		a.start()
+		var err error
+		tag, err = d.pool.Exec(ctx, sql, args...)
+		a.last = err
+		return err
+	})
+	return tag, err
+}
+
+func (d *retryDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	var rows pgx.Rows
+	a := attempts{span: trace.SpanFromContext(ctx)}
+	err := occretry.Retry(ctx, d.config, func() error {
+		a.start()
+		var err error
+		rows, err = d.pool.Query(ctx, sql, args...)
+		a.last = err
+		return err
+	})
+	return rows, err
+}
+
+// QueryRow is not retried, since a row defers its error to Scan.
+func (d *retryDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return d.pool.QueryRow(ctx, sql, args...)
+}
+
+func (d *retryDB) WithTransaction(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	a := attempts{span: trace.SpanFromContext(ctx)}
+	return occretry.WithRetry(ctx, d.pool, d.config, func(tx pgx.Tx) error {
+		a.start()
+		err := fn(tx)
+		a.last = err
+		return err
+	})
+}
+
+// attempts counts the attempts of one retried operation.
+type attempts struct {
+	span trace.Span
+	n    int
+	last error // the error of the previous attempt; nil if it failed at commit
+}
+
+// start records the start of an attempt, adding an event for each retry.
+func (a *attempts) start() {
+	a.n++
+	if a.n == 1 {
+		return
+	}
+	attrs := []attribute.KeyValue{attribute.Int("occ.attempt", a.n)}
+	if a.last != nil {
+		attrs = append(attrs, attribute.String("occ.error", a.last.Error()))
+	} else {
+		attrs = append(attrs, attribute.String("occ.error", "commit conflict"))
+	}
+	a.span.AddEvent("occ.retry", trace.WithAttributes(attrs...))
+	a.last = nil
+}
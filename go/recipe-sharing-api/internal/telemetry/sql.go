@@ -0,0 +1,162 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package telemetry
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxQueryText bounds the length of the db.query.text attribute.
+const maxQueryText = 2048
+
+// QueryTracer is a pgx.QueryTracer that records a client span for every SQL
+// statement, named by its operation and table, such as
+// "SELECT recipe_share.recipes". The statement text is sanitized before it
+// is recorded, so literal values never reach the trace backend; bound
+// arguments are not recorded at all. Set it as the Tracer of the pool's
+// ConnConfig.
+type QueryTracer struct{}
+
+// TraceQueryStart starts the statement's span as a child of the span in ctx.
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	text := SanitizeSQL(data.SQL)
+	op, target := summarize(text)
+	name := op
+	if target != "" {
+		name += " " + target
+	}
+	ctx, _ = tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(op),
+			semconv.DBQuerySummary(name),
+			semconv.DBQueryText(text),
+		),
+	)
+	return ctx
+}
+
+// TraceQueryEnd records the number of rows affected, or the error, and ends
+// the statement's span. For a query it is called when the rows are closed.
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	} else {
+		span.SetAttributes(semconv.DBResponseReturnedRows(int(data.CommandTag.RowsAffected())))
+	}
+	span.End()
+}
+
+// SanitizeSQL returns sql with its string and numeric literals replaced by
+// "?" and runs of whitespace collapsed to one space, truncated to a bounded
+// length. Placeholders such as $1 and quoted identifiers are kept.
+func SanitizeSQL(sql string) string {
+	var b strings.Builder
+	space := false
+	var prev byte // last byte copied from sql, for telling numbers from identifiers
+	emit := func(s string) {
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteString(s)
+	}
+	for i := 0; i < len(sql) && b.Len() < maxQueryText; {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			prev = c
+			i++
+			continue
+		case c == '\'':
+			// A string literal, in which '' is an escaped quote.
+			j := i + 1
+			for j < len(sql) {
+				if sql[j] == '\'' {
+					if j+1 < len(sql) && sql[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					j++
+					break
+				}
+				j++
+			}
+			emit("?")
+			i = j
+		case c == '"':
+			j := strings.IndexByte(sql[i+1:], '"')
+			if j < 0 {
+				j = len(sql) - i - 2
+			}
+			emit(sql[i : i+j+2])
+			i += j + 2
+		case isDigit(c) && !isIdent(prev):
+			j := i
+			for j < len(sql) && (isDigit(sql[j]) || sql[j] == '.') {
+				j++
+			}
+			emit("?")
+			i = j
+		default:
+			emit(sql[i : i+1])
+			i++
+		}
+		prev = sql[i-1]
+	}
+	s := b.String()
+	if len(s) > maxQueryText {
+		s = s[:maxQueryText]
+	}
+	return s
+}
+
+// summarize returns the operation of a sanitized statement, such as
+// SELECT, and the first table it names, if it can tell.
+func summarize(sql string) (op, target string) {
+	words := strings.Fields(sql)
+	if len(words) == 0 {
+		return "", ""
+	}
+	op = strings.ToUpper(words[0])
+	after := ""
+	switch op {
+	case "SELECT", "DELETE":
+		after = "FROM"
+	case "INSERT":
+		after = "INTO"
+	case "UPDATE":
+		if len(words) > 1 {
+			target = words[1]
+		}
+	}
+	if after != "" {
+		for i, w := range words[:len(words)-1] {
+			if strings.EqualFold(w, after) {
+				target = words[i+1]
+				break
+			}
+		}
+	}
+	if strings.ContainsAny(target, "(?$") {
+		target = strings.TrimRight(target[:strings.IndexAny(target, "(?$")], ",;")
+	}
+	return op, strings.TrimRight(target, ",;")
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isIdent(c byte) bool {
+	return c == '_' || c == '$' || c == '"' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
@@ -0,0 +1,107 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package telemetry configures OpenTelemetry tracing for the API and traces
+// its database access. Spans are created through the global tracer
+// provider, which does nothing until Setup or SetupInMemory installs one,
+// so instrumented code costs little when tracing is off.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies the API in exported spans unless OTEL_SERVICE_NAME
+// says otherwise.
+const ServiceName = "recipe-sharing-api"
+
+const scope = "github.com/aws-samples/recipe-share-dsql-go/internal/telemetry"
+
+// Provider is the tracer provider installed by Setup. Its methods do
+// nothing when tracing is off.
+type Provider struct {
+	tp *sdktrace.TracerProvider
+}
+
+// ForceFlush exports any spans that have ended but not been exported yet.
+// On AWS Lambda it should be called before each invocation returns, since
+// the execution environment may be frozen before a batch is sent.
+func (p *Provider) ForceFlush(ctx context.Context) error {
+	if p.tp == nil {
+		return nil
+	}
+	return p.tp.ForceFlush(ctx)
+}
+
+// Shutdown exports any remaining spans and stops the provider.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.tp == nil {
+		return nil
+	}
+	return p.tp.Shutdown(ctx)
+}
+
+// Setup installs a global tracer provider that sends spans to the named
+// exporter: "stdout" writes them to standard output as JSON, and "otlp"
+// sends them over OTLP/HTTP to the collector configured by the standard
+// OTEL_EXPORTER_OTLP_* environment variables. An empty name or "none"
+// leaves tracing off. Trace context is propagated with the W3C traceparent
+// and baggage headers either way.
+func Setup(ctx context.Context, exporter string) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exp sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "", "none":
+		return &Provider{}, nil
+	case "stdout":
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		exp, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q: must be one of: none, stdout, otlp", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s trace exporter: %w", exporter, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create trace resource: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return &Provider{tp: tp}, nil
+}
+
+// SetupInMemory installs a global tracer provider that records every span
+// in the returned exporter as soon as it ends. It is meant for tests, which
+// can inspect the spans with GetSpans and clear them with Reset.
+func SetupInMemory() *tracetest.InMemoryExporter {
+	exp := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return exp
+}
+
+// tracer returns the package's tracer from the current global provider.
+func tracer() trace.Tracer {
+	return otel.Tracer(scope)
+}
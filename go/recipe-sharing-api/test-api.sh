@@ -141,9 +141,34 @@ assert_status "GET /api/v1/recipes?difficulty=easy (filter)" 200 "$RESP_STATUS"
 call GET "/api/v1/recipes?status=published"
 assert_status "GET /api/v1/recipes?status=published (filter)" 200 "$RESP_STATUS" "$RESP_BODY"
 
+call GET "/api/v1/recipes?limit=1"
+assert_status "GET /api/v1/recipes?limit=1 (first page)" 200 "$RESP_STATUS" "$RESP_BODY"
+CURSOR=$(json_field "$RESP_BODY" ".get('next_cursor', '')")
+if [[ -n "$CURSOR" ]]; then
+  call GET "/api/v1/recipes?limit=1&cursor=$CURSOR"
+  assert_status "GET /api/v1/recipes?cursor=... (next page)" 200 "$RESP_STATUS" "$RESP_BODY"
+fi
+
+call GET "/api/v1/recipes/search?q=french+eggs"
+assert_status "GET /api/v1/recipes/search?q=french+eggs (search)" 200 "$RESP_STATUS" "$RESP_BODY"
+COUNT=$(json_field "$RESP_BODY" "['count']")
+echo "  Search results: $COUNT"
+
+call GET "/api/v1/recipes/search?q=+"
+assert_status "GET /api/v1/recipes/search?q= (empty query)" 400 "$RESP_STATUS" "$RESP_BODY"
+
 call GET "/api/v1/recipes/$RECIPE1_ID"
 assert_status "GET /api/v1/recipes/:id (get with ratings)" 200 "$RESP_STATUS" "$RESP_BODY"
 
+call GET "/api/v1/recipes/$RECIPE1_ID?units=metric"
+assert_status "GET /api/v1/recipes/:id?units=metric (convert units)" 200 "$RESP_STATUS" "$RESP_BODY"
+
+call GET "/api/v1/recipes/$RECIPE1_ID?servings=12"
+assert_status "GET /api/v1/recipes/:id?servings=12 (scale)" 200 "$RESP_STATUS" "$RESP_BODY"
+
+call GET "/api/v1/recipes/$RECIPE2_ID?servings=4"
+assert_status "GET /api/v1/recipes/:id?servings=4 (no servings to scale from)" 400 "$RESP_STATUS" "$RESP_BODY"
+
 call PUT "/api/v1/recipes/$RECIPE1_ID" '{"status":"published"}'
 assert_status "PUT /api/v1/recipes/:id (update status)" 200 "$RESP_STATUS" "$RESP_BODY"
 echo
@@ -187,6 +212,9 @@ assert_status "GET /api/v1/recipes?difficulty=extreme (invalid filter)" 400 "$RE
 call GET "/api/v1/recipes?status=bogus"
 assert_status "GET /api/v1/recipes?status=bogus (invalid filter)" 400 "$RESP_STATUS" "$RESP_BODY"
 
+call GET "/api/v1/recipes?cursor=bogus"
+assert_status "GET /api/v1/recipes?cursor=bogus (malformed cursor)" 400 "$RESP_STATUS" "$RESP_BODY"
+
 call POST "/api/v1/recipes/$RECIPE1_ID/ratings" "{\"chef_id\":\"$CHEF2_ID\",\"score\":10}"
 assert_status "POST ratings (score out of range)" 400 "$RESP_STATUS" "$RESP_BODY"
 
@@ -0,0 +1,200 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/router"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+	c := store.NewLRUCache(2)
+	get := func(key string) string {
+		t.Helper()
+		b, ok, err := c.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", key, err)
+		}
+		if !ok {
+			return ""
+		}
+		return string(b)
+	}
+
+	c.Set(ctx, "a", []byte("1"), 0)
+	c.Set(ctx, "b", []byte("2"), 0)
+	get("a") // a is now more recently used than b
+	c.Set(ctx, "c", []byte("3"), 0)
+	if get("b") != "" || get("a") != "1" || get("c") != "3" || c.Len() != 2 {
+		t.Errorf("expected b to be evicted, got a=%q b=%q c=%q len=%d", get("a"), get("b"), get("c"), c.Len())
+	}
+
+	c.Set(ctx, "a", []byte("4"), 0)
+	c.Delete(ctx, "c", "missing")
+	if get("a") != "4" || get("c") != "" || c.Len() != 1 {
+		t.Errorf("expected a=4 and c deleted, got a=%q c=%q len=%d", get("a"), get("c"), c.Len())
+	}
+
+	c.Set(ctx, "short", []byte("5"), 10*time.Millisecond)
+	if get("short") != "5" {
+		t.Errorf("expected the entry before it expires")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if get("short") != "" || c.Len() != 1 {
+		t.Errorf("expected the expired entry to be dropped, len=%d", c.Len())
+	}
+}
+
+func TestCachingStore(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewCachingStore(store.NewMemoryStore(), store.NewLRUCache(100), time.Minute)
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Cached Chef", Email: "cached@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	rater, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Rater", Email: "rater@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	recipe, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+		ChefID: chef.ID, Title: "Focaccia", Ingredients: "flour", Instructions: "bake", Status: model.StatusPublished,
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	for range 3 {
+		if _, err := s.GetRecipeWithRatings(ctx, recipe.ID); err != nil {
+			t.Fatalf("GetRecipeWithRatings: %v", err)
+		}
+	}
+	if stats := s.Stats(); stats.Misses != 1 || stats.Hits != 2 {
+		t.Errorf("expected 1 miss then 2 hits, got %+v", stats)
+	}
+
+	// Every mutation is visible to the next read.
+	if _, err := s.UpdateRecipe(ctx, recipe.ID, model.UpdateRecipeInput{Title: ptr("Rosemary Focaccia")}); err != nil {
+		t.Fatalf("UpdateRecipe: %v", err)
+	}
+	if got, _ := s.GetRecipeWithRatings(ctx, recipe.ID); got.Title != "Rosemary Focaccia" {
+		t.Errorf("expected the updated title, got %q", got.Title)
+	}
+	if _, err := s.CreateRating(ctx, recipe.ID, model.CreateRatingInput{ChefID: rater.ID, Score: 4}); err != nil {
+		t.Fatalf("CreateRating: %v", err)
+	}
+	if got, _ := s.GetRecipeWithRatings(ctx, recipe.ID); len(got.Ratings) != 1 || got.RatingCount != 1 {
+		t.Errorf("expected the new rating, got %+v", got)
+	}
+	if list, _, _ := s.ListRecipes(ctx, model.RecipeFilter{}, model.PageRequest{}); len(list) != 1 || list[0].RatingCount != 1 {
+		t.Errorf("expected the rating in the list, got %+v", list)
+	}
+	if _, err := s.AddRecipeTags(ctx, recipe.ID, []string{"Bread"}); err != nil {
+		t.Fatalf("AddRecipeTags: %v", err)
+	}
+	if got, _ := s.GetRecipe(ctx, recipe.ID); len(got.Tags) != 1 {
+		t.Errorf("expected the new tag, got %+v", got.Tags)
+	}
+	if tags, _, _ := s.ListTags(ctx, model.PageRequest{}); len(tags) != 1 || tags[0].RecipeCount != 1 {
+		t.Errorf("expected the tag to be listed, got %+v", tags)
+	}
+
+	// Deleting a fork changes the fork count of the original.
+	fork, err := s.ForkRecipe(ctx, recipe.ID, rater.ID)
+	if err != nil {
+		t.Fatalf("ForkRecipe: %v", err)
+	}
+	if got, _ := s.GetRecipeWithRatings(ctx, recipe.ID); got.ForkCount == nil || *got.ForkCount != 1 {
+		t.Errorf("expected a fork count of 1, got %v", got.ForkCount)
+	}
+	if err := s.DeleteRecipe(ctx, fork.ID); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	if got, _ := s.GetRecipeWithRatings(ctx, recipe.ID); got.ForkCount == nil || *got.ForkCount != 0 {
+		t.Errorf("expected a fork count of 0 after deleting the fork, got %v", got.ForkCount)
+	}
+
+	// A deleted chef's recipes disappear with them, and include_deleted
+	// reads bypass the cache.
+	if _, err := s.DeleteChef(ctx, chef.ID); err != nil {
+		t.Fatalf("DeleteChef: %v", err)
+	}
+	if got, err := s.GetRecipe(ctx, recipe.ID); err != nil || got != nil {
+		t.Errorf("expected the chef's recipe to be gone, got %+v %v", got, err)
+	}
+	if got, err := s.GetRecipe(store.IncludeDeleted(ctx), recipe.ID); err != nil || got == nil || got.DeletedAt == nil {
+		t.Errorf("expected the deleted recipe under include_deleted, got %+v %v", got, err)
+	}
+	if stats := s.Stats(); stats.Invalidations == 0 || stats.Errors != 0 {
+		t.Errorf("expected invalidations and no errors, got %+v", stats)
+	}
+}
+
+// failingCache is a Cache whose every operation fails, like an unreachable
+// remote cache.
+type failingCache struct{}
+
+var errCacheDown = errors.New("cache down")
+
+func (failingCache) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errCacheDown
+}
+
+func (failingCache) Set(context.Context, string, []byte, time.Duration) error {
+	return errCacheDown
+}
+
+func (failingCache) Delete(context.Context, ...string) error { return errCacheDown }
+
+func TestCachingStoreFallsBack(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewCachingStore(store.NewMemoryStore(), failingCache{}, time.Minute)
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Offline", Email: "offline@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	got, err := s.GetChef(ctx, chef.ID)
+	if err != nil || got == nil || got.Name != "Offline" {
+		t.Fatalf("expected the chef from the store, got %+v %v", got, err)
+	}
+	if stats := s.Stats(); stats.Errors < 2 || stats.Hits != 0 {
+		t.Errorf("expected cache errors to be counted, got %+v", stats)
+	}
+}
+
+func TestRouterCacheStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := router.New(store.NewCachingStore(store.NewMemoryStore(), store.NewLRUCache(100), time.Minute), nil)
+
+	var chef chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Stats Chef", Email: "stats@example.com"}, &chef)
+	for range 2 {
+		doJSON(t, h, http.MethodGet, "/api/v1/chefs/"+chef.Data.ID, nil, nil)
+	}
+
+	var stats struct {
+		Data model.CacheStats `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/cache/stats", nil, &stats); code != http.StatusOK {
+		t.Fatalf("cache stats: expected 200, got %d", code)
+	}
+	if stats.Data.Hits < 1 || stats.Data.Misses < 1 || stats.Data.HitRate <= 0 {
+		t.Errorf("expected hits and misses, got %+v", stats.Data)
+	}
+
+	// Without a cache there are no statistics to serve.
+	if code := doJSON(t, setupRouter(t), http.MethodGet, "/api/v1/cache/stats", nil, nil); code != http.StatusNotFound {
+		t.Errorf("uncached router: expected 404, got %d", code)
+	}
+}
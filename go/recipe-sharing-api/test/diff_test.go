@@ -0,0 +1,197 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/diff"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+)
+
+// ops renders a line diff compactly, one "<op> <text>" per line, with
+// " ", "+", and "-" for equal, insert, and delete.
+func ops(changes []model.LineChange) []string {
+	sign := map[string]string{model.LineEqual: " ", model.LineInsert: "+", model.LineDelete: "-"}
+	out := []string{}
+	for _, c := range changes {
+		out = append(out, sign[c.Op]+c.Text)
+	}
+	return out
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name, a, b string
+		want       []string
+	}{
+		{"equal", "a\nb", "a\nb", []string{" a", " b"}},
+		{"both empty", "", "", []string{}},
+		{"from empty", "", "a\nb", []string{"+a", "+b"}},
+		{"to empty", "a\nb", "", []string{"-a", "-b"}},
+		{"insert in middle", "a\nc", "a\nb\nc", []string{" a", "+b", " c"}},
+		{"delete at end", "a\nb\nc", "a\nb", []string{" a", " b", "-c"}},
+		{"replace", "a\nb\nc", "a\nx\nc", []string{" a", "-b", "+x", " c"}},
+		{"move", "a\nb\nc", "b\nc\na", []string{"-a", " b", " c", "+a"}},
+		{"trailing newline and CRLF", "a\r\nb\r\n", "a\nb", []string{" a", " b"}},
+	}
+	for _, tt := range tests {
+		if got := ops(diff.Lines(tt.a, tt.b)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Lines(%q, %q) = %q, want %q", tt.name, tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	// Texts too large to align still produce a diff that replays to b.
+	a := strings.Repeat("x\n", 3000) + "y"
+	b := strings.Repeat("z\n", 3000) + "y"
+	var replay []string
+	for _, c := range diff.Lines(a, b) {
+		if c.Op != model.LineDelete {
+			replay = append(replay, c.Text)
+		}
+	}
+	if strings.Join(replay, "\n") != strings.TrimSuffix(b, "\n") {
+		t.Errorf("expected the large diff to replay to b")
+	}
+}
+
+func TestDiffRecipes(t *testing.T) {
+	from := model.Recipe{ID: "r1", Version: 1, Title: "Stew", Servings: 4, Status: model.StatusDraft,
+		Ingredients: "beef\ncarrots", Instructions: "Brown.\nSimmer."}
+	to := from
+	to.Version, to.Servings, to.Status = 3, 6, model.StatusPublished
+	to.Ingredients = "beef\ncarrots\npotatoes"
+
+	d := diff.Recipes(from, to)
+	if d.RecipeID != "r1" || d.From != 1 || d.To != 3 {
+		t.Errorf("unexpected header %+v", d)
+	}
+	want := []model.FieldChange{
+		{Field: "servings", From: 4, To: 6},
+		{Field: "status", From: model.StatusDraft, To: model.StatusPublished},
+	}
+	if !slices.Equal(d.Fields, want) {
+		t.Errorf("Fields = %+v, want %+v", d.Fields, want)
+	}
+	if got := ops(d.Ingredients); !slices.Equal(got, []string{" beef", " carrots", "+potatoes"}) {
+		t.Errorf("unexpected ingredient diff %q", got)
+	}
+	if d.Instructions != nil {
+		t.Errorf("expected no instruction diff for unchanged text, got %+v", d.Instructions)
+	}
+	if same := diff.Recipes(from, from); len(same.Fields) != 0 || same.Ingredients != nil {
+		t.Errorf("expected no changes between equal versions, got %+v", same)
+	}
+}
+
+func TestRouterRevisions(t *testing.T) {
+	h := setupRouter(t)
+
+	var chef chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Historian", Email: "historian@example.com"}, &chef)
+	var recipe recipeEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID: chef.Data.ID, Title: "Chili", Ingredients: "1 lb beef\n1 can beans", Instructions: "Brown the beef.\nSimmer.", Servings: 4,
+	}, &recipe)
+	path := "/api/v1/recipes/" + recipe.Data.ID
+
+	var errResp errorEnvelope
+	var updated recipeEnvelope
+	if code := doJSON(t, h, http.MethodPut, path, map[string]any{
+		"title": "Smoky Chili", "servings": 6, "instructions": "Brown the beef.\nAdd chipotle.\nSimmer.",
+	}, &updated); code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d", code)
+	}
+	doJSON(t, h, http.MethodPost, path+"/publish", nil, &updated)
+
+	var list struct {
+		Data  []model.RecipeRevision `json:"data"`
+		Count int                    `json:"count"`
+	}
+	if code := doJSON(t, h, http.MethodGet, path+"/revisions", nil, &list); code != http.StatusOK {
+		t.Fatalf("list revisions: expected 200, got %d", code)
+	}
+	if list.Count != 2 || list.Data[0].Revision != 2 || list.Data[1].Revision != 1 {
+		t.Fatalf("expected revisions 2 and 1, got %+v", list.Data)
+	}
+
+	var rev struct {
+		Data model.RecipeRevision `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodGet, path+"/revisions/1", nil, &rev); code != http.StatusOK {
+		t.Fatalf("get revision: expected 200, got %d", code)
+	}
+	if rev.Data.Recipe.Title != "Chili" || len(rev.Data.Recipe.IngredientList) != 2 {
+		t.Errorf("expected the original recipe with its ingredient lines, got %+v", rev.Data.Recipe)
+	}
+
+	// Revision 1 against the current version spans both changes.
+	var d struct {
+		Data model.RecipeDiff `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodGet, path+"/revisions/1/diff", nil, &d); code != http.StatusOK {
+		t.Fatalf("diff: expected 200, got %d", code)
+	}
+	fields := []string{}
+	for _, f := range d.Data.Fields {
+		fields = append(fields, f.Field)
+	}
+	if d.Data.From != 1 || d.Data.To != 3 || !slices.Equal(fields, []string{"title", "servings", "status"}) {
+		t.Errorf("expected title, servings, and status to change from 1 to 3, got %+v", d.Data)
+	}
+	if got := ops(d.Data.Instructions); !slices.Equal(got, []string{" Brown the beef.", "+Add chipotle.", " Simmer."}) {
+		t.Errorf("unexpected instruction diff %q", got)
+	}
+	if code := doJSON(t, h, http.MethodGet, path+"/revisions/2/diff?to=1", nil, &d); code != http.StatusOK || d.Data.From != 2 || d.Data.To != 1 {
+		t.Errorf("diff to an older revision: expected 200 from 2 to 1, got %d %+v", code, d.Data)
+	}
+
+	for _, tc := range []struct {
+		name, method, path string
+		want               int
+	}{
+		{"revision zero", http.MethodGet, path + "/revisions/0", http.StatusBadRequest},
+		{"revision not a number", http.MethodGet, path + "/revisions/first", http.StatusBadRequest},
+		{"current version is not a revision", http.MethodGet, path + "/revisions/3", http.StatusNotFound},
+		{"bad to", http.MethodGet, path + "/revisions/1/diff?to=-1", http.StatusBadRequest},
+		{"missing to", http.MethodGet, path + "/revisions/1/diff?to=9", http.StatusNotFound},
+		{"missing revision", http.MethodPost, path + "/revisions/9/revert", http.StatusNotFound},
+		{"missing recipe", http.MethodGet, "/api/v1/recipes/missing/revisions", http.StatusNotFound},
+	} {
+		if code := doJSON(t, h, tc.method, tc.path, nil, &errResp); code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, code)
+		}
+	}
+
+	// Reverting applies the old content as a new version and keeps the
+	// status; a stale If-Match is rejected.
+	revert := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path+"/revisions/1/revert", nil)
+		req.Header.Set("If-Match", ifMatch)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := revert(`"2"`); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("revert with a stale If-Match: expected 412, got %d", rec.Code)
+	}
+	rec := revert(`"3"`)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"4"` {
+		t.Fatalf("revert: expected 200 with ETag \"4\", got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+	var reverted recipeEnvelope
+	json.Unmarshal(rec.Body.Bytes(), &reverted)
+	if r := reverted.Data; r.Title != "Chili" || r.Servings != 4 || r.Instructions != "Brown the beef.\nSimmer." || r.Status != model.StatusPublished {
+		t.Errorf("expected the original content, still published, got %+v", r)
+	}
+	doJSON(t, h, http.MethodGet, path+"/revisions", nil, &list)
+	if list.Count != 3 || list.Data[0].Revision != 3 || list.Data[0].Recipe.Title != "Smoky Chili" {
+		t.Errorf("expected the revert to record the replaced version 3, got %+v", list.Data)
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/images"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+)
+
+// testPNG encodes a solid PNG image of the given size.
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{R: 200, G: 80, B: 40, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessImage(t *testing.T) {
+	p, err := images.Process(testPNG(t, 800, 400), "image/png")
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if p.ContentType != "image/png" || p.Width != 800 || p.Height != 400 {
+		t.Errorf("unexpected metadata: %s %dx%d", p.ContentType, p.Width, p.Height)
+	}
+	thumb, err := png.Decode(bytes.NewReader(p.Thumbnail))
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != model.ThumbnailSize || b.Dy() != model.ThumbnailSize/2 {
+		t.Errorf("expected a %dx%d thumbnail, got %dx%d", model.ThumbnailSize, model.ThumbnailSize/2, b.Dx(), b.Dy())
+	}
+
+	// JPEG images get JPEG thumbnails, and small images are not enlarged.
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 60)), nil); err != nil {
+		t.Fatalf("encode JPEG: %v", err)
+	}
+	p, err = images.Process(buf.Bytes(), "")
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	thumb, err = jpeg.Decode(bytes.NewReader(p.Thumbnail))
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	if p.ThumbnailContentType != "image/jpeg" || thumb.Bounds().Dx() != 40 || thumb.Bounds().Dy() != 60 {
+		t.Errorf("expected a 40x60 JPEG thumbnail, got %s %v", p.ThumbnailContentType, thumb.Bounds())
+	}
+
+	if _, err := images.Process([]byte("not an image at all"), ""); !errors.Is(err, images.ErrUnsupportedType) {
+		t.Errorf("text: expected ErrUnsupportedType, got %v", err)
+	}
+	if _, err := images.Process(testPNG(t, 4, 4), "image/jpeg"); !errors.Is(err, images.ErrUnsupportedType) {
+		t.Errorf("mismatched type: expected ErrUnsupportedType, got %v", err)
+	}
+	truncated := testPNG(t, 64, 64)[:60]
+	if _, err := images.Process(truncated, "image/png"); !errors.Is(err, images.ErrInvalidImage) {
+		t.Errorf("truncated PNG: expected ErrInvalidImage, got %v", err)
+	}
+}
+
+func TestFSBlobStore(t *testing.T) {
+	ctx := context.Background()
+	b, err := store.NewFSBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFSBlobStore: %v", err)
+	}
+	if err := b.Put(ctx, "recipes/r1/images/i1", "image/png", strings.NewReader("first")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := b.Put(ctx, "recipes/r1/images/i1", "image/png", strings.NewReader("second")); err != nil {
+		t.Fatalf("Put replacing: %v", err)
+	}
+	r, err := b.Open(ctx, "recipes/r1/images/i1")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(got) != "second" {
+		t.Errorf("expected the replaced contents, got %q %v", got, err)
+	}
+
+	for _, key := range []string{"../escape", "/etc/passwd", ""} {
+		if err := b.Put(ctx, key, "", strings.NewReader("x")); err == nil {
+			t.Errorf("Put(%q): expected an invalid key error", key)
+		}
+	}
+	if err := b.Delete(ctx, "recipes/r1/images/i1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := b.Delete(ctx, "recipes/r1/images/i1"); err != nil {
+		t.Errorf("Delete of a missing blob: %v", err)
+	}
+	if _, err := b.Open(ctx, "recipes/r1/images/i1"); !errors.Is(err, store.ErrBlobNotFound) {
+		t.Errorf("expected ErrBlobNotFound, got %v", err)
+	}
+}
+
+func TestRecipeImagesPurge(t *testing.T) {
+	s, ctx := setupStore(t)
+	blobs, err := store.NewFSBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFSBlobStore: %v", err)
+	}
+	s.(interface{ SetBlobStore(store.BlobStore) }).SetBlobStore(blobs)
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Photographer", Email: "images-photographer@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, chef.ID) })
+	recipe, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+		ChefID: chef.ID, Title: "Pictured Pie", Ingredients: "apples", Instructions: "bake",
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	img := model.RecipeImage{
+		ID: "img-" + recipe.ID, RecipeID: recipe.ID, ContentType: "image/png", SizeBytes: 5,
+		Width: 1, Height: 1, ThumbnailContentType: "image/png",
+		BlobKey: "recipes/" + recipe.ID + "/images/1", ThumbnailKey: "recipes/" + recipe.ID + "/thumbnails/1",
+	}
+	for _, key := range []string{img.BlobKey, img.ThumbnailKey} {
+		if err := blobs.Put(ctx, key, "image/png", strings.NewReader("bytes")); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	if _, err := s.CreateRecipeImage(ctx, img); err != nil {
+		t.Fatalf("CreateRecipeImage: %v", err)
+	}
+	got, err := s.GetRecipeImage(ctx, recipe.ID, img.ID)
+	if err != nil || got == nil || got.BlobKey != img.BlobKey {
+		t.Fatalf("GetRecipeImage: %+v %v", got, err)
+	}
+
+	// Soft-deleting the recipe keeps its images and blobs, so a restore
+	// brings them back; only purging it removes the rows and the blobs.
+	if err := s.DeleteRecipe(ctx, recipe.ID); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	for _, key := range []string{img.BlobKey, img.ThumbnailKey} {
+		if r, err := blobs.Open(ctx, key); err != nil {
+			t.Errorf("blob %s: expected it to survive a soft delete: %v", key, err)
+		} else {
+			r.Close()
+		}
+	}
+	if _, err := s.RestoreRecipe(ctx, recipe.ID); err != nil {
+		t.Fatalf("RestoreRecipe: %v", err)
+	}
+	if list, err := s.ListRecipeImages(ctx, recipe.ID); err != nil || len(list) != 1 || list[0].ID != img.ID {
+		t.Errorf("expected the image back after restore, got %+v %v", list, err)
+	}
+	if r, err := blobs.Open(ctx, img.BlobKey); err != nil {
+		t.Errorf("expected the blob readable after restore: %v", err)
+	} else {
+		r.Close()
+	}
+	if err := s.DeleteRecipe(ctx, recipe.ID); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	purged, err := s.PurgeDeleted(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	if purged.Images < 1 {
+		t.Errorf("expected the image to be purged, got %d", purged.Images)
+	}
+	if list, err := s.ListRecipeImages(ctx, recipe.ID); err != nil || len(list) != 0 {
+		t.Errorf("expected no images after purge, got %d %v", len(list), err)
+	}
+	for _, key := range []string{img.BlobKey, img.ThumbnailKey} {
+		if _, err := blobs.Open(ctx, key); !errors.Is(err, store.ErrBlobNotFound) {
+			t.Errorf("blob %s: expected ErrBlobNotFound after purge, got %v", key, err)
+		}
+	}
+}
+
+// upload posts data to the image upload endpoint as a multipart form file
+// with the given content type.
+func upload(t *testing.T, h http.Handler, recipeID, contentType string, data []byte, out any) int {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="image"; filename="photo"`)
+	header.Set("Content-Type", contentType)
+	part, err := w.CreatePart(header)
+	if err != nil {
+		t.Fatalf("create part: %v", err)
+	}
+	part.Write(data)
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/recipes/"+recipeID+"/images", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("upload: decode response %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestRouterImages(t *testing.T) {
+	h := setupRouter(t)
+
+	var chef chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Photographer", Email: "photo@example.com"}, &chef)
+	var recipe recipeEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID:       chef.Data.ID,
+		Title:        "Pictured Pie",
+		Ingredients:  "apples",
+		Instructions: "bake",
+	}, &recipe)
+
+	var created struct {
+		Data model.RecipeImage `json:"data"`
+	}
+	if code := upload(t, h, recipe.Data.ID, "image/png", testPNG(t, 640, 480), &created); code != http.StatusCreated {
+		t.Fatalf("upload: expected 201, got %d", code)
+	}
+	if created.Data.Width != 640 || created.Data.Height != 480 || created.Data.URL == "" {
+		t.Errorf("unexpected image metadata: %+v", created.Data)
+	}
+
+	var errResp errorEnvelope
+	if code := upload(t, h, recipe.Data.ID, "text/plain", []byte("hello"), &errResp); code != http.StatusUnsupportedMediaType {
+		t.Errorf("text upload: expected 415, got %d", code)
+	}
+	if code := upload(t, h, recipe.Data.ID, "image/png", make([]byte, model.MaxImageBytes+1), &errResp); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized upload: expected 413, got %d", code)
+	}
+	if code := upload(t, h, "missing", "image/png", testPNG(t, 4, 4), &errResp); code != http.StatusNotFound {
+		t.Errorf("upload to missing recipe: expected 404, got %d", code)
+	}
+
+	var list struct {
+		Data []model.RecipeImage `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes/"+recipe.Data.ID+"/images", nil, &list); code != http.StatusOK || len(list.Data) != 1 {
+		t.Fatalf("list images: expected 200 with 1 image, got %d with %d", code, len(list.Data))
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, list.Data[0].ThumbnailURL, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("get thumbnail: expected 200 image/png, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	thumb, err := png.Decode(rec.Body)
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != model.ThumbnailSize || b.Dy() != 240 {
+		t.Errorf("expected a %dx240 thumbnail, got %v", model.ThumbnailSize, b)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, list.Data[0].URL, nil))
+	if rec.Code != http.StatusOK || rec.Body.Len() != int(created.Data.SizeBytes) {
+		t.Errorf("get image: expected 200 with %d bytes, got %d with %d", created.Data.SizeBytes, rec.Code, rec.Body.Len())
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes/"+recipe.Data.ID+"/images/missing", nil, &errResp); code != http.StatusNotFound {
+		t.Errorf("get missing image: expected 404, got %d", code)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package test
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/ingredients"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+)
+
+func TestParseIngredientLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want model.Ingredient
+	}{
+		{"2 cups flour", model.Ingredient{Quantity: 2, Unit: "cup", Name: "flour"}},
+		{"1 1/2 tbsp olive oil", model.Ingredient{Quantity: 1.5, Unit: "tbsp", Name: "olive oil"}},
+		{"½ tsp salt", model.Ingredient{Quantity: 0.5, Unit: "tsp", Name: "salt"}},
+		{"3 cloves garlic, minced", model.Ingredient{Quantity: 3, Unit: "clove", Name: "garlic", Note: "minced"}},
+		{"200 g butter (softened)", model.Ingredient{Quantity: 200, Unit: "g", Name: "butter", Note: "softened"}},
+		{"2 fl oz cream", model.Ingredient{Quantity: 2, Unit: "fl oz", Name: "cream"}},
+		{"1 lb of ground beef", model.Ingredient{Quantity: 1, Unit: "lb", Name: "ground beef"}},
+		{"1 T butter", model.Ingredient{Quantity: 1, Unit: "tbsp", Name: "butter"}},
+		{"2 t. vanilla extract", model.Ingredient{Quantity: 2, Unit: "tsp", Name: "vanilla extract"}},
+		{"2 eggs", model.Ingredient{Quantity: 2, Name: "eggs"}},
+		{"salt to taste", model.Ingredient{Name: "salt to taste"}},
+	}
+	for _, tt := range tests {
+		got := ingredients.ParseLine(tt.line, true)
+		if got != tt.want {
+			t.Errorf("ParseLine(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseIngredients(t *testing.T) {
+	// Single-line text is split on commas, so commas are not notes.
+	got := ingredients.Parse("pasta, 2 cups tomato sauce, basil")
+	var names []string
+	for i, ing := range got {
+		if ing.Position != i+1 {
+			t.Errorf("expected position %d, got %d", i+1, ing.Position)
+		}
+		names = append(names, ing.Name)
+	}
+	if want := []string{"pasta", "tomato sauce", "basil"}; !slices.Equal(names, want) {
+		t.Errorf("expected names %v, got %v", want, names)
+	}
+
+	// Multi-line text is split on newlines and skips blank and bullet markers.
+	got = ingredients.Parse("- 1 cup rice\n\n* 2 cups water, cold\n")
+	if len(got) != 2 || got[1].Note != "cold" || got[1].Quantity != 2 {
+		t.Errorf("unexpected multi-line parse: %+v", got)
+	}
+}
+
+func TestFormatIngredients(t *testing.T) {
+	list := []model.Ingredient{
+		{Quantity: 1.5, Unit: "cup", Name: "flour", Note: "sifted"},
+		{Quantity: 1.0 / 3, Unit: "cup", Name: "sugar"},
+		{Name: "salt"},
+	}
+	want := "1 1/2 cup flour (sifted)\n1/3 cup sugar\nsalt"
+	if got := ingredients.Format(list); got != want {
+		t.Errorf("Format = %q, want %q", got, want)
+	}
+
+	// Formatted text parses back to the same structure.
+	for i, ing := range ingredients.Parse(want) {
+		list[i].Position = i + 1
+		if ing.Name != list[i].Name || ing.Unit != list[i].Unit || ing.Note != list[i].Note {
+			t.Errorf("round trip line %d: got %+v, want %+v", i, ing, list[i])
+		}
+	}
+}
+
+func TestRecipeStructuredIngredients(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{
+		Name:  "Ingredient Chef",
+		Email: "ingredient-chef@example.com",
+	})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, chef.ID) })
+
+	// Structured input renders the free-text column.
+	recipe, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+		ChefID:       chef.ID,
+		Title:        "Pancakes",
+		Instructions: "mix and fry",
+		IngredientList: []model.Ingredient{
+			{Name: "flour", Quantity: 2, Unit: "cup"},
+			{Name: "milk", Quantity: 1.5, Unit: "cup"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteRecipe(ctx, recipe.ID) })
+	if recipe.Ingredients != "2 cup flour\n1 1/2 cup milk" {
+		t.Errorf("unexpected rendered ingredients %q", recipe.Ingredients)
+	}
+
+	got, err := s.GetRecipe(ctx, recipe.ID)
+	if err != nil {
+		t.Fatalf("GetRecipe: %v", err)
+	}
+	if len(got.IngredientList) != 2 || got.IngredientList[1].Name != "milk" || got.IngredientList[1].Position != 2 {
+		t.Fatalf("unexpected ingredient list %+v", got.IngredientList)
+	}
+
+	// Updating the free text re-parses the structured lines.
+	updated, err := s.UpdateRecipe(ctx, recipe.ID, model.UpdateRecipeInput{
+		Ingredients: ptr("3 eggs\n1 tbsp sugar"),
+	})
+	if err != nil {
+		t.Fatalf("UpdateRecipe: %v", err)
+	}
+	if len(updated.IngredientList) != 2 || updated.IngredientList[0].Name != "eggs" || updated.IngredientList[1].Unit != "tbsp" {
+		t.Fatalf("unexpected ingredient list after update %+v", updated.IngredientList)
+	}
+
+	// Unrelated updates keep the structured lines.
+	updated, err = s.UpdateRecipe(ctx, recipe.ID, model.UpdateRecipeInput{Title: ptr("Egg Pancakes")})
+	if err != nil {
+		t.Fatalf("UpdateRecipe: %v", err)
+	}
+	if len(updated.IngredientList) != 2 {
+		t.Errorf("expected ingredient list to be preserved, got %+v", updated.IngredientList)
+	}
+}
+
+func TestConvertUnits(t *testing.T) {
+	tests := []struct {
+		qty      float64
+		unit     string
+		to       ingredients.System
+		wantQty  float64
+		wantUnit string
+	}{
+		{48, "tsp", ingredients.Imperial, 1, "cup"},
+		{2, "tbsp", ingredients.Metric, 29.5736, "ml"},
+		{1.5, "lb", ingredients.Metric, 680.388, "g"},
+		{2500, "g", ingredients.Metric, 2.5, "kg"},
+		{500, "g", ingredients.Imperial, 1.10231, "lb"},
+		{3, "clove", ingredients.Metric, 3, "clove"},
+	}
+	for _, tt := range tests {
+		qty, unit, _ := ingredients.Convert(tt.qty, tt.unit, tt.to)
+		if unit != tt.wantUnit || math.Abs(qty-tt.wantQty) > 0.001 {
+			t.Errorf("Convert(%v %s, %s) = %v %s, want %v %s", tt.qty, tt.unit, tt.to, qty, unit, tt.wantQty, tt.wantUnit)
+		}
+	}
+}
+
+func TestRoundQuantity(t *testing.T) {
+	tests := []struct {
+		qty  float64
+		unit string
+		want float64
+	}{
+		{0.3, "cup", 1.0 / 3},
+		{1.45, "cup", 1.5},
+		{0.01, "tsp", 0.125},
+		{2.9, "", 3},
+		{12.3, "", 12.5},
+		{29.57, "ml", 30},
+		{680.4, "g", 680},
+		{4.4, "g", 4},
+		{1.13, "kg", 1.15},
+	}
+	for _, tt := range tests {
+		if got := ingredients.Round(tt.qty, tt.unit); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Round(%v, %q) = %v, want %v", tt.qty, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestRouterScaledRecipe(t *testing.T) {
+	h := setupRouter(t)
+
+	var chef chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Scaling Chef", Email: "scale@example.com"}, &chef)
+
+	var recipe recipeEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID:       chef.Data.ID,
+		Title:        "Scalable Cake",
+		Instructions: "bake",
+		Servings:     4,
+		IngredientList: []model.Ingredient{
+			{Name: "flour", Quantity: 1, Unit: "cup"},
+			{Name: "eggs", Quantity: 2},
+			{Name: "salt"},
+		},
+	}, &recipe)
+
+	var scaled recipeEnvelope
+	path := "/api/v1/recipes/" + recipe.Data.ID + "?servings=6"
+	if code := doJSON(t, h, http.MethodGet, path, nil, &scaled); code != http.StatusOK {
+		t.Fatalf("GET %s: expected 200, got %d", path, code)
+	}
+	if scaled.Data.Servings != 6 {
+		t.Errorf("expected servings 6, got %d", scaled.Data.Servings)
+	}
+	if want := "1 1/2 cup flour\n3 eggs\nsalt"; scaled.Data.Ingredients != want {
+		t.Errorf("expected scaled ingredients %q, got %q", want, scaled.Data.Ingredients)
+	}
+
+	path = "/api/v1/recipes/" + recipe.Data.ID + "?servings=8&units=metric"
+	if code := doJSON(t, h, http.MethodGet, path, nil, &scaled); code != http.StatusOK {
+		t.Fatalf("GET %s: expected 200, got %d", path, code)
+	}
+	if got := scaled.Data.IngredientList[0]; got.Unit != "ml" || got.Quantity != 470 {
+		t.Errorf("expected 470 ml flour, got %v %s", got.Quantity, got.Unit)
+	}
+
+	// Recipes without servings cannot be scaled.
+	var unscalable recipeEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID: chef.Data.ID, Title: "Mystery Stew", Ingredients: "stuff", Instructions: "stew",
+	}, &unscalable)
+	var errResp errorEnvelope
+	code := doJSON(t, h, http.MethodGet, "/api/v1/recipes/"+unscalable.Data.ID+"?servings=2", nil, &errResp)
+	if code != http.StatusBadRequest || errResp.Error.Code != "VALIDATION_ERROR" {
+		t.Errorf("scaling recipe without servings: expected 400 VALIDATION_ERROR, got %d %q", code, errResp.Error.Code)
+	}
+
+	code = doJSON(t, h, http.MethodGet, "/api/v1/recipes/"+recipe.Data.ID+"?units=cubits", nil, &errResp)
+	if code != http.StatusBadRequest {
+		t.Errorf("invalid units: expected 400, got %d", code)
+	}
+}
@@ -5,26 +5,37 @@ package test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
 	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
 )
 
-func setupStore(t *testing.T) (*store.DSQLStore, context.Context) {
+// setupStore returns an Amazon Aurora DSQL store when DSQL_ENDPOINT is set,
+// and an in-memory store otherwise so the suite can run without AWS.
+func setupStore(t *testing.T) (store.Store, context.Context) {
 	t.Helper()
+	ctx := context.Background()
 	endpoint := os.Getenv("DSQL_ENDPOINT")
 	if endpoint == "" {
-		t.Fatal("DSQL_ENDPOINT environment variable is required")
+		return store.NewMemoryStore(), ctx
 	}
-	ctx := context.Background()
 	s, err := store.NewDSQLStore(ctx, endpoint)
 	if err != nil {
 		t.Fatalf("NewDSQLStore: %v", err)
 	}
-	if err := s.InitSchema(ctx); err != nil {
-		t.Fatalf("InitSchema: %v", err)
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
 	}
 	t.Cleanup(func() { s.Close() })
 	return s, ctx
@@ -71,7 +82,7 @@ func TestChefCRUD(t *testing.T) {
 	}
 
 	// List
-	chefs, err := s.ListChefs(ctx)
+	chefs, _, err := s.ListChefs(ctx, model.PageRequest{})
 	if err != nil {
 		t.Fatalf("ListChefs: %v", err)
 	}
@@ -86,7 +97,7 @@ func TestChefCRUD(t *testing.T) {
 	}
 
 	// Delete
-	if err := s.DeleteChef(ctx, chef.ID); err != nil {
+	if _, err := s.DeleteChef(ctx, chef.ID); err != nil {
 		t.Fatalf("DeleteChef: %v", err)
 	}
 	deleted, err := s.GetChef(ctx, chef.ID)
@@ -148,7 +159,7 @@ func TestRecipeCRUD(t *testing.T) {
 	}
 
 	// List with filter
-	recipes, err := s.ListRecipes(ctx, model.RecipeFilter{Cuisine: "Italian"})
+	recipes, _, err := s.ListRecipes(ctx, model.RecipeFilter{Cuisine: "Italian"}, model.PageRequest{})
 	if err != nil {
 		t.Fatalf("ListRecipes: %v", err)
 	}
@@ -212,7 +223,7 @@ func TestRatingCRUD(t *testing.T) {
 	}
 
 	// List ratings
-	ratings, err := s.ListRatings(ctx, recipe.ID)
+	ratings, _, err := s.ListRatings(ctx, recipe.ID, model.PageRequest{})
 	if err != nil {
 		t.Fatalf("ListRatings: %v", err)
 	}
@@ -279,27 +290,1675 @@ func TestGetRecipeWithRatings(t *testing.T) {
 	}
 	t.Cleanup(func() { s.DeleteRecipe(ctx, recipe.ID) })
 
-	_, err = s.CreateRating(ctx, recipe.ID, model.CreateRatingInput{
-		ChefID: chef.ID, Score: 4, Comment: "Great",
+	for i, rating := range []model.CreateRatingInput{
+		{Score: 4, Comment: "Great"},
+		{Score: 5, Comment: "Amazing"},
+	} {
+		critic, err := s.CreateChef(ctx, model.CreateChefInput{
+			Name:  "Ratings Critic",
+			Email: fmt.Sprintf("ratings-critic-%d@example.com", i),
+		})
+		if err != nil {
+			t.Fatalf("CreateChef: %v", err)
+		}
+		t.Cleanup(func() { s.DeleteChef(ctx, critic.ID) })
+		rating.ChefID = critic.ID
+		if _, err := s.CreateRating(ctx, recipe.ID, rating); err != nil {
+			t.Fatalf("CreateRating: %v", err)
+		}
+	}
+
+	result, err := s.GetRecipeWithRatings(ctx, recipe.ID)
+	if err != nil {
+		t.Fatalf("GetRecipeWithRatings: %v", err)
+	}
+	if result.RatingCount != 2 {
+		t.Errorf("expected 2 ratings, got %d", result.RatingCount)
+	}
+	if result.AverageScore != 4.5 {
+		t.Errorf("expected average 4.5, got %f", result.AverageScore)
+	}
+}
+
+func TestRatingAggregates(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	owner, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Aggregate Chef", Email: "aggregate@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, owner.ID) })
+	var raters []string
+	for i := range 3 {
+		rater, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Rater", Email: fmt.Sprintf("rater-%d@example.com", i)})
+		if err != nil {
+			t.Fatalf("CreateChef: %v", err)
+		}
+		t.Cleanup(func() { s.DeleteChef(ctx, rater.ID) })
+		raters = append(raters, rater.ID)
+	}
+
+	// A cuisine unique to this test keeps the listings free of other rows.
+	cuisine := "aggregate-" + owner.ID
+	scores := map[string][]int{"Loved": {5, 5, 4}, "Liked": {4, 4}, "Mixed": {1, 5}, "Unrated": nil}
+	ids := make(map[string]string)
+	for title, ratings := range scores {
+		recipe, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+			ChefID: owner.ID, Title: title, Ingredients: "salt", Instructions: "stir", Cuisine: cuisine,
+		})
+		if err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+		ids[title] = recipe.ID
+		for i, score := range ratings {
+			if _, err := s.CreateRating(ctx, recipe.ID, model.CreateRatingInput{ChefID: raters[i], Score: score}); err != nil {
+				t.Fatalf("CreateRating: %v", err)
+			}
+		}
+	}
+	// Deleted ratings are not counted.
+	extra, err := s.CreateRating(ctx, ids["Liked"], model.CreateRatingInput{ChefID: raters[2], Score: 1})
+	if err != nil {
+		t.Fatalf("CreateRating: %v", err)
+	}
+	if err := s.DeleteRating(ctx, extra.ID); err != nil {
+		t.Fatalf("DeleteRating: %v", err)
+	}
+
+	got, err := s.GetRecipeWithRatings(ctx, ids["Loved"])
+	if err != nil {
+		t.Fatalf("GetRecipeWithRatings: %v", err)
+	}
+	wantHistogram := map[int]int{1: 0, 2: 0, 3: 0, 4: 1, 5: 2}
+	if !maps.Equal(got.RatingHistogram, wantHistogram) {
+		t.Errorf("expected histogram %v, got %v", wantHistogram, got.RatingHistogram)
+	}
+	if got.RatingCount != 3 || math.Abs(got.AverageScore-14.0/3) > 1e-9 {
+		t.Errorf("expected 3 ratings averaging 4.67, got %d averaging %f", got.RatingCount, got.AverageScore)
+	}
+
+	// Newest-first listings carry the summary too.
+	list, _, err := s.ListRecipes(ctx, model.RecipeFilter{Cuisine: cuisine}, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("ListRecipes: %v", err)
+	}
+	for _, r := range list {
+		if r.RatingSummary == nil || r.RatingCount != len(scores[r.Title]) {
+			t.Errorf("%s: expected rating count %d, got %+v", r.Title, len(scores[r.Title]), r.RatingSummary)
+		}
+	}
+
+	// Sorting by rating pages through recipes best first.
+	var titles []string
+	var cursor *model.Cursor
+	for {
+		page, next, err := s.ListRecipes(ctx, model.RecipeFilter{Cuisine: cuisine, Sort: model.SortRating},
+			model.PageRequest{Limit: 1, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("ListRecipes by rating: %v", err)
+		}
+		for _, r := range page {
+			titles = append(titles, r.Title)
+		}
+		if next == "" {
+			break
+		}
+		if cursor, err = model.DecodeCursor(next); err != nil {
+			t.Fatalf("DecodeCursor: %v", err)
+		}
+	}
+	if want := []string{"Loved", "Liked", "Mixed", "Unrated"}; !slices.Equal(titles, want) {
+		t.Errorf("expected rating order %v, got %v", want, titles)
+	}
+
+	filtered, _, err := s.ListRecipes(ctx, model.RecipeFilter{Cuisine: cuisine, MinRating: 3.5}, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("ListRecipes min_rating: %v", err)
+	}
+	titles = nil
+	for _, r := range filtered {
+		titles = append(titles, r.Title)
+	}
+	slices.Sort(titles)
+	if want := []string{"Liked", "Loved"}; !slices.Equal(titles, want) {
+		t.Errorf("expected %v at or above 3.5, got %v", want, titles)
+	}
+}
+
+func TestSearchRecipes(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{
+		Name:  "Search Chef",
+		Email: "search-chef@example.com",
+	})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, chef.ID) })
+
+	// A token unique to this run keeps results isolated from other data.
+	token := "zq" + strings.ReplaceAll(chef.ID[:8], "-", "")
+	inputs := []model.CreateRecipeInput{
+		{Title: "Plain Rice", Description: "Goes with " + token + " chicken", Ingredients: "rice, garlic"},
+		{Title: token + " Garlic Chicken", Ingredients: "chicken, garlic"},
+		{Title: "Roast", Ingredients: token + " chicken, garlic"},
+		{Title: token + " Salad", Ingredients: "lettuce"},
+	}
+	ids := make([]string, len(inputs))
+	for i, in := range inputs {
+		in.ChefID = chef.ID
+		in.Instructions = "cook"
+		r, err := s.CreateRecipe(ctx, in)
+		if err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+		ids[i] = r.ID
+		t.Cleanup(func() { s.DeleteRecipe(ctx, r.ID) })
+	}
+
+	// All three terms must match; the salad has no chicken or garlic.
+	terms := model.SearchTerms(strings.ToUpper(token) + " garlic, CHICKEN")
+	var got []string
+	page := model.PageRequest{Limit: 2}
+	for {
+		recipes, next, err := s.SearchRecipes(ctx, terms, page)
+		if err != nil {
+			t.Fatalf("SearchRecipes: %v", err)
+		}
+		for _, r := range recipes {
+			got = append(got, r.ID)
+		}
+		if next == "" {
+			break
+		}
+		if page.Cursor, err = model.DecodeCursor(next); err != nil {
+			t.Fatalf("DecodeCursor: %v", err)
+		}
+	}
+
+	// Title match ranks above ingredients, which ranks above description.
+	want := []string{ids[1], ids[2], ids[0]}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected results %v, got %v", want, got)
+	}
+}
+
+func TestDeleteChefCascade(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Leaving Chef", Email: "leaving@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	other, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Staying Chef", Email: "staying@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, other.ID) })
+
+	var owned, onOwned []string
+	for range 2 {
+		r, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+			ChefID:       chef.ID,
+			Title:        "Doomed Stew",
+			Ingredients:  "1 cup water\n2 carrots",
+			Instructions: "simmer",
+		})
+		if err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+		owned = append(owned, r.ID)
+		// A rating by another chef on the deleted chef's recipe.
+		rating, err := s.CreateRating(ctx, r.ID, model.CreateRatingInput{ChefID: other.ID, Score: 3})
+		if err != nil {
+			t.Fatalf("CreateRating: %v", err)
+		}
+		onOwned = append(onOwned, rating.ID)
+	}
+	kept, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+		ChefID:       other.ID,
+		Title:        "Surviving Stew",
+		Ingredients:  "water",
+		Instructions: "simmer",
 	})
 	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	// A rating by the deleted chef on a recipe that survives.
+	if _, err := s.CreateRating(ctx, kept.ID, model.CreateRatingInput{ChefID: chef.ID, Score: 5}); err != nil {
+		t.Fatalf("CreateRating: %v", err)
+	}
+	if _, err := s.CreateRating(ctx, kept.ID, model.CreateRatingInput{ChefID: other.ID, Score: 4}); err != nil {
 		t.Fatalf("CreateRating: %v", err)
 	}
-	_, err = s.CreateRating(ctx, recipe.ID, model.CreateRatingInput{
-		ChefID: chef.ID, Score: 5, Comment: "Amazing",
+
+	// A recipe deleted before the chef stays deleted when the chef is restored.
+	early, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+		ChefID:       chef.ID,
+		Title:        "Abandoned Stew",
+		Ingredients:  "water",
+		Instructions: "simmer",
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	if err := s.DeleteRecipe(ctx, early.ID); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	// Cascades are matched by deleted_at, which has microsecond precision.
+	time.Sleep(time.Millisecond)
+
+	result, err := s.DeleteChef(ctx, chef.ID)
+	if err != nil {
+		t.Fatalf("DeleteChef: %v", err)
+	}
+	// The chef's own rating and the other chef's ratings on both recipes.
+	want := model.ChefDeletion{Deleted: true, Recipes: 2, Ratings: 3, Ingredients: 4}
+	if *result != want {
+		t.Errorf("expected %+v, got %+v", want, *result)
+	}
+
+	for _, id := range owned {
+		if r, err := s.GetRecipe(ctx, id); err != nil || r != nil {
+			t.Errorf("recipe %s: expected deleted, got %v, %v", id, r, err)
+		}
+		r, err := s.GetRecipe(store.IncludeDeleted(ctx), id)
+		if err != nil || r == nil || r.DeletedAt == nil {
+			t.Errorf("recipe %s: expected soft-deleted row with include deleted, got %v, %v", id, r, err)
+		}
+	}
+	for _, id := range onOwned {
+		if r, err := s.GetRating(ctx, id); err != nil || r != nil {
+			t.Errorf("rating %s on a deleted recipe: expected deleted, got %v, %v", id, r, err)
+		}
+	}
+	ratings, _, err := s.ListRatings(ctx, kept.ID, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("ListRatings: %v", err)
+	}
+	if len(ratings) != 1 || ratings[0].ChefID != other.ID {
+		t.Errorf("expected only the other chef's rating to remain, got %+v", ratings)
+	}
+
+	// Deleting again finds nothing left to cascade, as when resuming a
+	// cascade that completed.
+	result, err = s.DeleteChef(ctx, chef.ID)
+	if err != nil {
+		t.Fatalf("DeleteChef again: %v", err)
+	}
+	if *result != (model.ChefDeletion{Deleted: true}) {
+		t.Errorf("expected nothing deleted on second call, got %+v", *result)
+	}
+
+	// Restoring the chef restores exactly the rows the cascade deleted.
+	restored, err := s.RestoreChef(ctx, chef.ID)
+	if err != nil || restored == nil || restored.DeletedAt != nil {
+		t.Fatalf("RestoreChef: %v, %v", restored, err)
+	}
+	for _, id := range owned {
+		if r, err := s.GetRecipe(ctx, id); err != nil || r == nil {
+			t.Errorf("recipe %s: expected restored, got %v, %v", id, r, err)
+		}
+	}
+	if r, err := s.GetRecipe(ctx, early.ID); err != nil || r != nil {
+		t.Errorf("recipe deleted before the chef: expected still deleted, got %v, %v", r, err)
+	}
+	if ratings, _, _ = s.ListRatings(ctx, kept.ID, model.PageRequest{}); len(ratings) != 2 {
+		t.Errorf("expected 2 ratings after restore, got %d", len(ratings))
+	}
+	for _, id := range owned {
+		if ratings, _, _ = s.ListRatings(ctx, id, model.PageRequest{}); len(ratings) != 1 || ratings[0].ChefID != other.ID {
+			t.Errorf("recipe %s: expected the other chef's rating to be restored, got %+v", id, ratings)
+		}
+	}
+
+	// Purging after the retention period removes the rows permanently.
+	if _, err := s.DeleteChef(ctx, chef.ID); err != nil {
+		t.Fatalf("DeleteChef: %v", err)
+	}
+	purged, err := s.PurgeDeleted(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	if purged.Chefs < 1 || purged.Recipes < 3 || purged.Ratings < 3 || purged.Ingredients < 5 {
+		t.Errorf("expected at least 1 chef, 3 recipes, 3 ratings, 5 ingredient lines purged, got %+v", *purged)
+	}
+	if c, err := s.GetChef(store.IncludeDeleted(ctx), chef.ID); err != nil || c != nil {
+		t.Errorf("expected chef purged, got %v, %v", c, err)
+	}
+	for _, id := range append(owned, early.ID) {
+		if r, err := s.GetRecipe(store.IncludeDeleted(ctx), id); err != nil || r != nil {
+			t.Errorf("recipe %s: expected purged, got %v, %v", id, r, err)
+		}
+	}
+}
+
+func TestUpdateRecipeVersion(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Version Chef", Email: "version@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, chef.ID) })
+
+	recipe, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+		ChefID:       chef.ID,
+		Title:        "Contested Curry",
+		Ingredients:  "rice",
+		Instructions: "cook",
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	if recipe.Version != 1 {
+		t.Fatalf("expected version 1 on create, got %d", recipe.Version)
+	}
+
+	updated, err := s.UpdateRecipe(ctx, recipe.ID, model.UpdateRecipeInput{
+		Title:     ptr("Settled Curry"),
+		IfVersion: ptr(recipe.Version),
+	})
+	if err != nil {
+		t.Fatalf("UpdateRecipe: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("expected version 2 after update, got %d", updated.Version)
+	}
+
+	_, err = s.UpdateRecipe(ctx, recipe.ID, model.UpdateRecipeInput{
+		Title:     ptr("Overwritten Curry"),
+		IfVersion: ptr(recipe.Version),
+	})
+	if !errors.Is(err, store.ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed for stale version, got %v", err)
+	}
+	got, err := s.GetRecipe(ctx, recipe.ID)
+	if err != nil {
+		t.Fatalf("GetRecipe: %v", err)
+	}
+	if got.Title != "Settled Curry" || got.Version != 2 {
+		t.Errorf("stale update was applied: got %q at version %d", got.Title, got.Version)
+	}
+}
+
+func TestAuditEvents(t *testing.T) {
+	s, ctx := setupStore(t)
+	ctx = store.WithActor(ctx, "auditor@example.com")
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Audit Chef", Email: "audit@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, chef.ID) })
+
+	recipe, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+		ChefID:       chef.ID,
+		Title:        "Audited Stew",
+		Ingredients:  "beans",
+		Instructions: "simmer",
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	if _, err := s.UpdateRecipe(ctx, recipe.ID, model.UpdateRecipeInput{Title: ptr("Revised Stew")}); err != nil {
+		t.Fatalf("UpdateRecipe: %v", err)
+	}
+	if err := s.DeleteRecipe(ctx, recipe.ID); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	if _, err := s.RestoreRecipe(ctx, recipe.ID); err != nil {
+		t.Fatalf("RestoreRecipe: %v", err)
+	}
+
+	events, next, err := s.ListAuditEvents(ctx, recipe.ID, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("ListAuditEvents: %v", err)
+	}
+	if next != "" {
+		t.Errorf("expected a single page, got cursor %q", next)
+	}
+	var actions []string
+	for _, e := range events {
+		actions = append(actions, e.Action)
+		if e.EntityType != model.EntityRecipe || e.Actor != "auditor@example.com" {
+			t.Errorf("%s event: unexpected entity type %q or actor %q", e.Action, e.EntityType, e.Actor)
+		}
+	}
+	want := []string{model.ActionRestore, model.ActionDelete, model.ActionUpdate, model.ActionCreate}
+	if !slices.Equal(actions, want) {
+		t.Fatalf("expected actions %v newest first, got %v", want, actions)
+	}
+
+	var before, after model.Recipe
+	if err := json.Unmarshal(events[2].Before, &before); err != nil {
+		t.Fatalf("decode before: %v", err)
+	}
+	if err := json.Unmarshal(events[2].After, &after); err != nil {
+		t.Fatalf("decode after: %v", err)
+	}
+	if before.Title != "Audited Stew" || after.Title != "Revised Stew" {
+		t.Errorf("update event: expected title %q -> %q, got %q -> %q", "Audited Stew", "Revised Stew", before.Title, after.Title)
+	}
+	if events[3].Before != nil {
+		t.Errorf("create event: expected null before, got %s", events[3].Before)
+	}
+	if err := json.Unmarshal(events[1].After, &after); err != nil || after.DeletedAt == nil {
+		t.Errorf("delete event: expected deleted_at in after, got %s", events[1].After)
+	}
+
+	// Events page like every other list.
+	page, next, err := s.ListAuditEvents(ctx, recipe.ID, model.PageRequest{Limit: 3})
+	if err != nil || len(page) != 3 || next == "" {
+		t.Fatalf("first page: expected 3 events and a cursor, got %d %q %v", len(page), next, err)
+	}
+	cursor, _ := model.DecodeCursor(next)
+	page, next, err = s.ListAuditEvents(ctx, recipe.ID, model.PageRequest{Limit: 3, Cursor: cursor})
+	if err != nil || len(page) != 1 || next != "" || page[0].ID != events[3].ID {
+		t.Errorf("second page: expected the create event alone, got %d %q %v", len(page), next, err)
+	}
+}
+
+func TestChefEmailUnique(t *testing.T) {
+	s, ctx := setupStore(t)
+	email := fmt.Sprintf("race-%d@example.com", time.Now().UnixNano())
+
+	// Two racing creates that differ only in case: exactly one wins.
+	var wg sync.WaitGroup
+	results := make([]*model.Chef, 2)
+	errs := make([]error, 2)
+	for i, addr := range []string{email, strings.ToUpper(email)} {
+		wg.Go(func() {
+			results[i], errs[i] = s.CreateChef(ctx, model.CreateChefInput{Name: "Racer", Email: addr})
+		})
+	}
+	wg.Wait()
+	var winner *model.Chef
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			if winner != nil {
+				t.Fatal("both racing creates succeeded")
+			}
+			winner = results[i]
+			t.Cleanup(func() { s.DeleteChef(ctx, winner.ID) })
+		case !errors.Is(err, store.ErrConflict):
+			t.Fatalf("expected ErrConflict for the losing create, got %v", err)
+		}
+	}
+	if winner == nil {
+		t.Fatalf("expected one create to succeed, got %v", errs)
+	}
+
+	other, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Other", Email: "other-" + email})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, other.ID) })
+	if _, err := s.UpdateChef(ctx, other.ID, model.UpdateChefInput{Email: ptr(" " + strings.ToUpper(email))}); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("expected ErrConflict updating to a taken email, got %v", err)
+	}
+	if _, err := s.UpdateChef(ctx, winner.ID, model.UpdateChefInput{Email: ptr(strings.ToUpper(email))}); err != nil {
+		t.Errorf("changing the case of a chef's own email: %v", err)
+	}
+
+	// Deleting a chef frees the email; the chef cannot be restored while
+	// another chef holds it.
+	if _, err := s.DeleteChef(ctx, winner.ID); err != nil {
+		t.Fatalf("DeleteChef: %v", err)
+	}
+	if _, err := s.UpdateChef(ctx, other.ID, model.UpdateChefInput{Email: ptr(email)}); err != nil {
+		t.Fatalf("reusing a deleted chef's email: %v", err)
+	}
+	if _, err := s.RestoreChef(ctx, winner.ID); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("expected ErrConflict restoring a chef whose email was reused, got %v", err)
+	}
+}
+
+func TestRatingPerChef(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	owner, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Rated Owner", Email: "rated-owner@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, owner.ID) })
+	critic, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Repeat Critic", Email: "repeat-critic@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, critic.ID) })
+	recipe, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+		ChefID:       owner.ID,
+		Title:        "Rated Once",
+		Ingredients:  "flour",
+		Instructions: "bake",
 	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	first, err := s.CreateRating(ctx, recipe.ID, model.CreateRatingInput{ChefID: critic.ID, Score: 2})
 	if err != nil {
 		t.Fatalf("CreateRating: %v", err)
 	}
+	if _, err := s.CreateRating(ctx, recipe.ID, model.CreateRatingInput{ChefID: critic.ID, Score: 3}); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("expected ErrConflict rating the same recipe twice, got %v", err)
+	}
 
-	result, err := s.GetRecipeWithRatings(ctx, recipe.ID)
+	// Upsert replaces the existing rating rather than adding another.
+	upserted, created, err := s.UpsertRating(ctx, recipe.ID, model.CreateRatingInput{ChefID: critic.ID, Score: 4, Comment: "Better"})
 	if err != nil {
-		t.Fatalf("GetRecipeWithRatings: %v", err)
+		t.Fatalf("UpsertRating: %v", err)
 	}
-	if result.RatingCount != 2 {
-		t.Errorf("expected 2 ratings, got %d", result.RatingCount)
+	if created || upserted.ID != first.ID || upserted.Score != 4 || upserted.Comment != "Better" {
+		t.Errorf("expected rating %s updated to 4 %q, got created=%v %+v", first.ID, "Better", created, upserted)
 	}
-	if result.AverageScore != 4.5 {
-		t.Errorf("expected average 4.5, got %f", result.AverageScore)
+
+	updated, err := s.UpdateRating(ctx, first.ID, model.UpdateRatingInput{Score: ptr(5)})
+	if err != nil {
+		t.Fatalf("UpdateRating: %v", err)
+	}
+	if updated.Score != 5 || updated.Comment != "Better" {
+		t.Errorf("expected score 5 with comment kept, got %d %q", updated.Score, updated.Comment)
+	}
+	ratings, _, err := s.ListRatings(ctx, recipe.ID, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("ListRatings: %v", err)
+	}
+	if len(ratings) != 1 || ratings[0].Score != 5 {
+		t.Errorf("expected a single rating of 5, got %+v", ratings)
+	}
+
+	// Deleting the rating frees the slot; the old rating cannot be restored
+	// once the chef has rated again.
+	if err := s.DeleteRating(ctx, first.ID); err != nil {
+		t.Fatalf("DeleteRating: %v", err)
+	}
+	if got, err := s.UpdateRating(ctx, first.ID, model.UpdateRatingInput{Score: ptr(1)}); err != nil || got != nil {
+		t.Errorf("expected nil updating a deleted rating, got %+v %v", got, err)
+	}
+	second, created, err := s.UpsertRating(ctx, recipe.ID, model.CreateRatingInput{ChefID: critic.ID, Score: 3})
+	if err != nil {
+		t.Fatalf("UpsertRating: %v", err)
+	}
+	if !created || second.ID == first.ID {
+		t.Errorf("expected a new rating after deleting the old one, got created=%v %s", created, second.ID)
+	}
+	if _, err := s.RestoreRating(ctx, first.ID); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("expected ErrConflict restoring a superseded rating, got %v", err)
+	}
+}
+
+func TestRecipeTags(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Tagging Chef", Email: "tagging@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, chef.ID) })
+
+	// Tags are unique per test run so that counts are not shared with other
+	// runs against the same cluster.
+	suffix := fmt.Sprint(time.Now().UnixNano())
+	vegan, quick := "vegan-"+suffix, "quick-meals-"+suffix
+	ids := make(map[string]string)
+	for title, tags := range map[string][]string{
+		"Both":  {"Vegan " + suffix, "Quick Meals " + suffix},
+		"Vegan": {vegan},
+		"None":  nil,
+	} {
+		r, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+			ChefID: chef.ID, Title: title, Ingredients: "beans", Instructions: "cook",
+		})
+		if err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+		ids[title] = r.ID
+		if tags == nil {
+			continue
+		}
+		if _, err := s.AddRecipeTags(ctx, r.ID, tags); err != nil {
+			t.Fatalf("AddRecipeTags: %v", err)
+		}
+	}
+
+	// Re-adding a tag under another spelling is a no-op.
+	slugs, err := s.AddRecipeTags(ctx, ids["Both"], []string{"VEGAN-" + suffix})
+	if err != nil {
+		t.Fatalf("AddRecipeTags: %v", err)
+	}
+	if !slices.Equal(slugs, []string{quick, vegan}) {
+		t.Errorf("expected tags %v, got %v", []string{quick, vegan}, slugs)
+	}
+	recipe, err := s.GetRecipe(ctx, ids["Both"])
+	if err != nil {
+		t.Fatalf("GetRecipe: %v", err)
+	}
+	if !slices.Equal(recipe.Tags, slugs) {
+		t.Errorf("expected recipe tags %v, got %v", slugs, recipe.Tags)
+	}
+
+	list := func(match string, tags ...string) []string {
+		t.Helper()
+		recipes, _, err := s.ListRecipes(ctx, model.RecipeFilter{Tags: tags, TagMatch: match}, model.PageRequest{})
+		if err != nil {
+			t.Fatalf("ListRecipes: %v", err)
+		}
+		var titles []string
+		for _, r := range recipes {
+			titles = append(titles, r.Title)
+		}
+		slices.Sort(titles)
+		return titles
+	}
+	if got := list(model.TagMatchAny, vegan, quick); !slices.Equal(got, []string{"Both", "Vegan"}) {
+		t.Errorf("any: expected [Both Vegan], got %v", got)
+	}
+	if got := list(model.TagMatchAll, vegan, quick); !slices.Equal(got, []string{"Both"}) {
+		t.Errorf("all: expected [Both], got %v", got)
+	}
+
+	// Usage counts cover live recipes only.
+	if err := s.DeleteRecipe(ctx, ids["Vegan"]); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	removed, err := s.RemoveRecipeTag(ctx, ids["Both"], quick)
+	if err != nil || !removed {
+		t.Fatalf("RemoveRecipeTag: %v %v", removed, err)
+	}
+	if removed, _ := s.RemoveRecipeTag(ctx, ids["Both"], quick); removed {
+		t.Error("expected removing a missing tag to report false")
+	}
+	counts := make(map[string]int)
+	var page model.PageRequest
+	for {
+		tags, next, err := s.ListTags(ctx, page)
+		if err != nil {
+			t.Fatalf("ListTags: %v", err)
+		}
+		for _, tag := range tags {
+			counts[tag.Slug] = tag.RecipeCount
+		}
+		if next == "" {
+			break
+		}
+		if page.Cursor, err = model.DecodeCursor(next); err != nil {
+			t.Fatalf("DecodeCursor: %v", err)
+		}
+	}
+	if counts[vegan] != 1 || counts[quick] != 0 {
+		t.Errorf("expected usage %s=1 %s=0, got %d and %d", vegan, quick, counts[vegan], counts[quick])
+	}
+
+	many := make([]string, model.MaxRecipeTags)
+	for i := range many {
+		many[i] = fmt.Sprintf("extra-%d-%s", i, suffix)
+	}
+	if _, err := s.AddRecipeTags(ctx, ids["None"], many); err != nil {
+		t.Fatalf("AddRecipeTags up to the limit: %v", err)
+	}
+	if _, err := s.AddRecipeTags(ctx, ids["None"], []string{"one-more-" + suffix}); !errors.Is(err, store.ErrTooManyTags) {
+		t.Errorf("expected ErrTooManyTags, got %v", err)
+	}
+}
+
+func TestCollectionItemsOfDeletedRecipes(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Hidden Items", Email: "hidden-items@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, chef.ID) })
+	recipes := make([]string, model.MaxCollectionItems+1)
+	for i := range recipes {
+		r, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+			ChefID: chef.ID, Title: fmt.Sprintf("Recipe %d", i+1), Ingredients: "salt", Instructions: "season",
+		})
+		if err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+		recipes[i] = r.ID
+	}
+	full, err := s.CreateCollection(ctx, model.CreateCollectionInput{ChefID: chef.ID, Name: "Full"})
+	if err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteCollection(ctx, full.ID) })
+	for _, id := range recipes[:model.MaxCollectionItems] {
+		if _, err := s.AddCollectionItem(ctx, full.ID, model.AddCollectionItemInput{RecipeID: id}); err != nil {
+			t.Fatalf("AddCollectionItem: %v", err)
+		}
+	}
+	spare := recipes[model.MaxCollectionItems]
+	if _, err := s.AddCollectionItem(ctx, full.ID, model.AddCollectionItemInput{RecipeID: spare}); !errors.Is(err, store.ErrCollectionFull) {
+		t.Fatalf("expected ErrCollectionFull, got %v", err)
+	}
+
+	items := func() []model.CollectionItem {
+		t.Helper()
+		c, err := s.GetCollection(ctx, full.ID)
+		if err != nil {
+			t.Fatalf("GetCollection: %v", err)
+		}
+		for i, it := range c.Items {
+			if it.Position != i+1 {
+				t.Errorf("item %s: expected position %d, got %d", it.Title, i+1, it.Position)
+			}
+		}
+		return c.Items
+	}
+
+	// Deleting the second recipe closes its gap and frees its place.
+	if err := s.DeleteRecipe(ctx, recipes[1]); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	got := items()
+	if len(got) != model.MaxCollectionItems-1 {
+		t.Fatalf("expected %d items after delete, got %d", model.MaxCollectionItems-1, len(got))
+	}
+	if got[1].RecipeID != recipes[2] {
+		t.Errorf("expected the third recipe at position 2, got %s", got[1].Title)
+	}
+	moved, err := s.MoveCollectionItem(ctx, full.ID, recipes[0], 0)
+	if err != nil || moved == nil {
+		t.Fatalf("MoveCollectionItem: %v %v", moved, err)
+	}
+	if moved.Position != model.MaxCollectionItems-1 {
+		t.Errorf("expected a move to the end to land at %d, got %d", model.MaxCollectionItems-1, moved.Position)
+	}
+	if _, err := s.MoveCollectionItem(ctx, full.ID, recipes[0], 1); err != nil {
+		t.Fatalf("MoveCollectionItem: %v", err)
+	}
+
+	// Restoring the recipe puts it back in its old place.
+	if _, err := s.RestoreRecipe(ctx, recipes[1]); err != nil {
+		t.Fatalf("RestoreRecipe: %v", err)
+	}
+	got = items()
+	if len(got) != model.MaxCollectionItems || got[1].RecipeID != recipes[1] {
+		t.Fatalf("expected the restored recipe back at position 2 of %d, got %d items", model.MaxCollectionItems, len(got))
+	}
+
+	// If the freed place is taken in the meantime, restoring the recipe
+	// leaves the collection as it is.
+	if err := s.DeleteRecipe(ctx, recipes[1]); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	if _, err := s.AddCollectionItem(ctx, full.ID, model.AddCollectionItemInput{RecipeID: spare}); err != nil {
+		t.Fatalf("AddCollectionItem after a delete: %v", err)
+	}
+	if _, err := s.RestoreRecipe(ctx, recipes[1]); err != nil {
+		t.Fatalf("RestoreRecipe: %v", err)
+	}
+	got = items()
+	if len(got) != model.MaxCollectionItems || slices.ContainsFunc(got, func(it model.CollectionItem) bool { return it.RecipeID == recipes[1] }) {
+		t.Errorf("expected the full collection without the restored recipe, got %d items", len(got))
+	}
+	if _, err := s.AddCollectionItem(ctx, full.ID, model.AddCollectionItemInput{RecipeID: recipes[1]}); !errors.Is(err, store.ErrCollectionFull) {
+		t.Errorf("expected ErrCollectionFull adding the restored recipe back, got %v", err)
+	}
+}
+
+func TestCollectionItemsRestoredInPlace(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	var chefs []*model.Chef
+	for _, name := range []string{"saver", "author"} {
+		c, err := s.CreateChef(ctx, model.CreateChefInput{Name: name, Email: "restored-in-place-" + name + "@example.com"})
+		if err != nil {
+			t.Fatalf("CreateChef: %v", err)
+		}
+		t.Cleanup(func() { s.DeleteChef(ctx, c.ID) })
+		chefs = append(chefs, c)
+	}
+	saver, author := chefs[0], chefs[1]
+	ids := make(map[string]string)
+	for _, title := range []string{"A", "B", "C", "D", "P", "Q"} {
+		owner := saver.ID
+		if title == "P" || title == "Q" {
+			owner = author.ID
+		}
+		r, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+			ChefID: owner, Title: title, Ingredients: "salt", Instructions: "season",
+		})
+		if err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+		ids[title] = r.ID
+	}
+	collection, err := s.CreateCollection(ctx, model.CreateCollectionInput{ChefID: saver.ID, Name: "In Place"})
+	if err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteCollection(ctx, collection.ID) })
+	order := func() string {
+		t.Helper()
+		c, err := s.GetCollection(ctx, collection.ID)
+		if err != nil {
+			t.Fatalf("GetCollection: %v", err)
+		}
+		var titles string
+		for i, it := range c.Items {
+			if it.Position != i+1 {
+				t.Errorf("item %s: expected position %d, got %d", it.Title, i+1, it.Position)
+			}
+			titles += it.Title
+		}
+		return titles
+	}
+	move := func(title string, position int) {
+		t.Helper()
+		if _, err := s.MoveCollectionItem(ctx, collection.ID, ids[title], position); err != nil {
+			t.Fatalf("MoveCollectionItem: %v", err)
+		}
+	}
+	for _, title := range []string{"A", "B", "C", "D"} {
+		if _, err := s.AddCollectionItem(ctx, collection.ID, model.AddCollectionItemInput{RecipeID: ids[title]}); err != nil {
+			t.Fatalf("AddCollectionItem: %v", err)
+		}
+	}
+
+	// A restored recipe is reinserted at the position it had, whatever
+	// moved while it was deleted.
+	if err := s.DeleteRecipe(ctx, ids["B"]); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	move("D", 1)
+	if got := order(); got != "DAC" {
+		t.Errorf("after moving with B deleted: got %s", got)
+	}
+	if _, err := s.RestoreRecipe(ctx, ids["B"]); err != nil {
+		t.Fatalf("RestoreRecipe: %v", err)
+	}
+	if got := order(); got != "DBAC" {
+		t.Errorf("after restoring B: got %s", got)
+	}
+
+	// Items hidden together with a chef come back in their old order.
+	for i, title := range []string{"P", "Q"} {
+		input := model.AddCollectionItemInput{RecipeID: ids[title], Position: 2 + i}
+		if _, err := s.AddCollectionItem(ctx, collection.ID, input); err != nil {
+			t.Fatalf("AddCollectionItem: %v", err)
+		}
+	}
+	if got := order(); got != "DPQBAC" {
+		t.Fatalf("before deleting the author: got %s", got)
+	}
+	if _, err := s.DeleteChef(ctx, author.ID); err != nil {
+		t.Fatalf("DeleteChef: %v", err)
+	}
+	move("C", 1)
+	if got := order(); got != "CDBA" {
+		t.Errorf("after moving with the author deleted: got %s", got)
+	}
+	if _, err := s.RestoreChef(ctx, author.ID); err != nil {
+		t.Fatalf("RestoreChef: %v", err)
+	}
+	if got := order(); got != "CPQDBA" {
+		t.Errorf("after restoring the author: got %s", got)
+	}
+}
+
+func TestCollectionItemsHiddenInBatches(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Popular", Email: "hidden-in-batches@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, chef.ID) })
+	recipe, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+		ChefID: chef.ID, Title: "Saved Everywhere", Ingredients: "salt", Instructions: "season",
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	// More memberships than the 500 changed per transaction.
+	collections := make([]string, 501)
+	for i := range collections {
+		c, err := s.CreateCollection(ctx, model.CreateCollectionInput{ChefID: chef.ID, Name: fmt.Sprintf("Collection %d", i)})
+		if err != nil {
+			t.Fatalf("CreateCollection: %v", err)
+		}
+		t.Cleanup(func() { s.DeleteCollection(ctx, c.ID) })
+		if _, err := s.AddCollectionItem(ctx, c.ID, model.AddCollectionItemInput{RecipeID: recipe.ID}); err != nil {
+			t.Fatalf("AddCollectionItem: %v", err)
+		}
+		collections[i] = c.ID
+	}
+	counts := func() (empty, full int) {
+		t.Helper()
+		for _, id := range collections {
+			c, err := s.GetCollection(ctx, id)
+			if err != nil {
+				t.Fatalf("GetCollection: %v", err)
+			}
+			if len(c.Items) == 0 {
+				empty++
+			} else {
+				full++
+			}
+		}
+		return empty, full
+	}
+
+	for _, step := range []struct {
+		name string
+		run  func() error
+		full int
+	}{
+		{"delete recipe", func() error { return s.DeleteRecipe(ctx, recipe.ID) }, 0},
+		{"restore recipe", func() error { _, err := s.RestoreRecipe(ctx, recipe.ID); return err }, len(collections)},
+		{"delete chef", func() error { _, err := s.DeleteChef(ctx, chef.ID); return err }, 0},
+		{"restore chef", func() error { _, err := s.RestoreChef(ctx, chef.ID); return err }, len(collections)},
+	} {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		// The store still reads a deleted chef's collections by ID; only
+		// the handler hides them.
+		if _, full := counts(); full != step.full {
+			t.Errorf("after %s: expected %d collections holding the recipe, got %d", step.name, step.full, full)
+		}
+	}
+}
+
+func TestCollections(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	var chefs []*model.Chef
+	for _, name := range []string{"author", "saver", "other-saver"} {
+		c, err := s.CreateChef(ctx, model.CreateChefInput{Name: name, Email: "collections-" + name + "@example.com"})
+		if err != nil {
+			t.Fatalf("CreateChef: %v", err)
+		}
+		t.Cleanup(func() { s.DeleteChef(ctx, c.ID) })
+		chefs = append(chefs, c)
+	}
+	author, saver, other := chefs[0], chefs[1], chefs[2]
+	var recipes []string
+	for _, title := range []string{"First", "Second", "Third"} {
+		r, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+			ChefID: author.ID, Title: title, Ingredients: "salt", Instructions: "season",
+		})
+		if err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+		recipes = append(recipes, r.ID)
+	}
+
+	weeknight, err := s.CreateCollection(ctx, model.CreateCollectionInput{ChefID: saver.ID, Name: "Weeknight"})
+	if err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	order := func() []string {
+		t.Helper()
+		c, err := s.GetCollection(ctx, weeknight.ID)
+		if err != nil {
+			t.Fatalf("GetCollection: %v", err)
+		}
+		var titles []string
+		for i, it := range c.Items {
+			if it.Position != i+1 {
+				t.Errorf("item %s: expected position %d, got %d", it.Title, i+1, it.Position)
+			}
+			titles = append(titles, it.Title)
+		}
+		return titles
+	}
+
+	for _, id := range recipes[:2] {
+		if _, err := s.AddCollectionItem(ctx, weeknight.ID, model.AddCollectionItemInput{RecipeID: id}); err != nil {
+			t.Fatalf("AddCollectionItem: %v", err)
+		}
+	}
+	if _, err := s.AddCollectionItem(ctx, weeknight.ID, model.AddCollectionItemInput{RecipeID: recipes[2], Position: 1}); err != nil {
+		t.Fatalf("AddCollectionItem at the front: %v", err)
+	}
+	if got := order(); !slices.Equal(got, []string{"Third", "First", "Second"}) {
+		t.Errorf("after inserting at the front: got %v", got)
+	}
+	if _, err := s.AddCollectionItem(ctx, weeknight.ID, model.AddCollectionItemInput{RecipeID: recipes[0]}); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("expected ErrConflict adding a recipe twice, got %v", err)
+	}
+
+	moved, err := s.MoveCollectionItem(ctx, weeknight.ID, recipes[2], 99)
+	if err != nil {
+		t.Fatalf("MoveCollectionItem: %v", err)
+	}
+	if moved.Position != 3 {
+		t.Errorf("expected a move past the end to land at 3, got %d", moved.Position)
+	}
+	if got := order(); !slices.Equal(got, []string{"First", "Second", "Third"}) {
+		t.Errorf("after moving to the end: got %v", got)
+	}
+	if removed, err := s.RemoveCollectionItem(ctx, weeknight.ID, recipes[1]); err != nil || !removed {
+		t.Fatalf("RemoveCollectionItem: %v %v", removed, err)
+	}
+	if got := order(); !slices.Equal(got, []string{"First", "Third"}) {
+		t.Errorf("after removing the middle item: got %v", got)
+	}
+
+	// Favorites count chefs, not collections.
+	holiday, err := s.CreateCollection(ctx, model.CreateCollectionInput{ChefID: saver.ID, Name: "Holiday"})
+	if err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	otherFavorites, err := s.CreateCollection(ctx, model.CreateCollectionInput{ChefID: other.ID, Name: "Favorites"})
+	if err != nil {
+		t.Fatalf("CreateCollection: %v", err)
+	}
+	for _, cid := range []string{holiday.ID, otherFavorites.ID} {
+		if _, err := s.AddCollectionItem(ctx, cid, model.AddCollectionItemInput{RecipeID: recipes[0]}); err != nil {
+			t.Fatalf("AddCollectionItem: %v", err)
+		}
+	}
+	first, err := s.GetRecipeWithRatings(ctx, recipes[0])
+	if err != nil {
+		t.Fatalf("GetRecipeWithRatings: %v", err)
+	}
+	if first.FavoritedCount == nil || *first.FavoritedCount != 2 {
+		t.Errorf("expected favorited_count 2, got %v", first.FavoritedCount)
+	}
+
+	// Deleting a recipe hides it from collections; purging it removes its
+	// memberships.
+	if err := s.DeleteRecipe(ctx, recipes[0]); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	if got := order(); !slices.Equal(got, []string{"Third"}) {
+		t.Errorf("after deleting a recipe: got %v", got)
+	}
+	if _, err := s.DeleteChef(ctx, other.ID); err != nil {
+		t.Fatalf("DeleteChef: %v", err)
+	}
+	purged, err := s.PurgeDeleted(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	if purged.Collections < 1 {
+		t.Errorf("expected the purged chef's collection to be purged, got %+v", *purged)
+	}
+	if c, err := s.GetCollection(ctx, otherFavorites.ID); err != nil || c != nil {
+		t.Errorf("expected collection of purged chef removed, got %v, %v", c, err)
+	}
+	events, _, err := s.ListAuditEvents(ctx, holiday.ID, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("ListAuditEvents: %v", err)
+	}
+	if len(events) == 0 || events[0].EntityType != model.EntityCollectionItem || events[0].Action != model.ActionPurge {
+		t.Errorf("expected the recipe's membership to be purged, got %+v", events)
+	}
+
+	if deleted, err := s.DeleteCollection(ctx, weeknight.ID); err != nil || !deleted {
+		t.Fatalf("DeleteCollection: %v %v", deleted, err)
+	}
+	if c, err := s.GetCollection(ctx, weeknight.ID); err != nil || c != nil {
+		t.Errorf("expected deleted collection to be gone, got %v, %v", c, err)
+	}
+}
+
+func TestFollowsAndFeed(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	var chefs []*model.Chef
+	for _, name := range []string{"reader", "author", "other-author", "quiet"} {
+		c, err := s.CreateChef(ctx, model.CreateChefInput{Name: name, Email: "follows-" + name + "@example.com"})
+		if err != nil {
+			t.Fatalf("CreateChef: %v", err)
+		}
+		t.Cleanup(func() { s.DeleteChef(ctx, c.ID) })
+		chefs = append(chefs, c)
+	}
+	reader, author, other, quiet := chefs[0], chefs[1], chefs[2], chefs[3]
+
+	for _, followee := range []*model.Chef{author, other} {
+		created, err := s.Follow(ctx, reader.ID, followee.ID)
+		if err != nil || !created {
+			t.Fatalf("Follow: %v %v", created, err)
+		}
+	}
+	if created, err := s.Follow(ctx, reader.ID, author.ID); err != nil || created {
+		t.Errorf("expected a repeated follow to succeed without creating, got %v %v", created, err)
+	}
+	if _, err := s.Follow(ctx, quiet.ID, author.ID); err != nil {
+		t.Fatalf("Follow: %v", err)
+	}
+
+	following, _, err := s.ListFollowing(ctx, reader.ID, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("ListFollowing: %v", err)
+	}
+	if len(following) != 2 || following[0].ID != other.ID || following[1].ID != author.ID {
+		t.Errorf("expected reader to follow other-author then author, got %+v", following)
+	}
+	followers, _, err := s.ListFollowers(ctx, author.ID, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("ListFollowers: %v", err)
+	}
+	if len(followers) != 2 {
+		t.Errorf("expected author to have 2 followers, got %d", len(followers))
+	}
+
+	// Only the published, live recipes of followed chefs reach the feed.
+	create := func(chef *model.Chef, title, status string) string {
+		t.Helper()
+		r, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+			ChefID: chef.ID, Title: title, Ingredients: "flour", Instructions: "bake", Status: status,
+		})
+		if err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+		return r.ID
+	}
+	// The feed is ordered by publication, so a draft written first and
+	// published last leads it.
+	late := create(author, "Late Bread", model.StatusDraft)
+	create(author, "Bread", model.StatusPublished)
+	create(author, "Draft Bread", model.StatusDraft)
+	create(other, "Rolls", model.StatusPublished)
+	deleted := create(other, "Deleted Rolls", model.StatusPublished)
+	create(quiet, "Unfollowed Buns", model.StatusPublished)
+	if err := s.DeleteRecipe(ctx, deleted); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	if _, err := s.TransitionRecipe(ctx, late, model.TransitionPublish, nil, nil); err != nil {
+		t.Fatalf("TransitionRecipe: %v", err)
+	}
+
+	var titles []string
+	page := model.PageRequest{Limit: 1}
+	for {
+		recipes, next, err := s.Feed(ctx, reader.ID, page)
+		if err != nil {
+			t.Fatalf("Feed: %v", err)
+		}
+		for _, r := range recipes {
+			if r.RatingSummary == nil {
+				t.Errorf("feed recipe %s has no rating summary", r.Title)
+			}
+			titles = append(titles, r.Title)
+		}
+		if next == "" {
+			break
+		}
+		cursor, err := model.DecodeCursor(next)
+		if err != nil {
+			t.Fatalf("DecodeCursor: %v", err)
+		}
+		page.Cursor = cursor
+	}
+	if !slices.Equal(titles, []string{"Late Bread", "Rolls", "Bread"}) {
+		t.Errorf("expected feed [Late Bread Rolls Bread], got %v", titles)
+	}
+
+	if removed, err := s.Unfollow(ctx, reader.ID, other.ID); err != nil || !removed {
+		t.Fatalf("Unfollow: %v %v", removed, err)
+	}
+	if removed, err := s.Unfollow(ctx, reader.ID, other.ID); err != nil || removed {
+		t.Errorf("expected a repeated unfollow to report false, got %v %v", removed, err)
+	}
+	recipes, _, err := s.Feed(ctx, reader.ID, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("Feed: %v", err)
+	}
+	if len(recipes) != 2 || recipes[0].Title != "Late Bread" || recipes[1].Title != "Bread" {
+		t.Errorf("expected only the author's recipes after unfollowing, got %+v", recipes)
+	}
+
+	// A deleted chef drops out of follow lists, and purging removes the
+	// follows on both sides.
+	if _, err := s.DeleteChef(ctx, quiet.ID); err != nil {
+		t.Fatalf("DeleteChef: %v", err)
+	}
+	followers, _, err = s.ListFollowers(ctx, author.ID, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("ListFollowers: %v", err)
+	}
+	if len(followers) != 1 || followers[0].ID != reader.ID {
+		t.Errorf("expected only reader to follow author, got %+v", followers)
+	}
+	purged, err := s.PurgeDeleted(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	if purged.Follows < 1 {
+		t.Errorf("expected the deleted chef's follow to be purged, got %d", purged.Follows)
+	}
+}
+
+func TestCreateRecipesAndExport(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Importer", Email: "import-chef@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, chef.ID) })
+
+	// Enough recipes with enough ingredient lines that Aurora DSQL needs
+	// several transactions to hold them.
+	var lines []string
+	for i := range 30 {
+		lines = append(lines, fmt.Sprintf("%d g ingredient %d", i+1, i))
+	}
+	inputs := make([]model.CreateRecipeInput, 120)
+	for i := range inputs {
+		inputs[i] = model.CreateRecipeInput{
+			ChefID:       chef.ID,
+			Title:        fmt.Sprintf("Imported %03d", i),
+			Ingredients:  strings.Join(lines, "\n"),
+			Instructions: "combine",
+		}
+	}
+	created, err := s.CreateRecipes(ctx, inputs)
+	if err != nil {
+		t.Fatalf("CreateRecipes: %v", err)
+	}
+	if len(created) != len(inputs) {
+		t.Fatalf("expected %d recipes, got %d", len(inputs), len(created))
+	}
+	for i, r := range created {
+		if r.Title != inputs[i].Title || len(r.IngredientList) != 30 || r.Status != model.StatusDraft {
+			t.Fatalf("recipe %d: unexpected %q with %d lines and status %q", i, r.Title, len(r.IngredientList), r.Status)
+		}
+	}
+	got, err := s.GetRecipe(ctx, created[len(created)-1].ID)
+	if err != nil || got == nil || len(got.IngredientList) != 30 {
+		t.Fatalf("GetRecipe after import: %+v %v", got, err)
+	}
+
+	if err := s.DeleteRecipe(ctx, created[0].ID); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	exported := make(map[string]bool)
+	err = s.ExportRecipes(ctx, func(r model.Recipe) error {
+		if r.DeletedAt != nil {
+			t.Errorf("exported deleted recipe %s", r.ID)
+		}
+		exported[r.ID] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportRecipes: %v", err)
+	}
+	if exported[created[0].ID] {
+		t.Error("expected the deleted recipe to be left out of the export")
+	}
+	for _, r := range created[1:] {
+		if !exported[r.ID] {
+			t.Fatalf("recipe %s missing from the export", r.ID)
+		}
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = s.ExportRecipes(ctx, func(model.Recipe) error {
+		n++
+		return stop
+	})
+	if !errors.Is(err, stop) || n != 1 {
+		t.Errorf("expected the export to stop at the first error, got %v after %d", err, n)
+	}
+}
+
+func TestRecipeForks(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	var chefs []*model.Chef
+	for _, name := range []string{"original", "forker", "reforker"} {
+		c, err := s.CreateChef(ctx, model.CreateChefInput{Name: name, Email: "forks-" + name + "@example.com"})
+		if err != nil {
+			t.Fatalf("CreateChef: %v", err)
+		}
+		t.Cleanup(func() { s.DeleteChef(ctx, c.ID) })
+		chefs = append(chefs, c)
+	}
+	original, forker, reforker := chefs[0], chefs[1], chefs[2]
+
+	src, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+		ChefID: original.ID, Title: "Focaccia", Description: "Airy bread",
+		Ingredients: "500 g flour\n2 tbsp olive oil", Instructions: "proof and bake",
+		PrepTime: 20, CookTime: 25, Servings: 8, Cuisine: "Italian", Status: model.StatusDraft,
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	if _, err := s.AddRecipeTags(ctx, src.ID, []string{"Bread", "Baking"}); err != nil {
+		t.Fatalf("AddRecipeTags: %v", err)
+	}
+
+	if _, err := s.ForkRecipe(ctx, src.ID, forker.ID); !errors.Is(err, store.ErrNotPublished) {
+		t.Errorf("forking a draft: expected ErrNotPublished, got %v", err)
+	}
+	if _, err := s.UpdateRecipe(ctx, src.ID, model.UpdateRecipeInput{Status: ptr(model.StatusPublished)}); err != nil {
+		t.Fatalf("UpdateRecipe: %v", err)
+	}
+
+	fork, err := s.ForkRecipe(ctx, src.ID, forker.ID)
+	if err != nil {
+		t.Fatalf("ForkRecipe: %v", err)
+	}
+	if fork.ID == src.ID || fork.ChefID != forker.ID || fork.Status != model.StatusDraft || fork.Version != 1 {
+		t.Errorf("unexpected fork identity: %+v", fork)
+	}
+	if fork.ForkedFromID == nil || *fork.ForkedFromID != src.ID {
+		t.Errorf("expected forked_from_id %s, got %v", src.ID, fork.ForkedFromID)
+	}
+	got, err := s.GetRecipe(ctx, fork.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetRecipe fork: %v %v", got, err)
+	}
+	if got.Title != "Focaccia" || got.Description != "Airy bread" || got.Servings != 8 || got.Cuisine != "Italian" || got.PrepTime != 20 {
+		t.Errorf("fork did not copy the recipe content: %+v", got)
+	}
+	if len(got.IngredientList) != 2 || got.IngredientList[0].Name != "flour" {
+		t.Errorf("fork did not copy the ingredients: %+v", got.IngredientList)
+	}
+	if !slices.Equal(got.Tags, []string{"baking", "bread"}) {
+		t.Errorf("fork did not copy the tags: %v", got.Tags)
+	}
+
+	// A fork of the fork, once published, extends the lineage.
+	if _, err := s.UpdateRecipe(ctx, fork.ID, model.UpdateRecipeInput{Status: ptr(model.StatusPublished)}); err != nil {
+		t.Fatalf("UpdateRecipe: %v", err)
+	}
+	grandchild, err := s.ForkRecipe(ctx, fork.ID, reforker.ID)
+	if err != nil {
+		t.Fatalf("ForkRecipe: %v", err)
+	}
+	sibling, err := s.ForkRecipe(ctx, src.ID, reforker.ID)
+	if err != nil {
+		t.Fatalf("ForkRecipe: %v", err)
+	}
+
+	withRatings, err := s.GetRecipeWithRatings(ctx, src.ID)
+	if err != nil {
+		t.Fatalf("GetRecipeWithRatings: %v", err)
+	}
+	if withRatings.ForkCount == nil || *withRatings.ForkCount != 2 {
+		t.Errorf("expected 2 forks of the original, got %v", withRatings.ForkCount)
+	}
+
+	lineage, err := s.RecipeLineage(ctx, grandchild.ID, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("RecipeLineage: %v", err)
+	}
+	if len(lineage.Ancestors) != 2 || lineage.Ancestors[0].ID != fork.ID || lineage.Ancestors[1].ID != src.ID {
+		t.Errorf("expected ancestors fork then original, got %+v", lineage.Ancestors)
+	}
+	if len(lineage.Descendants) != 0 {
+		t.Errorf("expected no descendants of the grandchild, got %+v", lineage.Descendants)
+	}
+
+	lineage, err = s.RecipeLineage(ctx, src.ID, model.PageRequest{Limit: 1})
+	if err != nil {
+		t.Fatalf("RecipeLineage: %v", err)
+	}
+	if len(lineage.Ancestors) != 0 || len(lineage.Descendants) != 1 || lineage.Descendants[0].ID != sibling.ID || lineage.NextCursor == "" {
+		t.Errorf("expected the newest fork and a cursor, got %+v", lineage)
+	}
+	cursor, err := model.DecodeCursor(lineage.NextCursor)
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	lineage, err = s.RecipeLineage(ctx, src.ID, model.PageRequest{Limit: 1, Cursor: cursor})
+	if err != nil {
+		t.Fatalf("RecipeLineage: %v", err)
+	}
+	if len(lineage.Descendants) != 1 || lineage.Descendants[0].ID != fork.ID || *lineage.Descendants[0].ForkCount != 1 {
+		t.Errorf("expected the first fork with one fork of its own, got %+v", lineage.Descendants)
+	}
+
+	// Deleting an ancestor ends the walk there.
+	if err := s.DeleteRecipe(ctx, fork.ID); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	lineage, err = s.RecipeLineage(ctx, grandchild.ID, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("RecipeLineage: %v", err)
+	}
+	if len(lineage.Ancestors) != 0 {
+		t.Errorf("expected the walk to stop at the deleted fork, got %+v", lineage.Ancestors)
+	}
+	if lineage, err := s.RecipeLineage(ctx, "missing", model.PageRequest{}); err != nil || lineage != nil {
+		t.Errorf("missing recipe: expected nil, got %+v %v", lineage, err)
+	}
+}
+
+func TestRecipePublishing(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Publisher", Email: "publishing@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, chef.ID) })
+	create := func(title string, publishAt *time.Time) *model.Recipe {
+		t.Helper()
+		r, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+			ChefID: chef.ID, Title: title, Ingredients: "flour", Instructions: "bake", PublishAt: publishAt,
+		})
+		if err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+		return r
+	}
+
+	recipe := create("Brioche", nil)
+	if recipe.Status != model.StatusDraft || recipe.PublishedAt != nil {
+		t.Fatalf("expected an unpublished draft, got %+v", recipe)
+	}
+	if _, err := s.TransitionRecipe(ctx, recipe.ID, model.TransitionUnpublish, nil, nil); !errors.Is(err, store.ErrInvalidTransition) {
+		t.Errorf("unpublishing a draft: expected ErrInvalidTransition, got %v", err)
+	}
+	if _, err := s.TransitionRecipe(ctx, recipe.ID, model.TransitionPublish, nil, ptr(recipe.Version+1)); !errors.Is(err, store.ErrPreconditionFailed) {
+		t.Errorf("stale If-Match: expected ErrPreconditionFailed, got %v", err)
+	}
+
+	published, err := s.TransitionRecipe(ctx, recipe.ID, model.TransitionPublish, nil, ptr(recipe.Version))
+	if err != nil {
+		t.Fatalf("TransitionRecipe publish: %v", err)
+	}
+	if published.Status != model.StatusPublished || published.PublishedAt == nil || published.Version != recipe.Version+1 {
+		t.Errorf("expected a published recipe with published_at, got %+v", published)
+	}
+	if _, err := s.TransitionRecipe(ctx, recipe.ID, model.TransitionPublish, nil, nil); !errors.Is(err, store.ErrInvalidTransition) {
+		t.Errorf("publishing twice: expected ErrInvalidTransition, got %v", err)
+	}
+
+	archived, err := s.TransitionRecipe(ctx, recipe.ID, model.TransitionArchive, nil, nil)
+	if err != nil {
+		t.Fatalf("TransitionRecipe archive: %v", err)
+	}
+	if archived.Status != model.StatusArchived || archived.PublishedAt == nil {
+		t.Errorf("expected an archived recipe keeping published_at, got %+v", archived)
+	}
+	// An archived recipe cannot go back to draft, by transition or update.
+	if _, err := s.TransitionRecipe(ctx, recipe.ID, model.TransitionUnpublish, nil, nil); !errors.Is(err, store.ErrInvalidTransition) {
+		t.Errorf("unpublishing an archived recipe: expected ErrInvalidTransition, got %v", err)
+	}
+	if _, err := s.UpdateRecipe(ctx, recipe.ID, model.UpdateRecipeInput{Status: ptr(model.StatusDraft)}); !errors.Is(err, store.ErrInvalidTransition) {
+		t.Errorf("updating an archived recipe to draft: expected ErrInvalidTransition, got %v", err)
+	}
+	if got, err := s.GetRecipe(ctx, recipe.ID); err != nil || got.Status != model.StatusArchived || got.PublishedAt == nil {
+		t.Errorf("expected the recipe to stay archived, got %+v %v", got, err)
+	}
+
+	republished, err := s.UpdateRecipe(ctx, recipe.ID, model.UpdateRecipeInput{Status: ptr(model.StatusPublished)})
+	if err != nil {
+		t.Fatalf("UpdateRecipe: %v", err)
+	}
+	if !republished.PublishedAt.After(*published.PublishedAt) {
+		t.Errorf("expected publishing again to move published_at past %v, got %v", published.PublishedAt, republished.PublishedAt)
+	}
+	unpublished, err := s.TransitionRecipe(ctx, recipe.ID, model.TransitionUnpublish, nil, nil)
+	if err != nil {
+		t.Fatalf("TransitionRecipe unpublish: %v", err)
+	}
+	if unpublished.Status != model.StatusDraft || unpublished.PublishedAt != nil {
+		t.Errorf("expected a draft without published_at, got %+v", unpublished)
+	}
+
+	// Scheduled drafts are published by the sweep once due, exactly once.
+	now := time.Now().UTC()
+	soon := create("Scheduled Loaf", ptr(now.Add(time.Hour)))
+	later := create("Later Loaf", ptr(now.Add(3*time.Hour)))
+	cancelled := create("Cancelled Loaf", nil)
+	if soon.Status != model.StatusDraft || soon.PublishAt == nil {
+		t.Fatalf("expected a scheduled draft, got %+v", soon)
+	}
+	scheduled, err := s.TransitionRecipe(ctx, cancelled.ID, model.TransitionPublish, ptr(now.Add(time.Hour)), nil)
+	if err != nil {
+		t.Fatalf("TransitionRecipe schedule: %v", err)
+	}
+	if scheduled.Status != model.StatusDraft || scheduled.PublishAt == nil {
+		t.Errorf("expected publishing with a future publish_at to schedule, got %+v", scheduled)
+	}
+	if unscheduled, err := s.TransitionRecipe(ctx, cancelled.ID, model.TransitionUnpublish, nil, nil); err != nil || unscheduled.PublishAt != nil {
+		t.Errorf("expected unpublishing to cancel the schedule, got %+v %v", unscheduled, err)
+	}
+
+	if _, err := s.PublishDue(ctx, now); err != nil {
+		t.Fatalf("PublishDue: %v", err)
+	}
+	if got, _ := s.GetRecipe(ctx, soon.ID); got.Status != model.StatusDraft {
+		t.Errorf("expected a recipe that is not due to stay a draft, got %s", got.Status)
+	}
+	n, err := s.PublishDue(ctx, now.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("PublishDue: %v", err)
+	}
+	if n < 1 {
+		t.Errorf("expected the due recipe to be published, published %d", n)
+	}
+	got, err := s.GetRecipe(ctx, soon.ID)
+	if err != nil {
+		t.Fatalf("GetRecipe: %v", err)
+	}
+	if got.Status != model.StatusPublished || got.PublishedAt == nil || got.PublishAt != nil || got.Version != soon.Version+1 {
+		t.Errorf("expected the sweep to publish the recipe, got %+v", got)
+	}
+	if _, err := s.PublishDue(ctx, now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("PublishDue: %v", err)
+	}
+	if again, _ := s.GetRecipe(ctx, soon.ID); again.Version != got.Version {
+		t.Errorf("expected a second sweep to leave the recipe alone, got version %d", again.Version)
+	}
+	for _, id := range []string{later.ID, cancelled.ID} {
+		if r, _ := s.GetRecipe(ctx, id); r.Status != model.StatusDraft {
+			t.Errorf("expected recipe %s to stay a draft, got %s", id, r.Status)
+		}
+	}
+
+	events, _, err := s.ListAuditEvents(ctx, soon.ID, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("ListAuditEvents: %v", err)
+	}
+	if len(events) != 2 || events[0].Action != model.ActionUpdate {
+		t.Errorf("expected the sweep to record an update, got %+v", events)
+	}
+}
+
+func TestRecipeRevisions(t *testing.T) {
+	s, ctx := setupStore(t)
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Reviser", Email: "revisions@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	t.Cleanup(func() { s.DeleteChef(ctx, chef.ID) })
+	recipe, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+		ChefID: chef.ID, Title: "Pancakes", Ingredients: "1 cup flour\n1 egg", Instructions: "Mix.\nFry.", Servings: 2,
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	revisions, _, err := s.ListRecipeRevisions(ctx, recipe.ID, model.PageRequest{})
+	if err != nil {
+		t.Fatalf("ListRecipeRevisions: %v", err)
+	}
+	if len(revisions) != 0 {
+		t.Errorf("expected a new recipe to have no revisions, got %+v", revisions)
+	}
+
+	// Every update snapshots the version it replaces, including a rejected
+	// If-Match leaving none behind.
+	if _, err := s.UpdateRecipe(ctx, recipe.ID, model.UpdateRecipeInput{Title: ptr("Stale"), IfVersion: ptr(int64(9))}); !errors.Is(err, store.ErrPreconditionFailed) {
+		t.Fatalf("stale If-Match: expected ErrPreconditionFailed, got %v", err)
+	}
+	v2, err := s.UpdateRecipe(ctx, recipe.ID, model.UpdateRecipeInput{Title: ptr("Buttermilk Pancakes"), Ingredients: ptr("1 cup flour\n1 cup buttermilk\n1 egg")})
+	if err != nil {
+		t.Fatalf("UpdateRecipe: %v", err)
+	}
+	if _, err := s.UpdateRecipe(ctx, recipe.ID, model.UpdateRecipeInput{Servings: ptr(4)}); err != nil {
+		t.Fatalf("UpdateRecipe: %v", err)
+	}
+	if _, err := s.TransitionRecipe(ctx, recipe.ID, model.TransitionPublish, nil, nil); err != nil {
+		t.Fatalf("TransitionRecipe: %v", err)
+	}
+
+	revisions, next, err := s.ListRecipeRevisions(ctx, recipe.ID, model.PageRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListRecipeRevisions: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Revision != 3 || revisions[1].Revision != 2 || next == "" {
+		t.Fatalf("expected revisions 3 and 2 and a next cursor, got %+v %q", revisions, next)
+	}
+	if revisions[0].Recipe.Servings != 4 || revisions[0].Recipe.Status != model.StatusDraft {
+		t.Errorf("expected revision 3 to hold the draft with 4 servings, got %+v", revisions[0].Recipe)
+	}
+	cursor, err := model.DecodeCursor(next)
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	rest, next, err := s.ListRecipeRevisions(ctx, recipe.ID, model.PageRequest{Limit: 2, Cursor: cursor})
+	if err != nil {
+		t.Fatalf("ListRecipeRevisions: %v", err)
+	}
+	if len(rest) != 1 || rest[0].Revision != 1 || next != "" {
+		t.Errorf("expected only revision 1 on the last page, got %+v %q", rest, next)
+	}
+
+	first, err := s.GetRecipeRevision(ctx, recipe.ID, 1)
+	if err != nil {
+		t.Fatalf("GetRecipeRevision: %v", err)
+	}
+	if first == nil || first.Recipe.Title != "Pancakes" || first.Recipe.Ingredients != "1 cup flour\n1 egg" || first.Recipe.Version != 1 {
+		t.Fatalf("expected revision 1 to hold the original recipe, got %+v", first)
+	}
+	if len(first.Recipe.IngredientList) != 2 {
+		t.Errorf("expected revision 1 to keep its ingredient lines, got %+v", first.Recipe.IngredientList)
+	}
+	if !first.CreatedAt.Equal(v2.UpdatedAt) {
+		t.Errorf("expected revision 1 to be replaced at %v, got %v", v2.UpdatedAt, first.CreatedAt)
+	}
+	for _, n := range []int64{4, 99} {
+		if rev, err := s.GetRecipeRevision(ctx, recipe.ID, n); err != nil || rev != nil {
+			t.Errorf("revision %d: expected nil, got %+v %v", n, rev, err)
+		}
+	}
+
+	// Deleting leaves the history; purging removes it with the recipe.
+	if err := s.DeleteRecipe(ctx, recipe.ID); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	if rev, err := s.GetRecipeRevision(ctx, recipe.ID, 1); err != nil || rev == nil {
+		t.Errorf("expected revisions to outlive a soft delete, got %+v %v", rev, err)
+	}
+	purged, err := s.PurgeDeleted(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	if purged.Revisions < 3 {
+		t.Errorf("expected the recipe's 3 revisions to be purged, got %d", purged.Revisions)
+	}
+	if rev, err := s.GetRecipeRevision(ctx, recipe.ID, 1); err != nil || rev != nil {
+		t.Errorf("expected no revisions after the purge, got %+v %v", rev, err)
 	}
 }
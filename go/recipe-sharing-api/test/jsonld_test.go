@@ -0,0 +1,243 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/schemaorg"
+)
+
+func TestISODurations(t *testing.T) {
+	for minutes, want := range map[int]string{0: "PT0M", 45: "PT45M", 60: "PT1H", 90: "PT1H30M", 1500: "PT25H"} {
+		if got := schemaorg.FormatDuration(minutes); got != want {
+			t.Errorf("FormatDuration(%d) = %q, want %q", minutes, got, want)
+		}
+	}
+	for in, want := range map[string]int{"PT45M": 45, "PT1H30M": 90, "pt2h": 120, "P1DT2H": 1560, "PT90S": 2, "PT0.5H": 30} {
+		got, err := schemaorg.ParseDuration(in)
+		if err != nil || got != want {
+			t.Errorf("ParseDuration(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "45 minutes", "P", "PT", "P1M", "PTxM", "PT5X"} {
+		if _, err := schemaorg.ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q): expected an error", in)
+		}
+	}
+}
+
+func TestFromRecipe(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := model.Recipe{
+		ID:            "r1",
+		Title:         "Pancakes",
+		Ingredients:   "2 cups flour\n\n1 egg",
+		Instructions:  "Whisk.\n  Fry.  \n",
+		PrepTime:      10,
+		CookTime:      80,
+		Servings:      4,
+		Cuisine:       "American",
+		Tags:          []string{"breakfast", "sweet"},
+		CreatedAt:     created,
+		UpdatedAt:     created,
+		RatingSummary: &model.RatingSummary{AverageScore: 4.5, RatingCount: 2},
+	}
+	got := schemaorg.FromRecipe(r, "Ada")
+
+	if got.Context != "https://schema.org" || got.Type != "Recipe" || got.Name != "Pancakes" {
+		t.Errorf("unexpected header fields: %+v", got)
+	}
+	if got.PrepTime != "PT10M" || got.CookTime != "PT1H20M" || got.TotalTime != "PT1H30M" {
+		t.Errorf("unexpected durations %q %q %q", got.PrepTime, got.CookTime, got.TotalTime)
+	}
+	if got.RecipeYield != "4 servings" || got.Keywords != "breakfast,sweet" {
+		t.Errorf("unexpected yield %q or keywords %q", got.RecipeYield, got.Keywords)
+	}
+	if len(got.RecipeIngredient) != 2 || got.RecipeIngredient[1] != "1 egg" {
+		t.Errorf("unexpected ingredients %q", got.RecipeIngredient)
+	}
+	if len(got.RecipeInstructions) != 2 || got.RecipeInstructions[1].Text != "Fry." {
+		t.Errorf("unexpected instructions %+v", got.RecipeInstructions)
+	}
+	if got.Author == nil || got.Author.Name != "Ada" {
+		t.Errorf("expected author Ada, got %+v", got.Author)
+	}
+	if got.AggregateRating == nil || got.AggregateRating.RatingValue != 4.5 || got.AggregateRating.BestRating != model.MaxScore {
+		t.Errorf("unexpected rating %+v", got.AggregateRating)
+	}
+
+	// Structured ingredients are rendered line by line, and a recipe
+	// without times, servings, or ratings omits them.
+	r = model.Recipe{Title: "Salad", IngredientList: []model.Ingredient{{Name: "lettuce", Quantity: 1.5, Unit: "cup"}}}
+	got = schemaorg.FromRecipe(r, "")
+	if len(got.RecipeIngredient) != 1 || got.RecipeIngredient[0] != "1 1/2 cup lettuce" {
+		t.Errorf("unexpected ingredients %q", got.RecipeIngredient)
+	}
+	if got.PrepTime != "" || got.TotalTime != "" || got.RecipeYield != "" || got.Author != nil || got.AggregateRating != nil {
+		t.Errorf("expected optional fields to be omitted: %+v", got)
+	}
+}
+
+const recipePage = `<!doctype html>
+<html><head>
+<title>Lemon Tart</title>
+<script type="application/ld+json">{"@context":"https://schema.org","@type":"WebSite","name":"Tarts"}</script>
+<script type="application/ld+json">{broken</script>
+<script type="application/ld+json; charset=utf-8">
+{"@context":"https://schema.org","@graph":[
+  {"@type":"BreadcrumbList","itemListElement":[]},
+  {"@type":["Recipe","NewsArticle"],
+   "name":"Lemon &amp; Almond Tart",
+   "description":"A <b>bright</b> tart.",
+   "prepTime":"PT30M","cookTime":"PT45M",
+   "recipeYield":["8","8 slices"],
+   "recipeCuisine":["French"],
+   "recipeIngredient":["3 lemons","200 g almonds"],
+   "recipeInstructions":[
+     {"@type":"HowToSection","name":"Crust","itemListElement":[{"@type":"HowToStep","text":"Blind bake the crust."}]},
+     {"@type":"HowToStep","text":"Fill and bake."}
+   ]}
+]}
+</script>
+</head><body><h1>Lemon Tart</h1></body></html>`
+
+func TestExtractRecipe(t *testing.T) {
+	input, err := schemaorg.Extract([]byte(recipePage))
+	if err != nil {
+		t.Fatalf("Extract HTML: %v", err)
+	}
+	if input.Title != "Lemon & Almond Tart" || input.Description != "A bright tart." {
+		t.Errorf("unexpected title %q or description %q", input.Title, input.Description)
+	}
+	if input.PrepTime != 30 || input.CookTime != 45 || input.Servings != 8 || input.Cuisine != "French" {
+		t.Errorf("unexpected times, servings, or cuisine: %+v", input)
+	}
+	if input.Ingredients != "3 lemons\n200 g almonds" {
+		t.Errorf("unexpected ingredients %q", input.Ingredients)
+	}
+	if input.Instructions != "Blind bake the crust.\nFill and bake." {
+		t.Errorf("unexpected instructions %q", input.Instructions)
+	}
+	if input.Status != model.StatusDraft {
+		t.Errorf("expected a draft, got %q", input.Status)
+	}
+
+	// A bare JSON-LD blob with text instructions, a numeric yield, and
+	// only a total time.
+	blob := `{"@context":"https://schema.org","@type":"Recipe","name":"Toast","recipeYield":2,
+		"totalTime":"PT5M","recipeIngredient":["bread"],"recipeInstructions":"Slice.\nToast."}`
+	input, err = schemaorg.Extract([]byte(blob))
+	if err != nil {
+		t.Fatalf("Extract JSON-LD: %v", err)
+	}
+	if input.Servings != 2 || input.CookTime != 5 || input.Instructions != "Slice.\nToast." {
+		t.Errorf("unexpected input %+v", input)
+	}
+
+	if _, err := schemaorg.Extract([]byte(`<html><body>no data</body></html>`)); !errors.Is(err, schemaorg.ErrNoRecipe) {
+		t.Errorf("page without JSON-LD: expected ErrNoRecipe, got %v", err)
+	}
+	if _, err := schemaorg.Extract([]byte(`{"@type":"Recipe","name":"x","prepTime":"soon"}`)); err == nil {
+		t.Error("invalid prepTime: expected an error")
+	}
+}
+
+func TestRouterRecipeJSONLD(t *testing.T) {
+	h := setupRouter(t)
+
+	var chef chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Linked Chef", Email: "linked@example.com"}, &chef)
+	var recipe recipeEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID: chef.Data.ID, Title: "Risotto", Ingredients: "1 cup rice\n4 cups stock",
+		Instructions: "Toast rice.\nAdd stock slowly.", PrepTime: 5, CookTime: 25, Servings: 2,
+	}, &recipe)
+
+	path := "/api/v1/recipes/" + recipe.Data.ID
+	req := httptest.NewRequest(http.MethodGet, path+"?servings=4", nil)
+	req.Header.Set("Accept", "application/ld+json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), schemaorg.MediaType) {
+		t.Fatalf("GET %s as JSON-LD: got %d %q", path, rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if rec.Header().Get("Vary") != "Accept" {
+		t.Errorf("expected Vary: Accept, got %q", rec.Header().Get("Vary"))
+	}
+	var ld schemaorg.Recipe
+	if err := json.Unmarshal(rec.Body.Bytes(), &ld); err != nil {
+		t.Fatalf("decode JSON-LD: %v", err)
+	}
+	if ld.Type != "Recipe" || ld.Name != "Risotto" || ld.Author == nil || ld.Author.Name != "Linked Chef" {
+		t.Errorf("unexpected JSON-LD %+v", ld)
+	}
+	if ld.TotalTime != "PT30M" || ld.RecipeYield != "4 servings" || len(ld.RecipeInstructions) != 2 {
+		t.Errorf("unexpected JSON-LD details %+v", ld)
+	}
+	if len(ld.RecipeIngredient) != 2 || ld.RecipeIngredient[0] != "2 cup rice" {
+		t.Errorf("expected scaled ingredients, got %q", ld.RecipeIngredient)
+	}
+
+	// Plain JSON remains the default.
+	var plain recipeEnvelope
+	if code := doJSON(t, h, http.MethodGet, path, nil, &plain); code != http.StatusOK || plain.Data.ID != recipe.Data.ID {
+		t.Errorf("GET %s: expected the JSON envelope, got %d", path, code)
+	}
+
+	// Import the page as a draft.
+	importPath := "/api/v1/recipes/import?chef_id=" + chef.Data.ID
+	rec = doRaw(h, http.MethodPost, importPath, "text/html; charset=utf-8", recipePage)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST %s: expected 201, got %d: %s", importPath, rec.Code, rec.Body)
+	}
+	var imported recipeEnvelope
+	if err := json.Unmarshal(rec.Body.Bytes(), &imported); err != nil {
+		t.Fatalf("decode import response: %v", err)
+	}
+	if imported.Data.Title != "Lemon & Almond Tart" || imported.Data.Status != model.StatusDraft || imported.Data.ChefID != chef.Data.ID {
+		t.Errorf("unexpected imported recipe %+v", imported.Data)
+	}
+	if len(imported.Data.IngredientList) != 2 {
+		t.Errorf("expected imported ingredients to be parsed, got %+v", imported.Data.IngredientList)
+	}
+
+	// Round trip a recipe's own JSON-LD.
+	rec = doRaw(h, http.MethodPost, importPath, schemaorg.MediaType, mustJSON(t, ld))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("importing emitted JSON-LD: expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+
+	for _, tc := range []struct {
+		name, path, contentType, body string
+		want                          int
+	}{
+		{"unsupported type", importPath, "text/plain", recipePage, http.StatusUnsupportedMediaType},
+		{"missing chef_id", "/api/v1/recipes/import", "text/html", recipePage, http.StatusBadRequest},
+		{"unknown chef", "/api/v1/recipes/import?chef_id=missing", "text/html", recipePage, http.StatusBadRequest},
+		{"no recipe", importPath, "text/html", "<html></html>", http.StatusBadRequest},
+		{"invalid JSON", importPath, "application/ld+json", "{", http.StatusBadRequest},
+		{"no instructions", importPath, "application/json", `{"@type":"Recipe","name":"x","recipeIngredient":["y"]}`, http.StatusBadRequest},
+	} {
+		if rec := doRaw(h, http.MethodPost, tc.path, tc.contentType, tc.body); rec.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", tc.name, tc.want, rec.Code, rec.Body)
+		}
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(b)
+}
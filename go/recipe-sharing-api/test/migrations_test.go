@@ -0,0 +1,65 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/migrations"
+)
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	migs, err := migrations.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(migs) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	for i, m := range migs {
+		if m.Version != i+1 {
+			t.Errorf("migration %d_%s: expected version %d, versions must be contiguous", m.Version, m.Name, i+1)
+		}
+		for _, stmt := range m.Statements {
+			if strings.Contains(stmt, ";") {
+				t.Errorf("migration %d_%s: statement contains a semicolon: %q", m.Version, m.Name, stmt)
+			}
+			// A statement may be re-run after an interruption.
+			if strings.Contains(stmt, "ADD COLUMN") && !strings.Contains(stmt, "ADD COLUMN IF NOT EXISTS") {
+				t.Errorf("migration %d_%s: ADD COLUMN without IF NOT EXISTS: %q", m.Version, m.Name, stmt)
+			}
+		}
+	}
+}
+
+func TestLoadMigrationsFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_second.sql": {Data: []byte("-- comment\nCREATE INDEX ASYNC a ON t(x);\n")},
+		"0001_first.sql":  {Data: []byte("CREATE TABLE t (\n  x INTEGER\n);\n\n-- trailing\nALTER TABLE t ADD COLUMN y TEXT;\n")},
+	}
+	migs, err := migrations.LoadFS(fsys)
+	if err != nil {
+		t.Fatalf("LoadFS: %v", err)
+	}
+	if len(migs) != 2 || migs[0].Name != "first" || migs[1].Version != 2 {
+		t.Fatalf("unexpected migrations: %+v", migs)
+	}
+	want := []string{"CREATE TABLE t (\n  x INTEGER\n)", "ALTER TABLE t ADD COLUMN y TEXT"}
+	if !slices.Equal(migs[0].Statements, want) {
+		t.Errorf("expected statements %q, got %q", want, migs[0].Statements)
+	}
+
+	for name, bad := range map[string]fstest.MapFS{
+		"bad name":  {"first.sql": {Data: []byte("SELECT 1;")}},
+		"duplicate": {"0001_a.sql": {Data: []byte("SELECT 1;")}, "01_b.sql": {Data: []byte("SELECT 1;")}},
+		"empty":     {"0001_empty.sql": {Data: []byte("-- nothing\n")}},
+	} {
+		if _, err := migrations.LoadFS(bad); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
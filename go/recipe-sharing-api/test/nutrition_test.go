@@ -0,0 +1,127 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package test
+
+import (
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/nutrition"
+)
+
+func TestMatchFood(t *testing.T) {
+	for name, want := range map[string]string{
+		"Flour":                     "all-purpose flour",
+		"all-purpose flour":         "all-purpose flour",
+		"finely chopped red onions": "onion",
+		"peanut butter":             "peanut butter",
+		"unsalted butter, softened": "butter",
+		"large eggs":                "egg",
+		"egg whites":                "egg white",
+		"low-sodium chicken stock":  "chicken stock",
+		"boneless chicken breasts":  "chicken breast",
+		"freshly ground pepper":     "black pepper",
+		"red bell pepper":           "bell pepper",
+		"tomatoes":                  "tomato",
+		"parmesean":                 "parmesan cheese",
+		"brocoli":                   "broccoli",
+		"sweet potatoes, cubed":     "sweet potato",
+	} {
+		food, ok := nutrition.Match(name)
+		if !ok || food.Name != want {
+			got := "<none>"
+			if ok {
+				got = food.Name
+			}
+			t.Errorf("Match(%q) = %s, want %s", name, got, want)
+		}
+	}
+	for _, name := range []string{"", "dragon fruit", "unobtainium"} {
+		if food, ok := nutrition.Match(name); ok {
+			t.Errorf("Match(%q): expected no match, got %s", name, food.Name)
+		}
+	}
+}
+
+func TestEstimateNutrition(t *testing.T) {
+	got := nutrition.Estimate([]model.Ingredient{
+		{Position: 1, Name: "butter", Quantity: 100, Unit: "g"},
+		{Position: 2, Name: "milk", Quantity: 1, Unit: "cup"},
+		{Position: 3, Name: "eggs", Quantity: 2},
+		{Position: 4, Name: "salt"},
+		{Position: 5, Name: "dragon fruit", Quantity: 1},
+		{Position: 6, Name: "spinach", Quantity: 2},
+	}, 2)
+
+	if len(got.Lines) != 3 {
+		t.Fatalf("expected 3 estimated lines, got %+v", got.Lines)
+	}
+	if l := got.Lines[0]; l.Food != "butter" || l.Grams != 100 || l.Calories != 717 || l.Fat != 81.1 {
+		t.Errorf("unexpected butter line %+v", l)
+	}
+	// 1 cup of milk is 236.588 ml at 1.03 g/ml.
+	if l := got.Lines[1]; l.Grams != 243.7 || l.Calories != 149 {
+		t.Errorf("unexpected milk line %+v", l)
+	}
+	if l := got.Lines[2]; l.Grams != 100 || l.Protein != 12.6 {
+		t.Errorf("unexpected egg line %+v", l)
+	}
+
+	wantTotal := 717 + 236.588*1.03*0.61 + 143
+	if math.Abs(got.Total.Calories-math.Round(wantTotal)) > 0 {
+		t.Errorf("expected %v total calories, got %v", math.Round(wantTotal), got.Total.Calories)
+	}
+	if got.Servings != 2 || got.PerServing == nil || got.PerServing.Calories != math.Round(wantTotal/2) {
+		t.Errorf("unexpected per-serving estimate %+v", got.PerServing)
+	}
+
+	if len(got.Unmatched) != 3 {
+		t.Fatalf("expected 3 unmatched lines, got %+v", got.Unmatched)
+	}
+	for i, want := range []int{4, 5, 6} {
+		if u := got.Unmatched[i]; u.Position != want || u.Reason == "" || u.Ingredient == "" {
+			t.Errorf("unexpected unmatched line %+v", u)
+		}
+	}
+
+	if got := nutrition.Estimate(nil, 0); got.PerServing != nil || got.Lines == nil || got.Unmatched == nil {
+		t.Errorf("empty estimate: unexpected %+v", got)
+	}
+}
+
+func TestRouterNutrition(t *testing.T) {
+	h := setupRouter(t)
+
+	var chef chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Nutri Chef", Email: "nutri@example.com"}, &chef)
+	var recipe recipeEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID: chef.Data.ID, Title: "Omelette", Servings: 1, Instructions: "whisk and fry",
+		Ingredients: "3 eggs\n1 tbsp butter\nsalt to taste\n2 oz moon cheese",
+	}, &recipe)
+
+	var resp struct {
+		Data model.Nutrition `json:"data"`
+	}
+	path := "/api/v1/recipes/" + recipe.Data.ID + "/nutrition"
+	if code := doJSON(t, h, http.MethodGet, path, nil, &resp); code != http.StatusOK {
+		t.Fatalf("GET %s: expected 200, got %d", path, code)
+	}
+	if resp.Data.RecipeID != recipe.Data.ID || len(resp.Data.Lines) != 2 || resp.Data.Total.Calories == 0 {
+		t.Errorf("unexpected estimate %+v", resp.Data)
+	}
+	if resp.Data.PerServing == nil || *resp.Data.PerServing != resp.Data.Total {
+		t.Errorf("expected one serving to equal the total, got %+v", resp.Data.PerServing)
+	}
+	if u := resp.Data.Unmatched; len(u) != 2 || u[0].Position != 3 || u[1].Position != 4 {
+		t.Errorf("expected salt and moon cheese to be unmatched, got %+v", u)
+	}
+
+	var errResp errorEnvelope
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes/missing/nutrition", nil, &errResp); code != http.StatusNotFound {
+		t.Errorf("missing recipe: expected 404, got %d", code)
+	}
+}
@@ -0,0 +1,1062 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/router"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+func setupRouter(t *testing.T) http.Handler {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	s := store.NewMemoryStore()
+	blobs, err := store.NewFSBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFSBlobStore: %v", err)
+	}
+	s.SetBlobStore(blobs)
+	return router.New(s, blobs)
+}
+
+// doJSON sends a request to the router and decodes the JSON response body into out.
+func doJSON(t *testing.T, h http.Handler, method, path string, body any, out any) int {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: decode response %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+type chefEnvelope struct {
+	Data model.Chef `json:"data"`
+}
+
+type recipeEnvelope struct {
+	Data model.RecipeWithRatings `json:"data"`
+}
+
+type errorEnvelope = model.ErrorResponse
+
+func TestRouterHealth(t *testing.T) {
+	h := setupRouter(t)
+	var body map[string]string
+	if code := doJSON(t, h, http.MethodGet, "/health", nil, &body); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if body["status"] != "ok" {
+		t.Errorf("expected status ok, got %q", body["status"])
+	}
+}
+
+func TestRouterRecipeLifecycle(t *testing.T) {
+	h := setupRouter(t)
+
+	var chef chefEnvelope
+	code := doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{
+		Name:  "Router Chef",
+		Email: "router-chef@example.com",
+	}, &chef)
+	if code != http.StatusCreated {
+		t.Fatalf("create chef: expected 201, got %d", code)
+	}
+
+	// A recipe referencing a missing chef is rejected.
+	var errResp errorEnvelope
+	code = doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID:       "missing",
+		Title:        "Orphan",
+		Ingredients:  "nothing",
+		Instructions: "nothing",
+	}, &errResp)
+	if code != http.StatusBadRequest || errResp.Error.Code != "VALIDATION_ERROR" {
+		t.Fatalf("create orphan recipe: expected 400 VALIDATION_ERROR, got %d %q", code, errResp.Error.Code)
+	}
+
+	var recipe recipeEnvelope
+	code = doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID:       chef.Data.ID,
+		Title:        "Router Soup",
+		Ingredients:  "water, salt",
+		Instructions: "boil",
+	}, &recipe)
+	if code != http.StatusCreated {
+		t.Fatalf("create recipe: expected 201, got %d", code)
+	}
+	if recipe.Data.Difficulty != "medium" || recipe.Data.Status != "draft" {
+		t.Errorf("expected default difficulty/status, got %q/%q", recipe.Data.Difficulty, recipe.Data.Status)
+	}
+
+	// Structured ingredient lines are validated on update.
+	code = doJSON(t, h, http.MethodPut, "/api/v1/recipes/"+recipe.Data.ID, map[string]any{
+		"ingredient_list": []map[string]any{{"quantity": 1, "unit": "cup"}},
+	}, &errResp)
+	if code != http.StatusBadRequest {
+		t.Fatalf("update with unnamed ingredient: expected 400, got %d", code)
+	}
+
+	// Free text must parse into lines the ingredient table can hold.
+	tooMany := strings.Repeat("salt\n", model.MaxIngredients+1)
+	code = doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID: chef.Data.ID, Title: "Long List", Ingredients: tooMany, Instructions: "mix",
+	}, &errResp)
+	if code != http.StatusBadRequest {
+		t.Errorf("create with %d parsed lines: expected 400, got %d", model.MaxIngredients+1, code)
+	}
+	code = doJSON(t, h, http.MethodPut, "/api/v1/recipes/"+recipe.Data.ID, map[string]any{
+		"ingredients": tooMany,
+	}, &errResp)
+	if code != http.StatusBadRequest {
+		t.Errorf("update with %d parsed lines: expected 400, got %d", model.MaxIngredients+1, code)
+	}
+	longName := "1 cup " + strings.Repeat("x", model.MaxIngredientName+1)
+	code = doJSON(t, h, http.MethodPut, "/api/v1/recipes/"+recipe.Data.ID, map[string]any{
+		"ingredients": longName,
+	}, &errResp)
+	if code != http.StatusBadRequest {
+		t.Errorf("update with a long ingredient name: expected 400, got %d", code)
+	}
+	code = doJSON(t, h, http.MethodPut, "/api/v1/recipes/"+recipe.Data.ID, map[string]any{
+		"ingredients": strings.Repeat("salt\n", model.MaxIngredients),
+	}, nil)
+	if code != http.StatusOK {
+		t.Errorf("update with %d parsed lines: expected 200, got %d", model.MaxIngredients, code)
+	}
+
+	var critic chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Router Critic", Email: "router-critic@example.com"}, &critic)
+	code = doJSON(t, h, http.MethodPost, "/api/v1/recipes/"+recipe.Data.ID+"/ratings", model.CreateRatingInput{
+		ChefID: critic.Data.ID,
+		Score:  4,
+	}, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("create rating: expected 201, got %d", code)
+	}
+
+	var got recipeEnvelope
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes/"+recipe.Data.ID, nil, &got); code != http.StatusOK {
+		t.Fatalf("get recipe: expected 200, got %d", code)
+	}
+	if got.Data.RatingCount != 1 || got.Data.AverageScore != 4 {
+		t.Errorf("expected 1 rating averaging 4, got %d averaging %f", got.Data.RatingCount, got.Data.AverageScore)
+	}
+
+	if code := doJSON(t, h, http.MethodDelete, "/api/v1/recipes/"+recipe.Data.ID, nil, nil); code != http.StatusOK {
+		t.Fatalf("delete recipe: expected 200, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes/"+recipe.Data.ID, nil, &errResp); code != http.StatusNotFound {
+		t.Fatalf("get deleted recipe: expected 404, got %d", code)
+	}
+}
+
+func TestMemoryStoreConcurrentWrites(t *testing.T) {
+	s := store.NewMemoryStore()
+	ctx := t.Context()
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Busy Chef", Email: "busy@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			if _, err := s.CreateRecipe(ctx, model.CreateRecipeInput{
+				ChefID:       chef.ID,
+				Title:        "Concurrent",
+				Ingredients:  "x",
+				Instructions: "y",
+				Servings:     i,
+			}); err != nil {
+				t.Errorf("CreateRecipe: %v", err)
+			}
+			if _, _, err := s.ListRecipes(ctx, model.RecipeFilter{}, model.PageRequest{}); err != nil {
+				t.Errorf("ListRecipes: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	recipes, _, err := s.ListRecipes(ctx, model.RecipeFilter{}, model.PageRequest{Limit: model.MaxPageLimit})
+	if err != nil {
+		t.Fatalf("ListRecipes: %v", err)
+	}
+	if len(recipes) != 50 {
+		t.Fatalf("expected 50 recipes, got %d", len(recipes))
+	}
+	for i := 1; i < len(recipes); i++ {
+		if recipes[i].CreatedAt.After(recipes[i-1].CreatedAt) {
+			t.Fatal("recipes are not ordered by created_at DESC")
+		}
+	}
+}
+
+func TestRouterChefPagination(t *testing.T) {
+	h := setupRouter(t)
+
+	created := map[string]bool{}
+	for i := range 5 {
+		var chef chefEnvelope
+		code := doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{
+			Name:  "Paged Chef",
+			Email: fmt.Sprintf("paged-%d@example.com", i),
+		}, &chef)
+		if code != http.StatusCreated {
+			t.Fatalf("create chef: expected 201, got %d", code)
+		}
+		created[chef.Data.ID] = true
+	}
+
+	seen := map[string]bool{}
+	path := "/api/v1/chefs?limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		var resp struct {
+			Data       []model.Chef `json:"data"`
+			NextCursor string       `json:"next_cursor"`
+		}
+		if code := doJSON(t, h, http.MethodGet, path, nil, &resp); code != http.StatusOK {
+			t.Fatalf("list chefs: expected 200, got %d", code)
+		}
+		for _, c := range resp.Data {
+			if seen[c.ID] {
+				t.Fatalf("chef %s returned on more than one page", c.ID)
+			}
+			seen[c.ID] = true
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		path = "/api/v1/chefs?limit=2&cursor=" + resp.NextCursor
+	}
+	if len(seen) != len(created) {
+		t.Errorf("expected %d chefs across pages, got %d", len(created), len(seen))
+	}
+
+	for _, bad := range []string{"?cursor=not-a-cursor", "?limit=0", "?limit=abc"} {
+		var errResp errorEnvelope
+		code := doJSON(t, h, http.MethodGet, "/api/v1/chefs"+bad, nil, &errResp)
+		if code != http.StatusBadRequest || errResp.Error.Code != "VALIDATION_ERROR" {
+			t.Errorf("GET /api/v1/chefs%s: expected 400 VALIDATION_ERROR, got %d %q", bad, code, errResp.Error.Code)
+		}
+	}
+}
+
+func TestRouterIfMatch(t *testing.T) {
+	h := setupRouter(t)
+
+	var chef chefEnvelope
+	if code := doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{
+		Name:  "Versioned Chef",
+		Email: "versioned@example.com",
+	}, &chef); code != http.StatusCreated {
+		t.Fatalf("create chef: expected 201, got %d", code)
+	}
+
+	send := func(method, ifMatch string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, "/api/v1/chefs/"+chef.Data.ID, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	etag := send(http.MethodGet, "", nil).Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf(`expected ETag "1", got %q`, etag)
+	}
+
+	// The first editor wins and receives the new ETag.
+	rec := send(http.MethodPut, etag, map[string]string{"bio": "first"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update with current ETag: expected 200, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("ETag"); got != `"2"` {
+		t.Errorf(`expected ETag "2" after update, got %q`, got)
+	}
+
+	// The second editor still holds the old ETag and is rejected.
+	for _, stale := range []string{etag, `W/"2"`, "garbage"} {
+		rec = send(http.MethodPut, stale, map[string]string{"bio": "second"})
+		if rec.Code != http.StatusPreconditionFailed {
+			t.Errorf("update with If-Match %s: expected 412, got %d", stale, rec.Code)
+		}
+	}
+
+	// Without If-Match, or with *, the update is unconditional.
+	for _, ifMatch := range []string{"", "*"} {
+		if rec = send(http.MethodPut, ifMatch, map[string]string{"bio": "last"}); rec.Code != http.StatusOK {
+			t.Errorf("update with If-Match %q: expected 200, got %d", ifMatch, rec.Code)
+		}
+	}
+	var got chefEnvelope
+	json.Unmarshal(send(http.MethodGet, "", nil).Body.Bytes(), &got)
+	if got.Data.Bio != "last" || got.Data.Version != 4 {
+		t.Errorf("expected bio %q at version 4, got %q at version %d", "last", got.Data.Bio, got.Data.Version)
+	}
+}
+
+func TestRouterSoftDelete(t *testing.T) {
+	h := setupRouter(t)
+
+	var chef chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Undo Chef", Email: "undo@example.com"}, &chef)
+	var recipe recipeEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID:       chef.Data.ID,
+		Title:        "Undo Soup",
+		Ingredients:  "water",
+		Instructions: "boil",
+	}, &recipe)
+	var rating struct {
+		Data model.Rating `json:"data"`
+	}
+	var critic chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Undo Critic", Email: "undo-critic@example.com"}, &critic)
+	ratingPath := "/api/v1/recipes/" + recipe.Data.ID + "/ratings"
+	doJSON(t, h, http.MethodPost, ratingPath, model.CreateRatingInput{ChefID: critic.Data.ID, Score: 5}, &rating)
+
+	// A deleted rating disappears from the list unless include_deleted is set.
+	if code := doJSON(t, h, http.MethodDelete, ratingPath+"/"+rating.Data.ID, nil, nil); code != http.StatusOK {
+		t.Fatalf("delete rating: expected 200, got %d", code)
+	}
+	var list struct {
+		Data []model.Rating `json:"data"`
+	}
+	doJSON(t, h, http.MethodGet, ratingPath, nil, &list)
+	if len(list.Data) != 0 {
+		t.Errorf("expected deleted rating to be hidden, got %d ratings", len(list.Data))
+	}
+	doJSON(t, h, http.MethodGet, ratingPath+"?include_deleted=true", nil, &list)
+	if len(list.Data) != 1 || list.Data[0].DeletedAt == nil {
+		t.Errorf("expected deleted rating with include_deleted, got %+v", list.Data)
+	}
+	if code := doJSON(t, h, http.MethodPost, ratingPath+"/"+rating.Data.ID+"/restore", nil, nil); code != http.StatusOK {
+		t.Fatalf("restore rating: expected 200, got %d", code)
+	}
+
+	// Deleting the chef hides the recipe; it cannot be restored on its own.
+	recipePath := "/api/v1/recipes/" + recipe.Data.ID
+	if code := doJSON(t, h, http.MethodDelete, "/api/v1/chefs/"+chef.Data.ID, nil, nil); code != http.StatusOK {
+		t.Fatalf("delete chef: expected 200, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodGet, recipePath, nil, nil); code != http.StatusNotFound {
+		t.Errorf("get recipe of deleted chef: expected 404, got %d", code)
+	}
+	var got recipeEnvelope
+	if code := doJSON(t, h, http.MethodGet, recipePath+"?include_deleted=true", nil, &got); code != http.StatusOK || got.Data.DeletedAt == nil {
+		t.Errorf("get deleted recipe with include_deleted: expected 200 with deleted_at, got %d %v", code, got.Data.DeletedAt)
+	}
+	var errResp errorEnvelope
+	if code := doJSON(t, h, http.MethodPost, recipePath+"/restore", nil, &errResp); code != http.StatusConflict || errResp.Error.Code != "CONFLICT" {
+		t.Errorf("restore recipe of deleted chef: expected 409 CONFLICT, got %d %q", code, errResp.Error.Code)
+	}
+
+	// Restoring the chef brings back the recipe and its rating.
+	if code := doJSON(t, h, http.MethodPost, "/api/v1/chefs/"+chef.Data.ID+"/restore", nil, nil); code != http.StatusOK {
+		t.Fatalf("restore chef: expected 200, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodGet, recipePath, nil, &got); code != http.StatusOK || got.Data.RatingCount != 1 {
+		t.Errorf("get restored recipe: expected 200 with 1 rating, got %d with %d", code, got.Data.RatingCount)
+	}
+
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes?include_deleted=maybe", nil, &errResp); code != http.StatusBadRequest {
+		t.Errorf("invalid include_deleted: expected 400, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodPost, "/api/v1/recipes/missing/restore", nil, &errResp); code != http.StatusNotFound {
+		t.Errorf("restore missing recipe: expected 404, got %d", code)
+	}
+}
+
+func TestRouterAudit(t *testing.T) {
+	h := setupRouter(t)
+
+	send := func(method, path, actor string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		if actor != "" {
+			req.Header.Set("X-Actor", actor)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var chef chefEnvelope
+	rec := send(http.MethodPost, "/api/v1/chefs", "alice", model.CreateChefInput{Name: "Logged Chef", Email: "logged@example.com"})
+	json.Unmarshal(rec.Body.Bytes(), &chef)
+	send(http.MethodPut, "/api/v1/chefs/"+chef.Data.ID, "", map[string]string{"bio": "edited"})
+
+	var list struct {
+		Data       []model.AuditEvent `json:"data"`
+		NextCursor string             `json:"next_cursor"`
+	}
+	rec = send(http.MethodGet, "/api/v1/audit?entity_id="+chef.Data.ID, "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list audit: expected 200, got %d", rec.Code)
+	}
+	json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list.Data) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(list.Data))
+	}
+	if e := list.Data[0]; e.Action != model.ActionUpdate || e.Actor != store.AnonymousActor {
+		t.Errorf("expected update by %s, got %s by %s", store.AnonymousActor, e.Action, e.Actor)
+	}
+	if e := list.Data[1]; e.Action != model.ActionCreate || e.Actor != "alice" || e.EntityType != model.EntityChef {
+		t.Errorf("expected chef create by alice, got %s %s by %s", e.EntityType, e.Action, e.Actor)
+	}
+
+	if rec = send(http.MethodGet, "/api/v1/audit", "", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("list audit without entity_id: expected 400, got %d", rec.Code)
+	}
+	if rec = send(http.MethodPost, "/api/v1/chefs", strings.Repeat("a", 256), nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("oversized X-Actor: expected 400, got %d", rec.Code)
+	}
+}
+
+func TestRouterRecipeListRatingParams(t *testing.T) {
+	h := setupRouter(t)
+
+	for _, query := range []string{"sort=rating", "sort=newest", "min_rating=4.5", "min_rating=1&sort=rating"} {
+		if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes?"+query, nil, nil); code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", query, code)
+		}
+	}
+	for _, query := range []string{"sort=popular", "min_rating=0", "min_rating=6", "min_rating=NaN", "min_rating=high"} {
+		var errResp errorEnvelope
+		if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes?"+query, nil, &errResp); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, code)
+		}
+	}
+}
+
+func TestRouterChefEmailConflict(t *testing.T) {
+	h := setupRouter(t)
+
+	var first chefEnvelope
+	if code := doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "First", Email: "dup@example.com"}, &first); code != http.StatusCreated {
+		t.Fatalf("create chef: expected 201, got %d", code)
+	}
+	var errResp errorEnvelope
+	if code := doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Second", Email: "DUP@example.com"}, &errResp); code != http.StatusConflict || errResp.Error.Code != "CONFLICT" {
+		t.Errorf("duplicate create: expected 409 CONFLICT, got %d %q", code, errResp.Error.Code)
+	}
+
+	var second chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Second", Email: "second@example.com"}, &second)
+	if code := doJSON(t, h, http.MethodPut, "/api/v1/chefs/"+second.Data.ID, map[string]string{"email": "Dup@Example.com"}, &errResp); code != http.StatusConflict {
+		t.Errorf("update to taken email: expected 409, got %d", code)
+	}
+}
+
+func TestRouterRatingRules(t *testing.T) {
+	h := setupRouter(t)
+
+	var owner, critic chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Owner", Email: "owner@example.com"}, &owner)
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Critic", Email: "critic@example.com"}, &critic)
+	var recipe recipeEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID:       owner.Data.ID,
+		Title:        "Judged Stew",
+		Ingredients:  "beef",
+		Instructions: "simmer",
+	}, &recipe)
+	ratingPath := "/api/v1/recipes/" + recipe.Data.ID + "/ratings"
+
+	var errResp errorEnvelope
+	if code := doJSON(t, h, http.MethodPost, ratingPath, model.CreateRatingInput{ChefID: owner.Data.ID, Score: 5}, &errResp); code != http.StatusBadRequest || errResp.Error.Code != "VALIDATION_ERROR" {
+		t.Errorf("rate own recipe: expected 400 VALIDATION_ERROR, got %d %q", code, errResp.Error.Code)
+	}
+
+	var rating struct {
+		Data model.Rating `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodPost, ratingPath, model.CreateRatingInput{ChefID: critic.Data.ID, Score: 2}, &rating); code != http.StatusCreated {
+		t.Fatalf("create rating: expected 201, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodPost, ratingPath, model.CreateRatingInput{ChefID: critic.Data.ID, Score: 3}, &errResp); code != http.StatusConflict || errResp.Error.Code != "CONFLICT" {
+		t.Errorf("rate twice: expected 409 CONFLICT, got %d %q", code, errResp.Error.Code)
+	}
+	if code := doJSON(t, h, http.MethodPost, ratingPath+"?upsert=true", model.CreateRatingInput{ChefID: critic.Data.ID, Score: 3}, &rating); code != http.StatusOK || rating.Data.Score != 3 {
+		t.Errorf("upsert existing rating: expected 200 with score 3, got %d with %d", code, rating.Data.Score)
+	}
+
+	itemPath := ratingPath + "/" + rating.Data.ID
+	if code := doJSON(t, h, http.MethodPut, itemPath, map[string]any{"comment": "Grew on me"}, &rating); code != http.StatusOK || rating.Data.Score != 3 || rating.Data.Comment != "Grew on me" {
+		t.Errorf("update rating: expected 200 with score 3 and new comment, got %d %+v", code, rating.Data)
+	}
+	if code := doJSON(t, h, http.MethodPut, itemPath, map[string]any{"score": 6}, &errResp); code != http.StatusBadRequest {
+		t.Errorf("update with out-of-range score: expected 400, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodPut, ratingPath+"/missing", map[string]any{"score": 4}, &errResp); code != http.StatusNotFound {
+		t.Errorf("update missing rating: expected 404, got %d", code)
+	}
+
+	// A rating superseded by a newer one from the same chef cannot be restored.
+	doJSON(t, h, http.MethodDelete, itemPath, nil, nil)
+	if code := doJSON(t, h, http.MethodPost, ratingPath+"?upsert=true", model.CreateRatingInput{ChefID: critic.Data.ID, Score: 4}, nil); code != http.StatusCreated {
+		t.Errorf("upsert after delete: expected 201, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodPost, itemPath+"/restore", nil, &errResp); code != http.StatusConflict {
+		t.Errorf("restore superseded rating: expected 409, got %d", code)
+	}
+}
+
+func TestRouterTags(t *testing.T) {
+	h := setupRouter(t)
+
+	var chef chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Tag Chef", Email: "tag-chef@example.com"}, &chef)
+	var recipe recipeEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID:       chef.Data.ID,
+		Title:        "Tagged Salad",
+		Ingredients:  "lettuce",
+		Instructions: "toss",
+	}, &recipe)
+	tagsPath := "/api/v1/recipes/" + recipe.Data.ID + "/tags"
+
+	var added struct {
+		Data []string `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodPost, tagsPath, model.AddTagsInput{Tags: []string{"Summer Salads", "Vegan!"}}, &added); code != http.StatusOK {
+		t.Fatalf("add tags: expected 200, got %d", code)
+	}
+	if !slices.Equal(added.Data, []string{"summer-salads", "vegan"}) {
+		t.Errorf("expected slugs [summer-salads vegan], got %v", added.Data)
+	}
+	var got recipeEnvelope
+	doJSON(t, h, http.MethodGet, "/api/v1/recipes/"+recipe.Data.ID, nil, &got)
+	if !slices.Equal(got.Data.Tags, added.Data) {
+		t.Errorf("expected recipe tags %v, got %v", added.Data, got.Data.Tags)
+	}
+
+	var errResp errorEnvelope
+	for name, body := range map[string]any{
+		"empty list":     model.AddTagsInput{Tags: []string{}},
+		"blank name":     model.AddTagsInput{Tags: []string{"!!!"}},
+		"long name":      model.AddTagsInput{Tags: []string{strings.Repeat("a", 51)}},
+		"missing tags":   map[string]any{},
+		"wrong type":     map[string]any{"tags": "vegan"},
+		"too many names": model.AddTagsInput{Tags: make([]string, 21)},
+	} {
+		if code := doJSON(t, h, http.MethodPost, tagsPath, body, &errResp); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, code)
+		}
+	}
+	if code := doJSON(t, h, http.MethodPost, "/api/v1/recipes/missing/tags", model.AddTagsInput{Tags: []string{"x"}}, &errResp); code != http.StatusNotFound {
+		t.Errorf("tag missing recipe: expected 404, got %d", code)
+	}
+
+	var list struct {
+		Data []model.Recipe `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes?tags=Vegan,summer-salads&match=all", nil, &list); code != http.StatusOK || len(list.Data) != 1 {
+		t.Errorf("filter by all tags: expected 200 with 1 recipe, got %d with %d", code, len(list.Data))
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes?tags=vegan,winter&match=all", nil, &list); code != http.StatusOK || len(list.Data) != 0 {
+		t.Errorf("filter by all tags with one missing: expected 200 with 0 recipes, got %d with %d", code, len(list.Data))
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes?tags=vegan,winter", nil, &list); code != http.StatusOK || len(list.Data) != 1 {
+		t.Errorf("filter by any tag: expected 200 with 1 recipe, got %d with %d", code, len(list.Data))
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes?tags=vegan&match=some", nil, &errResp); code != http.StatusBadRequest {
+		t.Errorf("invalid match: expected 400, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes?tags=a,b,c,d,e,f,g,h,i,j,k", nil, &errResp); code != http.StatusBadRequest {
+		t.Errorf("too many tag filters: expected 400, got %d", code)
+	}
+
+	if code := doJSON(t, h, http.MethodDelete, tagsPath+"/Summer%20Salads", nil, nil); code != http.StatusOK {
+		t.Errorf("remove tag by name: expected 200, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodDelete, tagsPath+"/summer-salads", nil, &errResp); code != http.StatusNotFound {
+		t.Errorf("remove missing tag: expected 404, got %d", code)
+	}
+
+	var tags struct {
+		Data []model.TagUsage `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/tags", nil, &tags); code != http.StatusOK {
+		t.Fatalf("list tags: expected 200, got %d", code)
+	}
+	if len(tags.Data) != 2 || tags.Data[0].Slug != "summer-salads" || tags.Data[0].Name != "Summer Salads" ||
+		tags.Data[0].RecipeCount != 0 || tags.Data[1].Slug != "vegan" || tags.Data[1].RecipeCount != 1 {
+		t.Errorf("expected summer-salads used 0 times and vegan once, got %+v", tags.Data)
+	}
+}
+
+func TestRouterTagPagination(t *testing.T) {
+	h := setupRouter(t)
+
+	var chef chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Pager", Email: "pager@example.com"}, &chef)
+	var recipe recipeEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID: chef.Data.ID, Title: "Many Tags", Ingredients: "salt", Instructions: "season",
+	}, &recipe)
+	want := []string{"alpha", "bravo", "charlie", "delta", "echo"}
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes/"+recipe.Data.ID+"/tags", model.AddTagsInput{Tags: []string{"Echo", "Charlie", "Alpha", "Delta", "Bravo"}}, nil)
+
+	// Following next_cursor visits every tag once, in slug order.
+	var got []string
+	path := "/api/v1/tags?limit=2"
+	for pages := 0; path != ""; pages++ {
+		if pages > len(want) {
+			t.Fatalf("expected at most %d pages, got more", len(want))
+		}
+		var page struct {
+			Data       []model.TagUsage `json:"data"`
+			NextCursor string           `json:"next_cursor"`
+		}
+		if code := doJSON(t, h, http.MethodGet, path, nil, &page); code != http.StatusOK {
+			t.Fatalf("list tags %s: expected 200, got %d", path, code)
+		}
+		for _, tag := range page.Data {
+			got = append(got, tag.Slug)
+		}
+		path = ""
+		if page.NextCursor != "" {
+			path = "/api/v1/tags?limit=2&cursor=" + page.NextCursor
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %q across pages, got %q", want, got)
+	}
+}
+
+func TestRouterCollections(t *testing.T) {
+	h := setupRouter(t)
+
+	var author, saver chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Author", Email: "author@example.com"}, &author)
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Saver", Email: "saver@example.com"}, &saver)
+	var recipe recipeEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID:       author.Data.ID,
+		Title:        "Saved Stew",
+		Ingredients:  "beef",
+		Instructions: "simmer",
+	}, &recipe)
+
+	var errResp errorEnvelope
+	if code := doJSON(t, h, http.MethodPost, "/api/v1/collections", model.CreateCollectionInput{ChefID: "missing", Name: "Nope"}, &errResp); code != http.StatusBadRequest {
+		t.Errorf("create for missing chef: expected 400, got %d", code)
+	}
+	var collection struct {
+		Data model.CollectionWithItems `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodPost, "/api/v1/collections", model.CreateCollectionInput{ChefID: saver.Data.ID, Name: "Weeknight"}, &collection); code != http.StatusCreated {
+		t.Fatalf("create collection: expected 201, got %d", code)
+	}
+	path := "/api/v1/collections/" + collection.Data.ID
+
+	if code := doJSON(t, h, http.MethodPut, path, map[string]string{"name": "Weeknights"}, &collection); code != http.StatusOK || collection.Data.Name != "Weeknights" {
+		t.Errorf("rename collection: expected 200 with new name, got %d %q", code, collection.Data.Name)
+	}
+	if code := doJSON(t, h, http.MethodPut, path, map[string]string{"name": ""}, &errResp); code != http.StatusBadRequest {
+		t.Errorf("blank name: expected 400, got %d", code)
+	}
+
+	var item struct {
+		Data model.CollectionItem `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodPost, path+"/items", model.AddCollectionItemInput{RecipeID: recipe.Data.ID}, &item); code != http.StatusCreated || item.Data.Position != 1 {
+		t.Fatalf("add item: expected 201 at position 1, got %d at %d", code, item.Data.Position)
+	}
+	if code := doJSON(t, h, http.MethodPost, path+"/items", model.AddCollectionItemInput{RecipeID: recipe.Data.ID}, &errResp); code != http.StatusConflict {
+		t.Errorf("add item twice: expected 409, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodPost, path+"/items", model.AddCollectionItemInput{RecipeID: "missing"}, &errResp); code != http.StatusBadRequest {
+		t.Errorf("add missing recipe: expected 400, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodPut, path+"/items/"+recipe.Data.ID, map[string]int{"position": 0}, &errResp); code != http.StatusBadRequest {
+		t.Errorf("move to position 0: expected 400, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodPut, path+"/items/missing", map[string]int{"position": 1}, &errResp); code != http.StatusNotFound {
+		t.Errorf("move missing item: expected 404, got %d", code)
+	}
+
+	var got recipeEnvelope
+	doJSON(t, h, http.MethodGet, "/api/v1/recipes/"+recipe.Data.ID, nil, &got)
+	if got.Data.FavoritedCount == nil || *got.Data.FavoritedCount != 1 {
+		t.Errorf("expected favorited_count 1, got %v", got.Data.FavoritedCount)
+	}
+	var list struct {
+		Data []model.Recipe `json:"data"`
+	}
+	doJSON(t, h, http.MethodGet, "/api/v1/recipes", nil, &list)
+	if len(list.Data) != 1 || list.Data[0].FavoritedCount == nil || *list.Data[0].FavoritedCount != 1 {
+		t.Errorf("expected favorited_count 1 in list results, got %+v", list.Data)
+	}
+
+	var collections struct {
+		Data []model.Collection `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/chefs/"+saver.Data.ID+"/collections", nil, &collections); code != http.StatusOK || len(collections.Data) != 1 {
+		t.Errorf("list collections: expected 200 with 1 collection, got %d with %d", code, len(collections.Data))
+	}
+	doJSON(t, h, http.MethodGet, path, nil, &collection)
+	if len(collection.Data.Items) != 1 || collection.Data.Items[0].Title != "Saved Stew" {
+		t.Errorf("expected one item titled Saved Stew, got %+v", collection.Data.Items)
+	}
+
+	// A deleted recipe drops out of the collection.
+	doJSON(t, h, http.MethodDelete, "/api/v1/recipes/"+recipe.Data.ID, nil, nil)
+	doJSON(t, h, http.MethodGet, path, nil, &collection)
+	if len(collection.Data.Items) != 0 {
+		t.Errorf("expected deleted recipe to be hidden, got %+v", collection.Data.Items)
+	}
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes/"+recipe.Data.ID+"/restore", nil, nil)
+
+	if code := doJSON(t, h, http.MethodDelete, path+"/items/"+recipe.Data.ID, nil, nil); code != http.StatusOK {
+		t.Errorf("remove item: expected 200, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodDelete, path+"/items/"+recipe.Data.ID, nil, &errResp); code != http.StatusNotFound {
+		t.Errorf("remove item twice: expected 404, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodDelete, path, nil, nil); code != http.StatusOK {
+		t.Errorf("delete collection: expected 200, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodGet, path, nil, &errResp); code != http.StatusNotFound {
+		t.Errorf("get deleted collection: expected 404, got %d", code)
+	}
+}
+
+func TestRouterFollows(t *testing.T) {
+	h := setupRouter(t)
+
+	var reader, author chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Reader", Email: "reader@example.com"}, &reader)
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Author", Email: "author@example.com"}, &author)
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID:       author.Data.ID,
+		Title:        "Published Pie",
+		Ingredients:  "apples",
+		Instructions: "bake",
+		Status:       model.StatusPublished,
+	}, nil)
+	path := "/api/v1/chefs/" + author.Data.ID + "/follow"
+
+	var errResp errorEnvelope
+	if code := doJSON(t, h, http.MethodPost, path, model.FollowInput{FollowerID: author.Data.ID}, &errResp); code != http.StatusBadRequest {
+		t.Errorf("self-follow: expected 400, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodPost, path, model.FollowInput{FollowerID: "missing"}, &errResp); code != http.StatusBadRequest {
+		t.Errorf("missing follower: expected 400, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodPost, "/api/v1/chefs/missing/follow", model.FollowInput{FollowerID: reader.Data.ID}, &errResp); code != http.StatusNotFound {
+		t.Errorf("missing followee: expected 404, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodPost, path, model.FollowInput{FollowerID: reader.Data.ID}, nil); code != http.StatusCreated {
+		t.Errorf("follow: expected 201, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodPost, path, model.FollowInput{FollowerID: reader.Data.ID}, nil); code != http.StatusOK {
+		t.Errorf("follow again: expected 200, got %d", code)
+	}
+
+	var chefs struct {
+		Data []model.FollowedChef `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/chefs/"+author.Data.ID+"/followers", nil, &chefs); code != http.StatusOK || len(chefs.Data) != 1 || chefs.Data[0].ID != reader.Data.ID {
+		t.Errorf("followers: expected 200 with reader, got %d %+v", code, chefs.Data)
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/chefs/"+reader.Data.ID+"/following", nil, &chefs); code != http.StatusOK || len(chefs.Data) != 1 || chefs.Data[0].ID != author.Data.ID {
+		t.Errorf("following: expected 200 with author, got %d %+v", code, chefs.Data)
+	}
+
+	var feed struct {
+		Data []model.Recipe `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/feed?chef_id="+reader.Data.ID, nil, &feed); code != http.StatusOK || len(feed.Data) != 1 || feed.Data[0].Title != "Published Pie" {
+		t.Errorf("feed: expected 200 with Published Pie, got %d %+v", code, feed.Data)
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/feed", nil, &errResp); code != http.StatusBadRequest {
+		t.Errorf("feed without chef_id: expected 400, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/feed?chef_id=missing", nil, &errResp); code != http.StatusNotFound {
+		t.Errorf("feed for missing chef: expected 404, got %d", code)
+	}
+
+	if code := doJSON(t, h, http.MethodDelete, path+"?follower_id="+reader.Data.ID, nil, nil); code != http.StatusOK {
+		t.Errorf("unfollow: expected 200, got %d", code)
+	}
+	if code := doJSON(t, h, http.MethodDelete, path+"?follower_id="+reader.Data.ID, nil, &errResp); code != http.StatusNotFound {
+		t.Errorf("unfollow again: expected 404, got %d", code)
+	}
+}
+
+func TestRouterForks(t *testing.T) {
+	h := setupRouter(t)
+
+	var author, forker chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Author", Email: "fork-author@example.com"}, &author)
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Forker", Email: "forker@example.com"}, &forker)
+	var published, draft recipeEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID: author.Data.ID, Title: "Shakshuka", Ingredients: "4 eggs\n1 can tomatoes", Instructions: "simmer",
+		Status: model.StatusPublished,
+	}, &published)
+	doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+		ChefID: author.Data.ID, Title: "Secret Sauce", Ingredients: "secrets", Instructions: "stir",
+	}, &draft)
+	path := "/api/v1/recipes/" + published.Data.ID + "/fork"
+
+	var fork recipeEnvelope
+	if code := doJSON(t, h, http.MethodPost, path, model.ForkRecipeInput{ChefID: forker.Data.ID}, &fork); code != http.StatusCreated {
+		t.Fatalf("POST %s: expected 201, got %d", path, code)
+	}
+	if fork.Data.ChefID != forker.Data.ID || fork.Data.Status != model.StatusDraft || fork.Data.ForkedFromID == nil || *fork.Data.ForkedFromID != published.Data.ID {
+		t.Errorf("unexpected fork %+v", fork.Data)
+	}
+
+	var errResp errorEnvelope
+	for _, tc := range []struct {
+		name, path string
+		body       any
+		want       int
+	}{
+		{"missing body", path, nil, http.StatusBadRequest},
+		{"own recipe", path, model.ForkRecipeInput{ChefID: author.Data.ID}, http.StatusBadRequest},
+		{"unknown chef", path, model.ForkRecipeInput{ChefID: "missing"}, http.StatusBadRequest},
+		{"missing recipe", "/api/v1/recipes/missing/fork", model.ForkRecipeInput{ChefID: forker.Data.ID}, http.StatusNotFound},
+		{"draft", "/api/v1/recipes/" + draft.Data.ID + "/fork", model.ForkRecipeInput{ChefID: forker.Data.ID}, http.StatusConflict},
+	} {
+		if code := doJSON(t, h, http.MethodPost, tc.path, tc.body, &errResp); code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, code)
+		}
+	}
+
+	var original recipeEnvelope
+	doJSON(t, h, http.MethodGet, "/api/v1/recipes/"+published.Data.ID, nil, &original)
+	if original.Data.ForkCount == nil || *original.Data.ForkCount != 1 {
+		t.Errorf("expected fork_count 1, got %v", original.Data.ForkCount)
+	}
+
+	var lineage struct {
+		Data model.Lineage `json:"data"`
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes/"+fork.Data.ID+"/lineage", nil, &lineage); code != http.StatusOK {
+		t.Fatalf("lineage: expected 200, got %d", code)
+	}
+	if len(lineage.Data.Ancestors) != 1 || lineage.Data.Ancestors[0].ID != published.Data.ID {
+		t.Errorf("expected the original as the only ancestor, got %+v", lineage.Data.Ancestors)
+	}
+	doJSON(t, h, http.MethodGet, "/api/v1/recipes/"+published.Data.ID+"/lineage", nil, &lineage)
+	if len(lineage.Data.Descendants) != 1 || lineage.Data.Descendants[0].ID != fork.Data.ID {
+		t.Errorf("expected the fork as the only descendant, got %+v", lineage.Data.Descendants)
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/recipes/missing/lineage", nil, &errResp); code != http.StatusNotFound {
+		t.Errorf("lineage of missing recipe: expected 404, got %d", code)
+	}
+}
+
+// doRaw sends a request with a raw body and content type to the router.
+func doRaw(h http.Handler, method, path, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRouterImportExport(t *testing.T) {
+	h := setupRouter(t)
+
+	var chef chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Importer", Email: "importer@example.com"}, &chef)
+	id := chef.Data.ID
+
+	ndjson := strings.Join([]string{
+		fmt.Sprintf(`{"chef_id":%q,"title":"Soup","ingredients":"2 cups stock","instructions":"simmer","status":"published"}`, id),
+		``,
+		fmt.Sprintf(`{"chef_id":%q,"title":"No Ingredients","instructions":"wait"}`, id),
+		`{"chef_id":"missing","title":"Orphan","ingredients":"salt","instructions":"season"}`,
+		`{not json`,
+		fmt.Sprintf(`{"chef_id":%q,"ingredients":"salt","instructions":"season"}`, id),
+		fmt.Sprintf(`{"chef_id":%q,"title":"Stew","ingredients":"beef","instructions":"braise","difficulty":"extreme"}`, id),
+	}, "\n")
+	var report struct {
+		Data model.ImportReport `json:"data"`
+	}
+	rec := doRaw(h, http.MethodPost, "/api/v1/import", "application/x-ndjson", ndjson)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("NDJSON import: expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	json.Unmarshal(rec.Body.Bytes(), &report)
+	if report.Data.Total != 6 || report.Data.Created != 1 || report.Data.Failed != 5 {
+		t.Errorf("expected 1 of 6 rows created, got %+v", report.Data)
+	}
+	wantRows := []int{1, 3, 4, 5, 6, 7}
+	for i, row := range report.Data.Rows {
+		if row.Row != wantRows[i] {
+			t.Errorf("row %d: expected line %d, got %d", i, wantRows[i], row.Row)
+		}
+		if (row.Status == model.ImportCreated) != (i == 0) || (row.Status == model.ImportFailed && row.Error == "") {
+			t.Errorf("row %d: unexpected result %+v", i, row)
+		}
+	}
+	if msg := report.Data.Rows[4].Error; !strings.Contains(msg, "title") {
+		t.Errorf("expected the missing title to be named, got %q", msg)
+	}
+
+	csvBody := "title,chef_id,ingredients,instructions,servings\n" +
+		fmt.Sprintf("Bread,%s,\"500 g flour\n1 tsp salt\",bake,8\n", id) +
+		fmt.Sprintf("Rolls,%s,flour,bake,many\n", id)
+	rec = doRaw(h, http.MethodPost, "/api/v1/import", "text/csv", csvBody)
+	json.Unmarshal(rec.Body.Bytes(), &report)
+	if rec.Code != http.StatusOK || report.Data.Created != 1 || report.Data.Rows[1].Row != 4 || report.Data.Rows[1].Error != "servings must be an integer" {
+		t.Errorf("CSV import: unexpected %d %+v", rec.Code, report.Data)
+	}
+
+	var errResp errorEnvelope
+	if rec := doRaw(h, http.MethodPost, "/api/v1/import", "text/csv", "title,color\nSoup,red\n"); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown CSV column: expected 400, got %d", rec.Code)
+	}
+	if code := doJSON(t, h, http.MethodPost, "/api/v1/import", map[string]string{}, &errResp); code != http.StatusUnsupportedMediaType {
+		t.Errorf("JSON import: expected 415, got %d", code)
+	}
+
+	rec = doRaw(h, http.MethodGet, "/api/v1/export", "", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("NDJSON export: unexpected %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	exported := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(exported) != 2 {
+		t.Fatalf("expected 2 exported recipes, got %d", len(exported))
+	}
+	var first model.Recipe
+	json.Unmarshal([]byte(exported[0]), &first)
+	if first.Title != "Soup" {
+		t.Errorf("expected the oldest recipe first, got %q", first.Title)
+	}
+
+	// A CSV export can be imported again.
+	rec = doRaw(h, http.MethodGet, "/api/v1/export?format=csv", "", "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "id,chef_id,title,") {
+		t.Fatalf("CSV export: unexpected %d %q", rec.Code, rec.Body.String())
+	}
+	rec = doRaw(h, http.MethodPost, "/api/v1/import", "text/csv", rec.Body.String())
+	json.Unmarshal(rec.Body.Bytes(), &report)
+	if report.Data.Created != 2 || report.Data.Failed != 0 {
+		t.Errorf("re-importing the CSV export: expected 2 created, got %+v", report.Data)
+	}
+	if code := doJSON(t, h, http.MethodGet, "/api/v1/export?format=xml", nil, &errResp); code != http.StatusBadRequest {
+		t.Errorf("unknown export format: expected 400, got %d", code)
+	}
+}
+
+func TestRouterPublishing(t *testing.T) {
+	h := setupRouter(t)
+
+	var chef chefEnvelope
+	doJSON(t, h, http.MethodPost, "/api/v1/chefs", model.CreateChefInput{Name: "Scheduler", Email: "scheduler@example.com"}, &chef)
+	newRecipe := func(title string) string {
+		t.Helper()
+		var r recipeEnvelope
+		if code := doJSON(t, h, http.MethodPost, "/api/v1/recipes", model.CreateRecipeInput{
+			ChefID: chef.Data.ID, Title: title, Ingredients: "rice", Instructions: "steam",
+		}, &r); code != http.StatusCreated {
+			t.Fatalf("create recipe: expected 201, got %d", code)
+		}
+		return "/api/v1/recipes/" + r.Data.ID
+	}
+
+	var errResp errorEnvelope
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	for name, input := range map[string]model.CreateRecipeInput{
+		"past publish_at":      {ChefID: chef.Data.ID, Title: "Late", Ingredients: "rice", Instructions: "steam", PublishAt: &past},
+		"published publish_at": {ChefID: chef.Data.ID, Title: "Early", Ingredients: "rice", Instructions: "steam", PublishAt: &future, Status: model.StatusPublished},
+	} {
+		if code := doJSON(t, h, http.MethodPost, "/api/v1/recipes", input, &errResp); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, code)
+		}
+	}
+
+	path := newRecipe("Congee")
+	var got recipeEnvelope
+	rec := doRaw(h, http.MethodPost, path+"/publish", "application/json", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2"` {
+		t.Fatalf("publish without a body: expected 200 with ETag \"2\", got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+	json.Unmarshal(rec.Body.Bytes(), &got)
+	if got.Data.Status != model.StatusPublished || got.Data.PublishedAt == nil {
+		t.Errorf("expected a published recipe with published_at, got %+v", got.Data)
+	}
+
+	for _, tc := range []struct {
+		name, method, path string
+		body               any
+		want               int
+	}{
+		{"publish twice", http.MethodPost, path + "/publish", nil, http.StatusConflict},
+		{"archive", http.MethodPost, path + "/archive", nil, http.StatusOK},
+		{"unpublish archived", http.MethodPost, path + "/unpublish", nil, http.StatusConflict},
+		{"update archived to draft", http.MethodPut, path, map[string]string{"status": model.StatusDraft}, http.StatusConflict},
+		{"republish", http.MethodPost, path + "/publish", nil, http.StatusOK},
+		{"unpublish", http.MethodPost, path + "/unpublish", nil, http.StatusOK},
+		{"malformed body", http.MethodPost, path + "/publish", map[string]int{"publish_at": 1}, http.StatusBadRequest},
+		{"missing recipe", http.MethodPost, "/api/v1/recipes/missing/archive", nil, http.StatusNotFound},
+	} {
+		if code := doJSON(t, h, tc.method, tc.path, tc.body, &errResp); code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, code)
+		}
+	}
+	var draft recipeEnvelope
+	doJSON(t, h, http.MethodGet, path, nil, &draft)
+	if draft.Data.Status != model.StatusDraft || draft.Data.PublishedAt != nil {
+		t.Errorf("expected an unpublished draft, got %+v", draft.Data)
+	}
+
+	// A publish_at in the future schedules the draft instead.
+	scheduled := newRecipe("Jook")
+	var pending recipeEnvelope
+	if code := doJSON(t, h, http.MethodPost, scheduled+"/publish", model.PublishInput{PublishAt: &future}, &pending); code != http.StatusOK {
+		t.Fatalf("schedule: expected 200, got %d", code)
+	}
+	if pending.Data.Status != model.StatusDraft || pending.Data.PublishAt == nil || !pending.Data.PublishAt.Equal(future) {
+		t.Errorf("expected a draft scheduled for %v, got %+v", future, pending.Data)
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws-samples/recipe-share-dsql-go/internal/model"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/router"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/store"
+	"github.com/aws-samples/recipe-share-dsql-go/internal/telemetry"
+	"github.com/awslabs/aurora-dsql-connectors/go/pgx/occretry"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// setupTracing records spans in memory for the rest of the test.
+func setupTracing(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exp := telemetry.SetupInMemory()
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	return exp
+}
+
+// findSpan returns the first recorded span with the given name.
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	names := []string{}
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	t.Fatalf("no span %q among %q", name, names)
+	return tracetest.SpanStub{}
+}
+
+// attr returns the value of a span attribute, or an invalid value if unset.
+func attr(attrs []attribute.KeyValue, key string) attribute.Value {
+	for _, kv := range attrs {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"SELECT id FROM recipes WHERE id = $1", "SELECT id FROM recipes WHERE id = $1"},
+		{"SELECT *\n\tFROM   recipes\n  LIMIT 101", "SELECT * FROM recipes LIMIT ?"},
+		{"UPDATE chefs SET name = 'O''Brien', rating = 4.5 WHERE email = 'a@b.c'", "UPDATE chefs SET name = ?, rating = ? WHERE email = ?"},
+		{`SELECT t1.col2, "Table 3".x FROM t1 WHERE n IN (1, 2)`, `SELECT t1.col2, "Table 3".x FROM t1 WHERE n IN (?, ?)`},
+		{"SELECT COALESCE(version, 1) FROM recipe_share.recipes", "SELECT COALESCE(version, ?) FROM recipe_share.recipes"},
+	}
+	for _, tt := range tests {
+		if got := telemetry.SanitizeSQL(tt.in); got != tt.want {
+			t.Errorf("SanitizeSQL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := telemetry.SanitizeSQL("SELECT '" + strings.Repeat("x", 5000) + "', " + strings.Repeat("a, ", 2000) + "b"); len(got) > 2048 {
+		t.Errorf("expected the sanitized text to be truncated, got %d bytes", len(got))
+	}
+}
+
+func TestQueryTracer(t *testing.T) {
+	exp := setupTracing(t)
+	ctx := context.Background()
+	var tracer telemetry.QueryTracer
+
+	ctx2 := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{
+		SQL: "UPDATE recipe_share.recipes SET title = 'Secret Stew' WHERE id = $1",
+	})
+	tracer.TraceQueryEnd(ctx2, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("UPDATE 1")})
+	ctx3 := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT id FROM recipe_share.chefs WHERE email = $1"})
+	tracer.TraceQueryEnd(ctx3, nil, pgx.TraceQueryEndData{Err: errors.New("connection reset")})
+
+	spans := exp.GetSpans()
+	update := findSpan(t, spans, "UPDATE recipe_share.recipes")
+	if update.SpanKind != trace.SpanKindClient {
+		t.Errorf("expected a client span, got %v", update.SpanKind)
+	}
+	if got := attr(update.Attributes, "db.query.text").AsString(); got != "UPDATE recipe_share.recipes SET title = ? WHERE id = $1" {
+		t.Errorf("expected sanitized query text, got %q", got)
+	}
+	if attr(update.Attributes, "db.system.name").AsString() != "postgresql" || attr(update.Attributes, "db.operation.name").AsString() != "UPDATE" {
+		t.Errorf("unexpected attributes %v", update.Attributes)
+	}
+	if got := attr(update.Attributes, "db.response.returned_rows").AsInt64(); got != 1 {
+		t.Errorf("expected 1 row, got %d", got)
+	}
+	if sel := findSpan(t, spans, "SELECT recipe_share.chefs"); sel.Status.Code != codes.Error || len(sel.Events) == 0 {
+		t.Errorf("expected the failed statement to be recorded as an error, got %+v", sel.Status)
+	}
+}
+
+// occPool is a telemetry.Pool whose statements fail with an OCC conflict
+// until they have been tried a number of times.
+type occPool struct {
+	failures int
+	calls    int
+	commits  int
+}
+
+var errOCC = &pgconn.PgError{Code: "OC000", Message: "change conflicts with another transaction"}
+
+func (p *occPool) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	p.calls++
+	if p.calls <= p.failures {
+		return pgconn.CommandTag{}, errOCC
+	}
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (p *occPool) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p *occPool) QueryRow(context.Context, string, ...any) pgx.Row { return nil }
+
+func (p *occPool) Begin(context.Context) (pgx.Tx, error) { return occTx{pool: p}, nil }
+
+// occTx is a transaction whose commit conflicts while its pool has
+// failures left. Only the methods WithTransaction calls are implemented.
+type occTx struct {
+	pgx.Tx
+	pool *occPool
+}
+
+func (tx occTx) Commit(context.Context) error {
+	tx.pool.commits++
+	if tx.pool.commits <= tx.pool.failures {
+		return errOCC
+	}
+	return nil
+}
+
+func (tx occTx) Rollback(context.Context) error { return nil }
+
+func TestRetryDBRecordsEvents(t *testing.T) {
+	exp := setupTracing(t)
+	cfg := occretry.Config{MaxRetries: 3, InitialWait: time.Millisecond, MaxWait: time.Millisecond, Multiplier: 1}
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "exec")
+	db := telemetry.NewRetryDB(&occPool{failures: 2}, cfg)
+	if _, err := db.Exec(ctx, "UPDATE recipes SET title = $1", "Stew"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	span.End()
+	ctx, span = otel.Tracer("test").Start(context.Background(), "transaction")
+	db = telemetry.NewRetryDB(&occPool{failures: 1}, cfg)
+	if err := db.WithTransaction(ctx, func(tx pgx.Tx) error { return nil }); err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+	span.End()
+	ctx, span = otel.Tracer("test").Start(context.Background(), "unretried")
+	db = telemetry.NewRetryDB(&occPool{}, cfg)
+	db.Exec(ctx, "DELETE FROM recipes")
+	span.End()
+
+	spans := exp.GetSpans()
+	exec := findSpan(t, spans, "exec")
+	if len(exec.Events) != 2 {
+		t.Fatalf("expected 2 retry events, got %+v", exec.Events)
+	}
+	for i, e := range exec.Events {
+		if e.Name != "occ.retry" || attr(e.Attributes, "occ.attempt").AsInt64() != int64(i+2) || !strings.Contains(attr(e.Attributes, "occ.error").AsString(), "OC000") {
+			t.Errorf("unexpected retry event %d: %s %v", i, e.Name, e.Attributes)
+		}
+	}
+	tx := findSpan(t, spans, "transaction")
+	if len(tx.Events) != 1 || attr(tx.Events[0].Attributes, "occ.error").AsString() != "commit conflict" {
+		t.Errorf("expected a commit conflict retry, got %+v", tx.Events)
+	}
+	if got := findSpan(t, spans, "unretried"); len(got.Events) != 0 {
+		t.Errorf("expected no events without a conflict, got %+v", got.Events)
+	}
+}
+
+func TestTracingStore(t *testing.T) {
+	exp := setupTracing(t)
+	ctx := context.Background()
+	s := store.NewTracingStore(store.NewMemoryStore())
+
+	chef, err := s.CreateChef(ctx, model.CreateChefInput{Name: "Traced", Email: "traced@example.com"})
+	if err != nil {
+		t.Fatalf("CreateChef: %v", err)
+	}
+	recipe, err := s.CreateRecipe(ctx, model.CreateRecipeInput{ChefID: chef.ID, Title: "Soup", Ingredients: "water", Instructions: "boil"})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	stale := int64(9)
+	if _, err := s.UpdateRecipe(ctx, recipe.ID, model.UpdateRecipeInput{Title: ptr("Broth"), IfVersion: &stale}); !errors.Is(err, store.ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed, got %v", err)
+	}
+
+	spans := exp.GetSpans()
+	findSpan(t, spans, "Store.CreateChef")
+	update := findSpan(t, spans, "Store.UpdateRecipe")
+	if attr(update.Attributes, "app.recipe.id").AsString() != recipe.ID {
+		t.Errorf("expected the recipe ID attribute, got %v", update.Attributes)
+	}
+	if update.Status.Code != codes.Error {
+		t.Errorf("expected the failed update to be an error, got %+v", update.Status)
+	}
+
+	// Decorators can be looked through to find the store they wrap.
+	cached := store.NewTracingStore(store.NewCachingStore(store.NewMemoryStore(), store.NewLRUCache(10), time.Minute))
+	if _, ok := store.As[*store.CachingStore](cached); !ok {
+		t.Errorf("expected to find the caching store beneath the tracing store")
+	}
+	if _, ok := store.As[*store.CachingStore](s); ok {
+		t.Errorf("expected no caching store beneath an uncached store")
+	}
+}
+
+func TestRouterTracing(t *testing.T) {
+	exp := setupTracing(t)
+	gin.SetMode(gin.TestMode)
+	h := router.New(store.NewTracingStore(store.NewMemoryStore()), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/recipes/missing", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+
+	spans := exp.GetSpans()
+	server := findSpan(t, spans, "GET /api/v1/recipes/:id")
+	if server.SpanKind != trace.SpanKindServer || server.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected a server span continuing the propagated trace, got %v %s", server.SpanKind, server.SpanContext.TraceID())
+	}
+	if attr(server.Attributes, "http.response.status_code").AsInt64() != 404 || attr(server.Attributes, "http.route").AsString() != "/api/v1/recipes/:id" {
+		t.Errorf("unexpected server attributes %v", server.Attributes)
+	}
+	if server.Status.Code == codes.Error {
+		t.Errorf("expected a client error not to fail the span")
+	}
+	get := findSpan(t, spans, "Store.GetRecipeWithRatings")
+	if get.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("expected the store span to be a child of the server span")
+	}
+}